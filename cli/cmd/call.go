@@ -0,0 +1,140 @@
+// Copyright 2025 CFC4N <cfc4n.cs@gmail.com>. All Rights Reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//   http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+//
+// Repository: https://github.com/gojue/moling
+
+package cmd
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"strings"
+
+	"github.com/spf13/cobra"
+
+	"github.com/gojue/moling/pkg/comm"
+	"github.com/gojue/moling/pkg/server"
+	"github.com/gojue/moling/pkg/services"
+)
+
+var callCmd = &cobra.Command{
+	Use:   "call <service> <tool>",
+	Short: "Call a tool of a service in-process and print its result, without MCP client",
+	Long: `Create the service with the config file, call one of its tools with the arguments given in JSON, and print the result,
+to script or debug the tools. The call goes through the timeouts, audit and policies of the server. The confirmations
+a tool asks, e.g. of a destructive command, are decided by --elicitation_fallback.
+    moling call FileSystem list_directory --args '{"path":"/tmp"}'
+    echo '{"command":"uptime"}' | moling call Command execute_command --args -
+`,
+	Args: cobra.ExactArgs(2),
+	RunE: CallCommandFunc,
+}
+
+var callArgs string
+
+// CallCommandFunc executes the "call" command.
+func CallCommandFunc(command *cobra.Command, args []string) error {
+	serviceName, toolName := args[0], args[1]
+	for name := range services.ServiceList() {
+		if strings.EqualFold(string(name), serviceName) {
+			serviceName = string(name)
+		}
+	}
+	if _, ok := services.ServiceList()[comm.MoLingServerType(serviceName)]; !ok {
+		return fmt.Errorf("unknown service %s", serviceName)
+	}
+	arguments, err := parseCallArgs(callArgs)
+	if err != nil {
+		return err
+	}
+
+	logger := initLogger(mlConfig.BasePath)
+	mlConfig.SetLogger(logger)
+	nowConfigJSON, err := loadConfigFile(logger)
+	if err != nil {
+		return err
+	}
+	// The service called is loaded, even if its section disables it.
+	mlConfig.Module = serviceName
+	ctx := context.WithValue(context.Background(), comm.MoLingConfigKey, mlConfig)
+	ctx = context.WithValue(ctx, comm.MoLingLoggerKey, logger)
+	ctx, cancel := context.WithCancel(ctx)
+	defer cancel()
+	srvs := newServices(ctx, nowConfigJSON, logger)
+	defer func() {
+		for _, srv := range srvs {
+			_ = srv.Close()
+		}
+	}()
+	if len(srvs) == 0 {
+		return fmt.Errorf("failed to create the service %s, see the log in %s", serviceName, mlConfig.BasePath)
+	}
+	srv, err := server.NewMoLingServer(ctx, srvs, *mlConfig)
+	if err != nil {
+		return err
+	}
+	defer srv.Close()
+
+	found := false
+	for _, tool := range srv.Tools() {
+		if tool.Service == serviceName && (tool.Tool.Name == toolName || tool.Tool.Name == server.ToolPrefix(comm.MoLingServerType(serviceName))+toolName) {
+			toolName, found = tool.Tool.Name, true
+			break
+		}
+	}
+	if !found {
+		return fmt.Errorf("service %s has no tool %s, list them with: %s tools -m %s", serviceName, toolName, CliName, serviceName)
+	}
+	result, err := srv.CallTool(ctx, toolName, arguments)
+	if err != nil {
+		return err
+	}
+	data, err := json.MarshalIndent(result, "", "  ")
+	if err != nil {
+		return err
+	}
+	fmt.Println(string(data))
+	if result.IsError {
+		return fmt.Errorf("%s returned an error", toolName)
+	}
+	return nil
+}
+
+// parseCallArgs parses the arguments of the tool, a JSON object, read from the standard input for "-".
+func parseCallArgs(value string) (map[string]any, error) {
+	if value == "-" {
+		data, err := io.ReadAll(os.Stdin)
+		if err != nil {
+			return nil, fmt.Errorf("failed to read the arguments: %w", err)
+		}
+		value = string(data)
+	}
+	arguments := make(map[string]any)
+	if strings.TrimSpace(value) == "" {
+		return arguments, nil
+	}
+	if err := json.Unmarshal([]byte(value), &arguments); err != nil {
+		return nil, errors.New("the arguments must be a JSON object, e.g. {\"path\":\"/tmp\"}: " + err.Error())
+	}
+	return arguments, nil
+}
+
+func init() {
+	callCmd.Flags().StringVar(&callArgs, "args", "", "arguments of the tool, a JSON object, or - to read them from the standard input")
+	rootCmd.AddCommand(callCmd)
+}
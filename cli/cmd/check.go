@@ -0,0 +1,134 @@
+// Copyright 2025 CFC4N <cfc4n.cs@gmail.com>. All Rights Reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//   http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+//
+// Repository: https://github.com/gojue/moling
+
+package cmd
+
+import (
+	"context"
+	"fmt"
+	"sort"
+	"strconv"
+	"strings"
+
+	"github.com/rs/zerolog"
+
+	"github.com/gojue/moling/pkg/comm"
+	"github.com/gojue/moling/pkg/config"
+	"github.com/gojue/moling/pkg/server"
+	"github.com/gojue/moling/pkg/services"
+	"github.com/gojue/moling/pkg/services/abstract"
+)
+
+// checkStartup runs the startup of MoLing without serving, for --check: the services of the modules loaded are
+// created and initialized, Chrome isn't launched, and the server is created with their tools. It prints the
+// readiness report, with the checks of the environment, and returns an error if MoLing wouldn't start.
+func checkStartup(loger zerolog.Logger) error {
+	checks := []doctorCheck{checkBasePath()}
+	configCheck, _ := checkConfigFile()
+	checks = append(checks, configCheck, checkConfigVersion())
+	nowConfigJSON, err := loadConfigFile(loger)
+	if err != nil {
+		checks = append(checks, doctorCheck{Name: "MoLingConfig", Status: checkFail, Detail: err.Error(),
+			Fix: "fix the MoLingConfig section of the config file, " + CliName + " config validate shows the invalid keys"})
+	} else {
+		ctx := context.WithValue(context.Background(), comm.MoLingConfigKey, mlConfig)
+		ctx = context.WithValue(ctx, comm.MoLingLoggerKey, loger)
+		srvs, srvChecks := initServices(ctx, nowConfigJSON)
+		checks = append(checks, srvChecks...)
+		checks = append(checks, checkServer(ctx, srvs))
+		for _, srv := range srvs {
+			_ = srv.Close()
+		}
+		checks = append(checks, checkChrome(nowConfigJSON))
+	}
+	pidCheck := checkPIDFile()
+	if pidCheck.Status == checkInfo && !force {
+		// A running instance holds the PID file, this one wouldn't start.
+		pidCheck.Status = checkFail
+	}
+	checks = append(checks, pidCheck, checkListenAddr())
+	if err = reportChecks(checks); err != nil {
+		return err
+	}
+	fmt.Println("MoLing is ready to start.")
+	return nil
+}
+
+// checkConfigVersion checks the version of the config file, an older one would be migrated at start.
+func checkConfigVersion() doctorCheck {
+	c := doctorCheck{Name: "config version"}
+	sections, version, changes, err := configMigration(mlConfig.ConfigFilePath())
+	switch {
+	case err != nil:
+		c.Status, c.Detail = checkFail, err.Error()
+		c.Fix = "set " + config.ConfigVersionKey + " to a version up to " + strconv.Itoa(config.ConfigVersion) + ", or upgrade " + CliName + " for a newer config file"
+	case sections != nil:
+		c.Status = checkInfo
+		c.Detail = fmt.Sprintf("version %d, would migrate to %d at start: %s", version, config.ConfigVersion, strings.Join(changes, "; "))
+	default:
+		c.Status, c.Detail = checkOK, fmt.Sprintf("version %d", version)
+	}
+	return c
+}
+
+// initServices creates and initializes the services of the modules loaded, with their section of the config file,
+// and returns the ones ready with the outcome of each one.
+func initServices(ctx context.Context, nowConfigJSON map[string]any) ([]abstract.Service, []doctorCheck) {
+	var names []string
+	for name := range services.ServiceList() {
+		if moduleLoaded(name) {
+			names = append(names, string(name))
+		}
+	}
+	sort.Strings(names)
+	var srvs []abstract.Service
+	var checks []doctorCheck
+	for _, name := range names {
+		c := doctorCheck{Name: "service " + name}
+		srv, err := services.ServiceList()[comm.MoLingServerType(name)](ctx)
+		if cfg, ok := nowConfigJSON[name].(map[string]any); ok && err == nil {
+			err = srv.LoadConfig(cfg)
+		}
+		if err == nil {
+			err = srv.Init()
+		}
+		if err != nil {
+			c.Status, c.Detail = checkFail, err.Error()
+			c.Fix = fmt.Sprintf("fix the %s section of the config file, or don't load the module with --module", name)
+		} else {
+			c.Status, c.Detail = checkOK, fmt.Sprintf("initialized, %d tools", len(srv.Tools()))
+			srvs = append(srvs, srv)
+		}
+		checks = append(checks, c)
+	}
+	return srvs, checks
+}
+
+// checkServer checks the server is created with the services: the settings of MoLingConfig and the tools exposed.
+func checkServer(ctx context.Context, srvs []abstract.Service) doctorCheck {
+	c := doctorCheck{Name: "server"}
+	srv, err := server.NewMoLingServer(ctx, srvs, *mlConfig)
+	if srv != nil {
+		_ = srv.Close()
+	}
+	if err != nil {
+		c.Status, c.Detail = checkFail, err.Error()
+		c.Fix = "fix the flags or the MoLingConfig section of the config file"
+		return c
+	}
+	c.Status, c.Detail = checkOK, fmt.Sprintf("created with %d services", len(srvs))
+	return c
+}
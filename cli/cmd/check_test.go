@@ -0,0 +1,49 @@
+// Copyright 2025 CFC4N <cfc4n.cs@gmail.com>. All Rights Reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//   http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+//
+// Repository: https://github.com/gojue/moling
+
+package cmd
+
+import (
+	"context"
+	"testing"
+
+	"github.com/rs/zerolog"
+
+	"github.com/gojue/moling/pkg/comm"
+)
+
+// TestInitServices verifies the services of the modules loaded only are initialized, an invalid section failing.
+func TestInitServices(t *testing.T) {
+	basePath, module := mlConfig.BasePath, mlConfig.Module
+	defer func() { mlConfig.BasePath, mlConfig.Module = basePath, module }()
+	mlConfig.BasePath, mlConfig.Module = t.TempDir(), "FileSystem,Command"
+	ctx := context.WithValue(context.Background(), comm.MoLingConfigKey, mlConfig)
+	ctx = context.WithValue(ctx, comm.MoLingLoggerKey, zerolog.Nop())
+
+	srvs, checks := initServices(ctx, map[string]any{"FileSystem": map[string]any{"allowed_dir": 1}})
+	if len(checks) != 2 || checks[0].Name != "service Command" || checks[1].Name != "service FileSystem" {
+		t.Fatalf("expected the checks of Command and FileSystem, got %v", checks)
+	}
+	if checks[0].Status != checkOK || checks[1].Status != checkFail {
+		t.Errorf("expected Command ready and FileSystem failing, got %v", checks)
+	}
+	if len(srvs) != 1 || srvs[0].Name() != "Command" {
+		t.Errorf("expected the Command service ready only, got %d services", len(srvs))
+	}
+	for _, srv := range srvs {
+		_ = srv.Close()
+	}
+}
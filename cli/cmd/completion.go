@@ -0,0 +1,115 @@
+// Copyright 2025 CFC4N <cfc4n.cs@gmail.com>. All Rights Reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//   http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+//
+// Repository: https://github.com/gojue/moling
+
+package cmd
+
+import (
+	"sort"
+	"strings"
+
+	"github.com/spf13/cobra"
+
+	"github.com/gojue/moling/pkg/services"
+)
+
+// hasPrefixFold tells whether s begins with prefix, ignoring case, so "brow" completes Browser.
+func hasPrefixFold(s, prefix string) bool {
+	return len(s) >= len(prefix) && strings.EqualFold(s[:len(prefix)], prefix)
+}
+
+// serviceNames returns the names of the services registered, sorted.
+func serviceNames() []string {
+	names := services.ServiceNames()
+	sort.Strings(names)
+	return names
+}
+
+// completeModules completes the list of modules of --module, separated by commas: the last one is completed, the
+// ones already listed aren't proposed again.
+func completeModules(command *cobra.Command, args []string, toComplete string) ([]string, cobra.ShellCompDirective) {
+	listed, last := "", toComplete
+	if i := strings.LastIndex(toComplete, ","); i >= 0 {
+		listed, last = toComplete[:i+1], toComplete[i+1:]
+	}
+	var completions []string
+	if listed == "" && hasPrefixFold("all", last) {
+		completions = append(completions, "all")
+	}
+	for _, name := range serviceNames() {
+		if hasPrefixFold(name, last) && !strings.Contains(","+listed, ","+name+",") {
+			completions = append(completions, listed+name)
+		}
+	}
+	return completions, cobra.ShellCompDirectiveNoFileComp | cobra.ShellCompDirectiveNoSpace
+}
+
+// completeServices completes the name of a service, the first argument.
+func completeServices(command *cobra.Command, args []string, toComplete string) ([]string, cobra.ShellCompDirective) {
+	if len(args) > 0 {
+		return nil, cobra.ShellCompDirectiveNoFileComp
+	}
+	var completions []string
+	for _, name := range serviceNames() {
+		if hasPrefixFold(name, toComplete) {
+			completions = append(completions, name)
+		}
+	}
+	return completions, cobra.ShellCompDirectiveNoFileComp
+}
+
+// configKeys returns the Section.key paths of the settings: the ones of MoLingConfig and of every service.
+func configKeys() []string {
+	var keys []string
+	for _, section := range append([]string{"MoLingConfig"}, serviceNames()...) {
+		defaults, err := defaultSection(section)
+		if err != nil {
+			continue
+		}
+		for key := range defaults {
+			keys = append(keys, section+"."+key)
+		}
+	}
+	sort.Strings(keys)
+	return keys
+}
+
+// completeConfigKeys completes the Section.key of the settings, followed by suffix, e.g. "=" for config set.
+func completeConfigKeys(suffix string) cobra.CompletionFunc {
+	return func(command *cobra.Command, args []string, toComplete string) ([]string, cobra.ShellCompDirective) {
+		directive := cobra.ShellCompDirectiveNoFileComp
+		if suffix != "" {
+			directive |= cobra.ShellCompDirectiveNoSpace
+		}
+		var completions []string
+		for _, key := range configKeys() {
+			if hasPrefixFold(key, toComplete) && !strings.Contains(toComplete, "=") {
+				completions = append(completions, key+suffix)
+			}
+		}
+		return completions, directive
+	}
+}
+
+func init() {
+	callCmd.ValidArgsFunction = completeServices
+	configGetCmd.ValidArgsFunction = func(command *cobra.Command, args []string, toComplete string) ([]string, cobra.ShellCompDirective) {
+		if len(args) > 0 {
+			return nil, cobra.ShellCompDirectiveNoFileComp
+		}
+		return completeConfigKeys("")(command, args, toComplete)
+	}
+	configSetCmd.ValidArgsFunction = completeConfigKeys("=")
+}
@@ -0,0 +1,40 @@
+// Copyright 2025 CFC4N <cfc4n.cs@gmail.com>. All Rights Reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//   http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+//
+// Repository: https://github.com/gojue/moling
+
+package cmd
+
+import (
+	"slices"
+	"testing"
+)
+
+// TestCompleteModules verifies the last module of the list is completed, by prefix ignoring case.
+func TestCompleteModules(t *testing.T) {
+	for _, tc := range []struct {
+		toComplete string
+		want       []string
+	}{
+		{"brow", []string{"Browser"}},
+		{"a", []string{"all", "Aggregate"}},
+		{"Browser,F", []string{"Browser,FileSystem"}},
+		{"Browser,", []string{"Browser,Aggregate", "Browser,Command", "Browser,FileSystem"}},
+	} {
+		got, _ := completeModules(rootCmd, nil, tc.toComplete)
+		if !slices.Equal(got, tc.want) {
+			t.Errorf("completeModules(%q) = %v, want %v", tc.toComplete, got, tc.want)
+		}
+	}
+}
@@ -20,15 +20,16 @@ import (
 	"bytes"
 	"context"
 	"encoding/json"
+	"errors"
 	"fmt"
 	"os"
-	"path/filepath"
 	"time"
 
 	"github.com/rs/zerolog"
 	"github.com/spf13/cobra"
 
 	"github.com/gojue/moling/pkg/comm"
+	"github.com/gojue/moling/pkg/config"
 	"github.com/gojue/moling/pkg/services"
 )
 
@@ -57,22 +58,25 @@ func ConfigCommandFunc(command *cobra.Command, args []string) error {
 	ctx = context.WithValue(ctx, comm.MoLingLoggerKey, logger)
 
 	// 当前配置文件检测
-	hasConfig := false
-	var nowConfig []byte
-	nowConfigJSON := make(map[string]any)
-	configFilePath := filepath.Join(mlConfig.BasePath, mlConfig.ConfigFile)
-	if nowConfig, err = os.ReadFile(configFilePath); err == nil {
-		hasConfig = true
+	// The settings of the files included are shown too.
+	configFilePath := mlConfig.ConfigFilePath()
+	nowConfigJSON, err := config.ReadConfigFile(configFilePath)
+	hasConfig := !errors.Is(err, os.ErrNotExist)
+	if !hasConfig {
+		nowConfigJSON, err = make(map[string]any), nil
 	}
-	if hasConfig {
-		err = json.Unmarshal(nowConfig, &nowConfigJSON)
-		if err != nil {
-			return fmt.Errorf("error unmarshaling JSON: %w, payload:%s", err, string(nowConfig))
-		}
+	if err != nil {
+		return err
 	}
 
+	// The editors complete and check the keys with the JSON Schema, written next to the config file by config schema.
+	if _, err := os.Stat(mlConfig.SchemaFilePath()); errors.Is(err, os.ErrNotExist) {
+		logger.Info().Msgf("Write the JSON Schema referenced by %s with: %s config schema --write", config.SchemaKey, CliName)
+	}
 	bf := bytes.Buffer{}
 	bf.WriteString("\n{\n")
+	bf.WriteString(fmt.Sprintf("\t\"%s\": %q,\n", config.SchemaKey, config.SchemaRef()))
+	bf.WriteString(fmt.Sprintf("\t\"%s\": %d,\n", config.ConfigVersionKey, config.ConfigVersion))
 
 	// 写入GlobalConfig
 	mlConfigJSON, err := json.Marshal(mlConfig)
@@ -143,6 +147,6 @@ func ConfigCommandFunc(command *cobra.Command, args []string) error {
 }
 
 func init() {
-	configCmd.PersistentFlags().BoolVar(&initial, "init", false, fmt.Sprintf("Save configuration to %s", filepath.Join(mlConfig.BasePath, mlConfig.ConfigFile)))
+	configCmd.PersistentFlags().BoolVar(&initial, "init", false, fmt.Sprintf("Save configuration to %s", mlConfig.ConfigFilePath()))
 	rootCmd.AddCommand(configCmd)
 }
@@ -0,0 +1,128 @@
+// Copyright 2025 CFC4N <cfc4n.cs@gmail.com>. All Rights Reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//   http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+//
+// Repository: https://github.com/gojue/moling
+
+package cmd
+
+import (
+	"encoding/json"
+	"fmt"
+	"reflect"
+	"sort"
+
+	"github.com/spf13/cobra"
+
+	"github.com/gojue/moling/pkg/config"
+)
+
+var configDiffCmd = &cobra.Command{
+	Use:   "diff [file]",
+	Short: "Show the settings of the config file differing from the defaults, and the unknown keys",
+	Long: `Show the settings of the config file, the current one unless another file is given, that differ from the built-in
+defaults, and the keys MoLing doesn't know, e.g. left over from an older version.
+    moling config diff
+    moling config diff ./config.json
+`,
+	Args: cobra.MaximumNArgs(1),
+	RunE: ConfigDiffCommandFunc,
+}
+
+// configDiff is a setting of the config file differing from its default, or unknown.
+type configDiff struct {
+	Section string
+	Key     string // Key is empty for an unknown section.
+	Default any
+	Value   any
+	Unknown bool
+}
+
+func (d configDiff) String() string {
+	if d.Key == "" {
+		return "? " + d.Section + ": unknown service"
+	}
+	if d.Unknown {
+		return "? " + d.Section + "." + d.Key + ": unknown key, " + jsonString(d.Value)
+	}
+	return "~ " + d.Section + "." + d.Key + ": " + jsonString(d.Default) + " -> " + jsonString(d.Value)
+}
+
+// jsonString returns the compact JSON of the value.
+func jsonString(v any) string {
+	data, err := json.Marshal(v)
+	if err != nil {
+		return fmt.Sprint(v)
+	}
+	return string(data)
+}
+
+// ConfigDiffCommandFunc executes the "config diff" command.
+func ConfigDiffCommandFunc(command *cobra.Command, args []string) error {
+	configFilePath := mlConfig.ConfigFilePath()
+	if len(args) > 0 {
+		configFilePath = args[0]
+	}
+	sections, err := readConfigSections(configFilePath)
+	if err != nil {
+		return err
+	}
+	diffs, err := diffConfig(sections)
+	if err != nil {
+		return err
+	}
+	if len(diffs) == 0 {
+		fmt.Printf("%s doesn't change the defaults\n", configFilePath)
+		return nil
+	}
+	for _, d := range diffs {
+		fmt.Println(d.String())
+	}
+	return nil
+}
+
+// diffConfig compares the sections of the config file with the defaults, sorted by section and key.
+func diffConfig(sections map[string]any) ([]configDiff, error) {
+	var diffs []configDiff
+	for name, v := range sections {
+		if !config.IsSection(name) {
+			continue
+		}
+		defaults, err := defaultSection(name)
+		if err != nil {
+			diffs = append(diffs, configDiff{Section: name, Value: v, Unknown: true})
+			continue
+		}
+		settings, ok := v.(map[string]any)
+		if !ok {
+			return nil, fmt.Errorf("the section %s must be a JSON object", name)
+		}
+		for key, value := range settings {
+			def, known := defaults[key]
+			if !known || !reflect.DeepEqual(def, value) {
+				diffs = append(diffs, configDiff{Section: name, Key: key, Default: def, Value: value, Unknown: !known})
+			}
+		}
+	}
+	sort.Slice(diffs, func(i, j int) bool {
+		if diffs[i].Section != diffs[j].Section {
+			return diffs[i].Section < diffs[j].Section
+		}
+		return diffs[i].Key < diffs[j].Key
+	})
+	return diffs, nil
+}
+
+func init() {
+	configCmd.AddCommand(configDiffCmd)
+}
@@ -0,0 +1,46 @@
+// Copyright 2025 CFC4N <cfc4n.cs@gmail.com>. All Rights Reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//   http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+//
+// Repository: https://github.com/gojue/moling
+
+package cmd
+
+import (
+	"testing"
+)
+
+// TestDiffConfig verifies the changed and the unknown settings are reported, not the defaults.
+func TestDiffConfig(t *testing.T) {
+	mlConfig.BasePath = t.TempDir()
+	diffs, err := diffConfig(map[string]any{
+		"Browser": map[string]any{"headless": true, "timeout": float64(30), "old_opt": 1},
+		"Foo":     map[string]any{},
+	})
+	if err != nil {
+		t.Fatalf("diffConfig: %v", err)
+	}
+	want := []string{
+		"~ Browser.headless: false -> true",
+		"? Browser.old_opt: unknown key, 1",
+		"? Foo: unknown service",
+	}
+	if len(diffs) != len(want) {
+		t.Fatalf("expected %d differences, got %v", len(want), diffs)
+	}
+	for i, d := range diffs {
+		if d.String() != want[i] {
+			t.Errorf("expected %q, got %q", want[i], d.String())
+		}
+	}
+}
@@ -0,0 +1,119 @@
+// Copyright 2025 CFC4N <cfc4n.cs@gmail.com>. All Rights Reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//   http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+//
+// Repository: https://github.com/gojue/moling
+
+package cmd
+
+import (
+	"encoding/json"
+	"fmt"
+	"maps"
+
+	"github.com/spf13/cobra"
+	"github.com/spf13/pflag"
+
+	"github.com/gojue/moling/pkg/config"
+)
+
+var configSchemaCmd = &cobra.Command{
+	Use:   "schema",
+	Short: "Print the JSON Schema of the config file, for the editors",
+	Long: `Print the JSON Schema of the config file: the settings of MoLingConfig and of every service. The schema is embedded
+in MoLing, --write writes it next to the config file, referenced by its $schema key.
+    moling config schema > pkg/config/schema.json
+    moling config schema --write     Write the schema next to the config file, for the editors
+`,
+	Args: cobra.NoArgs,
+	// Nothing is written to the base path, unless --write.
+	PersistentPreRunE: func(command *cobra.Command, args []string) error {
+		if writeSchema {
+			return mlsCommandPreFunc(command, args)
+		}
+		return nil
+	},
+	RunE: func(command *cobra.Command, args []string) error {
+		if writeSchema {
+			if _, err := mlConfig.WriteSchema(); err != nil {
+				return fmt.Errorf("error writing the JSON Schema: %w", err)
+			}
+			fmt.Printf("JSON Schema written to %s\n", mlConfig.SchemaFilePath())
+			return nil
+		}
+		data, err := configSchema()
+		if err != nil {
+			return err
+		}
+		fmt.Println(string(data))
+		return nil
+	},
+}
+
+// writeSchema is the --write flag of config schema.
+var writeSchema bool
+
+// fileSettingDescriptions describe the MoLingConfig settings read from the config file only, without a flag.
+var fileSettingDescriptions = map[string]string{
+	"auth_tokens":         "the API tokens of the SSE mode with their scopes, e.g. [{\"name\": \"ci\", \"token\": \"...\", \"scopes\": [\"FileSystem\"]}]",
+	"tool_timeouts":       "the timeouts in seconds of the tool calls by service or tool name, e.g. {\"Browser\": 300}. 0: none",
+	"enabled_tools":       "the only tools exposed, by name or glob pattern, e.g. \"browser_*\". default: all",
+	"disabled_tools":      "the tools not exposed, by name or glob pattern, e.g. [\"write_file\", \"move_file\"]",
+	"service_concurrency": "the most tool calls of a service run at once, by service name, e.g. {\"Browser\": 0}. 0: no limit",
+}
+
+// configSchema generates the JSON Schema of the config file, the MoLingConfig settings described by the usage of
+// their flag, and the settings of the services typed by their defaults.
+func configSchema() ([]byte, error) {
+	descriptions := maps.Clone(fileSettingDescriptions)
+	rootCmd.PersistentFlags().VisitAll(func(f *pflag.Flag) { descriptions[f.Name] = f.Usage })
+	properties := map[string]any{
+		config.SchemaKey: map[string]any{"type": "string", "description": "the JSON Schema of this file"},
+		config.ConfigVersionKey: map[string]any{
+			"type":        "integer",
+			"description": fmt.Sprintf("the version of the format of this file, older ones are upgraded at load. current: %d", config.ConfigVersion),
+		},
+		config.IncludeKey: map[string]any{
+			"type":        []string{"string", "array"},
+			"items":       map[string]any{"type": "string"},
+			"description": "the files merged under this one, in their order, relative to its directory, e.g. [\"base.json\", \"site-overrides.json\"]",
+		},
+		"MoLingConfig": config.SettingsSchema(descriptions),
+	}
+	for _, name := range serviceNames() {
+		defaults, err := defaultSection(name)
+		if err != nil {
+			return nil, err
+		}
+		schema := config.ValueSchema(defaults)
+		schema["description"] = "the settings of the " + name + " service"
+		if properties, ok := schema["properties"].(map[string]any); ok {
+			properties[config.EnabledKey] = map[string]any{"type": "boolean", "description": "load the " + name + " module, overridden by --module. default: true"}
+		}
+		// The unknown keys of a service are invalid, see config validate.
+		schema["additionalProperties"] = false
+		properties[name] = schema
+	}
+	return json.MarshalIndent(map[string]any{
+		"$schema":              "https://json-schema.org/draft-07/schema#",
+		"title":                "MoLing config file",
+		"type":                 "object",
+		"properties":           properties,
+		"additionalProperties": false,
+	}, "", "  ")
+}
+
+func init() {
+	configSchemaCmd.Flags().BoolVar(&writeSchema, "write", false, "write the schema next to the config file instead of printing it")
+	configCmd.AddCommand(configSchemaCmd)
+}
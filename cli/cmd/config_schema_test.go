@@ -0,0 +1,78 @@
+// Copyright 2025 CFC4N <cfc4n.cs@gmail.com>. All Rights Reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//   http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+//
+// Repository: https://github.com/gojue/moling
+
+package cmd
+
+import (
+	"bytes"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/gojue/moling/pkg/config"
+)
+
+// TestConfigSchema verifies the embedded JSON Schema is the one generated, and the config file referencing it
+// is valid.
+func TestConfigSchema(t *testing.T) {
+	mlConfig.BasePath = t.TempDir()
+	data, err := configSchema()
+	if err != nil {
+		t.Fatalf("configSchema: %v", err)
+	}
+	if !bytes.Equal(bytes.TrimSpace(config.Schema), data) {
+		t.Errorf("pkg/config/schema.json is outdated, regenerate it with: go run . config schema > pkg/config/schema.json")
+	}
+
+	problems, err := validateConfig([]byte(`{"$schema": "./config.schema.json", "FileSystem": {"bogus": true}}`))
+	if err != nil {
+		t.Fatalf("validateConfig: %v", err)
+	}
+	if len(problems) != 1 || !strings.HasSuffix(problems[0].String(), "(#/properties/FileSystem/properties/bogus)") {
+		t.Errorf("expected the unknown key with its schema path, got %v", problems)
+	}
+}
+
+// TestConfigReadOnly verifies config shows the configuration without writing the JSON Schema, config schema
+// --write does.
+func TestConfigReadOnly(t *testing.T) {
+	basePath, configFile := mlConfig.BasePath, mlConfig.ConfigFile
+	defer func() { mlConfig.BasePath, mlConfig.ConfigFile = basePath, configFile }()
+	mlConfig.BasePath = t.TempDir()
+	mlConfig.ConfigFile = "config.json"
+	if err := os.MkdirAll(filepath.Join(mlConfig.BasePath, "logs"), 0o755); err != nil {
+		t.Fatalf("MkdirAll: %v", err)
+	}
+	if err := os.WriteFile(mlConfig.ConfigFilePath(), []byte("{}"), 0o600); err != nil {
+		t.Fatalf("WriteFile: %v", err)
+	}
+	if err := ConfigCommandFunc(configCmd, nil); err != nil {
+		t.Fatalf("config: %v", err)
+	}
+	if _, err := os.Stat(mlConfig.SchemaFilePath()); !os.IsNotExist(err) {
+		t.Errorf("expected config not to write the JSON Schema, got %v", err)
+	}
+
+	writeSchema = true
+	defer func() { writeSchema = false }()
+	if err := configSchemaCmd.RunE(configSchemaCmd, nil); err != nil {
+		t.Fatalf("config schema --write: %v", err)
+	}
+	if data, err := os.ReadFile(mlConfig.SchemaFilePath()); err != nil || !bytes.Equal(data, config.Schema) {
+		t.Errorf("expected the JSON Schema written, got %v", err)
+	}
+}
@@ -0,0 +1,249 @@
+// Copyright 2025 CFC4N <cfc4n.cs@gmail.com>. All Rights Reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//   http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+//
+// Repository: https://github.com/gojue/moling
+
+package cmd
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"github.com/rs/zerolog"
+	"github.com/spf13/cobra"
+
+	"github.com/gojue/moling/pkg/comm"
+	"github.com/gojue/moling/pkg/config"
+	"github.com/gojue/moling/pkg/services"
+)
+
+var configGetCmd = &cobra.Command{
+	Use:   "get <Section.key>",
+	Short: "Show a setting of the config file, its default if it isn't set",
+	Long: `Show a setting of the config file, as JSON. The default of the service is shown if the file doesn't set it.
+    moling config get FileSystem.allowed_dir
+    moling config get MoLingConfig.auth_tokens
+`,
+	Args: cobra.ExactArgs(1),
+	RunE: ConfigGetCommandFunc,
+}
+
+var configSetCmd = &cobra.Command{
+	Use:   "set <Section.key=value>...",
+	Short: "Change settings of the config file, validated and the previous file backed up",
+	Long: `Change settings of the config file. The value is JSON, or a string if it isn't valid JSON. The settings are checked
+like "moling config validate" before the file is written, and the previous file is kept as config.json.bak.
+    moling config set Browser.headless=true
+    moling config set FileSystem.allowed_dir=/tmp/moling,/home/user/Documents
+    moling config set 'Command.allowed_command=["ls","cat"]'
+`,
+	Args: cobra.MinimumNArgs(1),
+	RunE: ConfigSetCommandFunc,
+}
+
+// configBackupSuffix is the suffix of the backup of the config file, written by "config set".
+const configBackupSuffix = ".bak"
+
+// splitConfigKey splits the "Section.key" path of a setting.
+func splitConfigKey(path string) (string, string, error) {
+	section, key, ok := strings.Cut(path, ".")
+	if !ok || section == "" || key == "" {
+		return "", "", fmt.Errorf("invalid setting %q, expected Section.key, e.g. Browser.headless", path)
+	}
+	return section, key, nil
+}
+
+// readConfigSections reads the sections of the config file, none if it doesn't exist, upgraded to the current
+// config.ConfigVersion.
+func readConfigSections(path string) (map[string]any, error) {
+	sections := make(map[string]any)
+	data, err := os.ReadFile(path)
+	if err != nil && !errors.Is(err, os.ErrNotExist) {
+		return nil, err
+	}
+	if err == nil {
+		if err = json.Unmarshal(data, &sections); err != nil {
+			return nil, fmt.Errorf("invalid JSON in %s: %w", path, err)
+		}
+	}
+	if _, err = config.MigrateConfig(sections); err != nil {
+		return nil, fmt.Errorf("%s: %w", path, err)
+	}
+	return sections, nil
+}
+
+// defaultSection returns the default settings of the section: the flags of MoLingConfig or the configuration of
+// the service, enabled.
+func defaultSection(section string) (map[string]any, error) {
+	var data []byte
+	if section == "MoLingConfig" {
+		var err error
+		if data, err = json.Marshal(mlConfig); err != nil {
+			return nil, err
+		}
+	} else {
+		factory, ok := services.ServiceList()[comm.MoLingServerType(section)]
+		if !ok {
+			return nil, fmt.Errorf("unknown service %s", section)
+		}
+		ctx := context.WithValue(context.Background(), comm.MoLingConfigKey, mlConfig)
+		ctx = context.WithValue(ctx, comm.MoLingLoggerKey, zerolog.Nop())
+		srv, err := factory(ctx)
+		if err != nil {
+			return nil, fmt.Errorf("failed to create the service %s: %w", section, err)
+		}
+		data = []byte(srv.Config())
+	}
+	defaults := make(map[string]any)
+	if err := json.Unmarshal(data, &defaults); err != nil {
+		return nil, err
+	}
+	if section != "MoLingConfig" {
+		// The modules are enabled unless their section disables them.
+		defaults[config.EnabledKey] = true
+	}
+	return defaults, nil
+}
+
+// ConfigGetCommandFunc executes the "config get" command.
+func ConfigGetCommandFunc(command *cobra.Command, args []string) error {
+	section, key, err := splitConfigKey(args[0])
+	if err != nil {
+		return err
+	}
+	// The value is the one merged over the files the config file includes, the one MoLing loads.
+	sections, err := config.ReadConfigFile(mlConfig.ConfigFilePath())
+	if errors.Is(err, os.ErrNotExist) {
+		sections, err = make(map[string]any), nil
+	}
+	if err != nil {
+		return err
+	}
+	settings, _ := sections[section].(map[string]any)
+	value, ok := settings[key]
+	if !ok {
+		defaults, err := defaultSection(section)
+		if err != nil {
+			return err
+		}
+		if value, ok = defaults[key]; !ok {
+			return fmt.Errorf("unknown setting %s", args[0])
+		}
+	}
+	data, err := json.MarshalIndent(value, "", "  ")
+	if err != nil {
+		return err
+	}
+	fmt.Println(string(data))
+	return nil
+}
+
+// ConfigSetCommandFunc executes the "config set" command.
+func ConfigSetCommandFunc(command *cobra.Command, args []string) error {
+	configFilePath := mlConfig.ConfigFilePath()
+	sections, err := readConfigSections(configFilePath)
+	if err != nil {
+		return err
+	}
+	changed := make(map[string]bool)
+	for _, arg := range args {
+		path, raw, ok := strings.Cut(arg, "=")
+		if !ok {
+			return fmt.Errorf("invalid argument %q, expected Section.key=value", arg)
+		}
+		section, key, err := splitConfigKey(path)
+		if err != nil {
+			return err
+		}
+		var value any
+		if err = json.Unmarshal([]byte(raw), &value); err != nil {
+			value = raw
+		}
+		settings, ok := sections[section].(map[string]any)
+		if !ok {
+			settings = make(map[string]any)
+			sections[section] = settings
+		}
+		settings[key] = value
+		changed[section] = true
+		changed[section+"."+key] = true
+	}
+
+	data, err := json.MarshalIndent(sections, "", "  ")
+	if err != nil {
+		return err
+	}
+	problems, err := validateConfig(data)
+	if err != nil {
+		return err
+	}
+	var invalid []string
+	for _, p := range problems {
+		if changed[p.Section+"."+p.Key] || (p.Key == "" && changed[p.Section]) {
+			invalid = append(invalid, p.String())
+		}
+	}
+	if len(invalid) > 0 {
+		return fmt.Errorf("invalid settings, %s is unchanged:\n%s", configFilePath, strings.Join(invalid, "\n"))
+	}
+	if err = writeConfigFile(configFilePath, append(data, '\n')); err != nil {
+		return err
+	}
+	fmt.Printf("%s updated\n", configFilePath)
+	return nil
+}
+
+// writeConfigFile replaces the config file atomically, the previous one kept with configBackupSuffix. Both keep
+// the mode of the previous one, e.g. 0600 for a config file with secrets.
+func writeConfigFile(path string, data []byte) error {
+	mode := os.FileMode(0644)
+	if fi, err := os.Stat(path); err == nil {
+		mode = fi.Mode().Perm()
+	}
+	if prev, err := os.ReadFile(path); err == nil {
+		backup := path + configBackupSuffix
+		if err = os.WriteFile(backup, prev, mode); err == nil {
+			err = os.Chmod(backup, mode)
+		}
+		if err != nil {
+			return fmt.Errorf("failed to back up the config file: %w", err)
+		}
+	}
+	tmp, err := os.CreateTemp(filepath.Dir(path), filepath.Base(path)+".*")
+	if err != nil {
+		return err
+	}
+	defer func() { _ = os.Remove(tmp.Name()) }()
+	if _, err = tmp.Write(data); err != nil {
+		_ = tmp.Close()
+		return err
+	}
+	if err = tmp.Close(); err != nil {
+		return err
+	}
+	if err = os.Chmod(tmp.Name(), mode); err != nil {
+		return err
+	}
+	return os.Rename(tmp.Name(), path)
+}
+
+func init() {
+	configCmd.AddCommand(configGetCmd)
+	configCmd.AddCommand(configSetCmd)
+}
@@ -0,0 +1,64 @@
+// Copyright 2025 CFC4N <cfc4n.cs@gmail.com>. All Rights Reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//   http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+//
+// Repository: https://github.com/gojue/moling
+
+package cmd
+
+import (
+	"os"
+	"path/filepath"
+	"runtime"
+	"testing"
+)
+
+// TestWriteConfigFile verifies the config file is replaced and the previous one backed up.
+func TestWriteConfigFile(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "config.json")
+	if err := writeConfigFile(path, []byte("{}\n")); err != nil {
+		t.Fatalf("writeConfigFile: %v", err)
+	}
+	if _, err := os.Stat(path + configBackupSuffix); !os.IsNotExist(err) {
+		t.Errorf("expected no backup of a new config file, got %v", err)
+	}
+	if err := writeConfigFile(path, []byte("{\"Browser\": {}}\n")); err != nil {
+		t.Fatalf("writeConfigFile: %v", err)
+	}
+	if data, _ := os.ReadFile(path); string(data) != "{\"Browser\": {}}\n" {
+		t.Errorf("expected the new config file, got %q", data)
+	}
+	if data, _ := os.ReadFile(path + configBackupSuffix); string(data) != "{}\n" {
+		t.Errorf("expected the previous config file backed up, got %q", data)
+	}
+	entries, _ := os.ReadDir(filepath.Dir(path))
+	if len(entries) != 2 {
+		t.Errorf("expected the config file and its backup only, got %d files", len(entries))
+	}
+
+	if err := os.Chmod(path, 0600); err != nil {
+		t.Fatal(err)
+	}
+	if err := writeConfigFile(path, []byte("{}\n")); err != nil {
+		t.Fatalf("writeConfigFile: %v", err)
+	}
+	for _, p := range []string{path, path + configBackupSuffix} {
+		fi, err := os.Stat(p)
+		if err != nil {
+			t.Fatalf("stat %s: %v", p, err)
+		}
+		if runtime.GOOS != "windows" && fi.Mode().Perm() != 0600 {
+			t.Errorf("expected %s to keep the mode 0600, got %v", p, fi.Mode())
+		}
+	}
+}
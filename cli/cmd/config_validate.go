@@ -0,0 +1,213 @@
+// Copyright 2025 CFC4N <cfc4n.cs@gmail.com>. All Rights Reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//   http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+//
+// Repository: https://github.com/gojue/moling
+
+package cmd
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"sort"
+
+	"github.com/rs/zerolog"
+	"github.com/spf13/cobra"
+
+	"github.com/gojue/moling/pkg/comm"
+	"github.com/gojue/moling/pkg/config"
+	"github.com/gojue/moling/pkg/services"
+	"github.com/gojue/moling/pkg/services/abstract"
+)
+
+var configValidateCmd = &cobra.Command{
+	Use:   "validate [file]",
+	Short: "Validate the config file, reporting every invalid key of every service",
+	Long: `Validate the config file, the current one unless another file is given: its JSON syntax, the settings of MoLingConfig
+and the section of every service, with the checks the services run at start. All the problems found are reported, by key.
+    moling config validate
+    moling config validate ./config.json
+`,
+	Args: cobra.MaximumNArgs(1),
+	RunE: ConfigValidateCommandFunc,
+}
+
+// configProblem is an invalid key, or section, of the config file.
+type configProblem struct {
+	Section string
+	Key     string // Key is empty for the problems of a whole section.
+	Message string
+}
+
+// String returns the problem with the path of the key in the JSON Schema of the config file.
+func (p configProblem) String() string {
+	path := config.SchemaPath(p.Section, p.Key)
+	if p.Key == "" {
+		return p.Section + ": " + p.Message + " (" + path + ")"
+	}
+	return p.Section + "." + p.Key + ": " + p.Message + " (" + path + ")"
+}
+
+// ConfigValidateCommandFunc executes the "config validate" command.
+func ConfigValidateCommandFunc(command *cobra.Command, args []string) error {
+	configFilePath := mlConfig.ConfigFilePath()
+	if len(args) > 0 {
+		configFilePath = args[0]
+	}
+	data, err := os.ReadFile(configFilePath)
+	if err != nil {
+		return err
+	}
+	problems, err := validateConfig(data)
+	if err != nil {
+		return fmt.Errorf("%s: %w", configFilePath, err)
+	}
+	for _, p := range problems {
+		fmt.Println(p.String())
+	}
+	if len(problems) > 0 {
+		return fmt.Errorf("%s has %d problems", configFilePath, len(problems))
+	}
+	fmt.Printf("%s is valid\n", configFilePath)
+	return nil
+}
+
+// validateConfig returns the problems of the config file, the error of its syntax if it isn't JSON.
+func validateConfig(data []byte) ([]configProblem, error) {
+	var sections map[string]any
+	if err := json.Unmarshal(data, &sections); err != nil {
+		var syntaxErr *json.SyntaxError
+		if errors.As(err, &syntaxErr) {
+			line := bytes.Count(data[:syntaxErr.Offset], []byte("\n")) + 1
+			column := int(syntaxErr.Offset) - bytes.LastIndexByte(data[:syntaxErr.Offset], '\n') - 1
+			return nil, fmt.Errorf("invalid JSON at line %d, column %d: %w", line, column, err)
+		}
+		return nil, fmt.Errorf("invalid JSON: %w", err)
+	}
+	names := make([]string, 0, len(sections))
+	for name := range sections {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+
+	ctx := context.WithValue(context.Background(), comm.MoLingConfigKey, mlConfig)
+	ctx = context.WithValue(ctx, comm.MoLingLoggerKey, zerolog.Nop())
+	var problems []configProblem
+	for _, name := range names {
+		if name == config.SchemaKey {
+			if _, ok := sections[name].(string); !ok {
+				problems = append(problems, configProblem{Section: name, Message: "must be a string, the JSON Schema of the file"})
+			}
+			continue
+		}
+		if name == config.ConfigVersionKey {
+			if version, err := config.FileVersion(sections); err != nil {
+				problems = append(problems, configProblem{Section: name, Message: err.Error()})
+			} else if version > config.ConfigVersion {
+				problems = append(problems, configProblem{Section: name, Message: fmt.Sprintf("newer than the version %d of this MoLing", config.ConfigVersion)})
+			}
+			continue
+		}
+		if name == config.IncludeKey {
+			if _, err := config.IncludeFiles(sections); err != nil {
+				problems = append(problems, configProblem{Section: name, Message: err.Error()})
+			}
+			continue
+		}
+		section, ok := sections[name].(map[string]any)
+		if !ok {
+			problems = append(problems, configProblem{Section: name, Message: "must be a JSON object"})
+			continue
+		}
+		if name == "MoLingConfig" {
+			problems = append(problems, validateSettings(section)...)
+			continue
+		}
+		factory, ok := services.ServiceList()[comm.MoLingServerType(name)]
+		if !ok {
+			problems = append(problems, configProblem{Section: name, Message: "unknown service"})
+			continue
+		}
+		problems = append(problems, validateService(ctx, name, factory, section)...)
+	}
+	return problems, nil
+}
+
+// validateSettings checks the types of the MoLingConfig settings read from the config file.
+func validateSettings(section map[string]any) []configProblem {
+	var problems []configProblem
+	for key, v := range fileSettings(&config.MoLingConfig{}) {
+		if section[key] == nil {
+			continue
+		}
+		data, _ := json.Marshal(section[key])
+		if err := json.Unmarshal(data, v); err != nil {
+			problems = append(problems, configProblem{Section: "MoLingConfig", Key: key, Message: err.Error()})
+		}
+	}
+	sort.Slice(problems, func(i, j int) bool { return problems[i].Key < problems[j].Key })
+	return problems
+}
+
+// validateService checks the section of the service: its keys are known, and each one, then all of them, pass
+// the checks of the service. The checks of a key are run with the defaults of the others, to tell it offends.
+func validateService(ctx context.Context, name string, factory abstract.ServiceFactory, section map[string]any) []configProblem {
+	srv, err := factory(ctx)
+	if err != nil {
+		return []configProblem{{Section: name, Message: "failed to create the service: " + err.Error()}}
+	}
+	var defaults map[string]any
+	_ = json.Unmarshal([]byte(srv.Config()), &defaults)
+
+	keys := make([]string, 0, len(section))
+	for key := range section {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+	var problems []configProblem
+	reported := make(map[string]bool)
+	for _, key := range keys {
+		if key == config.EnabledKey {
+			if _, ok := section[key].(bool); !ok {
+				problems = append(problems, configProblem{Section: name, Key: key, Message: "must be true or false"})
+			}
+			continue
+		}
+		if _, ok := defaults[key]; !ok {
+			problems = append(problems, configProblem{Section: name, Key: key, Message: "unknown key"})
+			continue
+		}
+		if srv, err = factory(ctx); err == nil {
+			err = srv.LoadConfig(map[string]any{key: section[key]})
+		}
+		if err != nil {
+			problems = append(problems, configProblem{Section: name, Key: key, Message: err.Error()})
+			reported[err.Error()] = true
+		}
+	}
+	if srv, err = factory(ctx); err == nil {
+		err = srv.LoadConfig(section)
+	}
+	if err != nil && !reported[err.Error()] {
+		problems = append(problems, configProblem{Section: name, Message: err.Error()})
+	}
+	return problems
+}
+
+func init() {
+	configCmd.AddCommand(configValidateCmd)
+}
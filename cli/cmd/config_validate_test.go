@@ -0,0 +1,48 @@
+// Copyright 2025 CFC4N <cfc4n.cs@gmail.com>. All Rights Reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//   http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+//
+// Repository: https://github.com/gojue/moling
+
+package cmd
+
+import (
+	"slices"
+	"testing"
+)
+
+// TestValidateConfig verifies every problem of the config file is reported with its key.
+func TestValidateConfig(t *testing.T) {
+	mlConfig.BasePath = t.TempDir()
+	problems, err := validateConfig([]byte(`{
+		"MoLingConfig": {"enabled_tools": "read_file"},
+		"FileSystem": {"allowed_dir": 1, "bogus": true, "enabled": false},
+		"Command": {"enabled": "no"},
+		"Foo": {}
+	}`))
+	if err != nil {
+		t.Fatalf("validateConfig: %v", err)
+	}
+	var keys []string
+	for _, p := range problems {
+		keys = append(keys, p.Section+"."+p.Key)
+	}
+	want := []string{"Command.enabled", "FileSystem.allowed_dir", "FileSystem.bogus", "Foo.", "MoLingConfig.enabled_tools"}
+	if !slices.Equal(keys, want) {
+		t.Errorf("expected the problems of %v, got %v", want, problems)
+	}
+
+	if _, err = validateConfig([]byte("{\n\"a\": }")); err == nil {
+		t.Errorf("expected a syntax error")
+	}
+}
@@ -0,0 +1,302 @@
+// Copyright 2025 CFC4N <cfc4n.cs@gmail.com>. All Rights Reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//   http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+//
+// Repository: https://github.com/gojue/moling
+
+package cmd
+
+import (
+	"crypto/rand"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/spf13/cobra"
+
+	"github.com/gojue/moling/pkg/config"
+	"github.com/gojue/moling/pkg/utils"
+)
+
+const (
+	// DaemonEnv is set in the environment of the daemon, it has no parent process to watch.
+	DaemonEnv = "MOLING_DAEMON"
+	// TokenEnv is the environment variable of --token, passing the token to the daemon and the service, not to
+	// expose it in their command line.
+	TokenEnv = config.EnvPrefix + "TOKEN"
+	// DaemonLogFileName is the log file of the output of the daemon, e.g. the URL of the SSE server.
+	DaemonLogFileName = "daemon.log"
+	// MLDaemonArgsName is the file of the arguments the daemon was started with, restarted with them.
+	MLDaemonArgsName = "moling.daemon.json"
+	// daemonWait is how long start and stop wait for the daemon to start or exit.
+	daemonWait = 10 * time.Second
+)
+
+// errNotRunning is returned by stop without daemon running.
+var errNotRunning = errors.New("MoLing is not running")
+
+var startCmd = &cobra.Command{
+	Use:   "start",
+	Short: "Start MoLing in SSE mode in the background",
+	Long: `Start MoLing in SSE mode as a background process, with the flags given, e.g. --listen_addr and --module. Its output,
+with the URL of the SSE server, is written to logs/` + DaemonLogFileName + `, its token is printed here. The running instance is
+tracked with the PID file, only one runs at once.
+    moling start -l 127.0.0.1:6789   Start MoLing in the background
+    moling start -l 127.0.0.1:6789 -d  Start MoLing in the background, with debug logging
+    moling status                    Show whether MoLing is running
+    moling restart                   Restart MoLing, with the flags it was started with unless others are given, and
+                                     the token of ` + TokenEnv + ` or a new one
+    moling stop                      Stop MoLing
+`,
+	Args: cobra.NoArgs,
+	RunE: func(command *cobra.Command, args []string) error {
+		if mlConfig.ListenAddr == "" {
+			return errors.New("the background process runs in SSE mode, set its listen address with --listen_addr")
+		}
+		return startDaemon(serviceArgs(command))
+	},
+}
+
+var stopCmd = &cobra.Command{
+	Use:   "stop",
+	Short: "Stop MoLing started in the background",
+	Args:  cobra.NoArgs,
+	RunE: func(command *cobra.Command, args []string) error {
+		return stopDaemon()
+	},
+}
+
+var statusCmd = &cobra.Command{
+	Use:   "status",
+	Short: "Show whether MoLing is running",
+	Args:  cobra.NoArgs,
+	RunE: func(command *cobra.Command, args []string) error {
+		pid, running, err := utils.ReadPIDFile(filepath.Join(mlConfig.BasePath, MLPidName))
+		if err != nil {
+			return err
+		}
+		if !running {
+			fmt.Println(errNotRunning.Error())
+			return nil
+		}
+		fmt.Printf("MoLing is running, PID %d\n", pid)
+		if args, err := readDaemonArgs(); err == nil {
+			args, token := splitTokenArg(args)
+			if token != "" {
+				args = append(args, "--token=***")
+			}
+			fmt.Printf("Started with: %s\n", strings.Join(args, " "))
+		}
+		return nil
+	},
+}
+
+var restartCmd = &cobra.Command{
+	Use:   "restart",
+	Short: "Restart MoLing in the background, with the flags it was started with unless others are given, the token of " + TokenEnv + " or a new one",
+	Args:  cobra.NoArgs,
+	RunE: func(command *cobra.Command, args []string) error {
+		daemonArgs := serviceArgs(command)
+		if !command.Flags().Changed("listen_addr") {
+			saved, err := readDaemonArgs()
+			if err != nil {
+				return fmt.Errorf("no flags given and none saved by start, set the listen address with --listen_addr: %w", err)
+			}
+			daemonArgs = saved
+		}
+		if err := stopDaemon(); err != nil && !errors.Is(err, errNotRunning) {
+			return err
+		}
+		return startDaemon(daemonArgs)
+	},
+}
+
+// readDaemonArgs returns the arguments the daemon was last started with.
+func readDaemonArgs() ([]string, error) {
+	data, err := os.ReadFile(filepath.Join(mlConfig.BasePath, MLDaemonArgsName))
+	if err != nil {
+		return nil, err
+	}
+	var args []string
+	if err = json.Unmarshal(data, &args); err != nil {
+		return nil, err
+	}
+	return args, nil
+}
+
+// splitTokenArg returns the arguments without --token, and the token.
+func splitTokenArg(args []string) ([]string, string) {
+	var rest []string
+	token := ""
+	for _, arg := range args {
+		if v, ok := strings.CutPrefix(arg, "--token="); ok {
+			token = v
+			continue
+		}
+		rest = append(rest, arg)
+	}
+	return rest, token
+}
+
+// configuredTokens tells whether API tokens are configured besides --token: the auth_tokens of the config file or
+// config.AuthTokensEnv.
+func configuredTokens() bool {
+	if os.Getenv(config.AuthTokensEnv) != "" {
+		return true
+	}
+	sections, err := config.ReadConfigFile(mlConfig.ConfigFilePath())
+	if err != nil {
+		return false
+	}
+	settings, _ := sections["MoLingConfig"].(map[string]any)
+	tokens, _ := settings["auth_tokens"].([]any)
+	return len(tokens) > 0
+}
+
+// backgroundToken returns the arguments of a background process without --token, passed by TokenEnv, and its
+// token: the one given, the one of TokenEnv, or one generated without any token configured, to be printed on the
+// terminal starting it. The output of the process is written to a file, it doesn't print its token.
+func backgroundToken(args []string) ([]string, string, error) {
+	args, token := splitTokenArg(args)
+	if token == "" {
+		token = os.Getenv(TokenEnv)
+	}
+	if token == "" && !configuredTokens() {
+		b := make([]byte, 16)
+		if _, err := rand.Read(b); err != nil {
+			return nil, "", fmt.Errorf("failed to generate the SSE auth token: %w", err)
+		}
+		token = hex.EncodeToString(b)
+	}
+	return args, token, nil
+}
+
+// writeDaemonArgs saves the arguments the daemon was started with, for restart, without its token: restart takes
+// it from TokenEnv or generates a new one.
+func writeDaemonArgs(args []string) error {
+	args, _ = splitTokenArg(args)
+	data, err := json.Marshal(args)
+	if err != nil {
+		return err
+	}
+	return writePrivateFile(filepath.Join(mlConfig.BasePath, MLDaemonArgsName), data)
+}
+
+// writePrivateFile writes the file readable by its owner only, the one existing too.
+func writePrivateFile(path string, data []byte) error {
+	if err := os.WriteFile(path, data, 0600); err != nil {
+		return err
+	}
+	return os.Chmod(path, 0600)
+}
+
+// startDaemon starts MoLing with the arguments in the background, detached from the terminal, and waits for it
+// to hold the PID file.
+func startDaemon(args []string) error {
+	pidFilePath := filepath.Join(mlConfig.BasePath, MLPidName)
+	if pid, running, err := utils.ReadPIDFile(pidFilePath); err != nil {
+		return err
+	} else if running {
+		return fmt.Errorf("MoLing is already running, PID %d", pid)
+	}
+	exe, err := os.Executable()
+	if err != nil {
+		return fmt.Errorf("failed to find the executable: %w", err)
+	}
+	args, token, err := backgroundToken(args)
+	if err != nil {
+		return err
+	}
+	logFile := filepath.Join(mlConfig.BasePath, "logs", DaemonLogFileName)
+	out, err := os.OpenFile(logFile, os.O_CREATE|os.O_WRONLY|os.O_APPEND, 0600)
+	if err != nil {
+		return fmt.Errorf("failed to open the log file %s: %w", logFile, err)
+	}
+	defer out.Close()
+	// The log file of an older version may be readable by others.
+	if err = out.Chmod(0600); err != nil {
+		return err
+	}
+
+	cmd := exec.Command(exe, args...)
+	cmd.Stdout, cmd.Stderr = out, out
+	cmd.Env = append(os.Environ(), DaemonEnv+"=1")
+	if token != "" {
+		cmd.Env = append(cmd.Env, TokenEnv+"="+token)
+	}
+	cmd.SysProcAttr = daemonSysProcAttr()
+	if err = cmd.Start(); err != nil {
+		return fmt.Errorf("failed to start MoLing: %w", err)
+	}
+	_ = writeDaemonArgs(args)
+
+	exited := make(chan error, 1)
+	go func() { exited <- cmd.Wait() }()
+	ticker := time.NewTicker(100 * time.Millisecond)
+	defer ticker.Stop()
+	timeout := time.After(daemonWait)
+	for {
+		select {
+		case err = <-exited:
+			return fmt.Errorf("MoLing exited at start: %v, see %s", err, logFile)
+		case <-timeout:
+			return fmt.Errorf("MoLing didn't start in %s, see %s", daemonWait, logFile)
+		case <-ticker.C:
+			// The daemon writes its PID once it holds the lock, which isn't tried here not to make its start fail.
+			if data, err := os.ReadFile(pidFilePath); err == nil && strings.TrimSpace(string(data)) == strconv.Itoa(cmd.Process.Pid) {
+				fmt.Printf("MoLing started, PID %d, its output is written to %s\n", cmd.Process.Pid, logFile)
+				if token != "" {
+					fmt.Printf("SSE auth token: %s\n", token)
+				}
+				return nil
+			}
+		}
+	}
+}
+
+// stopDaemon stops the running MoLing, and waits for it to release the PID file.
+func stopDaemon() error {
+	pidFilePath := filepath.Join(mlConfig.BasePath, MLPidName)
+	pid, running, err := utils.ReadPIDFile(pidFilePath)
+	if err != nil {
+		return err
+	}
+	if !running {
+		return errNotRunning
+	}
+	process, err := os.FindProcess(pid)
+	if err != nil {
+		return fmt.Errorf("failed to find MoLing, PID %d: %w", pid, err)
+	}
+	if err = stopProcess(process); err != nil {
+		return fmt.Errorf("failed to stop MoLing, PID %d: %w", pid, err)
+	}
+	for deadline := time.Now().Add(daemonWait); time.Now().Before(deadline); time.Sleep(100 * time.Millisecond) {
+		if _, running, _ = utils.ReadPIDFile(pidFilePath); !running {
+			fmt.Printf("MoLing stopped, PID %d\n", pid)
+			return nil
+		}
+	}
+	return fmt.Errorf("MoLing, PID %d, didn't stop in %s", pid, daemonWait)
+}
+
+func init() {
+	rootCmd.AddCommand(startCmd, stopCmd, statusCmd, restartCmd)
+}
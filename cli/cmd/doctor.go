@@ -0,0 +1,274 @@
+// Copyright 2025 CFC4N <cfc4n.cs@gmail.com>. All Rights Reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//   http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+//
+// Repository: https://github.com/gojue/moling
+
+package cmd
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net"
+	"net/url"
+	"os"
+	"path/filepath"
+	"strings"
+	"time"
+
+	"github.com/rs/zerolog"
+	"github.com/spf13/cobra"
+
+	"github.com/gojue/moling/pkg/comm"
+	"github.com/gojue/moling/pkg/config"
+	"github.com/gojue/moling/pkg/services"
+	"github.com/gojue/moling/pkg/services/browser"
+	"github.com/gojue/moling/pkg/utils"
+)
+
+// Outcomes of the checks of the doctor command.
+const (
+	checkOK   = "OK"
+	checkInfo = "INFO"
+	checkWarn = "WARN"
+	checkFail = "FAIL"
+)
+
+var doctorCmd = &cobra.Command{
+	Use:   "doctor",
+	Short: "Check the environment of MoLing and suggest the fixes of the problems found",
+	Long: `Check the environment MoLing runs in: the base path is writable, the config file is valid, Chrome or Chromium is
+installed for the Browser module, the listen address is free, the PID file isn't stale, and the permissions of macOS.
+Each problem found is printed with its fix. Give the flags MoLing runs with, e.g. --listen_addr and --module.
+    moling doctor
+    moling doctor -l 127.0.0.1:6789 -m Browser
+`,
+	Args: cobra.NoArgs,
+	// The base path isn't created, it is checked.
+	PersistentPreRunE: func(command *cobra.Command, args []string) error {
+		if err := applyEnvFlags(command); err != nil {
+			return err
+		}
+		return applyProfile()
+	},
+	RunE: DoctorCommandFunc,
+}
+
+// doctorCheck is the outcome of a check of the environment.
+type doctorCheck struct {
+	Name   string
+	Status string
+	Detail string
+	Fix    string // Fix is the action solving the problem found, empty if none.
+}
+
+// DoctorCommandFunc executes the "doctor" command.
+func DoctorCommandFunc(command *cobra.Command, args []string) error {
+	checks := []doctorCheck{checkBasePath()}
+	configCheck, sections := checkConfigFile()
+	applyEnabledModules(sections)
+	checks = append(checks, configCheck)
+	checks = append(checks, checkServices(sections)...)
+	checks = append(checks, checkChrome(sections), checkPIDFile(), checkListenAddr())
+	checks = append(checks, platformChecks()...)
+	if err := reportChecks(checks); err != nil {
+		return err
+	}
+	fmt.Println("No problem found.")
+	return nil
+}
+
+// reportChecks prints the outcomes of the checks with their fixes, and returns an error if some of them failed.
+func reportChecks(checks []doctorCheck) error {
+	failed := 0
+	for _, c := range checks {
+		fmt.Printf("[%-4s] %s: %s\n", c.Status, c.Name, c.Detail)
+		if c.Fix != "" {
+			fmt.Printf("       fix: %s\n", c.Fix)
+		}
+		if c.Status == checkFail {
+			failed++
+		}
+	}
+	if failed > 0 {
+		return fmt.Errorf("%d of %d checks failed", failed, len(checks))
+	}
+	return nil
+}
+
+// moduleLoaded reports whether the module is loaded, with the --module flag or the "enabled" key of its section.
+func moduleLoaded(name comm.MoLingServerType) bool {
+	return mlConfig.Module == "all" || utils.StringInSlice(string(name), strings.Split(mlConfig.Module, ","))
+}
+
+// checkBasePath checks the base path exists and is writable.
+func checkBasePath() doctorCheck {
+	c := doctorCheck{Name: "base path"}
+	if err := utils.CreateDirectory(mlConfig.BasePath); err != nil {
+		c.Status, c.Detail = checkFail, err.Error()
+		c.Fix = fmt.Sprintf("create %s with write access for the current user, or choose another with --base_path", mlConfig.BasePath)
+		return c
+	}
+	f, err := os.CreateTemp(mlConfig.BasePath, ".doctor-*")
+	if err != nil {
+		c.Status, c.Detail = checkFail, err.Error()
+		c.Fix = fmt.Sprintf("give the current user write access to %s, or choose another with --base_path", mlConfig.BasePath)
+		return c
+	}
+	_ = f.Close()
+	_ = os.Remove(f.Name())
+	c.Status, c.Detail = checkOK, mlConfig.BasePath+" is writable"
+	return c
+}
+
+// checkConfigFile checks the config file and the files it includes are valid JSON, and returns its sections.
+func checkConfigFile() (doctorCheck, map[string]any) {
+	c := doctorCheck{Name: "config file"}
+	configFilePath := mlConfig.ConfigFilePath()
+	_, err := os.ReadFile(configFilePath)
+	if errors.Is(err, os.ErrNotExist) {
+		c.Status, c.Detail = checkInfo, configFilePath+" doesn't exist, the defaults are used"
+		c.Fix = "create it with: " + CliName + " config --init"
+		return c, nil
+	}
+	if err != nil {
+		c.Status, c.Detail = checkFail, err.Error()
+		c.Fix = "give the current user read access to " + configFilePath
+		return c, nil
+	}
+	sections, err := config.ReadConfigFile(configFilePath)
+	if err != nil {
+		c.Status, c.Detail = checkFail, fmt.Sprintf("%s is not valid: %s", configFilePath, err.Error())
+		c.Fix = "fix the syntax of the file and of the files it includes, or move it away and re-create it with: " + CliName + " config --init"
+		return c, nil
+	}
+	var unknown []string
+	for name := range sections {
+		if _, ok := services.ServiceList()[comm.MoLingServerType(name)]; !ok && name != "MoLingConfig" && config.IsSection(name) {
+			unknown = append(unknown, name)
+		}
+	}
+	if len(unknown) > 0 {
+		c.Status, c.Detail = checkWarn, fmt.Sprintf("%s has unknown sections, ignored: %s", configFilePath, strings.Join(unknown, ", "))
+		c.Fix = "remove the sections or fix their names, they are the names of the modules, e.g. Browser"
+		return c, sections
+	}
+	c.Status, c.Detail = checkOK, configFilePath+" is valid JSON"
+	return c, sections
+}
+
+// checkServices checks the sections of the config file are valid configurations of the modules loaded.
+func checkServices(sections map[string]any) []doctorCheck {
+	ctx := context.WithValue(context.Background(), comm.MoLingConfigKey, mlConfig)
+	ctx = context.WithValue(ctx, comm.MoLingLoggerKey, zerolog.Nop())
+	var checks []doctorCheck
+	for name, factory := range services.ServiceList() {
+		section, ok := sections[string(name)].(map[string]any)
+		if !ok || !moduleLoaded(name) {
+			continue
+		}
+		c := doctorCheck{Name: "config of " + string(name)}
+		srv, err := factory(ctx)
+		if err == nil {
+			err = srv.LoadConfig(section)
+		}
+		if err != nil {
+			c.Status, c.Detail = checkFail, err.Error()
+			c.Fix = fmt.Sprintf("fix the %s section of the config file, %s config shows the defaults", name, CliName)
+		} else {
+			c.Status, c.Detail = checkOK, "valid"
+		}
+		checks = append(checks, c)
+	}
+	return checks
+}
+
+// checkChrome checks the browser of the Browser module is installed, or its remote debugging URL is reachable.
+func checkChrome(sections map[string]any) doctorCheck {
+	c := doctorCheck{Name: "browser"}
+	if !moduleLoaded(browser.BrowserServerName) {
+		c.Status, c.Detail = checkOK, "the Browser module isn't loaded"
+		return c
+	}
+	if section, ok := sections[string(browser.BrowserServerName)].(map[string]any); ok {
+		if remote, _ := section["remote_debugging_url"].(string); remote != "" {
+			u, err := url.Parse(remote)
+			if err == nil {
+				var conn net.Conn
+				if conn, err = net.DialTimeout("tcp", u.Host, 3*time.Second); err == nil {
+					_ = conn.Close()
+				}
+			}
+			if err != nil {
+				c.Status, c.Detail = checkFail, fmt.Sprintf("remote_debugging_url %s is unreachable: %s", remote, err.Error())
+				c.Fix = "start Chrome with --remote-debugging-port, or fix remote_debugging_url in the Browser section of the config file"
+				return c
+			}
+			c.Status, c.Detail = checkOK, "attaches to the Chrome at "+remote
+			return c
+		}
+	}
+	path, err := browser.ExecPath()
+	if err != nil {
+		c.Status, c.Detail = checkFail, err.Error()
+		c.Fix = "install Google Chrome or Chromium, e.g. apt install chromium, or don't load the Browser module with --module"
+		return c
+	}
+	c.Status, c.Detail = checkOK, "found "+path
+	return c
+}
+
+// checkPIDFile checks the PID file isn't left by a process that crashed.
+func checkPIDFile() doctorCheck {
+	c := doctorCheck{Name: "PID file"}
+	pidFilePath := filepath.Join(mlConfig.BasePath, MLPidName)
+	pid, running, err := utils.ReadPIDFile(pidFilePath)
+	switch {
+	case err != nil:
+		c.Status, c.Detail = checkWarn, err.Error()
+		c.Fix = "remove " + pidFilePath + " if MoLing isn't running"
+	case running:
+		c.Status, c.Detail = checkInfo, fmt.Sprintf("MoLing is running, PID %d, another instance with this base path can't start", pid)
+		c.Fix = "stop it with: " + CliName + " stop, use another --base_path, or take over with --force"
+	case pid != 0:
+		c.Status, c.Detail = checkWarn, fmt.Sprintf("%s was left by PID %d, not running", pidFilePath, pid)
+		c.Fix = "remove " + pidFilePath + ", it is replaced at the next start anyway"
+	default:
+		c.Status, c.Detail = checkOK, "no instance running"
+	}
+	return c
+}
+
+// checkListenAddr checks the listen address of the SSE mode is free.
+func checkListenAddr() doctorCheck {
+	c := doctorCheck{Name: "listen address"}
+	if mlConfig.ListenAddr == "" {
+		c.Status, c.Detail = checkOK, "STDIO mode, no listen address"
+		return c
+	}
+	addr := strings.TrimPrefix(mlConfig.ListenAddr, "http://")
+	ln, err := net.Listen("tcp", addr)
+	if err != nil {
+		c.Status, c.Detail = checkFail, fmt.Sprintf("can't listen on %s: %s", addr, err.Error())
+		c.Fix = "choose another --listen_addr, or stop the process using it, e.g. " + CliName + " stop"
+		return c
+	}
+	_ = ln.Close()
+	c.Status, c.Detail = checkOK, addr+" is free"
+	return c
+}
+
+func init() {
+	rootCmd.AddCommand(doctorCmd)
+}
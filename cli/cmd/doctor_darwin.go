@@ -0,0 +1,51 @@
+//go:build darwin
+
+// Copyright 2025 CFC4N <cfc4n.cs@gmail.com>. All Rights Reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//   http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+//
+// Repository: https://github.com/gojue/moling
+
+package cmd
+
+import (
+	"context"
+	"os/exec"
+	"strings"
+	"time"
+)
+
+// platformChecks checks the permissions of macOS the tools of MoLing need.
+func platformChecks() []doctorCheck {
+	automation := doctorCheck{Name: "macOS automation"}
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	defer cancel()
+	out, err := exec.CommandContext(ctx, "osascript", "-e", `tell application "System Events" to get name of first process`).CombinedOutput()
+	switch {
+	case err == nil:
+		automation.Status, automation.Detail = checkOK, "allowed to control System Events"
+	case strings.Contains(string(out), "-1743"):
+		automation.Status, automation.Detail = checkFail, "not allowed to control System Events, the AppleScript commands fail"
+		automation.Fix = "allow the terminal or the MCP client in System Settings > Privacy & Security > Automation"
+	default:
+		automation.Status, automation.Detail = checkWarn, "osascript failed: "+strings.TrimSpace(string(out))
+		automation.Fix = "check the terminal or the MCP client in System Settings > Privacy & Security > Automation"
+	}
+	screen := doctorCheck{
+		Name:   "macOS screen recording",
+		Status: checkInfo,
+		Detail: "can't be checked from the command line, the screenshots of the screen capture only the wallpaper without it",
+		Fix:    "allow the terminal or the MCP client in System Settings > Privacy & Security > Screen Recording",
+	}
+	return []doctorCheck{automation, screen}
+}
@@ -0,0 +1,111 @@
+// Copyright 2025 CFC4N <cfc4n.cs@gmail.com>. All Rights Reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//   http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+//
+// Repository: https://github.com/gojue/moling
+
+package cmd
+
+import (
+	"errors"
+	"fmt"
+	"net"
+	"os"
+	"slices"
+	"strings"
+
+	"github.com/spf13/cobra"
+
+	"github.com/gojue/moling/client"
+)
+
+var exportConfigCmd = &cobra.Command{
+	Use:   "export-config [claude|cursor|cline|zed|json]",
+	Short: "Print the MCP configuration snippets adding MoLing to Claude Desktop, Cursor, Cline, Zed or any client",
+	Long: `Print the snippets of the configuration of the MCP clients starting MoLing in STDIO mode, with the absolute path
+of this executable and the flags given, e.g. --module. With --sse, the snippets connect to the SSE server of
+--listen_addr, with the --token given. All the clients are printed unless one is given.
+    moling export-config
+    moling export-config cursor -m Browser,FileSystem
+    moling export-config claude --sse -l 127.0.0.1:6789 -t <token>
+`,
+	ValidArgs: []string{client.FormatClaude, client.FormatCursor, client.FormatCline, client.FormatZed, client.FormatJSON},
+	Args:      cobra.MatchAll(cobra.MaximumNArgs(1), cobra.OnlyValidArgs),
+	RunE:      ExportConfigCommandFunc,
+}
+
+// exportSSE exports the snippets of the SSE mode.
+var exportSSE bool
+
+// exportEntry returns how the clients reach MoLing: the executable and the flags given, or the SSE endpoint of
+// the listen address.
+func exportEntry(command *cobra.Command) (client.ServerEntry, error) {
+	entry := client.ServerEntry{Name: MCPServerName}
+	if exportSSE {
+		if mlConfig.ListenAddr == "" {
+			return entry, errors.New("the SSE mode needs the listen address, set it with --listen_addr")
+		}
+		host, port, err := net.SplitHostPort(strings.TrimPrefix(mlConfig.ListenAddr, "http://"))
+		if err != nil {
+			return entry, fmt.Errorf("invalid listen address %s: %w", mlConfig.ListenAddr, err)
+		}
+		// A wildcard address is reached locally.
+		if ip := net.ParseIP(host); host == "" || (ip != nil && ip.IsUnspecified()) {
+			host = "127.0.0.1"
+		}
+		entry.URL = "http://" + net.JoinHostPort(host, port) + "/sse"
+		entry.Token = mlConfig.AuthToken
+		return entry, nil
+	}
+	exe, err := os.Executable()
+	if err != nil {
+		return entry, fmt.Errorf("failed to find the executable: %w", err)
+	}
+	entry.Command = exe
+	// The flags of the SSE mode don't apply to the STDIO mode the clients start.
+	entry.Args = slices.DeleteFunc(serviceArgs(command), func(arg string) bool {
+		return strings.HasPrefix(arg, "--listen_addr=") || strings.HasPrefix(arg, "--token=")
+	})
+	return entry, nil
+}
+
+// ExportConfigCommandFunc executes the "export-config" command.
+func ExportConfigCommandFunc(command *cobra.Command, args []string) error {
+	entry, err := exportEntry(command)
+	if err != nil {
+		return err
+	}
+	if exportSSE && entry.Token == "" {
+		entry.Token = "<token>"
+		_, _ = fmt.Fprintln(os.Stderr, "No --token given, replace <token> with the auth token printed by MoLing at start.")
+	}
+	for _, format := range client.ExportFormats {
+		if len(args) > 0 && args[0] != format.Name {
+			continue
+		}
+		snippet, err := client.ExportSnippet(format.Name, entry)
+		if err != nil {
+			return err
+		}
+		if len(args) == 0 {
+			fmt.Printf("// %s\n", format.Title)
+		}
+		fmt.Println(string(snippet))
+	}
+	return nil
+}
+
+func init() {
+	exportConfigCmd.Flags().BoolVar(&exportSSE, "sse", false, "export the snippets connecting to the SSE server of --listen_addr, instead of starting MoLing in STDIO mode.")
+	rootCmd.AddCommand(exportConfigCmd)
+}
@@ -0,0 +1,293 @@
+// Copyright 2025 CFC4N <cfc4n.cs@gmail.com>. All Rights Reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//   http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+//
+// Repository: https://github.com/gojue/moling
+
+package cmd
+
+import (
+	"bufio"
+	"encoding/json"
+	"fmt"
+	"io"
+	"os"
+	"slices"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/rs/zerolog"
+	"github.com/spf13/cobra"
+
+	"github.com/gojue/moling/client"
+	"github.com/gojue/moling/pkg/config"
+	"github.com/gojue/moling/pkg/services/browser"
+	"github.com/gojue/moling/pkg/services/filesystem"
+)
+
+var initCmd = &cobra.Command{
+	Use:   "init",
+	Short: "Set up MoLing interactively: the modules, the allowed directories, the browser and the MCP clients",
+	Long: `Ask which modules to enable, which directories the FileSystem module may access, whether the browser runs headless
+and which of the installed MCP clients to configure, then write the config file, the previous one backed up, and add
+MoLing to the MCP clients. An empty answer keeps the default shown in brackets, without a terminal all the defaults
+are taken.
+    moling init
+    moling --config /etc/moling/config.json init
+`,
+	Args: cobra.NoArgs,
+	RunE: InitCommandFunc,
+}
+
+// initAnswers are the answers to the questions of "moling init".
+type initAnswers struct {
+	Modules    []string // Modules are the modules enabled, all if empty.
+	AllowedDir string   // AllowedDir is the allowed_dir of the FileSystem module.
+	Headless   bool     // Headless is the headless of the Browser module.
+	Clients    []string // Clients are the MCP clients to configure.
+}
+
+// enabled tells whether the module is enabled.
+func (a initAnswers) enabled(module string) bool {
+	return len(a.Modules) == 0 || slices.Contains(a.Modules, module)
+}
+
+// initPrompter asks the questions of "moling init", an empty answer or the end of the input takes the default.
+type initPrompter struct {
+	in  *bufio.Scanner
+	out io.Writer
+}
+
+// ask returns the answer to the question, the default if it is empty.
+func (p *initPrompter) ask(question, def string) (string, error) {
+	_, _ = fmt.Fprintf(p.out, "%s [%s]: ", question, def)
+	if !p.in.Scan() {
+		_, _ = fmt.Fprintln(p.out)
+		return def, p.in.Err()
+	}
+	if answer := strings.TrimSpace(p.in.Text()); answer != "" {
+		return answer, nil
+	}
+	return def, nil
+}
+
+// askList asks for a list separated by commas until check accepts it, and returns the list check makes of it.
+func (p *initPrompter) askList(question, def string, check func([]string) ([]string, error)) ([]string, error) {
+	for {
+		answer, err := p.ask(question, def)
+		if err != nil {
+			return nil, err
+		}
+		var items []string
+		for _, item := range strings.Split(answer, ",") {
+			if item = strings.TrimSpace(item); item != "" {
+				items = append(items, item)
+			}
+		}
+		if items, err = check(items); err == nil {
+			return items, nil
+		}
+		_, _ = fmt.Fprintln(p.out, err)
+	}
+}
+
+// askBool asks a yes or no question until the answer is one of them.
+func (p *initPrompter) askBool(question string, def bool) (bool, error) {
+	defAnswer := "n"
+	if def {
+		defAnswer = "y"
+	}
+	for {
+		answer, err := p.ask(question+" (y/n)", defAnswer)
+		if err != nil {
+			return false, err
+		}
+		switch strings.ToLower(answer) {
+		case "y", "yes":
+			return true, nil
+		case "n", "no":
+			return false, nil
+		}
+		_, _ = fmt.Fprintln(p.out, "answer y or n")
+	}
+}
+
+// askInit asks the questions of "moling init", the defaults are the ones of the config file. installed are the MCP
+// clients found, the clients may be given by name or by number in this list.
+func askInit(p *initPrompter, modules []string, defaults initAnswers, installed []string) (initAnswers, error) {
+	var answers initAnswers
+	var err error
+	defaultModules := "all"
+	if len(defaults.Modules) > 0 {
+		defaultModules = strings.Join(defaults.Modules, ",")
+	}
+	answers.Modules, err = p.askList("Modules to enable, separated by commas, among "+strings.Join(modules, ","), defaultModules,
+		func(items []string) ([]string, error) {
+			if slices.Equal(items, []string{"all"}) {
+				return nil, nil
+			}
+			for _, item := range items {
+				if !slices.Contains(modules, item) {
+					return nil, fmt.Errorf("unknown module %s, the modules are %s", item, strings.Join(modules, ","))
+				}
+			}
+			return items, nil
+		})
+	if err != nil {
+		return answers, err
+	}
+	if answers.enabled(string(filesystem.FilesystemServerName)) {
+		if answers.AllowedDir, err = p.ask("Directories the FileSystem module may access, separated by commas", defaults.AllowedDir); err != nil {
+			return answers, err
+		}
+	}
+	if answers.enabled(string(browser.BrowserServerName)) {
+		if answers.Headless, err = p.askBool("Run the browser headless, without a window?", defaults.Headless); err != nil {
+			return answers, err
+		}
+	}
+	if len(installed) == 0 {
+		_, _ = fmt.Fprintln(p.out, "No MCP client found, add MoLing to your client later with: moling client -i")
+		return answers, nil
+	}
+	for i, name := range installed {
+		_, _ = fmt.Fprintf(p.out, "  %d. %s\n", i+1, name)
+	}
+	answers.Clients, err = p.askList("MCP clients to add MoLing to, by number or name, or none", "all",
+		func(items []string) ([]string, error) {
+			switch {
+			case slices.Equal(items, []string{"all"}):
+				return installed, nil
+			case slices.Equal(items, []string{"none"}):
+				return nil, nil
+			}
+			clients := make([]string, 0, len(items))
+			for _, item := range items {
+				if n, err := strconv.Atoi(item); err == nil && n >= 1 && n <= len(installed) {
+					item = installed[n-1]
+				}
+				if !slices.Contains(installed, item) {
+					return nil, fmt.Errorf("unknown MCP client %s", item)
+				}
+				clients = append(clients, item)
+			}
+			return clients, nil
+		})
+	return answers, err
+}
+
+// InitCommandFunc executes the "init" command.
+func InitCommandFunc(command *cobra.Command, args []string) error {
+	logger := initLogger(mlConfig.BasePath)
+	consoleWriter := zerolog.ConsoleWriter{Out: os.Stdout, TimeFormat: time.RFC3339}
+	logger = zerolog.New(zerolog.MultiLevelWriter(consoleWriter, logger)).With().Timestamp().Logger()
+	mlConfig.SetLogger(logger)
+
+	configFilePath := mlConfig.ConfigFilePath()
+	sections, err := readConfigSections(configFilePath)
+	if err != nil {
+		return err
+	}
+	modules := serviceNames()
+	for _, name := range modules {
+		if _, ok := sections[name]; ok {
+			continue
+		}
+		if sections[name], err = defaultSection(name); err != nil {
+			return err
+		}
+	}
+	fsSection, _ := sections[string(filesystem.FilesystemServerName)].(map[string]any)
+	browserSection, _ := sections[string(browser.BrowserServerName)].(map[string]any)
+	var defaults initAnswers
+	for _, name := range modules {
+		if enabled, ok := config.SectionEnabled(sections[name]); !ok || enabled {
+			defaults.Modules = append(defaults.Modules, name)
+		}
+	}
+	if len(defaults.Modules) == len(modules) {
+		defaults.Modules = nil
+	}
+	defaults.AllowedDir, _ = fsSection["allowed_dir"].(string)
+	defaults.Headless, _ = browserSection["headless"].(bool)
+
+	mcpConfig := client.NewMCPServerConfig(CliDescription, CliName, MCPServerName)
+	if exePath, err := os.Executable(); err == nil {
+		mcpConfig.Command = exePath
+	}
+	cm := client.NewManager(logger, mcpConfig)
+	prompter := &initPrompter{in: bufio.NewScanner(os.Stdin), out: os.Stdout}
+	answers, err := askInit(prompter, modules, defaults, cm.Installed())
+	if err != nil {
+		return err
+	}
+
+	// The modules are enabled in the config file, the MCP clients start MoLing without --module.
+	changed := make(map[string]bool)
+	for _, name := range modules {
+		if section, ok := sections[name].(map[string]any); ok {
+			section[config.EnabledKey] = answers.enabled(name)
+		}
+	}
+	if answers.enabled(string(filesystem.FilesystemServerName)) && fsSection != nil {
+		fsSection["allowed_dir"] = answers.AllowedDir
+		changed[string(filesystem.FilesystemServerName)+".allowed_dir"] = true
+	}
+	if answers.enabled(string(browser.BrowserServerName)) && browserSection != nil {
+		browserSection["headless"] = answers.Headless
+		changed[string(browser.BrowserServerName)+".headless"] = true
+	}
+	if sections[config.SchemaKey], err = mlConfig.WriteSchema(); err != nil {
+		return err
+	}
+	data, err := json.MarshalIndent(sections, "", "  ")
+	if err != nil {
+		return err
+	}
+	problems, err := validateConfig(data)
+	if err != nil {
+		return err
+	}
+	var invalid []string
+	for _, p := range problems {
+		if changed[p.Section+"."+p.Key] {
+			invalid = append(invalid, p.String())
+		}
+	}
+	if len(invalid) > 0 {
+		return fmt.Errorf("invalid answers, %s is unchanged:\n%s", configFilePath, strings.Join(invalid, "\n"))
+	}
+	if err = writeConfigFile(configFilePath, append(data, '\n')); err != nil {
+		return err
+	}
+	logger.Info().Str("config", configFilePath).Msg("Configuration file written")
+
+	// The MCP clients start MoLing with the config file chosen.
+	var runArgs []string
+	if command.Root().PersistentFlags().Changed("config") {
+		runArgs = append(runArgs, "--config", mlConfig.ConfigFile)
+	}
+	if len(answers.Clients) > 0 {
+		mcpConfig.Args = runArgs
+		cm = client.NewManager(logger, mcpConfig)
+		cm.Select(answers.Clients)
+		cm.SetupConfig()
+	}
+	logger.Info().Msgf("MoLing is set up, run it with: %s", strings.Join(append([]string{CliName}, runArgs...), " "))
+	return nil
+}
+
+func init() {
+	rootCmd.AddCommand(initCmd)
+}
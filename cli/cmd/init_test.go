@@ -0,0 +1,51 @@
+// Copyright 2025 CFC4N <cfc4n.cs@gmail.com>. All Rights Reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//   http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+//
+// Repository: https://github.com/gojue/moling
+
+package cmd
+
+import (
+	"bufio"
+	"io"
+	"reflect"
+	"strings"
+	"testing"
+)
+
+// TestAskInit verifies the answers are checked, asked again if invalid, and the defaults taken at the end of input.
+func TestAskInit(t *testing.T) {
+	modules := []string{"Browser", "Command", "FileSystem"}
+	installed := []string{"Claude", "Cursor"}
+	defaults := initAnswers{AllowedDir: "/tmp/moling", Headless: true}
+
+	p := &initPrompter{in: bufio.NewScanner(strings.NewReader("Foo\nBrowser, FileSystem\n/data\nmaybe\nno\n2\n")), out: io.Discard}
+	answers, err := askInit(p, modules, defaults, installed)
+	if err != nil {
+		t.Fatalf("askInit: %v", err)
+	}
+	want := initAnswers{Modules: []string{"Browser", "FileSystem"}, AllowedDir: "/data", Headless: false, Clients: []string{"Cursor"}}
+	if !reflect.DeepEqual(answers, want) {
+		t.Errorf("expected %+v, got %+v", want, answers)
+	}
+
+	p = &initPrompter{in: bufio.NewScanner(strings.NewReader("")), out: io.Discard}
+	if answers, err = askInit(p, modules, defaults, installed); err != nil {
+		t.Fatalf("askInit: %v", err)
+	}
+	want = initAnswers{AllowedDir: "/tmp/moling", Headless: true, Clients: installed}
+	if !reflect.DeepEqual(answers, want) {
+		t.Errorf("expected the defaults %+v, got %+v", want, answers)
+	}
+}
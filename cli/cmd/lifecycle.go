@@ -0,0 +1,47 @@
+// Copyright 2025 CFC4N <cfc4n.cs@gmail.com>. All Rights Reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//   http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+//
+// Repository: https://github.com/gojue/moling
+
+package cmd
+
+import (
+	"os"
+	"syscall"
+	"time"
+
+	"github.com/rs/zerolog"
+)
+
+// watchParent stops MoLing, by SIGTERM on sigChan, once the MCP client that started it exited, after the grace
+// period given to the calls in progress. Started by init, e.g. as a service of systemd or launchd, as a daemon, or
+// with --no_parent_watch, there is no parent process to watch.
+func watchParent(sigChan chan<- os.Signal, loger zerolog.Logger) {
+	ppid := os.Getppid()
+	if noParentWatch || ppid == 1 || os.Getenv(DaemonEnv) != "" {
+		return
+	}
+	go func() {
+		if err := waitParent(ppid); err != nil {
+			loger.Warn().Err(err).Int("ppid", ppid).Msg("can't watch the parent process, MoLing won't stop with it")
+			return
+		}
+		loger.Warn().Int("ppid", ppid).Msg("parent process exited")
+		if parentExitGrace > 0 {
+			loger.Info().Int("grace", parentExitGrace).Msg("shutting down after the grace period")
+			time.Sleep(time.Duration(parentExitGrace) * time.Second)
+		}
+		sigChan <- syscall.SIGTERM
+	}()
+}
@@ -0,0 +1,38 @@
+//go:build windows
+
+// Copyright 2025 CFC4N <cfc4n.cs@gmail.com>. All Rights Reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//   http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+//
+// Repository: https://github.com/gojue/moling
+
+package cmd
+
+import (
+	"fmt"
+
+	"golang.org/x/sys/windows"
+)
+
+// waitParent returns once the parent process exited, waiting on its handle: Windows doesn't reparent the processes.
+func waitParent(ppid int) error {
+	handle, err := windows.OpenProcess(windows.SYNCHRONIZE, false, uint32(ppid))
+	if err != nil {
+		return fmt.Errorf("failed to open the parent process: %w", err)
+	}
+	defer windows.CloseHandle(handle)
+	if _, err = windows.WaitForSingleObject(handle, windows.INFINITE); err != nil {
+		return fmt.Errorf("failed to wait for the parent process: %w", err)
+	}
+	return nil
+}
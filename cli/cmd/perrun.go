@@ -17,15 +17,66 @@
 package cmd
 
 import (
+	"fmt"
+	"os"
 	"path/filepath"
+	"strings"
 
 	"github.com/spf13/cobra"
+	"github.com/spf13/pflag"
 
+	"github.com/gojue/moling/pkg/config"
 	"github.com/gojue/moling/pkg/utils"
 )
 
+// applyEnvFlags sets the flags of MoLing not given on the command line from their environment variable,
+// config.EnvPrefix followed by the name of the flag in upper case, e.g. MOLING_LISTEN_ADDR. A relative --config is
+// made absolute, from the working directory.
+func applyEnvFlags(cmd *cobra.Command) error {
+	var err error
+	cmd.Root().PersistentFlags().VisitAll(func(f *pflag.Flag) {
+		envName := config.EnvPrefix + strings.ToUpper(f.Name)
+		value, ok := os.LookupEnv(envName)
+		if err != nil || f.Changed || !ok {
+			return
+		}
+		if err = f.Value.Set(value); err != nil {
+			err = fmt.Errorf("invalid %s: %w", envName, err)
+			return
+		}
+		f.Changed = true
+	})
+	if err != nil {
+		return err
+	}
+	if cmd.Root().PersistentFlags().Changed("config") && !filepath.IsAbs(mlConfig.ConfigFile) {
+		if mlConfig.ConfigFile, err = filepath.Abs(mlConfig.ConfigFile); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// applyProfile moves the base path to the one of the named profile, with its own config, logs, PID and data.
+func applyProfile() error {
+	if profile == "" {
+		return nil
+	}
+	if profile == "." || profile == ".." || strings.ContainsAny(profile, `/\`) {
+		return fmt.Errorf("invalid profile %q, must be a name, e.g. work", profile)
+	}
+	mlConfig.BasePath = filepath.Join(mlConfig.BasePath, MLProfiles, profile)
+	return nil
+}
+
 // mlsCommandPreFunc is a pre-run function for the MoLing command.
 func mlsCommandPreFunc(cmd *cobra.Command, args []string) error {
+	if err := applyEnvFlags(cmd); err != nil {
+		return err
+	}
+	if err := applyProfile(); err != nil {
+		return err
+	}
 	err := utils.CreateDirectory(mlConfig.BasePath)
 	if err != nil {
 		return err
@@ -0,0 +1,42 @@
+// Copyright 2025 CFC4N <cfc4n.cs@gmail.com>. All Rights Reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//   http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+//
+// Repository: https://github.com/gojue/moling
+
+package cmd
+
+import (
+	"path/filepath"
+	"testing"
+)
+
+// TestApplyProfile verifies the base path of a profile, and the invalid profile names.
+func TestApplyProfile(t *testing.T) {
+	basePath := mlConfig.BasePath
+	defer func() { mlConfig.BasePath, profile = basePath, "" }()
+
+	mlConfig.BasePath, profile = "/home/user/.moling", "work"
+	if err := applyProfile(); err != nil {
+		t.Fatalf("applyProfile: %v", err)
+	}
+	if want := filepath.Join("/home/user/.moling", MLProfiles, "work"); mlConfig.BasePath != want {
+		t.Errorf("expected the base path %s, got %s", want, mlConfig.BasePath)
+	}
+	for _, name := range []string{"..", "../work", `a\b`} {
+		profile = name
+		if err := applyProfile(); err == nil {
+			t.Errorf("expected the profile %q to be rejected", name)
+		}
+	}
+}
@@ -19,7 +19,9 @@ package cmd
 import (
 	"context"
 	"encoding/json"
+	"errors"
 	"fmt"
+	"io"
 	"os"
 	"os/signal"
 	"os/user"
@@ -29,8 +31,10 @@ import (
 	"syscall"
 	"time"
 
+	"github.com/mattn/go-isatty"
 	"github.com/rs/zerolog"
 	"github.com/spf13/cobra"
+	"github.com/spf13/pflag"
 
 	"github.com/gojue/moling/cli/cobrautl"
 	"github.com/gojue/moling/pkg/comm"
@@ -61,6 +65,13 @@ Usage:
   moling -h
   moling client -i
   moling config 
+  moling --config /etc/moling/config.json
+  moling --profile work
+  moling --check -m Browser,FileSystem
+  source <(moling completion bash)
+
+Every flag may be set by an environment variable, e.g. MOLING_LISTEN_ADDR for --listen_addr, and every setting of
+the services by MOLING_<SERVICE>_<KEY>, e.g. MOLING_BROWSER_HEADLESS=true.
 `
 	CliDescriptionLongZh = `MoLing（魔灵）是一个computer-use的MCP Server，基于操作系统API实现了系统交互，可以实现文件系统的读写、合并、统计、聚合等操作，也可以执行系统命令操作。是一个无需任何依赖的本地办公自动化助手。
 没有任何安装依赖，直接运行，兼容Windows、Linux、macOS等操作系统。再也不用苦恼NodeJS、Python等环境冲突等问题。
@@ -78,6 +89,7 @@ const (
 	MLConfigName = "config.json"     // config file name of MoLing Server
 	MLRootPath   = ".moling"         // config file name of MoLing Server
 	MLPidName    = "moling.pid"      // pid file name
+	MLProfiles   = "profiles"        // directory of the profiles in the base path
 	LogFileName  = "moling.log"      //	log file name
 	MaxLogSize   = 1024 * 1024 * 512 // 512MB
 )
@@ -90,6 +102,25 @@ var (
 		BasePath:   filepath.Join(os.TempDir(), MLRootPath), // will set in mlsCommandPreFunc
 	}
 
+	// noParentWatch keeps MoLing running when its parent process exits, e.g. in SSE mode.
+	noParentWatch bool
+	// moduleFlag is the --module flag, given or not.
+	moduleFlag *pflag.Flag
+	// noConfigWatch disables the reload of the config file when it's written, SIGHUP still reloads it.
+	noConfigWatch bool
+
+	// parentExitGrace is the time in seconds given to the calls in progress once the parent process exited.
+	parentExitGrace int
+
+	// checkOnly validates the startup without serving.
+	checkOnly bool
+
+	// force takes over the PID file of a running instance.
+	force bool
+
+	// profile is the named profile, its base path is in MLProfiles of the base path.
+	profile string
+
 	// mlDirectories is a list of directories to be created in the base path
 	mlDirectories = []string{
 		"logs",    // log file
@@ -122,7 +153,6 @@ func usageFunc(c *cobra.Command) error {
 func Execute() {
 	rootCmd.SetUsageFunc(usageFunc)
 	rootCmd.SetHelpTemplate(`{{.UsageString}}`)
-	rootCmd.CompletionOptions.DisableDefaultCmd = true
 	rootCmd.Version = GitVersion
 	rootCmd.SetVersionTemplate(`{{with .Name}}{{printf "%s " .}}{{end}}{{printf "version:\t%s" .Version}}
 `)
@@ -142,16 +172,55 @@ func init() {
 	cobra.EnablePrefixMatching = true
 	// Cobra also supports local flags, which will only run
 	// when this action is called directly.
+	rootCmd.PersistentFlags().StringVar(&mlConfig.ConfigFile, "config", mlConfig.ConfigFile, "config file, relative to base_path unless absolute, e.g. /etc/moling/config.json.")
 	rootCmd.PersistentFlags().StringVar(&mlConfig.BasePath, "base_path", mlConfig.BasePath, "MoLing Base Data Path, automatically set by the system, cannot be changed, display only.")
+	rootCmd.PersistentFlags().StringVar(&profile, "profile", "", "named profile, with its own config, logs, PID and data in "+filepath.Join(mlConfig.BasePath, MLProfiles, "<profile>")+", to run isolated instances.")
 	rootCmd.PersistentFlags().BoolVarP(&mlConfig.Debug, "debug", "d", false, "Debug mode, default is false.")
 	rootCmd.PersistentFlags().StringVarP(&mlConfig.ListenAddr, "listen_addr", "l", "", "listen address for SSE mode. default:'', not listen, used STDIO mode.")
-	rootCmd.PersistentFlags().StringVarP(&mlConfig.AuthToken, "token", "t", "", "auth token for SSE mode. Auto-generated if empty. Clients must supply it as ?token=<token> or Authorization: Bearer <token>.")
-	rootCmd.PersistentFlags().StringVarP(&mlConfig.Module, "module", "m", "all", "module to load, default: all; others: Browser,FileSystem,Command, etc. Multiple modules are separated by commas")
+	rootCmd.PersistentFlags().BoolVar(&mlConfig.Stdio, "stdio", false, "also serve STDIO when listen_addr is set, for a local client sharing the services with the SSE clients. default is false.")
+	rootCmd.PersistentFlags().StringVarP(&mlConfig.AuthToken, "token", "t", "", "auth token for SSE mode. Auto-generated if empty and no auth_tokens are configured. Clients must supply it as ?token=<token>, Authorization: Bearer <token> or X-API-Key: <token>. More tokens with scopes are read from auth_tokens of the config file and from "+config.AuthTokensEnv+".")
+	rootCmd.PersistentFlags().StringVar(&mlConfig.OtelEndpoint, "otel_endpoint", "", "OTLP collector to export the traces of the tool calls to, e.g. localhost:4317. default:'', tracing disabled.")
+	rootCmd.PersistentFlags().StringVar(&mlConfig.OtelProtocol, "otel_protocol", "grpc", "OTLP protocol of otel_endpoint: grpc or http/protobuf.")
+	rootCmd.PersistentFlags().BoolVar(&mlConfig.OtelInsecure, "otel_insecure", false, "export the traces without TLS, e.g. to a local collector.")
+	rootCmd.PersistentFlags().Float64Var(&mlConfig.OtelSampleRatio, "otel_sample_ratio", 1, "ratio of the tool calls traced, from 0 to 1.")
+	rootCmd.PersistentFlags().IntVar(&mlConfig.RateLimitSession, "rate_limit_session", 0, "maximum tool calls per minute of each client session. default: 0, unlimited.")
+	rootCmd.PersistentFlags().IntVar(&mlConfig.RateLimitTool, "rate_limit_tool", 0, "maximum calls per minute of each tool, by all the clients. default: 0, unlimited.")
+	rootCmd.PersistentFlags().IntVar(&mlConfig.RateLimitGlobal, "rate_limit_global", 0, "maximum tool calls per minute to the server. default: 0, unlimited.")
+	rootCmd.PersistentFlags().BoolVar(&mlConfig.AuditLog, "audit_log", true, "record every tool call and resource read, with redacted arguments, in logs/audit.jsonl.")
+	rootCmd.PersistentFlags().IntVar(&mlConfig.AuditMaxSize, "audit_max_size", 100, "size in MB that rotates the audit log.")
+	rootCmd.PersistentFlags().IntVar(&mlConfig.AuditBackups, "audit_backups", 3, "number of rotated audit logs kept.")
+	rootCmd.PersistentFlags().BoolVar(&mlConfig.ToolPrefix, "tool_prefix", false, "prefix the tool names with their service, e.g. filesystem.read_file, for clients aggregating several MCP servers. The names without prefix stay callable. default is false.")
+	rootCmd.PersistentFlags().StringVar(&mlConfig.ElicitationFallback, "elicitation_fallback", config.ElicitationDeny, "confirmations of the services, e.g. of a destructive command, when the client doesn't support MCP elicitation: deny or allow.")
+	rootCmd.PersistentFlags().IntVar(&mlConfig.SessionIdleTimeout, "session_idle_timeout", 1800, "inactivity in seconds after which the browser and shell sessions of a network client are released. 0: none.")
+	rootCmd.PersistentFlags().IntVar(&mlConfig.SSEKeepAlive, "sse_keep_alive", 15, "interval in seconds of the keep-alive comments on the idle SSE streams, so proxies don't drop them. 0: none.")
+	rootCmd.PersistentFlags().StringSliceVar(&mlConfig.AllowedOrigins, "allowed_origins", nil, "browser origins allowed to call the SSE listener besides its own, e.g. https://app.example.com,http://localhost:*. Requests with another Origin header are rejected.")
+	rootCmd.PersistentFlags().IntVar(&mlConfig.ToolTimeout, "tool_timeout", 120, "timeout in seconds of the tool calls, raised to the longest call of the service. Overridden by service or tool name in tool_timeouts of the config file. 0: none.")
+	rootCmd.PersistentFlags().StringVarP(&mlConfig.Module, "module", "m", "all", "module to load, default: all; others: Browser,FileSystem,Command,Aggregate, etc. Multiple modules are separated by commas. Overrides the \"enabled\" key of the sections of the config file")
+	_ = rootCmd.RegisterFlagCompletionFunc("module", completeModules)
+	moduleFlag = rootCmd.PersistentFlags().Lookup("module")
+	rootCmd.Flags().BoolVar(&noConfigWatch, "no_config_watch", false, "don't reload the config file when it's written. The timeouts, the log level and the services sections are applied at runtime, the other settings need a restart. SIGHUP still reloads it.")
+	rootCmd.Flags().BoolVar(&noParentWatch, "no_parent_watch", false, "keep running when the parent process, e.g. the MCP client that started MoLing, exits. Useful in SSE mode started from a shell.")
+	rootCmd.Flags().IntVar(&parentExitGrace, "parent_exit_grace", 0, "seconds given to the tool calls in progress before shutting down, once the parent process exited. default: 0, at once.")
+	rootCmd.Flags().BoolVar(&checkOnly, "check", false, "create and initialize the services of the modules loaded, check the config, the permissions, the PID file and the listen address without serving, print the readiness report and exit, non-zero if MoLing wouldn't start.")
+	rootCmd.Flags().BoolVar(&force, "force", false, "stop the instance running with this base path and take over its PID file. A PID file left by a crash is replaced anyway.")
 	rootCmd.SilenceUsage = true
 }
 
 // initLogger init logger
 func initLogger(mlDataPath string) zerolog.Logger {
+	return newLogger(mlDataPath, nil)
+}
+
+// isForeground tells whether MoLing runs in a terminal, not spawned by a client over STDIO nor as a daemon.
+func isForeground() bool {
+	isTerminal := func(f *os.File) bool {
+		return isatty.IsTerminal(f.Fd()) || isatty.IsCygwinTerminal(f.Fd())
+	}
+	return isTerminal(os.Stdin) && isTerminal(os.Stderr)
+}
+
+// newLogger returns the logger of the rotated log file, its logs mirrored to console too if it isn't nil.
+func newLogger(mlDataPath string, console io.Writer) zerolog.Logger {
 	var logger zerolog.Logger
 	var err error
 	logFile := filepath.Join(mlDataPath, "logs", LogFileName)
@@ -165,46 +234,156 @@ func initLogger(mlDataPath string) zerolog.Logger {
 	if err != nil {
 		panic(fmt.Sprintf("failed to open log file %s: %s", logFile, err.Error()))
 	}
-	logger = zerolog.New(rw).With().Timestamp().Logger()
+	var w io.Writer = rw
+	if console != nil {
+		w = zerolog.MultiLevelWriter(zerolog.ConsoleWriter{Out: console, TimeFormat: time.RFC3339}, rw)
+	}
+	logger = zerolog.New(w).With().Timestamp().Logger()
 	logger.Info().Uint32("MaxLogSize", MaxLogSize).Msgf("Log files are automatically rotated when they exceed the size threshold, and saved to %s.1 and %s.2 respectively", LogFileName, LogFileName)
 	return logger
 }
 
-func mlsCommandFunc(command *cobra.Command, args []string) error {
-	loger := initLogger(mlConfig.BasePath)
-	mlConfig.SetLogger(loger)
-	var err error
-	var nowConfig []byte
-	var nowConfigJSON map[string]any
+// fileSettings returns the MoLingConfig settings read from the config file, by key, the other ones come from
+// the command line.
+func fileSettings(cfg *config.MoLingConfig) map[string]any {
+	return map[string]any{
+		"auth_tokens":         &cfg.AuthTokens,
+		"tool_timeouts":       &cfg.ToolTimeouts,
+		"enabled_tools":       &cfg.EnabledTools,
+		"disabled_tools":      &cfg.DisabledTools,
+		"allowed_origins":     &cfg.AllowedOrigins,
+		"service_concurrency": &cfg.ServiceConcurrency,
+	}
+}
 
-	// 增加实例重复运行检测
-	pidFilePath := filepath.Join(mlConfig.BasePath, MLPidName)
-	loger.Info().Str("pid", pidFilePath).Msg("Starting MoLing MCP Server...")
-	err = utils.CreatePIDFile(pidFilePath)
+// loadConfigFile reads the config file with the files it includes, with the MoLingConfig settings it holds, and
+// returns its sections overridden by the environment variables, with the secrets they reference.
+func loadConfigFile(loger zerolog.Logger) (map[string]any, error) {
+	configFilePath := mlConfig.ConfigFilePath()
+	// --check doesn't write, it reports the migration, the file is upgraded when read.
+	if !checkOnly {
+		if err := migrateConfigFile(configFilePath, loger); err != nil {
+			return nil, err
+		}
+	}
+	nowConfigJSON, err := config.ReadConfigFile(configFilePath)
+	if errors.Is(err, os.ErrNotExist) {
+		nowConfigJSON, err = make(map[string]any), nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	config.ApplyEnvSections(nowConfigJSON, services.ServiceNames(), os.Environ())
+	if err := config.ResolveSecrets(nowConfigJSON, os.LookupEnv); err != nil {
+		return nil, fmt.Errorf("error resolving the secrets: %w, config file:%s", err, configFilePath)
+	}
+	// The API tokens of the SSE mode, the tool timeouts, the tools exposed, the allowed origins and the service
+	// concurrency, the other MoLingConfig settings come from the command line.
+	if mlSection, ok := nowConfigJSON["MoLingConfig"].(map[string]any); ok {
+		for key, v := range fileSettings(mlConfig) {
+			if mlSection[key] == nil {
+				continue
+			}
+			sectionJSON, _ := json.Marshal(mlSection[key])
+			if err := json.Unmarshal(sectionJSON, v); err != nil {
+				return nil, fmt.Errorf("error parsing %s: %w, config file:%s", key, err, configFilePath)
+			}
+		}
+	}
+	applyEnabledModules(nowConfigJSON)
+	loger.Info().Str("config_file", configFilePath).Str("module", mlConfig.Module).Msg("load config file")
+	return nowConfigJSON, nil
+}
+
+// configMigration returns the sections of the config file upgraded to config.ConfigVersion, with its version and
+// the changes. The sections are nil for a file up to date, missing or not valid JSON, its syntax error reported by
+// the load.
+func configMigration(path string) (map[string]any, int, []string, error) {
+	data, err := os.ReadFile(path)
+	if errors.Is(err, os.ErrNotExist) {
+		return nil, config.ConfigVersion, nil, nil
+	}
+	if err != nil {
+		return nil, 0, nil, err
+	}
+	sections := make(map[string]any)
+	if json.Unmarshal(data, &sections) != nil {
+		return nil, config.ConfigVersion, nil, nil
+	}
+	version, err := config.FileVersion(sections)
+	if err != nil {
+		return nil, 0, nil, fmt.Errorf("%w, config file:%s", err, path)
+	}
+	changes, err := config.MigrateConfig(sections)
+	if err != nil {
+		return nil, 0, nil, fmt.Errorf("%w, config file:%s", err, path)
+	}
+	if version == config.ConfigVersion {
+		return nil, version, nil, nil
+	}
+	return sections, version, changes, nil
+}
+
+// migrateConfigFile upgrades the config file of an older version to config.ConfigVersion, the original kept with its
+// version, e.g. config.json.v0.bak, and its mode. The files it includes are upgraded when read, not rewritten.
+func migrateConfigFile(path string, loger zerolog.Logger) error {
+	sections, version, changes, err := configMigration(path)
+	if err != nil || sections == nil {
+		return err
+	}
+	fi, err := os.Stat(path)
+	if err != nil {
+		return err
+	}
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return err
+	}
+	backup := fmt.Sprintf("%s.v%d%s", path, version, configBackupSuffix)
+	if err = os.WriteFile(backup, data, fi.Mode().Perm()); err == nil {
+		err = os.Chmod(backup, fi.Mode().Perm())
+	}
 	if err != nil {
+		return fmt.Errorf("failed to back up the config file: %w", err)
+	}
+	if data, err = json.MarshalIndent(sections, "", "  "); err != nil {
+		return err
+	}
+	if err = writeConfigFile(path, append(data, '\n')); err != nil {
 		return err
 	}
+	loger.Info().Str("config_file", path).Str("backup", backup).Int("from", version).Int("to", config.ConfigVersion).
+		Strs("changes", changes).Msg("config file migrated")
+	return nil
+}
 
-	// 当前配置文件检测
-	loger.Info().Str("ServerName", MCPServerName).Str("version", GitVersion).Msg("start")
-	configFilePath := filepath.Join(mlConfig.BasePath, mlConfig.ConfigFile)
-	if nowConfig, err = os.ReadFile(configFilePath); err == nil {
-		err = json.Unmarshal(nowConfig, &nowConfigJSON)
-		if err != nil {
-			return fmt.Errorf("error unmarshaling JSON: %w, config file:%s", err, configFilePath)
+// applyEnabledModules loads the modules not disabled by the "enabled" key of their section of the config file,
+// unless --module is given.
+func applyEnabledModules(sections map[string]any) {
+	if moduleFlag.Changed {
+		return
+	}
+	var modules []string
+	disabled := false
+	for _, name := range serviceNames() {
+		if enabled, ok := config.SectionEnabled(sections[name]); ok && !enabled {
+			disabled = true
+			continue
 		}
+		modules = append(modules, name)
 	}
-	loger.Info().Str("config_file", configFilePath).Msg("load config file")
-	ctx := context.WithValue(context.Background(), comm.MoLingConfigKey, mlConfig)
-	ctx = context.WithValue(ctx, comm.MoLingLoggerKey, loger)
-	ctxNew, cancelFunc := context.WithCancel(ctx)
+	if disabled {
+		mlConfig.Module = strings.Join(modules, ",")
+	}
+}
 
+// newServices creates the services of the modules loaded, with their section of the config file.
+func newServices(ctx context.Context, nowConfigJSON map[string]any, loger zerolog.Logger) []abstract.Service {
 	var modules []string
 	if mlConfig.Module != "all" {
 		modules = strings.Split(mlConfig.Module, ",")
 	}
 	var srvs []abstract.Service
-	var closers = make(map[string]func() error)
 	for srvName, nsv := range services.ServiceList() {
 		if len(modules) > 0 {
 			if !utils.StringInSlice(string(srvName), modules) {
@@ -214,9 +393,9 @@ func mlsCommandFunc(command *cobra.Command, args []string) error {
 			loger.Debug().Str("moduleName", string(srvName)).Msgf("starting %s service", srvName)
 		}
 		cfg, ok := nowConfigJSON[string(srvName)].(map[string]any)
-		srv, err := nsv(ctxNew)
+		srv, err := nsv(ctx)
 		if err != nil {
-			loger.Error().Err(err).Msgf("failed to create service %s", srv.Name())
+			loger.Error().Err(err).Msgf("failed to create service %s", srvName)
 			break
 		}
 		if ok {
@@ -232,8 +411,45 @@ func mlsCommandFunc(command *cobra.Command, args []string) error {
 			break
 		}
 		srvs = append(srvs, srv)
-		closers[string(srv.Name())] = srv.Close
 	}
+	return srvs
+}
+
+func mlsCommandFunc(command *cobra.Command, args []string) error {
+	if checkOnly {
+		loger := initLogger(mlConfig.BasePath)
+		mlConfig.SetLogger(loger)
+		return checkStartup(loger)
+	}
+	// In a terminal, the logs are shown on stderr too, the MCP messages of the STDIO mode are on stdout.
+	var console io.Writer
+	if isForeground() {
+		console = os.Stderr
+	}
+	loger := newLogger(mlConfig.BasePath, console)
+	mlConfig.SetLogger(loger)
+	// The output of the daemon and of the service is written to a log file, their token is printed at their start.
+	mlConfig.HideAuthToken = os.Getenv(DaemonEnv) != ""
+	var err error
+
+	// 增加实例重复运行检测
+	pidFilePath := filepath.Join(mlConfig.BasePath, MLPidName)
+	loger.Info().Str("pid", pidFilePath).Msg("Starting MoLing MCP Server...")
+	err = utils.CreatePIDFile(pidFilePath, force)
+	if err != nil {
+		return err
+	}
+
+	// 当前配置文件检测
+	loger.Info().Str("ServerName", MCPServerName).Str("version", GitVersion).Msg("start")
+	nowConfigJSON, err := loadConfigFile(loger)
+	if err != nil {
+		return err
+	}
+	ctx := context.WithValue(context.Background(), comm.MoLingConfigKey, mlConfig)
+	ctx = context.WithValue(ctx, comm.MoLingLoggerKey, loger)
+	ctxNew, cancelFunc := context.WithCancel(ctx)
+	srvs := newServices(ctxNew, nowConfigJSON, loger)
 	// MCPServer
 	srv, err := server.NewMoLingServer(ctxNew, srvs, *mlConfig)
 	if err != nil {
@@ -254,24 +470,36 @@ func mlsCommandFunc(command *cobra.Command, args []string) error {
 	// 创建一个信号通道
 	sigChan := make(chan os.Signal, 2)
 	signal.Notify(sigChan, syscall.SIGINT, syscall.SIGTERM)
+	watchServiceStop(sigChan)
 
-	// 创建一个 goroutine 来判断父进程是否退出
-	// Claude Desktop 0.9.2 退出时，没有向MCP Server发送 SIGTERM信号，导致MCP 不能正常退出。
-	// fix https://github.com/gojue/moling/issues/32
+	// SIGHUP reloads the config file, re-creating the services whose configuration changed.
+	hupChan := make(chan os.Signal, 1)
+	signal.Notify(hupChan, syscall.SIGHUP)
 	go func() {
-		ppid := os.Getppid()
-		for {
-			time.Sleep(1 * time.Second)
-			newPpid := os.Getppid()
-			if newPpid == 1 {
-				loger.Info().Msgf("parent process changed,origin PPid:%d, New PPid:%d", ppid, newPpid)
-				loger.Warn().Msg("parent process exited")
-				sigChan <- syscall.SIGTERM
-				break
+		for range hupChan {
+			loger.Info().Msg("Received SIGHUP, reloading the config file")
+			reloaded, err := srv.Reload()
+			if err != nil {
+				loger.Error().Err(err).Strs("services", reloaded).Msg("failed to reload the config file")
+			} else {
+				loger.Info().Strs("services", reloaded).Msg("config file reloaded")
 			}
 		}
 	}()
 
+	if !noConfigWatch {
+		go func() {
+			if err := srv.WatchConfig(ctxNew); err != nil {
+				loger.Warn().Err(err).Msg("config file not watched, reload it with SIGHUP")
+			}
+		}()
+	}
+
+	// 创建一个 goroutine 来判断父进程是否退出
+	// Claude Desktop 0.9.2 退出时，没有向MCP Server发送 SIGTERM信号，导致MCP 不能正常退出。
+	// fix https://github.com/gojue/moling/issues/32
+	watchParent(sigChan, loger)
+
 	// 等待信号
 	_ = <-sigChan
 	loger.Info().Msg("Received signal, shutting down...")
@@ -283,7 +511,7 @@ func mlsCommandFunc(command *cobra.Command, args []string) error {
 
 	// 在goroutine中等待所有服务关闭
 	go func() {
-		for srvName, closer := range closers {
+		for _, s := range srv.Services() {
 			wg.Add(1)
 			go func(name string, closeFn func() error) {
 				defer wg.Done()
@@ -293,7 +521,7 @@ func mlsCommandFunc(command *cobra.Command, args []string) error {
 				} else {
 					loger.Info().Msgf("service %s closed", name)
 				}
-			}(srvName, closer)
+			}(string(s.Name()), s.Close)
 		}
 
 		// 等待所有服务关闭
@@ -310,6 +538,9 @@ func mlsCommandFunc(command *cobra.Command, args []string) error {
 		cancelFunc()
 		loger.Info().Msg("all services closed")
 	}
+	if err = srv.Close(); err != nil {
+		loger.Error().Err(err).Msg("failed to flush the traces")
+	}
 	err = utils.RemovePIDFile(pidFilePath)
 	if err != nil {
 		loger.Error().Err(err).Msgf("failed to remove pid file %s", pidFilePath)
@@ -0,0 +1,95 @@
+// Copyright 2025 CFC4N <cfc4n.cs@gmail.com>. All Rights Reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//   http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+//
+// Repository: https://github.com/gojue/moling
+
+package cmd
+
+import (
+	"bytes"
+	"os"
+	"reflect"
+	"runtime"
+	"strings"
+	"testing"
+
+	"github.com/rs/zerolog"
+)
+
+// TestApplyEnabledModules verifies the modules disabled in the config file aren't loaded, unless --module is given.
+func TestApplyEnabledModules(t *testing.T) {
+	module := mlConfig.Module
+	defer func() { mlConfig.Module, moduleFlag.Changed = module, false }()
+	mlConfig.Module = "all"
+	applyEnabledModules(map[string]any{"Browser": map[string]any{"enabled": true}})
+	if mlConfig.Module != "all" {
+		t.Errorf("expected all the modules loaded, got %s", mlConfig.Module)
+	}
+	sections := map[string]any{"Browser": map[string]any{"enabled": false}, "Command": map[string]any{"enabled": false}}
+	applyEnabledModules(sections)
+	if mlConfig.Module != "Aggregate,FileSystem" {
+		t.Errorf("expected the modules not disabled loaded, got %s", mlConfig.Module)
+	}
+
+	mlConfig.Module, moduleFlag.Changed = "Browser", true
+	applyEnabledModules(sections)
+	if mlConfig.Module != "Browser" {
+		t.Errorf("expected --module to override the config file, got %s", mlConfig.Module)
+	}
+}
+
+// TestMigrateConfigFile verifies a config file of an older version is rewritten upgraded, the original backed up
+// with its mode, and that --check reports the migration without writing.
+func TestMigrateConfigFile(t *testing.T) {
+	basePath, configFile := mlConfig.BasePath, mlConfig.ConfigFile
+	defer func() { mlConfig.BasePath, mlConfig.ConfigFile = basePath, configFile }()
+	mlConfig.BasePath, mlConfig.ConfigFile = t.TempDir(), "config.json"
+	path := mlConfig.ConfigFilePath()
+	old := []byte(`{"CommandServer": {"allowed_commands": ["ls", "cat"]}}`)
+	if err := os.WriteFile(path, old, 0o600); err != nil {
+		t.Fatalf("WriteFile: %v", err)
+	}
+	if c := checkConfigVersion(); c.Status != checkInfo || !strings.Contains(c.Detail, "would migrate") {
+		t.Errorf("expected the migration reported, got %s: %s", c.Status, c.Detail)
+	}
+	if data, _ := os.ReadFile(path); !bytes.Equal(data, old) {
+		t.Errorf("expected the check not to write the config file, got %s", data)
+	}
+	if err := migrateConfigFile(path, zerolog.Nop()); err != nil {
+		t.Fatalf("migrateConfigFile: %v", err)
+	}
+	if backup, err := os.ReadFile(path + ".v0.bak"); err != nil || !bytes.Equal(backup, old) {
+		t.Errorf("expected the original backed up, got %s, %v", backup, err)
+	}
+	sections, err := readConfigSections(path)
+	if err != nil {
+		t.Fatalf("readConfigSections: %v", err)
+	}
+	want := map[string]any{"config_version": float64(1), "Command": map[string]any{"allowed_command": "ls,cat"}}
+	if !reflect.DeepEqual(sections, want) {
+		t.Errorf("expected the config file upgraded, got %v", sections)
+	}
+	for _, p := range []string{path, path + ".v0.bak", path + configBackupSuffix} {
+		fi, err := os.Stat(p)
+		if err != nil {
+			t.Fatalf("stat %s: %v", p, err)
+		}
+		if runtime.GOOS != "windows" && fi.Mode().Perm() != 0o600 {
+			t.Errorf("expected %s to keep the mode 0600, got %v", p, fi.Mode())
+		}
+	}
+	if c := checkConfigVersion(); c.Status != checkOK {
+		t.Errorf("expected the config file up to date, got %s: %s", c.Status, c.Detail)
+	}
+}
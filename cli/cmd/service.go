@@ -0,0 +1,222 @@
+// Copyright 2025 CFC4N <cfc4n.cs@gmail.com>. All Rights Reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//   http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+//
+// Repository: https://github.com/gojue/moling
+
+package cmd
+
+import (
+	"bytes"
+	"encoding/xml"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"github.com/spf13/cobra"
+	"github.com/spf13/pflag"
+)
+
+const (
+	// ServiceName is the name of the system service of MoLing, the systemd unit and the Windows service.
+	ServiceName = "moling"
+	// ServiceLabel is the label of the launchd job of MoLing.
+	ServiceLabel = "cc.gojue.moling"
+	// ServiceLogFileName is the log file of the output of the service, in the logs of the base path.
+	ServiceLogFileName = "service.log"
+)
+
+var serviceCmd = &cobra.Command{
+	Use:   "service install|uninstall|start|stop|status",
+	Short: "Run MoLing in SSE mode as a system service, started at boot",
+	Long: `Install MoLing as a system service running in SSE mode, so it serves the network MCP clients persistently:
+a systemd unit on Linux, a launchd job on macOS and a Windows service. The flags given to install, e.g. --listen_addr
+and --module, are the ones the service runs with. Run as root, or as an administrator on Windows, to install the
+service of the system, otherwise the service of the current user is installed on Linux and macOS.
+    moling service install -l 127.0.0.1:6789 -m Browser,FileSystem   Install the service, started at boot
+    moling service start                                             Start the service
+    moling service stop                                              Stop the service
+    moling service status                                            Show the status of the service
+    moling service uninstall                                         Stop and remove the service
+`,
+	ValidArgs: []string{"install", "uninstall", "start", "stop", "status"},
+	Args:      cobra.MatchAll(cobra.ExactArgs(1), cobra.OnlyValidArgs),
+	RunE:      ServiceCommandFunc,
+}
+
+// serviceManager manages the system service of MoLing on the platform.
+type serviceManager interface {
+	// install registers the service running the executable with the arguments and the environment variables, e.g.
+	// the token, kept readable by the owner of the service only, started at boot.
+	install(exe string, args []string, env []string) error
+	// uninstall stops and removes the service.
+	uninstall() error
+	start() error
+	stop() error
+	// status returns the state of the service, e.g. "running".
+	status() (string, error)
+}
+
+// errServiceUnsupported is returned on the platforms without a supported service manager.
+var errServiceUnsupported = errors.New("the system service is not supported on this platform")
+
+// ServiceCommandFunc executes the "service" command.
+func ServiceCommandFunc(command *cobra.Command, args []string) error {
+	sm, err := newServiceManager()
+	if err != nil {
+		return err
+	}
+	switch args[0] {
+	case "install":
+		if mlConfig.ListenAddr == "" {
+			return errors.New("the service runs in SSE mode, set its listen address with --listen_addr")
+		}
+		exe, err := os.Executable()
+		if err != nil {
+			return fmt.Errorf("failed to find the executable: %w", err)
+		}
+		if exe, err = filepath.EvalSymlinks(exe); err != nil {
+			return fmt.Errorf("failed to find the executable: %w", err)
+		}
+		// The token is passed in the environment, not in the command line of the service.
+		svcArgs, token, err := backgroundToken(serviceArgs(command))
+		if err != nil {
+			return err
+		}
+		env := []string{DaemonEnv + "=1"}
+		if token != "" {
+			env = append(env, TokenEnv+"="+token)
+		}
+		if err = sm.install(exe, svcArgs, env); err != nil {
+			return fmt.Errorf("failed to install the service: %w", err)
+		}
+		fmt.Printf("Service %s installed, listening on %s. Start it with: %s service start\n", ServiceName, mlConfig.ListenAddr, CliName)
+		if token != "" {
+			fmt.Printf("SSE auth token: %s\n", token)
+		}
+	case "uninstall":
+		if err = sm.uninstall(); err != nil {
+			return fmt.Errorf("failed to uninstall the service: %w", err)
+		}
+		fmt.Printf("Service %s uninstalled\n", ServiceName)
+	case "start":
+		if err = sm.start(); err != nil {
+			return fmt.Errorf("failed to start the service: %w", err)
+		}
+		fmt.Printf("Service %s started\n", ServiceName)
+	case "stop":
+		if err = sm.stop(); err != nil {
+			return fmt.Errorf("failed to stop the service: %w", err)
+		}
+		fmt.Printf("Service %s stopped\n", ServiceName)
+	case "status":
+		state, err := sm.status()
+		if err != nil {
+			return fmt.Errorf("failed to query the service: %w", err)
+		}
+		fmt.Printf("Service %s: %s\n", ServiceName, state)
+	}
+	return nil
+}
+
+// serviceArgs returns the arguments of the service: the flags set on the command line and the base path, the
+// home directory of the service user may differ. The base path is the one of the profile, if any.
+func serviceArgs(command *cobra.Command) []string {
+	args := []string{"--base_path=" + mlConfig.BasePath}
+	command.Flags().Visit(func(f *pflag.Flag) {
+		if f.Name == "base_path" || f.Name == "profile" || command.InheritedFlags().Lookup(f.Name) == nil {
+			return
+		}
+		value := f.Value.String()
+		if sv, ok := f.Value.(pflag.SliceValue); ok {
+			value = strings.Join(sv.GetSlice(), ",")
+		}
+		args = append(args, "--"+f.Name+"="+value)
+	})
+	return args
+}
+
+// systemdQuote quotes the argument of ExecStart, the specifiers of systemd escaped.
+func systemdQuote(arg string) string {
+	arg = strings.NewReplacer(`\`, `\\`, `"`, `\"`, "%", "%%", "$", "$$").Replace(arg)
+	return `"` + arg + `"`
+}
+
+// systemdEnvFile returns the environment file of the systemd unit, with the environment variables quoted.
+func systemdEnvFile(env []string) string {
+	var b strings.Builder
+	for _, kv := range env {
+		key, value, _ := strings.Cut(kv, "=")
+		value = strings.NewReplacer(`\`, `\\`, `"`, `\"`).Replace(value)
+		fmt.Fprintf(&b, "%s=\"%s\"\n", key, value)
+	}
+	return b.String()
+}
+
+// systemdUnit returns the systemd unit running the executable, restarted on failure, its environment read from
+// the environment file.
+func systemdUnit(exe string, args []string, envFile string, wantedBy string) string {
+	var b strings.Builder
+	fmt.Fprintf(&b, "[Unit]\nDescription=%s\nAfter=network-online.target\nWants=network-online.target\n\n", MCPServerName)
+	b.WriteString("[Service]\nType=simple\n")
+	b.WriteString("EnvironmentFile=" + strings.ReplaceAll(envFile, "%", "%%") + "\n")
+	b.WriteString("ExecStart=" + systemdQuote(exe))
+	for _, arg := range args {
+		b.WriteString(" " + systemdQuote(arg))
+	}
+	b.WriteString("\nRestart=on-failure\nRestartSec=5\n\n")
+	fmt.Fprintf(&b, "[Install]\nWantedBy=%s\n", wantedBy)
+	return b.String()
+}
+
+// xmlEscape escapes the text of a plist element.
+func xmlEscape(s string) string {
+	var b bytes.Buffer
+	_ = xml.EscapeText(&b, []byte(s))
+	return b.String()
+}
+
+// launchdPlist returns the launchd job running the executable at load with the environment variables, restarted
+// if it exits, its output written to the log file.
+func launchdPlist(exe string, args []string, env []string, logFile string) string {
+	var b strings.Builder
+	b.WriteString(`<?xml version="1.0" encoding="UTF-8"?>
+<!DOCTYPE plist PUBLIC "-//Apple//DTD PLIST 1.0//EN" "http://www.apple.com/DTDs/PropertyList-1.0.dtd">
+<plist version="1.0">
+<dict>
+`)
+	fmt.Fprintf(&b, "\t<key>Label</key>\n\t<string>%s</string>\n", ServiceLabel)
+	b.WriteString("\t<key>ProgramArguments</key>\n\t<array>\n")
+	for _, arg := range append([]string{exe}, args...) {
+		fmt.Fprintf(&b, "\t\t<string>%s</string>\n", xmlEscape(arg))
+	}
+	b.WriteString("\t</array>\n")
+	b.WriteString("\t<key>EnvironmentVariables</key>\n\t<dict>\n")
+	for _, kv := range env {
+		key, value, _ := strings.Cut(kv, "=")
+		fmt.Fprintf(&b, "\t\t<key>%s</key>\n\t\t<string>%s</string>\n", xmlEscape(key), xmlEscape(value))
+	}
+	b.WriteString("\t</dict>\n")
+	b.WriteString("\t<key>RunAtLoad</key>\n\t<true/>\n")
+	b.WriteString("\t<key>KeepAlive</key>\n\t<dict>\n\t\t<key>SuccessfulExit</key>\n\t\t<false/>\n\t</dict>\n")
+	fmt.Fprintf(&b, "\t<key>StandardOutPath</key>\n\t<string>%s</string>\n", xmlEscape(logFile))
+	fmt.Fprintf(&b, "\t<key>StandardErrorPath</key>\n\t<string>%s</string>\n", xmlEscape(logFile))
+	b.WriteString("</dict>\n</plist>\n")
+	return b.String()
+}
+
+func init() {
+	rootCmd.AddCommand(serviceCmd)
+}
@@ -0,0 +1,92 @@
+//go:build darwin
+
+// Copyright 2025 CFC4N <cfc4n.cs@gmail.com>. All Rights Reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//   http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+//
+// Repository: https://github.com/gojue/moling
+
+package cmd
+
+import (
+	"fmt"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strings"
+)
+
+// launchdManager manages the launchd job of MoLing: a daemon of the system as root, else an agent of the user.
+type launchdManager struct {
+	plistPath string
+}
+
+func newServiceManager() (serviceManager, error) {
+	if os.Geteuid() == 0 {
+		return &launchdManager{plistPath: filepath.Join("/Library/LaunchDaemons", ServiceLabel+".plist")}, nil
+	}
+	home, err := os.UserHomeDir()
+	if err != nil {
+		return nil, err
+	}
+	return &launchdManager{plistPath: filepath.Join(home, "Library", "LaunchAgents", ServiceLabel+".plist")}, nil
+}
+
+// launchctl runs launchctl, its output returned in the error.
+func launchctl(args ...string) error {
+	out, err := exec.Command("launchctl", args...).CombinedOutput()
+	if err != nil {
+		return fmt.Errorf("launchctl %s: %w: %s", strings.Join(args, " "), err, strings.TrimSpace(string(out)))
+	}
+	return nil
+}
+
+// install writes the job, loaded by launchd at boot or login.
+func (lm *launchdManager) install(exe string, args []string, env []string) error {
+	if err := os.MkdirAll(filepath.Dir(lm.plistPath), 0o755); err != nil {
+		return err
+	}
+	logFile := filepath.Join(mlConfig.BasePath, "logs", ServiceLogFileName)
+	return writePrivateFile(lm.plistPath, []byte(launchdPlist(exe, args, env, logFile)))
+}
+
+func (lm *launchdManager) uninstall() error {
+	if _, err := os.Stat(lm.plistPath); err != nil {
+		return err
+	}
+	_ = launchctl("unload", lm.plistPath)
+	return os.Remove(lm.plistPath)
+}
+
+func (lm *launchdManager) start() error {
+	return launchctl("load", "-w", lm.plistPath)
+}
+
+func (lm *launchdManager) stop() error {
+	return launchctl("unload", lm.plistPath)
+}
+
+func (lm *launchdManager) status() (string, error) {
+	if _, err := os.Stat(lm.plistPath); os.IsNotExist(err) {
+		return "not installed", nil
+	}
+	// launchctl list fails for the jobs not loaded, else its output has the PID of the running job.
+	out, err := exec.Command("launchctl", "list", ServiceLabel).Output()
+	if err != nil {
+		return "stopped", nil
+	}
+	if strings.Contains(string(out), "\"PID\"") {
+		return "running", nil
+	}
+	return "loaded, not running", nil
+}
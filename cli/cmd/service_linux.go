@@ -0,0 +1,130 @@
+//go:build linux
+
+// Copyright 2025 CFC4N <cfc4n.cs@gmail.com>. All Rights Reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//   http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+//
+// Repository: https://github.com/gojue/moling
+
+package cmd
+
+import (
+	"fmt"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strings"
+)
+
+// systemdManager manages the systemd unit of MoLing: the one of the system as root, else the one of the user.
+type systemdManager struct {
+	unitPath string
+	user     bool // user runs systemctl --user.
+}
+
+func newServiceManager() (serviceManager, error) {
+	if _, err := exec.LookPath("systemctl"); err != nil {
+		return nil, fmt.Errorf("%w: systemctl not found", errServiceUnsupported)
+	}
+	if os.Geteuid() == 0 {
+		return &systemdManager{unitPath: filepath.Join("/etc/systemd/system", ServiceName+".service")}, nil
+	}
+	configDir, err := os.UserConfigDir()
+	if err != nil {
+		return nil, err
+	}
+	return &systemdManager{unitPath: filepath.Join(configDir, "systemd", "user", ServiceName+".service"), user: true}, nil
+}
+
+// systemctl runs systemctl on the unit, its output returned in the error.
+func (sm *systemdManager) systemctl(args ...string) (string, error) {
+	args = append(sm.userArgs(), args...)
+	out, err := exec.Command("systemctl", args...).CombinedOutput()
+	if err != nil {
+		return "", fmt.Errorf("systemctl %s: %w: %s", strings.Join(args, " "), err, strings.TrimSpace(string(out)))
+	}
+	return strings.TrimSpace(string(out)), nil
+}
+
+// envPath returns the environment file of the unit, next to it.
+func (sm *systemdManager) envPath() string {
+	return strings.TrimSuffix(sm.unitPath, ".service") + ".env"
+}
+
+func (sm *systemdManager) install(exe string, args []string, env []string) error {
+	wantedBy := "multi-user.target"
+	if sm.user {
+		wantedBy = "default.target"
+	}
+	if err := os.MkdirAll(filepath.Dir(sm.unitPath), 0o755); err != nil {
+		return err
+	}
+	if err := writePrivateFile(sm.envPath(), []byte(systemdEnvFile(env))); err != nil {
+		return err
+	}
+	if err := writePrivateFile(sm.unitPath, []byte(systemdUnit(exe, args, sm.envPath(), wantedBy))); err != nil {
+		return err
+	}
+	if _, err := sm.systemctl("daemon-reload"); err != nil {
+		return err
+	}
+	if _, err := sm.systemctl("enable", ServiceName); err != nil {
+		return err
+	}
+	if sm.user {
+		fmt.Printf("The service of the user starts at boot once lingering is enabled: loginctl enable-linger %s\n", os.Getenv("USER"))
+	}
+	return nil
+}
+
+func (sm *systemdManager) uninstall() error {
+	if _, err := os.Stat(sm.unitPath); err != nil {
+		return err
+	}
+	_, _ = sm.systemctl("disable", "--now", ServiceName)
+	if err := os.Remove(sm.unitPath); err != nil {
+		return err
+	}
+	if err := os.Remove(sm.envPath()); err != nil && !os.IsNotExist(err) {
+		return err
+	}
+	_, err := sm.systemctl("daemon-reload")
+	return err
+}
+
+func (sm *systemdManager) start() error {
+	_, err := sm.systemctl("start", ServiceName)
+	return err
+}
+
+func (sm *systemdManager) stop() error {
+	_, err := sm.systemctl("stop", ServiceName)
+	return err
+}
+
+func (sm *systemdManager) status() (string, error) {
+	if _, err := os.Stat(sm.unitPath); os.IsNotExist(err) {
+		return "not installed", nil
+	}
+	// is-active exits with an error for the units not active, its output is their state.
+	out, _ := exec.Command("systemctl", append(sm.userArgs(), "is-active", ServiceName)...).Output()
+	return strings.TrimSpace(string(out)), nil
+}
+
+// userArgs returns the arguments of systemctl selecting the manager of the user.
+func (sm *systemdManager) userArgs() []string {
+	if sm.user {
+		return []string{"--user"}
+	}
+	return nil
+}
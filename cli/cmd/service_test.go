@@ -0,0 +1,105 @@
+// Copyright 2025 CFC4N <cfc4n.cs@gmail.com>. All Rights Reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//   http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+//
+// Repository: https://github.com/gojue/moling
+
+package cmd
+
+import (
+	"os"
+	"path/filepath"
+	"runtime"
+	"slices"
+	"strings"
+	"testing"
+
+	"github.com/gojue/moling/pkg/config"
+)
+
+// TestServiceDefinitions verifies the systemd unit and the launchd job run the
+// executable with its arguments quoted, and their token in their environment.
+func TestServiceDefinitions(t *testing.T) {
+	args := []string{"--base_path=/home/a b/.moling", "--listen_addr=127.0.0.1:6789", "--module=50%$x"}
+	env := []string{DaemonEnv + "=1", TokenEnv + `=a"b\c`}
+
+	unit := systemdUnit("/usr/local/bin/moling", args, "/etc/systemd/system/moling.env", "multi-user.target")
+	for _, want := range []string{
+		`ExecStart="/usr/local/bin/moling" "--base_path=/home/a b/.moling" "--listen_addr=127.0.0.1:6789" "--module=50%%$$x"` + "\n",
+		"EnvironmentFile=/etc/systemd/system/moling.env\n",
+		"WantedBy=multi-user.target",
+	} {
+		if !strings.Contains(unit, want) {
+			t.Errorf("expected %s in the unit, got:\n%s", want, unit)
+		}
+	}
+	envFile := systemdEnvFile(env)
+	if want := "MOLING_DAEMON=\"1\"\nMOLING_TOKEN=\"a\\\"b\\\\c\"\n"; envFile != want {
+		t.Errorf("expected the environment file %q, got %q", want, envFile)
+	}
+
+	plist := launchdPlist("/usr/local/bin/moling", []string{"--module=a<b&c"}, []string{TokenEnv + "=a<b"}, "/tmp/service.log")
+	for _, want := range []string{"<string>" + ServiceLabel + "</string>", "<string>/usr/local/bin/moling</string>", "<string>--module=a&lt;b&amp;c</string>",
+		"<key>EnvironmentVariables</key>", "<key>MOLING_TOKEN</key>\n\t\t<string>a&lt;b</string>", "<key>RunAtLoad</key>"} {
+		if !strings.Contains(plist, want) {
+			t.Errorf("expected %s in the plist, got:\n%s", want, plist)
+		}
+	}
+}
+
+// TestBackgroundToken verifies the token is split off the arguments, and generated when none is configured.
+func TestBackgroundToken(t *testing.T) {
+	basePath := mlConfig.BasePath
+	defer func() { mlConfig.BasePath = basePath }()
+	mlConfig.BasePath = t.TempDir()
+	t.Setenv(config.AuthTokensEnv, "")
+
+	args, token, err := backgroundToken([]string{"--listen_addr=127.0.0.1:6789", "--token=secret"})
+	if err != nil || token != "secret" || slices.Contains(args, "--token=secret") {
+		t.Errorf("expected the token secret split off, got %v, %q, %v", args, token, err)
+	}
+	if _, token, _ = backgroundToken(nil); len(token) != 32 {
+		t.Errorf("expected a generated token, got %q", token)
+	}
+	t.Setenv(TokenEnv, "from-env")
+	if _, token, _ = backgroundToken(nil); token != "from-env" {
+		t.Errorf("expected the token of %s, got %q", TokenEnv, token)
+	}
+	t.Setenv(TokenEnv, "")
+	t.Setenv(config.AuthTokensEnv, "configured")
+	if _, token, _ = backgroundToken(nil); token != "" {
+		t.Errorf("expected no token generated with tokens configured, got %q", token)
+	}
+}
+
+// TestWriteDaemonArgs verifies the arguments are saved for restart without the token, readable by the owner only.
+func TestWriteDaemonArgs(t *testing.T) {
+	basePath := mlConfig.BasePath
+	defer func() { mlConfig.BasePath = basePath }()
+	mlConfig.BasePath = t.TempDir()
+
+	if err := writeDaemonArgs([]string{"--listen_addr=127.0.0.1:6789", "--token=secret", "--debug=true"}); err != nil {
+		t.Fatalf("writeDaemonArgs: %v", err)
+	}
+	path := filepath.Join(mlConfig.BasePath, MLDaemonArgsName)
+	data, err := os.ReadFile(path)
+	if err != nil {
+		t.Fatalf("ReadFile: %v", err)
+	}
+	if string(data) != `["--listen_addr=127.0.0.1:6789","--debug=true"]` {
+		t.Errorf("expected the arguments without the token, got %s", data)
+	}
+	if info, err := os.Stat(path); err == nil && runtime.GOOS != "windows" && info.Mode().Perm() != 0600 {
+		t.Errorf("expected the file readable by its owner only, got %v", info.Mode().Perm())
+	}
+}
@@ -0,0 +1,172 @@
+//go:build windows
+
+// Copyright 2025 CFC4N <cfc4n.cs@gmail.com>. All Rights Reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//   http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+//
+// Repository: https://github.com/gojue/moling
+
+package cmd
+
+import (
+	"errors"
+	"fmt"
+	"os"
+	"syscall"
+	"time"
+
+	"golang.org/x/sys/windows"
+	"golang.org/x/sys/windows/registry"
+	"golang.org/x/sys/windows/svc"
+	"golang.org/x/sys/windows/svc/mgr"
+)
+
+// windowsManager manages the Windows service of MoLing, with the Service Control Manager.
+type windowsManager struct{}
+
+func newServiceManager() (serviceManager, error) {
+	return windowsManager{}, nil
+}
+
+// open opens the service, to be closed with the manager.
+func (windowsManager) open() (*mgr.Mgr, *mgr.Service, error) {
+	m, err := mgr.Connect()
+	if err != nil {
+		return nil, nil, err
+	}
+	s, err := m.OpenService(ServiceName)
+	if err != nil {
+		_ = m.Disconnect()
+		return nil, nil, err
+	}
+	return m, s, nil
+}
+
+func (windowsManager) install(exe string, args []string, env []string) error {
+	m, err := mgr.Connect()
+	if err != nil {
+		return err
+	}
+	defer m.Disconnect()
+	s, err := m.CreateService(ServiceName, exe, mgr.Config{
+		DisplayName: MCPServerName,
+		Description: CliDescription,
+		StartType:   mgr.StartAutomatic,
+	}, args...)
+	if err != nil {
+		return err
+	}
+	defer s.Close()
+	// The Service Control Manager sets the Environment value of the key of the service in its environment.
+	k, err := registry.OpenKey(registry.LOCAL_MACHINE, `SYSTEM\CurrentControlSet\Services\`+ServiceName, registry.SET_VALUE)
+	if err != nil {
+		return err
+	}
+	defer k.Close()
+	if err = k.SetStringsValue("Environment", env); err != nil {
+		return err
+	}
+	// Restart the service 5 seconds after a failure.
+	return s.SetRecoveryActions([]mgr.RecoveryAction{{Type: mgr.ServiceRestart, Delay: 5 * time.Second}}, 86400)
+}
+
+func (wm windowsManager) uninstall() error {
+	m, s, err := wm.open()
+	if err != nil {
+		return err
+	}
+	defer m.Disconnect()
+	defer s.Close()
+	_, _ = s.Control(svc.Stop)
+	return s.Delete()
+}
+
+func (wm windowsManager) start() error {
+	m, s, err := wm.open()
+	if err != nil {
+		return err
+	}
+	defer m.Disconnect()
+	defer s.Close()
+	return s.Start()
+}
+
+func (wm windowsManager) stop() error {
+	m, s, err := wm.open()
+	if err != nil {
+		return err
+	}
+	defer m.Disconnect()
+	defer s.Close()
+	_, err = s.Control(svc.Stop)
+	return err
+}
+
+func (wm windowsManager) status() (string, error) {
+	m, s, err := wm.open()
+	if errors.Is(err, windows.ERROR_SERVICE_DOES_NOT_EXIST) {
+		return "not installed", nil
+	}
+	if err != nil {
+		return "", err
+	}
+	defer m.Disconnect()
+	defer s.Close()
+	st, err := s.Query()
+	if err != nil {
+		return "", err
+	}
+	switch st.State {
+	case svc.Running:
+		return "running", nil
+	case svc.Stopped:
+		return "stopped", nil
+	case svc.StartPending:
+		return "starting", nil
+	case svc.StopPending:
+		return "stopping", nil
+	default:
+		return fmt.Sprintf("state %d", st.State), nil
+	}
+}
+
+// serviceHandler reports the state of MoLing to the Service Control Manager, and stops it on request.
+type serviceHandler struct {
+	sigChan chan<- os.Signal
+}
+
+func (h serviceHandler) Execute(args []string, requests <-chan svc.ChangeRequest, status chan<- svc.Status) (bool, uint32) {
+	status <- svc.Status{State: svc.Running, Accepts: svc.AcceptStop | svc.AcceptShutdown}
+	for req := range requests {
+		switch req.Cmd {
+		case svc.Interrogate:
+			status <- req.CurrentStatus
+		case svc.Stop, svc.Shutdown:
+			status <- svc.Status{State: svc.StopPending}
+			h.sigChan <- syscall.SIGTERM
+			return false, 0
+		}
+	}
+	return false, 0
+}
+
+// watchServiceStop reports MoLing running to the Service Control Manager when run as a Windows service, its
+// stop requests sent as SIGTERM to the channel.
+func watchServiceStop(sigChan chan<- os.Signal) {
+	if isService, err := svc.IsWindowsService(); err != nil || !isService {
+		return
+	}
+	go func() {
+		_ = svc.Run(ServiceName, serviceHandler{sigChan: sigChan})
+	}()
+}
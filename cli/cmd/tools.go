@@ -0,0 +1,119 @@
+// Copyright 2025 CFC4N <cfc4n.cs@gmail.com>. All Rights Reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//   http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+//
+// Repository: https://github.com/gojue/moling
+
+package cmd
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"slices"
+	"sort"
+	"strings"
+
+	"github.com/spf13/cobra"
+
+	"github.com/gojue/moling/pkg/comm"
+	"github.com/gojue/moling/pkg/server"
+)
+
+var toolsCmd = &cobra.Command{
+	Use:   "tools",
+	Short: "List the tools MoLing exposes to the MCP clients, with their parameters",
+	Long: `Create the services of the modules selected, with the config file, and list the tools they expose to the MCP clients,
+with their description and parameters, without serving them. The tools disabled by the configuration aren't listed.
+    moling tools
+    moling tools -m FileSystem
+    moling tools --json
+`,
+	Args: cobra.NoArgs,
+	RunE: ToolsCommandFunc,
+}
+
+var toolsJSON bool
+
+// ToolsCommandFunc executes the "tools" command.
+func ToolsCommandFunc(command *cobra.Command, args []string) error {
+	logger := initLogger(mlConfig.BasePath)
+	mlConfig.SetLogger(logger)
+	nowConfigJSON, err := loadConfigFile(logger)
+	if err != nil {
+		return err
+	}
+	ctx := context.WithValue(context.Background(), comm.MoLingConfigKey, mlConfig)
+	ctx = context.WithValue(ctx, comm.MoLingLoggerKey, logger)
+	ctx, cancel := context.WithCancel(ctx)
+	defer cancel()
+	srvs := newServices(ctx, nowConfigJSON, logger)
+	defer func() {
+		for _, srv := range srvs {
+			_ = srv.Close()
+		}
+	}()
+	srv, err := server.NewMoLingServer(ctx, srvs, *mlConfig)
+	if err != nil {
+		return err
+	}
+	defer srv.Close()
+
+	tools := srv.Tools()
+	if toolsJSON {
+		data, err := json.MarshalIndent(tools, "", "  ")
+		if err != nil {
+			return err
+		}
+		fmt.Println(string(data))
+		return nil
+	}
+	for _, tool := range tools {
+		printTool(tool)
+	}
+	fmt.Printf("%d tools\n", len(tools))
+	return nil
+}
+
+// printTool prints the tool with its description and parameters.
+func printTool(tool server.ToolInfo) {
+	service := tool.Service
+	if service == "" {
+		service = CliName
+	}
+	fmt.Printf("%s (%s)\n", tool.Tool.Name, service)
+	for _, line := range strings.Split(strings.TrimSpace(tool.Tool.Description), "\n") {
+		fmt.Printf("    %s\n", line)
+	}
+	names := make([]string, 0, len(tool.Tool.InputSchema.Properties))
+	for name := range tool.Tool.InputSchema.Properties {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	for _, name := range names {
+		prop, _ := tool.Tool.InputSchema.Properties[name].(map[string]any)
+		typ, _ := prop["type"].(string)
+		description, _ := prop["description"].(string)
+		required := ""
+		if slices.Contains(tool.Tool.InputSchema.Required, name) {
+			required = ", required"
+		}
+		fmt.Printf("    - %s (%s%s): %s\n", name, typ, required, description)
+	}
+	fmt.Println()
+}
+
+func init() {
+	toolsCmd.Flags().BoolVar(&toolsJSON, "json", false, "print the tools in JSON, as listed to the MCP clients, with their service")
+	rootCmd.AddCommand(toolsCmd)
+}
@@ -0,0 +1,119 @@
+// Copyright 2025 CFC4N <cfc4n.cs@gmail.com>. All Rights Reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//   http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+//
+// Repository: https://github.com/gojue/moling
+
+package cmd
+
+import (
+	"encoding/json"
+	"fmt"
+	"runtime"
+	"runtime/debug"
+	"strings"
+
+	"github.com/spf13/cobra"
+)
+
+// BuildDate is the date of the build, set by the Makefile.
+var BuildDate = ""
+
+var versionCmd = &cobra.Command{
+	Use:   "version",
+	Short: "Show the version of MoLing and how it was built, to include in the bug reports",
+	Long: `Show the version of MoLing with its build provenance: the git commit, the build date, the Go version, the platform
+and the build tags.
+    moling version
+    moling version --json
+`,
+	Args: cobra.NoArgs,
+	// Nothing is written to the base path.
+	PersistentPreRunE: func(command *cobra.Command, args []string) error { return nil },
+	RunE:              VersionCommandFunc,
+}
+
+// versionJSON prints the version as JSON.
+var versionJSON bool
+
+// versionInfo is the version of MoLing with its build provenance.
+type versionInfo struct {
+	Version    string   `json:"version"`
+	Commit     string   `json:"commit"`
+	Modified   bool     `json:"modified"` // Modified tells the build had uncommitted changes.
+	CommitDate string   `json:"commit_date"`
+	BuildDate  string   `json:"build_date"`
+	GoVersion  string   `json:"go_version"`
+	Platform   string   `json:"platform"`
+	Tags       []string `json:"tags"`
+	CGOEnabled bool     `json:"cgo_enabled"`
+}
+
+// buildVersion returns the version with the provenance recorded in the binary by the Go toolchain.
+func buildVersion() versionInfo {
+	v := versionInfo{
+		Version:   GitVersion,
+		BuildDate: BuildDate,
+		GoVersion: runtime.Version(),
+		Platform:  runtime.GOOS + "/" + runtime.GOARCH,
+		Tags:      []string{},
+	}
+	info, ok := debug.ReadBuildInfo()
+	if !ok {
+		return v
+	}
+	for _, s := range info.Settings {
+		switch s.Key {
+		case "vcs.revision":
+			v.Commit = s.Value
+		case "vcs.time":
+			v.CommitDate = s.Value
+		case "vcs.modified":
+			v.Modified = s.Value == "true"
+		case "-tags":
+			v.Tags = strings.Split(s.Value, ",")
+		case "CGO_ENABLED":
+			v.CGOEnabled = s.Value == "1"
+		}
+	}
+	return v
+}
+
+// VersionCommandFunc executes the "version" command.
+func VersionCommandFunc(command *cobra.Command, args []string) error {
+	v := buildVersion()
+	if versionJSON {
+		data, err := json.MarshalIndent(v, "", "  ")
+		if err != nil {
+			return err
+		}
+		fmt.Println(string(data))
+		return nil
+	}
+	commit := v.Commit
+	if v.Modified {
+		commit += " (modified)"
+	}
+	fmt.Printf("%s version:\t%s\n", CliName, v.Version)
+	fmt.Printf("commit:\t\t%s %s\n", commit, v.CommitDate)
+	fmt.Printf("build date:\t%s\n", v.BuildDate)
+	fmt.Printf("go version:\t%s\n", v.GoVersion)
+	fmt.Printf("platform:\t%s\n", v.Platform)
+	fmt.Printf("build tags:\t%s\n", strings.Join(v.Tags, ","))
+	return nil
+}
+
+func init() {
+	versionCmd.Flags().BoolVar(&versionJSON, "json", false, "print the version as JSON.")
+	rootCmd.AddCommand(versionCmd)
+}
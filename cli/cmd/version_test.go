@@ -0,0 +1,42 @@
+// Copyright 2025 CFC4N <cfc4n.cs@gmail.com>. All Rights Reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//   http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+//
+// Repository: https://github.com/gojue/moling
+
+package cmd
+
+import (
+	"encoding/json"
+	"runtime"
+	"testing"
+)
+
+// TestBuildVersion verifies the version reports the toolchain and the platform, and its JSON keys.
+func TestBuildVersion(t *testing.T) {
+	v := buildVersion()
+	if v.Version != GitVersion || v.GoVersion != runtime.Version() || v.Platform != runtime.GOOS+"/"+runtime.GOARCH {
+		t.Errorf("unexpected version %+v", v)
+	}
+	data, err := json.Marshal(v)
+	if err != nil {
+		t.Fatalf("json.Marshal: %v", err)
+	}
+	var fields map[string]any
+	_ = json.Unmarshal(data, &fields)
+	for _, key := range []string{"version", "commit", "build_date", "go_version", "platform", "tags"} {
+		if _, ok := fields[key]; !ok {
+			t.Errorf("expected the key %s in %s", key, data)
+		}
+	}
+}
@@ -24,6 +24,7 @@ import (
 	"encoding/json"
 	"errors"
 	"os"
+	"sort"
 
 	"github.com/rs/zerolog"
 )
@@ -91,6 +92,29 @@ func (c *Manager) ListClient() {
 	return
 }
 
+// Installed returns the names of the clients whose config file exists, sorted.
+func (c *Manager) Installed() []string {
+	var names []string
+	for name, path := range c.clients {
+		if c.checkExist(path) {
+			names = append(names, name)
+		}
+	}
+	sort.Strings(names)
+	return names
+}
+
+// Select keeps the clients named only, the other ones are not listed nor set up. Unknown names are ignored.
+func (c *Manager) Select(names []string) {
+	clients := make(map[string]string, len(names))
+	for _, name := range names {
+		if path, ok := c.clients[name]; ok {
+			clients[name] = path
+		}
+	}
+	c.clients = clients
+}
+
 // SetupConfig sets up the configuration for the clients.
 func (c *Manager) SetupConfig() {
 	for name, path := range c.clients {
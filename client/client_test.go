@@ -21,6 +21,8 @@
 package client
 
 import (
+	"bytes"
+	"encoding/json"
 	"os"
 	"testing"
 
@@ -118,3 +120,57 @@ func TestClientManager_checkExist(t *testing.T) {
 		t.Errorf("Expected file to exist")
 	}
 }
+
+func TestClientManager_Select(t *testing.T) {
+	logger := zerolog.New(os.Stdout)
+	mcpConfig := NewMCPServerConfig("MoLing UnitTest Description", "moling_test", "MoLing MCP Server")
+	cm := NewManager(logger, mcpConfig)
+
+	file, err := os.CreateTemp("", "mcp.json")
+	if err != nil {
+		t.Fatalf("Failed to create temp file: %s", err.Error())
+	}
+	defer func() {
+		_ = os.Remove(file.Name())
+	}()
+	cm.clients = map[string]string{"Installed": file.Name(), "Other": file.Name(), "Missing": "/path/to/nonexistent/file"}
+	if installed := cm.Installed(); len(installed) != 2 || installed[0] != "Installed" || installed[1] != "Other" {
+		t.Fatalf("Expected the clients Installed and Other, got %v", installed)
+	}
+	cm.Select([]string{"Installed", "Unknown"})
+	if installed := cm.Installed(); len(installed) != 1 || installed[0] != "Installed" {
+		t.Errorf("Expected the client Installed only, got %v", installed)
+	}
+}
+
+func TestExportSnippet(t *testing.T) {
+	stdio := ServerEntry{Name: "MoLing", Command: "/usr/local/bin/moling", Args: []string{"--module=Browser"}}
+	sse := ServerEntry{Name: "MoLing", URL: "http://127.0.0.1:6789/sse", Token: "<token>"}
+	for _, tc := range []struct {
+		format string
+		entry  ServerEntry
+		want   string
+	}{
+		{FormatClaude, stdio, `{"mcpServers":{"MoLing":{"args":["--module=Browser"],"command":"/usr/local/bin/moling"}}}`},
+		{FormatClaude, sse, `{"mcpServers":{"MoLing":{"args":["-y","mcp-remote","http://127.0.0.1:6789/sse","--header","Authorization: Bearer <token>"],"command":"npx"}}}`},
+		{FormatCursor, sse, `{"mcpServers":{"MoLing":{"headers":{"Authorization":"Bearer <token>"},"url":"http://127.0.0.1:6789/sse"}}}`},
+		{FormatCline, stdio, `{"mcpServers":{"MoLing":{"args":["--module=Browser"],"autoApprove":[],"command":"/usr/local/bin/moling","disabled":false}}}`},
+		{FormatZed, stdio, `{"context_servers":{"MoLing":{"args":["--module=Browser"],"command":"/usr/local/bin/moling","source":"custom"}}}`},
+		{FormatJSON, sse, `{"mcpServers":{"MoLing":{"headers":{"Authorization":"Bearer <token>"},"type":"sse","url":"http://127.0.0.1:6789/sse"}}}`},
+	} {
+		snippet, err := ExportSnippet(tc.format, tc.entry)
+		if err != nil {
+			t.Fatalf("ExportSnippet(%s): %s", tc.format, err.Error())
+		}
+		var compact bytes.Buffer
+		if err = json.Compact(&compact, snippet); err != nil {
+			t.Fatalf("Expected valid JSON for %s, got error %s", tc.format, err.Error())
+		}
+		if compact.String() != tc.want {
+			t.Errorf("ExportSnippet(%s) = %s, want %s", tc.format, compact.String(), tc.want)
+		}
+	}
+	if _, err := ExportSnippet("vim", stdio); err == nil {
+		t.Errorf("Expected an unknown format to be rejected")
+	}
+}
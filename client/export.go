@@ -0,0 +1,117 @@
+/*
+ *
+ *  Copyright 2025 CFC4N <cfc4n.cs@gmail.com>. All Rights Reserved.
+ *
+ *  Licensed under the Apache License, Version 2.0 (the "License");
+ *  you may not use this file except in compliance with the License.
+ *  You may obtain a copy of the License at
+ *
+ *    http://www.apache.org/licenses/LICENSE-2.0
+ *
+ *  Unless required by applicable law or agreed to in writing, software
+ *  distributed under the License is distributed on an "AS IS" BASIS,
+ *  WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ *  See the License for the specific language governing permissions and
+ *  limitations under the License.
+ *
+ *  Repository: https://github.com/gojue/moling
+ *
+ */
+
+package client
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+)
+
+// Formats of the MCP configuration snippets of ExportSnippet.
+const (
+	FormatClaude = "claude"
+	FormatCursor = "cursor"
+	FormatCline  = "cline"
+	FormatZed    = "zed"
+	FormatJSON   = "json"
+)
+
+// ExportFormats are the formats of the snippets, with the file of the client they are pasted in.
+var ExportFormats = []struct {
+	Name  string
+	Title string
+}{
+	{FormatClaude, "Claude Desktop, claude_desktop_config.json"},
+	{FormatCursor, "Cursor, ~/.cursor/mcp.json"},
+	{FormatCline, "Cline, cline_mcp_settings.json"},
+	{FormatZed, "Zed, settings.json"},
+	{FormatJSON, "generic MCP client"},
+}
+
+// ServerEntry is how an MCP client reaches MoLing: the command it starts in STDIO mode, or the URL of the SSE mode.
+type ServerEntry struct {
+	Name    string   // Name is the key of MoLing in the configuration of the client.
+	Command string   // Command is the absolute path of the executable, STDIO mode.
+	Args    []string // Args are the arguments of the command, STDIO mode.
+	URL     string   // URL is the SSE endpoint, e.g. http://127.0.0.1:6789/sse, SSE mode if set.
+	Token   string   // Token is the API token of the SSE mode, sent as a bearer token.
+}
+
+// headers returns the HTTP headers of the SSE mode, none without a token.
+func (e ServerEntry) headers() map[string]string {
+	if e.Token == "" {
+		return nil
+	}
+	return map[string]string{"Authorization": "Bearer " + e.Token}
+}
+
+// stdio returns the command and the arguments starting MoLing, or the mcp-remote bridge to its SSE endpoint for the
+// clients that only start commands.
+func (e ServerEntry) stdio() map[string]any {
+	if e.URL == "" {
+		return map[string]any{"command": e.Command, "args": e.Args}
+	}
+	args := []string{"-y", "mcp-remote", e.URL}
+	if e.Token != "" {
+		args = append(args, "--header", "Authorization: Bearer "+e.Token)
+	}
+	return map[string]any{"command": "npx", "args": args}
+}
+
+// ExportSnippet returns the snippet of the configuration of the client adding MoLing, in indented JSON.
+func ExportSnippet(format string, e ServerEntry) ([]byte, error) {
+	server := e.stdio()
+	switch format {
+	case FormatClaude, FormatZed:
+		// Claude Desktop and Zed start commands only, the SSE endpoint is bridged with mcp-remote.
+	case FormatCursor, FormatCline, FormatJSON:
+		if e.URL != "" {
+			server = map[string]any{"url": e.URL}
+			if format != FormatCursor {
+				server["type"] = "sse"
+			}
+			if h := e.headers(); h != nil {
+				server["headers"] = h
+			}
+		}
+	default:
+		return nil, fmt.Errorf("unknown format %s", format)
+	}
+	key := MCPServersKey
+	switch format {
+	case FormatCline:
+		server["disabled"] = false
+		server["autoApprove"] = []string{}
+	case FormatZed:
+		server["source"] = "custom"
+		key = "context_servers"
+	}
+	// The placeholders, e.g. <token>, are kept readable.
+	var b bytes.Buffer
+	enc := json.NewEncoder(&b)
+	enc.SetEscapeHTML(false)
+	enc.SetIndent("", "  ")
+	if err := enc.Encode(map[string]any{key: map[string]any{e.Name: server}}); err != nil {
+		return nil, err
+	}
+	return bytes.TrimSuffix(b.Bytes(), []byte("\n")), nil
+}
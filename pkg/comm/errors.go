@@ -16,8 +16,82 @@
 
 package comm
 
-import "errors"
+import (
+	"context"
+	"errors"
+	"fmt"
+	"os"
+
+	"github.com/mark3labs/mcp-go/mcp"
+)
 
 var (
 	ErrConfigNotLoaded = errors.New("config not loaded, please call LoadConfig() first")
 )
+
+// ErrorCode is the machine-readable type of the failure of a tool call, for the clients to branch on.
+type ErrorCode string
+
+const (
+	CodePolicyDenied    ErrorCode = "POLICY_DENIED"    // CodePolicyDenied is a call rejected by the configuration, e.g. a path out of the allowed directories.
+	CodeNotFound        ErrorCode = "NOT_FOUND"        // CodeNotFound is a missing file, job, session or element.
+	CodeTimeout         ErrorCode = "TIMEOUT"          // CodeTimeout is a call that didn't finish in time.
+	CodeTooLarge        ErrorCode = "TOO_LARGE"        // CodeTooLarge is an input, an output or a number of jobs or sessions over a limit.
+	CodeInvalidArgument ErrorCode = "INVALID_ARGUMENT" // CodeInvalidArgument is a missing or malformed argument of the tool.
+	CodeInternal        ErrorCode = "INTERNAL"         // CodeInternal is any other failure.
+)
+
+// CodedError is an error with the code of its type, kept through the wrapping of fmt.Errorf with %w.
+type CodedError struct {
+	Code ErrorCode
+	Err  error
+}
+
+func (e *CodedError) Error() string {
+	return e.Err.Error()
+}
+
+func (e *CodedError) Unwrap() error {
+	return e.Err
+}
+
+// Errorf returns an error of the code, formatted like fmt.Errorf.
+func Errorf(code ErrorCode, format string, args ...any) error {
+	return &CodedError{Code: code, Err: fmt.Errorf(format, args...)}
+}
+
+// CodeOf returns the code of the error: the one of a CodedError it wraps, else NOT_FOUND, POLICY_DENIED or
+// TIMEOUT for the errors of missing files, denied permissions and deadlines, else INTERNAL.
+func CodeOf(err error) ErrorCode {
+	var coded *CodedError
+	switch {
+	case errors.As(err, &coded):
+		return coded.Code
+	case errors.Is(err, os.ErrNotExist):
+		return CodeNotFound
+	case errors.Is(err, os.ErrPermission):
+		return CodePolicyDenied
+	case errors.Is(err, context.DeadlineExceeded), errors.Is(err, os.ErrDeadlineExceeded):
+		return CodeTimeout
+	}
+	return CodeInternal
+}
+
+// ToolErrorPayload is the structured content of the error results of the tools, alongside their message.
+type ToolErrorPayload struct {
+	Error   ErrorCode `json:"error"`
+	Message string    `json:"message"`
+}
+
+// NewToolResultError returns an error result of the tool with the message, and its code in the structured
+// content.
+func NewToolResultError(code ErrorCode, message string) *mcp.CallToolResult {
+	result := mcp.NewToolResultStructured(ToolErrorPayload{Error: code, Message: message}, message)
+	result.IsError = true
+	return result
+}
+
+// NewToolResultErrorFor returns an error result of the tool with the message, and the code of the error.
+func NewToolResultErrorFor(err error, message string) *mcp.CallToolResult {
+	return NewToolResultError(CodeOf(err), message)
+}
@@ -0,0 +1,64 @@
+// Copyright 2025 CFC4N <cfc4n.cs@gmail.com>. All Rights Reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//   http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+//
+// Repository: https://github.com/gojue/moling
+
+package comm
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"os"
+	"testing"
+
+	"github.com/mark3labs/mcp-go/mcp"
+)
+
+func TestCodeOf(t *testing.T) {
+	denied := Errorf(CodePolicyDenied, "command not allowed")
+	tests := []struct {
+		name string
+		err  error
+		want ErrorCode
+	}{
+		{"coded", denied, CodePolicyDenied},
+		{"wrapped", fmt.Errorf("%w: matches a denied pattern", denied), CodePolicyDenied},
+		{"not exist", fmt.Errorf("open: %w", os.ErrNotExist), CodeNotFound},
+		{"permission", os.ErrPermission, CodePolicyDenied},
+		{"deadline", context.DeadlineExceeded, CodeTimeout},
+		{"other", errors.New("boom"), CodeInternal},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := CodeOf(tt.err); got != tt.want {
+				t.Errorf("CodeOf() = %s, want %s", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestNewToolResultError(t *testing.T) {
+	result := NewToolResultErrorFor(Errorf(CodeNotFound, "job 1 not found"), "Error: job 1 not found")
+	if !result.IsError {
+		t.Fatal("expected an error result")
+	}
+	if text := result.Content[0].(mcp.TextContent).Text; text != "Error: job 1 not found" {
+		t.Errorf("expected the message as text, got %q", text)
+	}
+	payload, ok := result.StructuredContent.(ToolErrorPayload)
+	if !ok || payload.Error != CodeNotFound || payload.Message != "Error: job 1 not found" {
+		t.Errorf("expected the NOT_FOUND payload, got %#v", result.StructuredContent)
+	}
+}
@@ -17,6 +17,12 @@
 package config
 
 import (
+	"encoding/json"
+	"fmt"
+	"path/filepath"
+	"slices"
+	"strings"
+
 	"github.com/rs/zerolog"
 )
 
@@ -33,11 +39,12 @@ type MoLingConfig struct {
 	//AllowDir   []string `json:"allow_dir"`   // The directories that are allowed to be accessed by the server.
 	Version    string `json:"version"`     // The version of the MoLing server.
 	ListenAddr string `json:"listen_addr"` // The address to listen on for SSE mode.
+	Stdio      bool   `json:"stdio"`       // Stdio serves STDIO too when ListenAddr is set, the clients of both share the services.
 	Debug      bool   `json:"debug"`       // Debug mode, if true, the server will run in debug mode.
 	Module     string `json:"module"`      // The module to load, default: all
 	Username   string // The username of the user running the server.
 	HomeDir    string // The home directory of the user running the server. macOS: /Users/user1, Linux: /home/user1
-	SystemInfo string // The system information of the user running the server. macOS: Darwin 15.3.3, Linux: Ubuntu 20.04.1 LTS
+	SystemInfo string // The operating system shown in prompts, e.g. Ubuntu 22.04. empty: detected by the services
 
 	// for MCP Server Config
 	Description string // Description of the MCP Server, default: CliDescription
@@ -45,8 +52,159 @@ type MoLingConfig struct {
 	Args        string // Arguments to pass to the command, STDIO mode only, default: empty
 	BaseURL     string // BaseURL , SSE mode only.
 	ServerName  string // ServerName MCP ServerName, add to the MCP Client config
-	AuthToken   string // AuthToken for SSE mode authentication. Auto-generated if empty.
+	AuthToken   string // AuthToken for SSE mode authentication, with all scopes. Auto-generated if empty and no AuthTokens are set.
 	logger      zerolog.Logger
+	allowedDirs []string // allowedDirs the directories published by the FileSystem service, shared with other services for path policy checks.
+	sharedDirs  []string // sharedDirs the directories other services write files to, e.g. browser downloads, that the FileSystem service allows too.
+
+	// AuthTokens are the API tokens of the SSE mode with their scopes, in addition to AuthToken and MOLING_AUTH_TOKENS.
+	AuthTokens []APIToken `json:"auth_tokens"`
+
+	// HideAuthToken doesn't print AuthToken at start: the console output is written to a file, e.g. by the daemon.
+	HideAuthToken bool `json:"-"`
+
+	// OpenTelemetry tracing of the tool calls, exported with OTLP. Disabled if OtelEndpoint is empty.
+	OtelEndpoint    string  `json:"otel_endpoint"`     // OtelEndpoint is the OTLP collector, e.g. localhost:4317 for grpc, localhost:4318 for http/protobuf
+	OtelProtocol    string  `json:"otel_protocol"`     // OtelProtocol is the OTLP protocol, grpc or http/protobuf. default: grpc
+	OtelInsecure    bool    `json:"otel_insecure"`     // OtelInsecure exports without TLS, e.g. to a local collector
+	OtelSampleRatio float64 `json:"otel_sample_ratio"` // OtelSampleRatio is the ratio of the tool calls traced, from 0 to 1. default: 1
+
+	// Rate limits of the tool calls, in calls per minute. 0: unlimited
+	RateLimitSession int `json:"rate_limit_session"` // RateLimitSession limits the calls of each client session
+	RateLimitTool    int `json:"rate_limit_tool"`    // RateLimitTool limits the calls of each tool, by all the clients
+	RateLimitGlobal  int `json:"rate_limit_global"`  // RateLimitGlobal limits all the calls to the server
+
+	// Audit log of the MCP requests, BasePath/logs/audit.jsonl, independent of the debug logging.
+	AuditLog     bool `json:"audit_log"`      // AuditLog records the tool calls and resource reads, with their redacted arguments
+	AuditMaxSize int  `json:"audit_max_size"` // AuditMaxSize is the size in MB that rotates the audit log. default: 100
+	AuditBackups int  `json:"audit_backups"`  // AuditBackups is the number of rotated audit logs kept. default: 3
+
+	// Timeouts of the tool calls, in seconds, a stuck call returns a timeout error instead of wedging the session.
+	ToolTimeout  int            `json:"tool_timeout"`  // ToolTimeout is the timeout of the tool calls, raised to the longest call of the service, e.g. a command. default: 120, 0: none
+	ToolTimeouts map[string]int `json:"tool_timeouts"` // ToolTimeouts overrides the timeout by service or tool name, e.g. {"Browser": 300, "execute_command": 600}. 0: none
+
+	// Tools exposed to the clients, by name or glob pattern, e.g. "browser_*". The other tools of the services are not registered.
+	EnabledTools  []string `json:"enabled_tools"`  // EnabledTools are the only tools exposed, if set. default: all
+	DisabledTools []string `json:"disabled_tools"` // DisabledTools are not exposed, e.g. ["write_file", "move_file"] for a read-only filesystem
+
+	// ToolPrefix lists the tools with the name of their service as prefix, e.g. filesystem.read_file, to avoid the
+	// collisions with the tools of other MCP servers. The names without prefix are still callable, not listed.
+	ToolPrefix bool `json:"tool_prefix"`
+
+	// ElicitationFallback decides the confirmations of the services, e.g. of a destructive command, when the client
+	// can't ask its user through MCP elicitation: deny or allow. default: deny
+	ElicitationFallback string `json:"elicitation_fallback"`
+
+	// SessionIdleTimeout is the inactivity in seconds after which the state of a network client session is
+	// released, e.g. its browser and shell sessions. default: 1800, 0: none
+	SessionIdleTimeout int `json:"session_idle_timeout"`
+
+	// AllowedOrigins are the browser origins allowed to call the SSE listener, besides its own, e.g.
+	// "https://app.example.com" or "http://localhost:*". The requests with another Origin header are rejected.
+	AllowedOrigins []string `json:"allowed_origins"`
+
+	// ServiceConcurrency overrides the most tool calls of a service run at once, by service name, e.g.
+	// {"Browser": 0} to run the browser calls in parallel. default: the one of the service, 0: no limit
+	ServiceConcurrency map[string]int `json:"service_concurrency"`
+
+	// SSEKeepAlive is the interval in seconds of the keep-alive comments sent on the idle SSE streams, so the
+	// proxies don't drop the long sessions. default: 15, 0: none
+	SSEKeepAlive int `json:"sse_keep_alive"`
+}
+
+// Fallbacks of ElicitationFallback.
+const (
+	ElicitationDeny  = "deny"
+	ElicitationAllow = "allow"
+)
+
+// AuthTokensEnv is the environment variable of API tokens of the SSE mode, added to AuthTokens.
+// The tokens are separated by spaces or ';', each one is token, name:token or name:token:scope,scope.
+const AuthTokensEnv = "MOLING_AUTH_TOKENS"
+
+// EnvPrefix is the prefix of the environment variables overriding the settings, e.g. MOLING_LISTEN_ADDR for the
+// listen_addr flag, MOLING_BROWSER_HEADLESS for headless in the Browser section of the config file.
+const EnvPrefix = "MOLING_"
+
+// ScopeAll is the scope of the API tokens that may call every tool.
+const ScopeAll = "*"
+
+// APIToken is an API token of the SSE mode, the scopes are the services, e.g. Browser, or the tools, e.g. read_file,
+// the token may call. A token without scopes may call every tool.
+type APIToken struct {
+	Name   string   `json:"name"` // Name identifies the token in the logs, the token itself is never logged.
+	Token  string   `json:"token"`
+	Scopes []string `json:"scopes"`
+}
+
+// ParseAPITokens parses the API tokens of AuthTokensEnv. The tokens without a name are named env-1, env-2...
+func ParseAPITokens(value string) ([]APIToken, error) {
+	var tokens []APIToken
+	for i, field := range strings.FieldsFunc(value, func(r rune) bool { return r == ';' || r == ' ' || r == '\t' || r == '\n' }) {
+		parts := strings.SplitN(field, ":", 3)
+		token := APIToken{Name: fmt.Sprintf("env-%d", i+1), Token: parts[0]}
+		if len(parts) > 1 {
+			token.Name, token.Token = parts[0], parts[1]
+		}
+		if len(parts) > 2 && parts[2] != "" {
+			token.Scopes = strings.Split(parts[2], ",")
+		}
+		if token.Name == "" || token.Token == "" {
+			return nil, fmt.Errorf("invalid API token #%d of %s, must be token, name:token or name:token:scope,scope", i+1, AuthTokensEnv)
+		}
+		tokens = append(tokens, token)
+	}
+	return tokens, nil
+}
+
+// ApplyEnvSections overrides the settings of the sections of the services by the environment variables
+// EnvPrefix+SECTION_KEY, e.g. MOLING_BROWSER_HEADLESS=true, environ is as returned by os.Environ. The values are
+// parsed as JSON, the ones that aren't are strings. A section missing from the config file is created.
+func ApplyEnvSections(sections map[string]any, names []string, environ []string) {
+	for _, kv := range environ {
+		name, value, ok := strings.Cut(kv, "=")
+		if !ok || !strings.HasPrefix(name, EnvPrefix) {
+			continue
+		}
+		for _, section := range names {
+			key, found := strings.CutPrefix(name, EnvPrefix+strings.ToUpper(section)+"_")
+			if !found || key == "" {
+				continue
+			}
+			if sections[section] == nil {
+				sections[section] = map[string]any{}
+			}
+			settings, ok := sections[section].(map[string]any)
+			if !ok {
+				break
+			}
+			var v any
+			if err := json.Unmarshal([]byte(value), &v); err != nil {
+				v = value
+			}
+			settings[strings.ToLower(key)] = v
+			break
+		}
+	}
+}
+
+// EnabledKey is the key of the sections of the services enabling or disabling their module, e.g. "enabled": false.
+// The --module flag overrides it.
+const EnabledKey = "enabled"
+
+// SectionEnabled returns the EnabledKey setting of the section of a service, and whether it's set to a boolean.
+func SectionEnabled(section any) (enabled bool, ok bool) {
+	settings, _ := section.(map[string]any)
+	enabled, ok = settings[EnabledKey].(bool)
+	return enabled, ok
+}
+
+// ConfigFilePath returns the path of the config file, ConfigFile if it is absolute, else relative to BasePath.
+func (cfg *MoLingConfig) ConfigFilePath() string {
+	if filepath.IsAbs(cfg.ConfigFile) {
+		return cfg.ConfigFile
+	}
+	return filepath.Join(cfg.BasePath, cfg.ConfigFile)
 }
 
 func (cfg *MoLingConfig) Check() error {
@@ -60,3 +218,25 @@ func (cfg *MoLingConfig) Logger() zerolog.Logger {
 func (cfg *MoLingConfig) SetLogger(logger zerolog.Logger) {
 	cfg.logger = logger
 }
+
+// AllowedDirs returns the directories the FileSystem service is allowed to access.
+func (cfg *MoLingConfig) AllowedDirs() []string {
+	return cfg.allowedDirs
+}
+
+// SetAllowedDirs publishes the directories the FileSystem service is allowed to access.
+func (cfg *MoLingConfig) SetAllowedDirs(dirs []string) {
+	cfg.allowedDirs = dirs
+}
+
+// SharedDirs returns the directories services publish for the files they create, e.g. browser downloads.
+func (cfg *MoLingConfig) SharedDirs() []string {
+	return cfg.sharedDirs
+}
+
+// AddSharedDir publishes a directory a service writes files to, the FileSystem service allows access to it.
+func (cfg *MoLingConfig) AddSharedDir(dir string) {
+	if !slices.Contains(cfg.sharedDirs, dir) {
+		cfg.sharedDirs = append(cfg.sharedDirs, dir)
+	}
+}
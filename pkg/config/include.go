@@ -0,0 +1,151 @@
+// Copyright 2025 CFC4N <cfc4n.cs@gmail.com>. All Rights Reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//	http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+//
+// Repository: https://github.com/gojue/moling
+
+package config
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"slices"
+)
+
+// IncludeKey is the key of the config file listing the files it includes, e.g. "include": ["base.json"], relative
+// to its directory. It isn't a section.
+const IncludeKey = "include"
+
+// ReadConfigFile reads the config file, merged over the files it includes, each one upgraded by MigrateConfig. The included files are merged in their
+// order, then the file itself: the objects are merged key by key, the other values, the arrays too, replace the
+// ones of the files before. The included files may include others, a cycle is an error. The error of a config
+// file missing is the one of os.ReadFile, the one of an included file missing is not.
+func ReadConfigFile(path string) (map[string]any, error) {
+	return readConfigFile(path, nil)
+}
+
+// readConfigFile reads the config file included by the files of including, the first one is the config file.
+func readConfigFile(path string, including []string) (map[string]any, error) {
+	abs, err := filepath.Abs(path)
+	if err != nil {
+		return nil, err
+	}
+	if slices.Contains(including, abs) {
+		return nil, fmt.Errorf("include cycle: %s includes itself", path)
+	}
+	data, err := os.ReadFile(abs)
+	if err != nil {
+		return nil, err
+	}
+	sections := make(map[string]any)
+	if err = json.Unmarshal(data, &sections); err != nil {
+		return nil, fmt.Errorf("error unmarshaling JSON: %w, config file:%s", err, path)
+	}
+	// The files of an older version are read upgraded, the config file is rewritten by its loader.
+	if _, err = MigrateConfig(sections); err != nil {
+		return nil, fmt.Errorf("%w, config file:%s", err, path)
+	}
+	delete(sections, ConfigVersionKey)
+	includes, err := IncludeFiles(sections)
+	if err != nil {
+		return nil, fmt.Errorf("%w, config file:%s", err, path)
+	}
+	delete(sections, IncludeKey)
+
+	merged := make(map[string]any)
+	for _, include := range includes {
+		if !filepath.IsAbs(include) {
+			include = filepath.Join(filepath.Dir(abs), include)
+		}
+		included, err := readConfigFile(include, append(including, abs))
+		if errors.Is(err, os.ErrNotExist) {
+			return nil, fmt.Errorf("the file %s included by %s doesn't exist", include, path)
+		}
+		if err != nil {
+			return nil, fmt.Errorf("failed to include %s in %s: %w", include, path, err)
+		}
+		MergeSections(merged, included)
+	}
+	MergeSections(merged, sections)
+	return merged, nil
+}
+
+// ConfigFiles returns the absolute paths of the config file and of the files it includes, recursively, e.g. to
+// watch them. The files missing or not valid are listed, not the files they would include.
+func ConfigFiles(path string) []string {
+	var files []string
+	collectConfigFiles(path, &files)
+	return files
+}
+
+// collectConfigFiles adds the config file and the files it includes to files, once.
+func collectConfigFiles(path string, files *[]string) {
+	abs, err := filepath.Abs(path)
+	if err != nil || slices.Contains(*files, abs) {
+		return
+	}
+	*files = append(*files, abs)
+	data, err := os.ReadFile(abs)
+	if err != nil {
+		return
+	}
+	sections := make(map[string]any)
+	if json.Unmarshal(data, &sections) != nil {
+		return
+	}
+	includes, _ := IncludeFiles(sections)
+	for _, include := range includes {
+		if !filepath.IsAbs(include) {
+			include = filepath.Join(filepath.Dir(abs), include)
+		}
+		collectConfigFiles(include, files)
+	}
+}
+
+// IncludeFiles returns the files the config file includes, its IncludeKey: a file or a list of files.
+func IncludeFiles(sections map[string]any) ([]string, error) {
+	switch v := sections[IncludeKey].(type) {
+	case nil:
+		return nil, nil
+	case string:
+		return []string{v}, nil
+	case []any:
+		files := make([]string, 0, len(v))
+		for _, file := range v {
+			name, ok := file.(string)
+			if !ok {
+				return nil, fmt.Errorf("%s must list file names, got %v", IncludeKey, file)
+			}
+			files = append(files, name)
+		}
+		return files, nil
+	}
+	return nil, fmt.Errorf("%s must be a file name or a list of file names", IncludeKey)
+}
+
+// MergeSections merges src into dst: the objects are merged key by key, recursively, the other values of src
+// replace the ones of dst.
+func MergeSections(dst, src map[string]any) {
+	for key, v := range src {
+		if from, ok := v.(map[string]any); ok {
+			if into, ok := dst[key].(map[string]any); ok {
+				MergeSections(into, from)
+				continue
+			}
+		}
+		dst[key] = v
+	}
+}
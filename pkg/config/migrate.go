@@ -0,0 +1,135 @@
+// Copyright 2025 CFC4N <cfc4n.cs@gmail.com>. All Rights Reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//	http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+//
+// Repository: https://github.com/gojue/moling
+
+package config
+
+import (
+	"fmt"
+	"maps"
+	"math"
+	"slices"
+	"strings"
+)
+
+const (
+	// ConfigVersionKey is the key of the config file holding the version of its format. It isn't a section.
+	ConfigVersionKey = "config_version"
+	// ConfigVersion is the version of the format of the config file, raised with every migration.
+	ConfigVersion = 1
+)
+
+// migrations upgrade the config file by one version, migrations[i] from the version i to i+1, and return the
+// changes made.
+var migrations = []func(sections map[string]any) []string{
+	migrateServerSections,
+}
+
+// IsSection tells whether the key of the config file is a section, of MoLingConfig or of a service, and not one
+// of the keys describing the file: SchemaKey, IncludeKey and ConfigVersionKey.
+func IsSection(key string) bool {
+	return key != SchemaKey && key != IncludeKey && key != ConfigVersionKey
+}
+
+// FileVersion returns the ConfigVersionKey of the config file, 0 if it has none, the files written before it.
+func FileVersion(sections map[string]any) (int, error) {
+	switch v := sections[ConfigVersionKey].(type) {
+	case nil:
+		return 0, nil
+	case float64:
+		if v >= 0 && v == math.Trunc(v) {
+			return int(v), nil
+		}
+	}
+	return 0, fmt.Errorf("%s must be a positive integer, got %v", ConfigVersionKey, sections[ConfigVersionKey])
+}
+
+// MigrateConfig upgrades the sections of a config file of an older version to ConfigVersion, and returns the
+// changes made. A config file of a newer version, written by a newer MoLing, is an error: its settings would be
+// misread.
+func MigrateConfig(sections map[string]any) ([]string, error) {
+	version, err := FileVersion(sections)
+	if err != nil {
+		return nil, err
+	}
+	if version > ConfigVersion {
+		return nil, fmt.Errorf("the config file is of version %d, newer than the version %d of this MoLing, upgrade MoLing", version, ConfigVersion)
+	}
+	var changes []string
+	for ; version < ConfigVersion; version++ {
+		changes = append(changes, migrations[version](sections)...)
+	}
+	// A float64, as the numbers decoded from JSON.
+	sections[ConfigVersionKey] = float64(ConfigVersion)
+	return changes, nil
+}
+
+// legacySections are the sections of the services of the version 0, named after their server.
+var legacySections = map[string]string{
+	"BrowserServer":    "Browser",
+	"CommandServer":    "Command",
+	"FilesystemServer": "FileSystem",
+}
+
+// legacyLists are the settings of the version 0 listing their values in arrays, by section, with the settings
+// replacing them, the values joined by commas.
+var legacyLists = []struct {
+	section, key, replacedBy string
+}{
+	{"Command", "allowed_commands", "allowed_command"},
+	{"FileSystem", "allowed_dirs", "allowed_dir"},
+}
+
+// migrateServerSections renames the sections of the version 0 after their service, and joins the lists of
+// commands and directories. The settings of a section already renamed are kept over the old ones.
+func migrateServerSections(sections map[string]any) []string {
+	var changes []string
+	for _, old := range slices.Sorted(maps.Keys(legacySections)) {
+		v, ok := sections[old]
+		if !ok {
+			continue
+		}
+		name := legacySections[old]
+		delete(sections, old)
+		settings, ok := v.(map[string]any)
+		if !ok {
+			settings = make(map[string]any)
+		}
+		if current, ok := sections[name].(map[string]any); ok {
+			MergeSections(settings, current)
+		}
+		sections[name] = settings
+		changes = append(changes, fmt.Sprintf("section %s renamed %s", old, name))
+	}
+	for _, l := range legacyLists {
+		settings, _ := sections[l.section].(map[string]any)
+		list, ok := settings[l.key].([]any)
+		if !ok {
+			continue
+		}
+		delete(settings, l.key)
+		if _, ok = settings[l.replacedBy]; ok {
+			changes = append(changes, fmt.Sprintf("%s.%s removed, replaced by %s", l.section, l.key, l.replacedBy))
+			continue
+		}
+		values := make([]string, 0, len(list))
+		for _, v := range list {
+			values = append(values, fmt.Sprint(v))
+		}
+		settings[l.replacedBy] = strings.Join(values, ",")
+		changes = append(changes, fmt.Sprintf("%s.%s renamed %s, joined by commas", l.section, l.key, l.replacedBy))
+	}
+	return changes
+}
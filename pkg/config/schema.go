@@ -0,0 +1,139 @@
+// Copyright 2025 CFC4N <cfc4n.cs@gmail.com>. All Rights Reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//	http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+//
+// Repository: https://github.com/gojue/moling
+
+package config
+
+import (
+	_ "embed"
+	"os"
+	"path/filepath"
+	"reflect"
+	"strings"
+)
+
+const (
+	// SchemaKey is the key of the config file referencing its JSON Schema, for the editors. It isn't a section.
+	SchemaKey = "$schema"
+	// SchemaFile is the JSON Schema of the config file, written next to it.
+	SchemaFile = "config.schema.json"
+)
+
+// Schema is the JSON Schema of the config file, generated by: moling config schema > pkg/config/schema.json
+//
+//go:embed schema.json
+var Schema []byte
+
+// SchemaPath returns the JSON pointer of the key of the section in Schema, of the section if key is empty.
+func SchemaPath(section, key string) string {
+	path := "#/properties/" + section
+	if key != "" {
+		path += "/properties/" + key
+	}
+	return path
+}
+
+// WriteSchema writes Schema next to the config file and returns the reference of its SchemaKey, relative to the
+// config file.
+func (cfg *MoLingConfig) WriteSchema() (string, error) {
+	if err := os.WriteFile(cfg.SchemaFilePath(), Schema, 0o644); err != nil {
+		return "", err
+	}
+	return SchemaRef(), nil
+}
+
+// SchemaFilePath returns the path of the schema written by WriteSchema, next to the config file.
+func (cfg *MoLingConfig) SchemaFilePath() string {
+	return filepath.Join(filepath.Dir(cfg.ConfigFilePath()), SchemaFile)
+}
+
+// SchemaRef returns the reference of SchemaKey to the schema written by WriteSchema, relative to the config file.
+func SchemaRef() string {
+	return "./" + SchemaFile
+}
+
+// SettingsSchema returns the JSON Schema of the MoLingConfig section, with the descriptions of its keys.
+func SettingsSchema(descriptions map[string]string) map[string]any {
+	schema := TypeSchema(reflect.TypeOf(MoLingConfig{}))
+	properties, _ := schema["properties"].(map[string]any)
+	for key, description := range descriptions {
+		if p, ok := properties[key].(map[string]any); ok {
+			p["description"] = description
+		}
+	}
+	return schema
+}
+
+// TypeSchema returns the JSON Schema of the values of the type encoded by encoding/json.
+func TypeSchema(t reflect.Type) map[string]any {
+	switch t.Kind() {
+	case reflect.Pointer:
+		return TypeSchema(t.Elem())
+	case reflect.Bool:
+		return map[string]any{"type": "boolean"}
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64,
+		reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64:
+		return map[string]any{"type": "integer"}
+	case reflect.Float32, reflect.Float64:
+		return map[string]any{"type": "number"}
+	case reflect.String:
+		return map[string]any{"type": "string"}
+	case reflect.Slice, reflect.Array:
+		return map[string]any{"type": []string{"array", "null"}, "items": TypeSchema(t.Elem())}
+	case reflect.Map:
+		return map[string]any{"type": []string{"object", "null"}, "additionalProperties": TypeSchema(t.Elem())}
+	case reflect.Struct:
+		properties := make(map[string]any)
+		for i := 0; i < t.NumField(); i++ {
+			f := t.Field(i)
+			name, _, _ := strings.Cut(f.Tag.Get("json"), ",")
+			if !f.IsExported() || name == "-" {
+				continue
+			}
+			if name == "" {
+				name = f.Name
+			}
+			properties[name] = TypeSchema(f.Type)
+		}
+		return map[string]any{"type": "object", "properties": properties}
+	}
+	return map[string]any{}
+}
+
+// ValueSchema returns the JSON Schema of the values of the type of v, decoded from JSON, e.g. the default of a
+// setting of a service. A null value allows any type.
+func ValueSchema(v any) map[string]any {
+	switch v := v.(type) {
+	case bool:
+		return map[string]any{"type": "boolean"}
+	case float64:
+		return map[string]any{"type": "number"}
+	case string:
+		return map[string]any{"type": "string"}
+	case []any:
+		schema := map[string]any{"type": "array"}
+		if len(v) > 0 {
+			schema["items"] = ValueSchema(v[0])
+		}
+		return schema
+	case map[string]any:
+		properties := make(map[string]any, len(v))
+		for key, value := range v {
+			properties[key] = ValueSchema(value)
+		}
+		return map[string]any{"type": "object", "properties": properties}
+	}
+	return map[string]any{}
+}
@@ -0,0 +1,91 @@
+// Copyright 2025 CFC4N <cfc4n.cs@gmail.com>. All Rights Reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//	http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+//
+// Repository: https://github.com/gojue/moling
+
+package config
+
+import (
+	"errors"
+	"fmt"
+	"regexp"
+	"sort"
+	"strconv"
+	"strings"
+
+	"github.com/zalando/go-keyring"
+)
+
+const (
+	// KeychainPrefix starts the values of the config file read from the OS keychain, e.g. "keychain:proxy_password".
+	KeychainPrefix = "keychain:"
+	// KeychainService is the service of the secrets of MoLing in the OS keychain: the Keychain of macOS, the
+	// Credential Manager of Windows, the Secret Service of Linux, e.g. GNOME Keyring.
+	KeychainService = "moling"
+)
+
+// envRef matches the references to the environment variables in the values of the config file, e.g. ${PROXY_PASSWORD}.
+var envRef = regexp.MustCompile(`\$\{([A-Za-z_][A-Za-z0-9_]*)\}`)
+
+// ResolveSecrets replaces, in the string values of the sections, the references to the environment variables,
+// ${NAME}, and the values "keychain:<name>" by the secret of the OS keychain, so the secrets don't sit in the
+// config file. lookupEnv is os.LookupEnv. Every reference that can't be resolved is reported, by key.
+func ResolveSecrets(sections map[string]any, lookupEnv func(string) (string, bool)) error {
+	var errs []error
+	resolveSecrets(sections, "", lookupEnv, &errs)
+	return errors.Join(errs...)
+}
+
+// resolveSecrets resolves the references in v, at path of the config file, and returns it.
+func resolveSecrets(v any, path string, lookupEnv func(string) (string, bool), errs *[]error) any {
+	switch v := v.(type) {
+	case map[string]any:
+		keys := make([]string, 0, len(v))
+		for key := range v {
+			keys = append(keys, key)
+		}
+		sort.Strings(keys)
+		for _, key := range keys {
+			if path == "" && key == SchemaKey {
+				continue
+			}
+			child := key
+			if path != "" {
+				child = path + "." + key
+			}
+			v[key] = resolveSecrets(v[key], child, lookupEnv, errs)
+		}
+	case []any:
+		for i := range v {
+			v[i] = resolveSecrets(v[i], path+"["+strconv.Itoa(i)+"]", lookupEnv, errs)
+		}
+	case string:
+		if name, ok := strings.CutPrefix(v, KeychainPrefix); ok {
+			secret, err := keyring.Get(KeychainService, name)
+			if err != nil {
+				*errs = append(*errs, fmt.Errorf("%s: failed to read %s from the OS keychain: %w", path, name, err))
+			}
+			return secret
+		}
+		return envRef.ReplaceAllStringFunc(v, func(ref string) string {
+			name := envRef.FindStringSubmatch(ref)[1]
+			value, ok := lookupEnv(name)
+			if !ok {
+				*errs = append(*errs, fmt.Errorf("%s: the environment variable %s is not set", path, name))
+			}
+			return value
+		})
+	}
+	return v
+}
@@ -18,17 +18,26 @@ package server
 import (
 	"context"
 	"crypto/rand"
-	"crypto/subtle"
 	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"expvar"
 	"fmt"
 	"log"
 	"net/http"
 	"os"
+	"path"
+	"path/filepath"
+	"slices"
 	"strings"
+	"sync"
 	"time"
 
+	"github.com/mark3labs/mcp-go/mcp"
 	"github.com/mark3labs/mcp-go/server"
 	"github.com/rs/zerolog"
+	sdktrace "go.opentelemetry.io/otel/sdk/trace"
+	"go.opentelemetry.io/otel/trace"
 
 	"github.com/gojue/moling/pkg/comm"
 	"github.com/gojue/moling/pkg/config"
@@ -42,39 +51,146 @@ type MoLingServer struct {
 	logger     zerolog.Logger
 	mlConfig   config.MoLingConfig
 	listenAddr string // SSE mode listen address, if empty, use STDIO mode.
-	authToken  string // Auth token for SSE mode. Required for all SSE requests.
+	authToken  string // Auth token for SSE mode, with all scopes. Empty if only AuthTokens are set.
+	auth       *tokenAuth
+	// toolServices are the names of the services of the tools, by tool name, for the scopes of the tokens.
+	toolServices map[string]string
+	// resourceServices are the names of the services of the resources and resource templates, by URI or
+	// URI template, and promptServices the ones of the prompts, by prompt name.
+	resourceServices map[string]string
+	promptServices   map[string]string
+	tracerProvider   *sdktrace.TracerProvider // tracerProvider exports the spans of the tool calls, nil if tracing is disabled.
+	tracer           trace.Tracer
+	limiter          *rateLimiter // limiter limits the tool calls per minute, nil without rate limits.
+	auditLog         *auditLog    // auditLog records the MCP requests, nil if disabled.
+	// mu guards the services and the maps of their tools below, replaced by Reload while the tools are called.
+	mu       sync.RWMutex
+	reloadMu sync.Mutex // reloadMu serializes the reloads.
+	// serviceConfigs are the sections of the config file the services were loaded with, in JSON, by service name.
+	serviceConfigs map[comm.MoLingServerType]string
+	// settings are the MoLingConfig settings of the config file at the last load, in JSON, by key.
+	settings map[string]string
+	// toolTimeouts are the timeouts of the tool calls, by tool name, 0: none.
+	toolTimeouts map[string]time.Duration
+	// toolNames are the names of the tools without their prefix, by prefixed name, with ToolPrefix set.
+	toolNames   map[string]string
+	toolAliases map[string]bool // toolAliases are the unprefixed names of the tools, callable but not listed.
+	sessions    *sessionRegistry
+	started     time.Time // started is the time the server was created, for its uptime.
+	// middlewares are the tool middlewares added by Use, guarded by middlewaresMu.
+	middlewares   []server.ToolHandlerMiddleware
+	middlewaresMu sync.RWMutex
+	scheduler     *scheduler // scheduler limits the tool calls run at once by service.
 }
 
 func NewMoLingServer(ctx context.Context, srvs []abstract.Service, mlConfig config.MoLingConfig) (*MoLingServer, error) {
-	mcpServer := server.NewMCPServer(
-		mlConfig.ServerName,
-		mlConfig.Version,
-		server.WithResourceCapabilities(true, true),
-		server.WithLogging(),
-		server.WithPromptCapabilities(true),
-	)
-
-	// Resolve auth token for SSE mode.  16 random bytes encoded as 32 hex chars.
+	switch mlConfig.ElicitationFallback {
+	case "", config.ElicitationDeny, config.ElicitationAllow:
+	default:
+		return nil, fmt.Errorf("elicitation_fallback must be %s or %s, got %s", config.ElicitationDeny, config.ElicitationAllow, mlConfig.ElicitationFallback)
+	}
+	// Resolve the API tokens for SSE mode, the configured ones and the ones of the environment.
+	tokens := slices.Clone(mlConfig.AuthTokens)
+	envTokens, err := config.ParseAPITokens(os.Getenv(config.AuthTokensEnv))
+	if err != nil {
+		return nil, err
+	}
+	tokens = append(tokens, envTokens...)
+	// Without any token, generate one: 16 random bytes encoded as 32 hex chars.
 	authToken := mlConfig.AuthToken
-	if mlConfig.ListenAddr != "" && authToken == "" {
+	if mlConfig.ListenAddr != "" && authToken == "" && len(tokens) == 0 {
 		tokenBytes := make([]byte, 16)
 		if _, err := rand.Read(tokenBytes); err != nil {
 			return nil, fmt.Errorf("failed to generate SSE auth token: %w", err)
 		}
 		authToken = hex.EncodeToString(tokenBytes)
 	}
+	if authToken != "" {
+		tokens = append([]config.APIToken{{Name: "default", Token: authToken}}, tokens...)
+	}
 
 	// Set the context for the server
 	ms := &MoLingServer{
-		ctx:        ctx,
-		server:     mcpServer,
-		services:   srvs,
-		listenAddr: mlConfig.ListenAddr,
-		logger:     ctx.Value(comm.MoLingLoggerKey).(zerolog.Logger),
-		mlConfig:   mlConfig,
-		authToken:  authToken,
-	}
-	err := ms.init()
+		ctx:              ctx,
+		services:         srvs,
+		listenAddr:       mlConfig.ListenAddr,
+		logger:           ctx.Value(comm.MoLingLoggerKey).(zerolog.Logger),
+		mlConfig:         mlConfig,
+		authToken:        authToken,
+		toolServices:     make(map[string]string),
+		resourceServices: make(map[string]string),
+		promptServices:   make(map[string]string),
+		toolTimeouts:     make(map[string]time.Duration),
+		toolNames:        make(map[string]string),
+		toolAliases:      make(map[string]bool),
+		limiter:          newRateLimiter(mlConfig),
+		sessions:         &sessionRegistry{sessions: make(map[string]*clientSession)},
+		started:          time.Now(),
+		scheduler:        &scheduler{slots: make(map[string]chan struct{})},
+	}
+	ms.auth, err = newTokenAuth(tokens, filepath.Join(mlConfig.BasePath, "logs", AuthAuditFileName), ms.logger)
+	if err != nil {
+		return nil, err
+	}
+	if mlConfig.AuditLog {
+		ms.auditLog, err = newAuditLog(filepath.Join(mlConfig.BasePath, "logs", AuditFileName), mlConfig.AuditMaxSize, mlConfig.AuditBackups)
+		if err != nil {
+			return nil, fmt.Errorf("failed to open the audit log: %w", err)
+		}
+	}
+	tp, err := newTracerProvider(ctx, mlConfig)
+	if err != nil {
+		return nil, err
+	}
+	if tp != nil {
+		ms.setTracerProvider(tp)
+		ms.logger.Info().Str("endpoint", mlConfig.OtelEndpoint).Msg("Tracing the tool calls with OpenTelemetry")
+	}
+	// The client sessions are tracked until they end, their state held by the services released then.
+	hooks := &server.Hooks{}
+	hooks.AddOnRegisterSession(func(ctx context.Context, session server.ClientSession) {
+		ms.sessions.register(ctx, session.SessionID())
+	})
+	hooks.AddOnUnregisterSession(func(ctx context.Context, session server.ClientSession) {
+		ms.releaseSession(session.SessionID())
+	})
+	hooks.AddAfterListResources(ms.scopeResources)
+	hooks.AddAfterListResourceTemplates(ms.scopeResourceTemplates)
+	hooks.AddAfterListPrompts(ms.scopePrompts)
+	ms.server = server.NewMCPServer(
+		mlConfig.ServerName,
+		mlConfig.Version,
+		server.WithResourceCapabilities(true, true),
+		server.WithLogging(),
+		server.WithPromptCapabilities(true),
+		server.WithElicitation(),
+		server.WithToolHandlerMiddleware(ms.tracingMiddleware),
+		server.WithToolHandlerMiddleware(ms.auditMiddleware),
+		server.WithToolHandlerMiddleware(ms.sessionMiddleware),
+		server.WithToolHandlerMiddleware(ms.scopeMiddleware),
+		server.WithToolHandlerMiddleware(ms.rateLimitMiddleware),
+		server.WithToolHandlerMiddleware(ms.useMiddleware),
+		server.WithToolHandlerMiddleware(ms.timeoutMiddleware),
+		server.WithToolHandlerMiddleware(ms.scheduleMiddleware),
+		server.WithToolHandlerMiddleware(ms.recoveryMiddleware),
+		server.WithToolFilter(ms.scopeFilter),
+		server.WithToolFilter(ms.aliasFilter),
+		server.WithHooks(hooks),
+	)
+	// The services may ask the LLM of the client for a completion, e.g. to summarize a page.
+	ms.server.EnableSampling()
+	err = ms.init()
+	ms.server.AddNotificationHandler(mcp.MethodNotificationRootsListChanged, func(ctx context.Context, notification mcp.JSONRPCNotification) {
+		if session := server.ClientSessionFromContext(ctx); session != nil {
+			abstract.ForgetRoots(session.SessionID())
+		}
+	})
+	ms.addReloadTool()
+	ms.addSessionsTool()
+	ms.addInfoTool()
+	if mlConfig.SessionIdleTimeout > 0 {
+		go ms.sweepSessions()
+	}
 	return ms, err
 }
 
@@ -87,23 +203,70 @@ func (m *MoLingServer) init() error {
 			m.logger.Info().Err(err).Str("serviceName", string(srv.Name())).Msg("Failed to load service")
 		}
 	}
+	m.checkScopes()
+	// The sections of the services in the config file, to reload the ones changed.
+	cfg, cfgErr := m.readConfigFile()
+	if cfgErr != nil {
+		m.logger.Warn().Err(cfgErr).Msg("Failed to read the config file, every service will be reloaded")
+	}
+	m.serviceConfigs = make(map[comm.MoLingServerType]string, len(m.services))
+	for _, srv := range m.services {
+		m.serviceConfigs[srv.Name()] = configSection(cfg, srv.Name())
+	}
+	m.settings = configSettings(cfg)
 	return err
 }
 
 func (m *MoLingServer) loadService(srv abstract.Service) error {
 
+	service := string(srv.Name())
 	// Add resources
 	for r, rhf := range srv.Resources() {
-		m.server.AddResource(r, rhf)
+		m.mu.Lock()
+		m.resourceServices[r.URI] = service
+		m.mu.Unlock()
+		m.server.AddResource(r, m.auditResource(m.scopeResource(service, m.recoverResource(rhf))))
 	}
 
 	// Add Resource Templates
 	for rt, rthf := range srv.ResourceTemplates() {
-		m.server.AddResourceTemplate(rt, rthf)
+		if rt.URITemplate != nil {
+			m.mu.Lock()
+			m.resourceServices[rt.URITemplate.Raw()] = service
+			m.mu.Unlock()
+		}
+		m.server.AddResourceTemplate(rt, server.ResourceTemplateHandlerFunc(m.auditResource(m.scopeResource(service, m.recoverResource(server.ResourceHandlerFunc(rthf))))))
 	}
 
-	// Add Tools
-	m.server.AddTools(srv.Tools()...)
+	// Add Tools, except the ones not exposed by the configuration
+	tools := slices.DeleteFunc(slices.Clone(srv.Tools()), func(tool server.ServerTool) bool {
+		if m.toolExposed(tool.Tool.Name) {
+			return false
+		}
+		m.logger.Debug().Str("serviceName", string(srv.Name())).Str("tool", tool.Tool.Name).Msg("Tool disabled by the configuration")
+		return true
+	})
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	for i, tool := range tools {
+		name, timeout := tool.Tool.Name, m.toolTimeout(srv, tool.Tool.Name)
+		if m.mlConfig.ToolPrefix {
+			// The tools are listed with the prefix, the names without it stay callable as hidden aliases.
+			tools[i].Tool.Name = ToolPrefix(srv.Name()) + name
+			m.toolNames[tools[i].Tool.Name] = name
+			if service, ok := m.toolServices[name]; ok {
+				m.logger.Warn().Str("serviceName", string(srv.Name())).Str("tool", name).Str("aliasOf", service).Msg("Tool alias already registered, skipped")
+			} else {
+				m.toolServices[name] = string(srv.Name())
+				m.toolTimeouts[name] = timeout
+				m.toolAliases[name] = true
+				m.server.AddTool(tool.Tool, tool.Handler)
+			}
+		}
+		m.toolServices[tools[i].Tool.Name] = string(srv.Name())
+		m.toolTimeouts[tools[i].Tool.Name] = timeout
+	}
+	m.server.AddTools(tools...)
 
 	// Add Notification Handlers
 	for n, nhf := range srv.NotificationHandlers() {
@@ -113,11 +276,137 @@ func (m *MoLingServer) loadService(srv abstract.Service) error {
 	// Add Prompts
 	for _, pe := range srv.Prompts() {
 		// Add Prompt
-		m.server.AddPrompt(pe.Prompt(), pe.Handler())
+		m.promptServices[pe.Prompt().Name] = service
+		m.server.AddPrompt(pe.Prompt(), m.scopePrompt(service, m.recoverPrompt(pe.Handler())))
 	}
 	return nil
 }
 
+// ToolPrefix returns the prefix of the names of the tools of the service with ToolPrefix set, e.g. "filesystem.".
+func ToolPrefix(name comm.MoLingServerType) string {
+	return strings.ToLower(string(name)) + "."
+}
+
+// baseToolName returns the name of the tool without its prefix, the name of the scopes and rate limits of the
+// tool shared by its alias.
+func (m *MoLingServer) baseToolName(name string) string {
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+	if base, ok := m.toolNames[name]; ok {
+		return base
+	}
+	return name
+}
+
+// aliasFilter hides the aliases of the prefixed tools from the tool list, they are kept for the clients
+// configured with the names without prefix.
+func (m *MoLingServer) aliasFilter(ctx context.Context, tools []mcp.Tool) []mcp.Tool {
+	if len(m.toolAliases) == 0 {
+		return tools
+	}
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+	return slices.DeleteFunc(tools, func(tool mcp.Tool) bool { return m.toolAliases[tool.Name] })
+}
+
+// toolService returns the name of the service of the tool, empty for the tools of the server, e.g. reload_config.
+func (m *MoLingServer) toolService(name string) string {
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+	return m.toolServices[name]
+}
+
+// Services returns the services loaded, the ones re-created by the last reload included.
+func (m *MoLingServer) Services() []abstract.Service {
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+	return slices.Clone(m.services)
+}
+
+// ToolInfo is a tool listed to the clients, with the name of its service, empty for the tools of the server.
+type ToolInfo struct {
+	Service string   `json:"service"`
+	Tool    mcp.Tool `json:"tool"`
+}
+
+// Tools returns the tools listed to the clients, by name: the ones exposed by the configuration, with their
+// prefix, and the ones of the server.
+func (m *MoLingServer) Tools() []ToolInfo {
+	var tools []ToolInfo
+	for name, tool := range m.server.ListTools() {
+		m.mu.RLock()
+		alias := m.toolAliases[name]
+		m.mu.RUnlock()
+		if !alias {
+			tools = append(tools, ToolInfo{Service: m.toolService(name), Tool: tool.Tool})
+		}
+	}
+	slices.SortFunc(tools, func(a, b ToolInfo) int { return strings.Compare(a.Tool.Name, b.Tool.Name) })
+	return tools
+}
+
+// CallTool calls the tool in-process, through the middlewares of the tool calls as a client would, e.g. for
+// the programs embedding MoLing or the call command.
+func (m *MoLingServer) CallTool(ctx context.Context, name string, args map[string]any) (*mcp.CallToolResult, error) {
+	message, err := json.Marshal(map[string]any{
+		"jsonrpc": mcp.JSONRPC_VERSION,
+		"id":      1,
+		"method":  string(mcp.MethodToolsCall),
+		"params":  map[string]any{"name": name, "arguments": args},
+	})
+	if err != nil {
+		return nil, err
+	}
+	switch response := m.server.HandleMessage(ctx, message).(type) {
+	case mcp.JSONRPCResponse:
+		if result, ok := response.Result.(mcp.CallToolResult); ok {
+			return &result, nil
+		}
+		return nil, fmt.Errorf("unexpected result of %s: %T", name, response.Result)
+	case mcp.JSONRPCError:
+		return nil, fmt.Errorf("failed to call %s: %s", name, response.Error.Message)
+	default:
+		return nil, fmt.Errorf("unexpected response of %s: %T", name, response)
+	}
+}
+
+// Use adds middlewares to the tool calls, for the programs embedding MoLing, e.g. their own authentication,
+// logging or policy checks. They run after the authentication, the scopes and the rate limits of MoLing, and
+// before the timeout of the call, the first one added outermost. They apply to the calls started after.
+func (m *MoLingServer) Use(mw ...server.ToolHandlerMiddleware) {
+	m.middlewaresMu.Lock()
+	defer m.middlewaresMu.Unlock()
+	m.middlewares = append(m.middlewares, mw...)
+}
+
+// useMiddleware runs the tool calls through the middlewares added by Use.
+func (m *MoLingServer) useMiddleware(next server.ToolHandlerFunc) server.ToolHandlerFunc {
+	return func(ctx context.Context, request mcp.CallToolRequest) (*mcp.CallToolResult, error) {
+		m.middlewaresMu.RLock()
+		handler := next
+		for i := len(m.middlewares) - 1; i >= 0; i-- {
+			handler = m.middlewares[i](handler)
+		}
+		m.middlewaresMu.RUnlock()
+		return handler(ctx, request)
+	}
+}
+
+// toolExposed reports whether the tool is exposed to the clients: matched by EnabledTools, if set, and not
+// by DisabledTools.
+func (m *MoLingServer) toolExposed(name string) bool {
+	matches := func(patterns []string) bool {
+		return slices.ContainsFunc(patterns, func(pattern string) bool {
+			ok, _ := path.Match(pattern, name)
+			return ok
+		})
+	}
+	if len(m.mlConfig.EnabledTools) > 0 && !matches(m.mlConfig.EnabledTools) {
+		return false
+	}
+	return !matches(m.mlConfig.DisabledTools)
+}
+
 // requireJSONContentType is a middleware that rejects POST requests whose
 // Content-Type is not application/json.  Browsers treat text/plain,
 // application/x-www-form-urlencoded, and multipart/form-data as "simple"
@@ -141,24 +430,30 @@ func requireJSONContentType(next http.Handler) http.Handler {
 
 // corsRemoverResponseWriter wraps http.ResponseWriter to strip the wildcard
 // Access-Control-Allow-Origin header hardcoded by the upstream mcp-go library,
-// preventing cross-origin browser access to the SSE endpoint.
+// preventing cross-origin browser access to the SSE endpoint.  The origin
+// allowed by originMiddleware, if any, is answered instead.
 type corsRemoverResponseWriter struct {
 	http.ResponseWriter
 	flusher http.Flusher
+	origin  string
 	cleaned bool
 }
 
-func newCORSRemoverResponseWriter(w http.ResponseWriter) *corsRemoverResponseWriter {
+func newCORSRemoverResponseWriter(w http.ResponseWriter, origin string) *corsRemoverResponseWriter {
 	flusher, _ := w.(http.Flusher)
 	return &corsRemoverResponseWriter{
 		ResponseWriter: w,
 		flusher:        flusher,
+		origin:         origin,
 	}
 }
 
 func (w *corsRemoverResponseWriter) cleanCORSHeader() {
 	if !w.cleaned {
 		w.ResponseWriter.Header().Del("Access-Control-Allow-Origin")
+		if w.origin != "" {
+			w.ResponseWriter.Header().Set("Access-Control-Allow-Origin", w.origin)
+		}
 		w.cleaned = true
 	}
 }
@@ -180,48 +475,128 @@ func (w *corsRemoverResponseWriter) Flush() {
 }
 
 // sseSecurityMiddleware enforces token-based authentication and removes the
-// wildcard CORS header from all responses.  Clients must supply the token either
-// as an Authorization: Bearer <token> header or as a ?token=<token> query
-// parameter.
-func sseSecurityMiddleware(token string, next http.Handler) http.Handler {
+// wildcard CORS header from all responses.  Clients must supply one of the
+// tokens as an Authorization: Bearer <token> header, an X-API-Key: <token>
+// header or a ?token=<token> query parameter.  The rejected requests are
+// recorded in the audit log, the token of the others is passed in the context
+// of the request for the scopes of the tool calls.
+func sseSecurityMiddleware(auth *tokenAuth, next http.Handler) http.Handler {
 	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
-		// Accept token from Authorization header (Bearer scheme only) or query parameter.
-		// Use constant-time comparison to prevent timing attacks.
-		var bearerToken string
-		if authHeader := r.Header.Get("Authorization"); strings.HasPrefix(authHeader, "Bearer ") {
-			bearerToken = authHeader[len("Bearer "):]
-		}
-		queryToken := r.URL.Query().Get("token")
-		tokenBytes := []byte(token)
-		validBearer := subtle.ConstantTimeCompare([]byte(bearerToken), tokenBytes) == 1
-		validQuery := subtle.ConstantTimeCompare([]byte(queryToken), tokenBytes) == 1
-		if !validBearer && !validQuery {
+		token := auth.authenticate(r)
+		if token == nil {
+			auth.audit(authAuditEntry{RemoteAddr: r.RemoteAddr, Method: r.Method, Path: r.URL.Path, Status: http.StatusUnauthorized, Error: "missing or invalid token"})
+			w.Header().Set("WWW-Authenticate", `Bearer realm="moling"`)
 			http.Error(w, "Unauthorized", http.StatusUnauthorized)
 			return
 		}
-		next.ServeHTTP(newCORSRemoverResponseWriter(w), r)
+		ctx := context.WithValue(r.Context(), authContextKey{}, token)
+		origin, _ := ctx.Value(originContextKey{}).(string)
+		next.ServeHTTP(newCORSRemoverResponseWriter(w, origin), r.WithContext(ctx))
 	})
 }
 
+// varsPrefix is the prefix of the expvar variables of MoLing, e.g. the command statistics.
+const varsPrefix = "moling_"
+
+// handleVars serves the expvar variables of MoLing, not the cmdline and memstats ones of the process, to the
+// tokens of full access: cmdline may hold the token of the command line.
+func (m *MoLingServer) handleVars(w http.ResponseWriter, r *http.Request) {
+	if token := tokenFromContext(r.Context()); token == nil || !tokenFullAccess(token) {
+		entry := authAuditEntry{RemoteAddr: r.RemoteAddr, Method: r.Method, Path: r.URL.Path, Status: http.StatusForbidden, Error: "the runtime metrics need a token of the scope *"}
+		if token != nil {
+			entry.Token = token.Name
+		}
+		m.auth.audit(entry)
+		http.Error(w, "Forbidden", http.StatusForbidden)
+		return
+	}
+	vars := make(map[string]json.RawMessage)
+	expvar.Do(func(kv expvar.KeyValue) {
+		if strings.HasPrefix(kv.Key, varsPrefix) {
+			vars[kv.Key] = json.RawMessage(kv.Value.String())
+		}
+	})
+	w.Header().Set("Content-Type", "application/json; charset=utf-8")
+	_ = json.NewEncoder(w).Encode(vars)
+}
+
+// Serve serves the MCP server over STDIO, or over SSE on the listen address. With Stdio set, it serves both,
+// the local client on STDIO and the network clients on SSE sharing the same services, until both end.
 func (m *MoLingServer) Serve() error {
-	mLogger := log.New(m.logger, m.mlConfig.ServerName, 0)
-	if m.listenAddr != "" {
-		ltnAddr := fmt.Sprintf("http://%s", strings.TrimPrefix(m.listenAddr, "http://"))
-		consoleWriter := zerolog.ConsoleWriter{Out: os.Stdout, TimeFormat: time.RFC3339}
-		multi := zerolog.MultiLevelWriter(consoleWriter, m.logger)
-		m.logger = zerolog.New(multi).With().Timestamp().Logger()
-		m.logger.Info().Str("listenAddr", m.listenAddr).Str("BaseURL", ltnAddr).Msg("Starting SSE server")
-		m.logger.Warn().Msgf("The SSE server URL must be: %s. Please do not make mistakes, even if it is another IP or domain name on the same computer, it cannot be mixed.", ltnAddr)
-		// Print auth token to stdout only — avoid persisting credentials to log file.
-		stdoutLogger := zerolog.New(consoleWriter).With().Timestamp().Logger()
-		stdoutLogger.Warn().Msgf("SSE auth token: %s", m.authToken)
-		stdoutLogger.Warn().Msgf("SSE server URL with token: %s/sse?token=%s", ltnAddr, m.authToken)
-		httpSrv := &http.Server{Addr: m.listenAddr}
-		sseServer := server.NewSSEServer(m.server, server.WithBaseURL(ltnAddr), server.WithHTTPServer(httpSrv))
-		httpSrv.Handler = sseSecurityMiddleware(m.authToken, requireJSONContentType(sseServer))
-
-		return sseServer.Start(m.listenAddr)
+	if m.listenAddr == "" {
+		return m.serveStdio()
+	}
+	if !m.mlConfig.Stdio {
+		return m.serveSSE(m.logToConsole(os.Stdout))
 	}
+	// Stdout carries the STDIO protocol, the console output of the SSE server goes to stderr. The logger is set
+	// before both transports use it.
+	console := m.logToConsole(os.Stderr)
+	errs := make(chan error, 2)
+	go func() { errs <- m.serveSSE(console) }()
+	go func() { errs <- m.serveStdio() }()
+	for range 2 {
+		if err := <-errs; err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func (m *MoLingServer) serveStdio() error {
+	mLogger := log.New(m.logger, m.mlConfig.ServerName, 0)
 	m.logger.Info().Msg("Starting STDIO server")
-	return server.ServeStdio(m.server, server.WithErrorLogger(mLogger))
+	return server.ServeStdio(m.server, server.WithErrorLogger(mLogger), server.WithWorkerPoolSize(stdioWorkers))
+}
+
+// logToConsole writes the logs of the server to the console too, in SSE mode, and returns the console writer.
+func (m *MoLingServer) logToConsole(console *os.File) zerolog.ConsoleWriter {
+	consoleWriter := zerolog.ConsoleWriter{Out: console, TimeFormat: time.RFC3339}
+	multi := zerolog.MultiLevelWriter(consoleWriter, m.logger)
+	m.logger = zerolog.New(multi).With().Timestamp().Logger()
+	return consoleWriter
+}
+
+// serveSSE serves the SSE server on the listen address, printing its URL and auth token to console.
+func (m *MoLingServer) serveSSE(consoleWriter zerolog.ConsoleWriter) error {
+	ltnAddr := fmt.Sprintf("http://%s", strings.TrimPrefix(m.listenAddr, "http://"))
+	m.logger.Info().Str("listenAddr", m.listenAddr).Str("BaseURL", ltnAddr).Msg("Starting SSE server")
+	m.logger.Warn().Msgf("The SSE server URL must be: %s. Please do not make mistakes, even if it is another IP or domain name on the same computer, it cannot be mixed.", ltnAddr)
+	if m.authToken != "" && m.mlConfig.HideAuthToken {
+		m.logger.Info().Msg("SSE auth token not printed, the console output is written to a file")
+	} else if m.authToken != "" {
+		// Print auth token to console only — avoid persisting credentials to log file.
+		consoleLogger := zerolog.New(consoleWriter).With().Timestamp().Logger()
+		consoleLogger.Warn().Msgf("SSE auth token: %s", m.authToken)
+		consoleLogger.Warn().Msgf("SSE server URL with token: %s/sse?token=%s", ltnAddr, m.authToken)
+	}
+	m.logger.Info().Int("tokens", len(m.auth.tokens)).Msg("SSE authentication enabled")
+	httpSrv := &http.Server{Addr: m.listenAddr}
+	sseServer := server.NewSSEServer(m.server, server.WithBaseURL(ltnAddr), server.WithHTTPServer(httpSrv))
+	// Runtime metrics of the services, e.g. the command statistics, are served on /debug/vars.
+	mux := http.NewServeMux()
+	mux.HandleFunc("/debug/vars", m.handleVars)
+	mux.Handle("/", sseKeepAlive(time.Duration(m.mlConfig.SSEKeepAlive)*time.Second, m.logger, requireJSONContentType(sseServer)))
+	// The health probes of supervisors and load balancers are served without a token.
+	root := http.NewServeMux()
+	root.HandleFunc("GET /healthz", m.handleHealthz)
+	root.HandleFunc("GET /readyz", m.handleReadyz)
+	root.Handle("/", sseSecurityMiddleware(m.auth, mux))
+	httpSrv.Handler = tracingHandler(originMiddleware(m.mlConfig.AllowedOrigins, m.auth, root))
+
+	return sseServer.Start(m.listenAddr)
+}
+
+// Close flushes the spans of the tool calls not exported yet, and closes the audit log.
+func (m *MoLingServer) Close() error {
+	var err error
+	if m.tracerProvider != nil {
+		ctx, cancel := context.WithTimeout(context.Background(), 3*time.Second)
+		defer cancel()
+		err = m.tracerProvider.Shutdown(ctx)
+	}
+	if m.auditLog != nil {
+		err = errors.Join(err, m.auditLog.Close())
+	}
+	return err
 }
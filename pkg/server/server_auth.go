@@ -0,0 +1,273 @@
+// Copyright 2025 CFC4N <cfc4n.cs@gmail.com>. All Rights Reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//	http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+//
+// Repository: https://github.com/gojue/moling
+package server
+
+import (
+	"context"
+	"crypto/sha256"
+	"crypto/subtle"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"os"
+	"path/filepath"
+	"slices"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/mark3labs/mcp-go/mcp"
+	"github.com/mark3labs/mcp-go/server"
+	"github.com/rs/zerolog"
+
+	"github.com/gojue/moling/pkg/comm"
+	"github.com/gojue/moling/pkg/config"
+	"github.com/gojue/moling/pkg/utils"
+)
+
+// AuthAuditFileName is the audit log of the requests and tool calls rejected by the authentication of the SSE
+// mode, under BasePath/logs.
+const AuthAuditFileName = "auth_audit.jsonl"
+
+// authContextKey is the context key of the API token that authenticated the request.
+type authContextKey struct{}
+
+// authAuditEntry is one line of the authentication audit log.
+type authAuditEntry struct {
+	Time       time.Time `json:"time"`
+	RemoteAddr string    `json:"remote_addr,omitempty"`
+	Method     string    `json:"method,omitempty"`
+	Path       string    `json:"path,omitempty"`
+	Token      string    `json:"token,omitempty"` // Token is the name of the token, empty if none matched.
+	Tool       string    `json:"tool,omitempty"`
+	Status     int       `json:"status"`
+	Error      string    `json:"error"`
+}
+
+// tokenAuth authenticates the requests of the SSE mode with the API tokens, and checks the scopes of their tool calls.
+type tokenAuth struct {
+	tokens    []config.APIToken
+	digests   [][sha256.Size]byte // digests are the SHA-256 of the tokens, compared in constant time whatever their length.
+	auditPath string
+	logger    zerolog.Logger
+	mu        sync.Mutex // mu serializes the writes of the audit log.
+}
+
+// newTokenAuth returns the authentication of the tokens, the names and the tokens must be unique.
+func newTokenAuth(tokens []config.APIToken, auditPath string, logger zerolog.Logger) (*tokenAuth, error) {
+	a := &tokenAuth{tokens: tokens, auditPath: auditPath, logger: logger}
+	names := make(map[string]bool, len(tokens))
+	for _, t := range tokens {
+		if t.Name == "" || t.Token == "" {
+			return nil, fmt.Errorf("API token %q must have a name and a token", t.Name)
+		}
+		if names[t.Name] {
+			return nil, fmt.Errorf("duplicate API token name %s", t.Name)
+		}
+		names[t.Name] = true
+		digest := sha256.Sum256([]byte(t.Token))
+		for _, d := range a.digests {
+			if d == digest {
+				return nil, fmt.Errorf("API token %s is the same as another token", t.Name)
+			}
+		}
+		a.digests = append(a.digests, digest)
+	}
+	return a, nil
+}
+
+// authenticate returns the token of the credentials of the request, nil if none matches. The credentials are
+// an Authorization: Bearer <token> header, an X-API-Key: <token> header or a ?token=<token> query parameter.
+func (a *tokenAuth) authenticate(r *http.Request) *config.APIToken {
+	var credentials []string
+	if authHeader := r.Header.Get("Authorization"); strings.HasPrefix(authHeader, "Bearer ") {
+		credentials = append(credentials, authHeader[len("Bearer "):])
+	}
+	credentials = append(credentials, r.Header.Get("X-API-Key"), r.URL.Query().Get("token"))
+	var match *config.APIToken
+	for _, credential := range credentials {
+		if credential == "" {
+			continue
+		}
+		digest := sha256.Sum256([]byte(credential))
+		// Every token is compared, so the time doesn't tell which one matched.
+		for i := range a.digests {
+			if subtle.ConstantTimeCompare(digest[:], a.digests[i][:]) == 1 && match == nil {
+				match = &a.tokens[i]
+			}
+		}
+	}
+	return match
+}
+
+// audit appends the entry to the audit log, and logs it.
+func (a *tokenAuth) audit(entry authAuditEntry) {
+	entry.Time = time.Now()
+	a.logger.Warn().Str("remoteAddr", entry.RemoteAddr).Str("path", entry.Path).Str("token", entry.Token).
+		Str("tool", entry.Tool).Int("status", entry.Status).Msg(entry.Error)
+	if a.auditPath == "" {
+		return
+	}
+	data, err := json.Marshal(entry)
+	if err != nil {
+		return
+	}
+	a.mu.Lock()
+	defer a.mu.Unlock()
+	if err = utils.CreateDirectory(filepath.Dir(a.auditPath)); err == nil {
+		var f *os.File
+		if f, err = os.OpenFile(a.auditPath, os.O_APPEND|os.O_CREATE|os.O_WRONLY, 0o600); err == nil {
+			_, err = f.Write(append(data, '\n'))
+			if cerr := f.Close(); err == nil {
+				err = cerr
+			}
+		}
+	}
+	if err != nil {
+		a.logger.Warn().Err(err).Msg("failed to write the authentication audit log")
+	}
+}
+
+// tokenAllows reports whether the token may call the tool of the service: a scope is "*", the name of the
+// service, case-insensitive, or the name of the tool. A token without scopes may call every tool.
+func tokenAllows(token *config.APIToken, service, tool string) bool {
+	if len(token.Scopes) == 0 {
+		return true
+	}
+	for _, scope := range token.Scopes {
+		if scope == config.ScopeAll || scope == tool || (service != "" && strings.EqualFold(scope, service)) {
+			return true
+		}
+	}
+	return false
+}
+
+// tokenFullAccess reports whether the token has no scopes or the scope "*".
+func tokenFullAccess(token *config.APIToken) bool {
+	return len(token.Scopes) == 0 || slices.Contains(token.Scopes, config.ScopeAll)
+}
+
+// scopeResource denies reading the resources of the service to the tokens out of its scopes.
+func (m *MoLingServer) scopeResource(service string, next server.ResourceHandlerFunc) server.ResourceHandlerFunc {
+	return func(ctx context.Context, request mcp.ReadResourceRequest) ([]mcp.ResourceContents, error) {
+		if token := tokenFromContext(ctx); token != nil && !tokenAllows(token, service, "") {
+			m.auth.audit(authAuditEntry{Token: token.Name, Tool: request.Params.URI, Status: http.StatusForbidden, Error: "resource out of the scopes of the token"})
+			return nil, comm.Errorf(comm.CodePolicyDenied, "the token %s is not allowed to read %s", token.Name, request.Params.URI)
+		}
+		return next(ctx, request)
+	}
+}
+
+// scopePrompt denies getting the prompts of the service to the tokens out of its scopes.
+func (m *MoLingServer) scopePrompt(service string, next server.PromptHandlerFunc) server.PromptHandlerFunc {
+	return func(ctx context.Context, request mcp.GetPromptRequest) (*mcp.GetPromptResult, error) {
+		if token := tokenFromContext(ctx); token != nil && !tokenAllows(token, service, "") {
+			m.auth.audit(authAuditEntry{Token: token.Name, Tool: request.Params.Name, Status: http.StatusForbidden, Error: "prompt out of the scopes of the token"})
+			return nil, comm.Errorf(comm.CodePolicyDenied, "the token %s is not allowed to get %s", token.Name, request.Params.Name)
+		}
+		return next(ctx, request)
+	}
+}
+
+// tokenFromContext returns the token that authenticated the request of the context, nil in STDIO mode.
+func tokenFromContext(ctx context.Context) *config.APIToken {
+	token, _ := ctx.Value(authContextKey{}).(*config.APIToken)
+	return token
+}
+
+// checkScopes warns of the scopes of the tokens that match no loaded service or tool, e.g. a typo.
+func (m *MoLingServer) checkScopes() {
+	for _, token := range m.auth.tokens {
+		for _, scope := range token.Scopes {
+			if scope == config.ScopeAll || m.toolServices[scope] != "" {
+				continue
+			}
+			known := false
+			for _, srv := range m.services {
+				known = known || strings.EqualFold(scope, string(srv.Name()))
+			}
+			if !known {
+				m.logger.Warn().Str("token", token.Name).Str("scope", scope).Msg("scope matches no loaded service or tool")
+			}
+		}
+	}
+}
+
+// scopeMiddleware rejects the tool calls out of the scopes of the token of the request.
+func (m *MoLingServer) scopeMiddleware(next server.ToolHandlerFunc) server.ToolHandlerFunc {
+	return func(ctx context.Context, request mcp.CallToolRequest) (*mcp.CallToolResult, error) {
+		name := request.Params.Name
+		if token := tokenFromContext(ctx); token != nil && !tokenAllows(token, m.toolService(name), m.baseToolName(name)) {
+			m.auth.audit(authAuditEntry{Token: token.Name, Tool: name, Status: http.StatusForbidden, Error: "tool out of the scopes of the token"})
+			return comm.NewToolResultError(comm.CodePolicyDenied, fmt.Sprintf("the token %s is not allowed to call %s", token.Name, name)), nil
+		}
+		return next(ctx, request)
+	}
+}
+
+// scopeFilter hides the tools out of the scopes of the token of the request.
+func (m *MoLingServer) scopeFilter(ctx context.Context, tools []mcp.Tool) []mcp.Tool {
+	token := tokenFromContext(ctx)
+	if token == nil {
+		return tools
+	}
+	allowed := make([]mcp.Tool, 0, len(tools))
+	for _, tool := range tools {
+		if tokenAllows(token, m.toolService(tool.Name), m.baseToolName(tool.Name)) {
+			allowed = append(allowed, tool)
+		}
+	}
+	return allowed
+}
+
+// scopeResources hides the resources out of the scopes of the token of the request from the resource list.
+func (m *MoLingServer) scopeResources(ctx context.Context, id any, message *mcp.ListResourcesRequest, result *mcp.ListResourcesResult) {
+	token := tokenFromContext(ctx)
+	if token == nil || result == nil {
+		return
+	}
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+	result.Resources = slices.DeleteFunc(result.Resources, func(r mcp.Resource) bool {
+		return !tokenAllows(token, m.resourceServices[r.URI], "")
+	})
+}
+
+// scopeResourceTemplates hides the resource templates out of the scopes of the token of the request.
+func (m *MoLingServer) scopeResourceTemplates(ctx context.Context, id any, message *mcp.ListResourceTemplatesRequest, result *mcp.ListResourceTemplatesResult) {
+	token := tokenFromContext(ctx)
+	if token == nil || result == nil {
+		return
+	}
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+	result.ResourceTemplates = slices.DeleteFunc(result.ResourceTemplates, func(rt mcp.ResourceTemplate) bool {
+		return rt.URITemplate == nil || !tokenAllows(token, m.resourceServices[rt.URITemplate.Raw()], "")
+	})
+}
+
+// scopePrompts hides the prompts out of the scopes of the token of the request from the prompt list.
+func (m *MoLingServer) scopePrompts(ctx context.Context, id any, message *mcp.ListPromptsRequest, result *mcp.ListPromptsResult) {
+	token := tokenFromContext(ctx)
+	if token == nil || result == nil {
+		return
+	}
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+	result.Prompts = slices.DeleteFunc(result.Prompts, func(p mcp.Prompt) bool {
+		return !tokenAllows(token, m.promptServices[p.Name], "")
+	})
+}
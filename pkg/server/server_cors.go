@@ -0,0 +1,79 @@
+// Copyright 2025 CFC4N <cfc4n.cs@gmail.com>. All Rights Reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//	http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+//
+// Repository: https://github.com/gojue/moling
+package server
+
+import (
+	"context"
+	"net/http"
+	"net/url"
+	"path"
+	"slices"
+	"strings"
+)
+
+// Headers of the CORS responses to the allowed origins.
+const (
+	corsAllowMethods  = "GET, POST, DELETE, OPTIONS"
+	corsAllowHeaders  = "Authorization, Content-Type, X-API-Key, Mcp-Session-Id, Mcp-Protocol-Version, Last-Event-ID"
+	corsExposeHeaders = "Mcp-Session-Id"
+	corsMaxAge        = "600"
+)
+
+// originContextKey is the context key of the allowed Origin of the request, answered in the CORS headers.
+type originContextKey struct{}
+
+// originAllowed reports whether the browser origin may call the server: the origin of the server itself, or
+// one matching an allowed origin, e.g. "https://app.example.com" or the pattern "http://localhost:*".
+func originAllowed(origin string, allowed []string, host string) bool {
+	if u, err := url.Parse(origin); err == nil && u.Host != "" && strings.EqualFold(u.Host, host) {
+		return true
+	}
+	return slices.ContainsFunc(allowed, func(pattern string) bool {
+		ok, _ := path.Match(strings.ToLower(strings.TrimSuffix(pattern, "/")), strings.ToLower(origin))
+		return ok
+	})
+}
+
+// originMiddleware rejects the requests of the browsers from an origin not allowed, so a page can't drive the
+// server of the user with its cookies or from its network. The requests without Origin header, e.g. of the
+// desktop clients, pass. It answers the CORS preflights of the allowed origins, before the authentication, the
+// preflights carry no token.
+func originMiddleware(allowed []string, auth *tokenAuth, next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		origin := r.Header.Get("Origin")
+		if origin == "" {
+			next.ServeHTTP(w, r)
+			return
+		}
+		if !originAllowed(origin, allowed, r.Host) {
+			auth.audit(authAuditEntry{RemoteAddr: r.RemoteAddr, Method: r.Method, Path: r.URL.Path, Status: http.StatusForbidden, Error: "origin not allowed: " + origin})
+			http.Error(w, "Origin not allowed", http.StatusForbidden)
+			return
+		}
+		h := w.Header()
+		h.Add("Vary", "Origin")
+		h.Set("Access-Control-Allow-Origin", origin)
+		h.Set("Access-Control-Expose-Headers", corsExposeHeaders)
+		if r.Method == http.MethodOptions && r.Header.Get("Access-Control-Request-Method") != "" {
+			h.Set("Access-Control-Allow-Methods", corsAllowMethods)
+			h.Set("Access-Control-Allow-Headers", corsAllowHeaders)
+			h.Set("Access-Control-Max-Age", corsMaxAge)
+			w.WriteHeader(http.StatusNoContent)
+			return
+		}
+		next.ServeHTTP(w, r.WithContext(context.WithValue(r.Context(), originContextKey{}, origin)))
+	})
+}
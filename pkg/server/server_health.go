@@ -0,0 +1,89 @@
+// Copyright 2025 CFC4N <cfc4n.cs@gmail.com>. All Rights Reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//	http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+//
+// Repository: https://github.com/gojue/moling
+package server
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"time"
+)
+
+// healthCheckTimeout is the time the readiness checks of the services may take.
+const healthCheckTimeout = 5 * time.Second
+
+// serviceHealth is the readiness of a service on /readyz.
+type serviceHealth struct {
+	Ready  bool              `json:"ready"`
+	Checks map[string]string `json:"checks,omitempty"` // Checks are "ok" or the error of the checks, by name.
+}
+
+// readiness is the body of /readyz.
+type readiness struct {
+	Ready    bool                     `json:"ready"`
+	Services map[string]serviceHealth `json:"services"`
+}
+
+// handleHealthz answers the liveness probes: the server is up and serving.
+func (m *MoLingServer) handleHealthz(w http.ResponseWriter, r *http.Request) {
+	writeJSON(w, http.StatusOK, map[string]string{"status": "ok"})
+}
+
+// handleReadyz answers the readiness probes with the checks of the services, 503 if one of them failed.
+// The probes don't need a token, so the errors of the checks, e.g. paths, are only shown to the requests
+// authenticated by one, the others get "failed".
+func (m *MoLingServer) handleReadyz(w http.ResponseWriter, r *http.Request) {
+	ctx, cancel := context.WithTimeout(r.Context(), healthCheckTimeout)
+	defer cancel()
+	details := m.auth.authenticate(r) != nil
+	res := m.readiness(ctx, details)
+	status := http.StatusOK
+	if !res.Ready {
+		status = http.StatusServiceUnavailable
+	}
+	writeJSON(w, status, res)
+}
+
+// readiness runs the checks of the services, with the errors if details is set.
+func (m *MoLingServer) readiness(ctx context.Context, details bool) readiness {
+	srvs := m.Services()
+	res := readiness{Ready: true, Services: make(map[string]serviceHealth, len(srvs))}
+	for _, srv := range srvs {
+		health := serviceHealth{Ready: true, Checks: make(map[string]string)}
+		for name, err := range srv.Health(ctx) {
+			switch {
+			case err == nil:
+				health.Checks[name] = "ok"
+			case details:
+				health.Checks[name] = err.Error()
+				health.Ready = false
+			default:
+				health.Checks[name] = "failed"
+				health.Ready = false
+			}
+		}
+		res.Ready = res.Ready && health.Ready
+		res.Services[string(srv.Name())] = health
+	}
+	return res
+}
+
+func writeJSON(w http.ResponseWriter, status int, v any) {
+	w.Header().Set("Content-Type", "application/json")
+	w.Header().Set("Cache-Control", "no-store")
+	w.WriteHeader(status)
+	_ = json.NewEncoder(w).Encode(v)
+}
@@ -0,0 +1,124 @@
+// Copyright 2025 CFC4N <cfc4n.cs@gmail.com>. All Rights Reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//	http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+//
+// Repository: https://github.com/gojue/moling
+package server
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"runtime"
+	"time"
+
+	"github.com/mark3labs/mcp-go/mcp"
+
+	"github.com/gojue/moling/pkg/comm"
+)
+
+// InfoToolName is the tool describing the server, its services and the host, only allowed to the tokens with
+// the "*" scope or its name.
+const InfoToolName = "moling_info"
+
+// serviceInfo is a service loaded, with its configuration redacted like the arguments of the audit log.
+type serviceInfo struct {
+	Name   string `json:"name"`
+	Tools  int    `json:"tools"`
+	Config any    `json:"config"`
+}
+
+// hostInfo is the platform MoLing runs on.
+type hostInfo struct {
+	OS        string `json:"os"`
+	Arch      string `json:"arch"`
+	CPUs      int    `json:"cpus"`
+	GoVersion string `json:"go_version"`
+}
+
+// usageInfo is the resource usage of the process.
+type usageInfo struct {
+	Goroutines int    `json:"goroutines"`
+	HeapBytes  uint64 `json:"heap_bytes"` // HeapBytes are the bytes of the heap objects allocated.
+	SysBytes   uint64 `json:"sys_bytes"`  // SysBytes are the bytes obtained from the OS.
+	NumGC      uint32 `json:"num_gc"`
+}
+
+// serverInfo is the result of InfoToolName.
+type serverInfo struct {
+	Name      string        `json:"name"`
+	Version   string        `json:"version"`
+	Transport string        `json:"transport"`
+	Started   time.Time     `json:"started"`
+	Uptime    string        `json:"uptime"`
+	Services  []serviceInfo `json:"services"`
+	Host      hostInfo      `json:"host"`
+	Usage     usageInfo     `json:"usage"`
+}
+
+// info returns the description of the server.
+func (m *MoLingServer) info() serverInfo {
+	res := serverInfo{
+		Name:      m.mlConfig.ServerName,
+		Version:   m.mlConfig.Version,
+		Transport: TransportStdio,
+		Started:   m.started,
+		Uptime:    time.Since(m.started).Round(time.Second).String(),
+		Host:      hostInfo{OS: runtime.GOOS, Arch: runtime.GOARCH, CPUs: runtime.NumCPU(), GoVersion: runtime.Version()},
+	}
+	if m.listenAddr != "" {
+		res.Transport = TransportSSE
+		if m.mlConfig.Stdio {
+			res.Transport = TransportStdio + "+" + TransportSSE
+		}
+	}
+	tools := make(map[string]int)
+	m.mu.RLock()
+	for tool, service := range m.toolServices {
+		if !m.toolAliases[tool] {
+			tools[service]++
+		}
+	}
+	m.mu.RUnlock()
+	for _, srv := range m.Services() {
+		var cfg any
+		if err := json.Unmarshal([]byte(srv.Config()), &cfg); err != nil {
+			cfg = nil
+		}
+		res.Services = append(res.Services, serviceInfo{Name: string(srv.Name()), Tools: tools[string(srv.Name())], Config: redact(cfg)})
+	}
+	var mem runtime.MemStats
+	runtime.ReadMemStats(&mem)
+	res.Usage = usageInfo{Goroutines: runtime.NumGoroutine(), HeapBytes: mem.HeapAlloc, SysBytes: mem.Sys, NumGC: mem.NumGC}
+	return res
+}
+
+// handleInfo is the handler of InfoToolName.
+func (m *MoLingServer) handleInfo(ctx context.Context, request mcp.CallToolRequest) (*mcp.CallToolResult, error) {
+	data, err := json.Marshal(m.info())
+	if err != nil {
+		return comm.NewToolResultErrorFor(err, fmt.Sprintf("failed to marshal the server info: %s", err.Error())), nil
+	}
+	return mcp.NewToolResultText(string(data)), nil
+}
+
+// addInfoTool registers InfoToolName, unless disabled by the configuration.
+func (m *MoLingServer) addInfoTool() {
+	if !m.toolExposed(InfoToolName) {
+		return
+	}
+	m.server.AddTool(mcp.NewTool(InfoToolName,
+		mcp.WithDescription("Describe this MoLing server: version, transport, uptime, the services loaded with their number of tools and their configuration (secrets redacted), the OS and the resource usage. Call it to learn what is available, e.g. the allowed directories or commands, before using the other tools."),
+		mcp.WithReadOnlyHintAnnotation(true),
+	), m.handleInfo)
+}
@@ -0,0 +1,133 @@
+// Copyright 2025 CFC4N <cfc4n.cs@gmail.com>. All Rights Reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//	http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+//
+// Repository: https://github.com/gojue/moling
+package server
+
+import (
+	"bytes"
+	"fmt"
+	"net/http"
+	"sync"
+	"time"
+
+	"github.com/rs/zerolog"
+)
+
+const (
+	// sseEndpoint is the path of the SSE streams of mcp-go.
+	sseEndpoint = "/sse"
+	// sseRetry is the delay the clients wait before reconnecting a dropped stream, sent in the retry field.
+	sseRetry = 3 * time.Second
+)
+
+// sseStreamWriter numbers the events of an SSE stream and sends the keep-alive comments between them. Its
+// writes are serialized, mcp-go writes each event at once.
+type sseStreamWriter struct {
+	http.ResponseWriter
+	flusher http.Flusher
+	mu      sync.Mutex
+	lastID  uint64
+	last    time.Time // last is the time of the last write, the keep-alive comments are sent once idle.
+	started bool
+	closed  bool // closed is set once the handler returned, the stream can't be written anymore.
+}
+
+func newSSEStreamWriter(w http.ResponseWriter) *sseStreamWriter {
+	flusher, _ := w.(http.Flusher)
+	return &sseStreamWriter{ResponseWriter: w, flusher: flusher, last: time.Now()}
+}
+
+// Write writes the event with an id, the stream starts with the retry delay of the client.
+func (w *sseStreamWriter) Write(b []byte) (int, error) {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+	var prefix []byte
+	if !w.started {
+		prefix = fmt.Appendf(prefix, "retry: %d\n\n", sseRetry.Milliseconds())
+		w.started = true
+	}
+	if bytes.HasPrefix(b, []byte("event: ")) {
+		w.lastID++
+		prefix = fmt.Appendf(prefix, "id: %d\n", w.lastID)
+	}
+	w.last = time.Now()
+	if len(prefix) > 0 {
+		if _, err := w.ResponseWriter.Write(prefix); err != nil {
+			return 0, err
+		}
+	}
+	return w.ResponseWriter.Write(b)
+}
+
+func (w *sseStreamWriter) Flush() {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+	if w.flusher != nil {
+		w.flusher.Flush()
+	}
+}
+
+// keepAlive sends a comment on the stream idle for the interval, ignored by the clients, so the proxies and
+// the NATs don't drop the connection and a dead client is detected, until the stream is closed.
+func (w *sseStreamWriter) keepAlive(interval time.Duration, done <-chan struct{}) {
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-done:
+			return
+		case now := <-ticker.C:
+			w.mu.Lock()
+			if !w.closed && w.started && now.Sub(w.last) >= interval {
+				_, _ = w.ResponseWriter.Write([]byte(": keep-alive\n\n"))
+				if w.flusher != nil {
+					w.flusher.Flush()
+				}
+				w.last = now
+			}
+			w.mu.Unlock()
+		}
+	}
+}
+
+// close stops the writes of keepAlive.
+func (w *sseStreamWriter) close() {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+	w.closed = true
+}
+
+// sseKeepAlive numbers the events of the SSE streams, tells the clients to reconnect a dropped stream after
+// sseRetry, and sends keep-alive comments on the streams idle for the interval, 0: none. The MCP session is
+// bound to its stream by mcp-go, a client reconnecting with Last-Event-ID starts a new session.
+func sseKeepAlive(interval time.Duration, logger zerolog.Logger, next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.Method != http.MethodGet || r.URL.Path != sseEndpoint {
+			next.ServeHTTP(w, r)
+			return
+		}
+		if lastID := r.Header.Get("Last-Event-ID"); lastID != "" {
+			logger.Info().Str("remoteAddr", r.RemoteAddr).Str("lastEventID", lastID).Msg("SSE client reconnected, starting a new session")
+		}
+		sw := newSSEStreamWriter(w)
+		defer sw.close()
+		if interval > 0 {
+			done := make(chan struct{})
+			defer close(done)
+			go sw.keepAlive(interval, done)
+		}
+		next.ServeHTTP(sw, r)
+	})
+}
@@ -0,0 +1,168 @@
+// Copyright 2025 CFC4N <cfc4n.cs@gmail.com>. All Rights Reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//	http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+//
+// Repository: https://github.com/gojue/moling
+package server
+
+import (
+	"context"
+	"fmt"
+	"math"
+	"sync"
+	"time"
+
+	"github.com/mark3labs/mcp-go/mcp"
+	"github.com/mark3labs/mcp-go/server"
+
+	"github.com/gojue/moling/pkg/config"
+)
+
+// rateLimitWindow is the period of the rate limits, they are numbers of calls per minute.
+const rateLimitWindow = time.Minute
+
+// Scopes of the rate limits.
+const (
+	RateLimitSession = "session"
+	RateLimitTool    = "tool"
+	RateLimitGlobal  = "global"
+)
+
+// bucket is a token bucket of limit calls per minute, refilled continuously, allowing bursts of limit calls.
+type bucket struct {
+	tokens float64
+	last   time.Time
+}
+
+// refill adds the tokens earned since the last call, a new bucket is full.
+func (b *bucket) refill(limit int, now time.Time) {
+	if b.last.IsZero() {
+		b.tokens = float64(limit)
+	} else {
+		b.tokens = math.Min(float64(limit), b.tokens+now.Sub(b.last).Seconds()*float64(limit)/rateLimitWindow.Seconds())
+	}
+	b.last = now
+}
+
+// wait returns the time until the bucket has a token.
+func (b *bucket) wait(limit int) time.Duration {
+	return time.Duration((1 - b.tokens) * float64(rateLimitWindow) / float64(limit))
+}
+
+// throttled is the structured error of a tool call over a rate limit.
+type throttled struct {
+	Error        string `json:"error"`
+	Scope        string `json:"scope"` // Scope is the limit exceeded: session, tool or global.
+	Tool         string `json:"tool"`
+	Limit        int    `json:"limit_per_minute"`
+	RetryAfterMs int64  `json:"retry_after_ms"`
+}
+
+// rateLimiter limits the tool calls per minute of each session, of each tool and of the server.
+type rateLimiter struct {
+	mu        sync.Mutex
+	session   int // session is the limit of each session, 0: unlimited
+	tool      int
+	global    int
+	sessions  map[string]*bucket
+	tools     map[string]*bucket
+	all       bucket
+	lastSweep time.Time
+}
+
+// newRateLimiter returns the rate limiter of the limits of the configuration, or nil if none is set.
+func newRateLimiter(cfg config.MoLingConfig) *rateLimiter {
+	if cfg.RateLimitSession <= 0 && cfg.RateLimitTool <= 0 && cfg.RateLimitGlobal <= 0 {
+		return nil
+	}
+	return &rateLimiter{
+		session:  max(cfg.RateLimitSession, 0),
+		tool:     max(cfg.RateLimitTool, 0),
+		global:   max(cfg.RateLimitGlobal, 0),
+		sessions: make(map[string]*bucket),
+		tools:    make(map[string]*bucket),
+	}
+}
+
+// allow takes a call of the tool by the session from the buckets of the limits, or returns the limit exceeded.
+// A throttled call takes nothing, so it doesn't delay the calls allowed by the other limits.
+func (l *rateLimiter) allow(session, tool string, now time.Time) *throttled {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	if now.Sub(l.lastSweep) > rateLimitWindow {
+		// The buckets unused for a window are full again, as good as new ones.
+		for id, b := range l.sessions {
+			if now.Sub(b.last) > rateLimitWindow {
+				delete(l.sessions, id)
+			}
+		}
+		l.lastSweep = now
+	}
+
+	type limited struct {
+		scope string
+		limit int
+		b     *bucket
+	}
+	var buckets []limited
+	if l.session > 0 {
+		b, ok := l.sessions[session]
+		if !ok {
+			b = &bucket{}
+			l.sessions[session] = b
+		}
+		buckets = append(buckets, limited{RateLimitSession, l.session, b})
+	}
+	if l.tool > 0 {
+		b, ok := l.tools[tool]
+		if !ok {
+			b = &bucket{}
+			l.tools[tool] = b
+		}
+		buckets = append(buckets, limited{RateLimitTool, l.tool, b})
+	}
+	if l.global > 0 {
+		buckets = append(buckets, limited{RateLimitGlobal, l.global, &l.all})
+	}
+	for _, lb := range buckets {
+		lb.b.refill(lb.limit, now)
+		if lb.b.tokens < 1 {
+			return &throttled{Error: "rate_limited", Scope: lb.scope, Tool: tool, Limit: lb.limit, RetryAfterMs: lb.b.wait(lb.limit).Milliseconds() + 1}
+		}
+	}
+	for _, lb := range buckets {
+		lb.b.tokens--
+	}
+	return nil
+}
+
+// rateLimitMiddleware rejects the tool calls over the rate limits with a throttled error.
+func (m *MoLingServer) rateLimitMiddleware(next server.ToolHandlerFunc) server.ToolHandlerFunc {
+	return func(ctx context.Context, request mcp.CallToolRequest) (*mcp.CallToolResult, error) {
+		if m.limiter == nil {
+			return next(ctx, request)
+		}
+		var session string
+		if s := server.ClientSessionFromContext(ctx); s != nil {
+			session = s.SessionID()
+		}
+		t := m.limiter.allow(session, m.baseToolName(request.Params.Name), time.Now())
+		if t == nil {
+			return next(ctx, request)
+		}
+		m.logger.Warn().Str("session", session).Str("tool", t.Tool).Str("scope", t.Scope).Int("limit", t.Limit).Msg("tool call rate limited")
+		result := mcp.NewToolResultStructured(t, fmt.Sprintf("Rate limited: more than %d calls per minute of the %s limit, retry after %dms", t.Limit, t.Scope, t.RetryAfterMs))
+		result.IsError = true
+		return result, nil
+	}
+}
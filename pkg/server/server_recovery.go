@@ -0,0 +1,79 @@
+// Copyright 2025 CFC4N <cfc4n.cs@gmail.com>. All Rights Reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//	http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+//
+// Repository: https://github.com/gojue/moling
+package server
+
+import (
+	"context"
+	"expvar"
+	"fmt"
+	"runtime/debug"
+
+	"github.com/mark3labs/mcp-go/mcp"
+	"github.com/mark3labs/mcp-go/server"
+
+	"github.com/gojue/moling/pkg/comm"
+)
+
+// PanicsVarName is the expvar variable of the number of panics recovered, by handler, served on /debug/vars.
+const PanicsVarName = "moling_handler_panics"
+
+// panics counts the panics recovered, by handler, e.g. "tool:read_file" or "resource:file:///tmp".
+var panics = expvar.NewMap(PanicsVarName)
+
+// recovered logs the panic of the handler with its stack, counts it and returns it as an error.
+func (m *MoLingServer) recovered(kind, name string, r any) error {
+	m.logger.Error().Str(kind, name).Any("panic", r).Str("stack", string(debug.Stack())).Msg("handler panicked")
+	panics.Add(kind+":"+name, 1)
+	return fmt.Errorf("%s %s failed: internal error: %v", kind, name, r)
+}
+
+// recoveryMiddleware turns a panic of a tool handler into an error result, so a bug of one tool doesn't kill
+// the process and every service with it. It is the innermost middleware, it runs in the goroutine of the
+// tool call started by timeoutMiddleware.
+func (m *MoLingServer) recoveryMiddleware(next server.ToolHandlerFunc) server.ToolHandlerFunc {
+	return func(ctx context.Context, request mcp.CallToolRequest) (result *mcp.CallToolResult, err error) {
+		defer func() {
+			if r := recover(); r != nil {
+				result, err = comm.NewToolResultError(comm.CodeInternal, m.recovered("tool", request.Params.Name, r).Error()), nil
+			}
+		}()
+		return next(ctx, request)
+	}
+}
+
+// recoverResource turns a panic of a resource handler into an error of the read.
+func (m *MoLingServer) recoverResource(next server.ResourceHandlerFunc) server.ResourceHandlerFunc {
+	return func(ctx context.Context, request mcp.ReadResourceRequest) (contents []mcp.ResourceContents, err error) {
+		defer func() {
+			if r := recover(); r != nil {
+				contents, err = nil, m.recovered("resource", request.Params.URI, r)
+			}
+		}()
+		return next(ctx, request)
+	}
+}
+
+// recoverPrompt turns a panic of a prompt handler into an error of the request.
+func (m *MoLingServer) recoverPrompt(next server.PromptHandlerFunc) server.PromptHandlerFunc {
+	return func(ctx context.Context, request mcp.GetPromptRequest) (result *mcp.GetPromptResult, err error) {
+		defer func() {
+			if r := recover(); r != nil {
+				result, err = nil, m.recovered("prompt", request.Params.Name, r)
+			}
+		}()
+		return next(ctx, request)
+	}
+}
@@ -0,0 +1,338 @@
+// Copyright 2025 CFC4N <cfc4n.cs@gmail.com>. All Rights Reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//	http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+//
+// Repository: https://github.com/gojue/moling
+package server
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"reflect"
+	"slices"
+	"strings"
+
+	"github.com/mark3labs/mcp-go/mcp"
+	"github.com/rs/zerolog"
+
+	"github.com/gojue/moling/pkg/comm"
+	"github.com/gojue/moling/pkg/config"
+	"github.com/gojue/moling/pkg/services"
+	"github.com/gojue/moling/pkg/services/abstract"
+)
+
+// ReloadToolName is the admin tool reloading the config file, only allowed to the tokens with the "*" scope or
+// its name.
+const ReloadToolName = "reload_config"
+
+// readConfigFile returns the sections of the config file by name, with the files it includes, none if the file
+// doesn't exist, overridden by the environment variables, with the secrets they reference.
+func (m *MoLingServer) readConfigFile() (map[string]any, error) {
+	path := m.mlConfig.ConfigFilePath()
+	cfg, err := config.ReadConfigFile(path)
+	if errors.Is(err, os.ErrNotExist) {
+		cfg, err = make(map[string]any), nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	config.ApplyEnvSections(cfg, services.ServiceNames(), os.Environ())
+	if err = config.ResolveSecrets(cfg, os.LookupEnv); err != nil {
+		return nil, fmt.Errorf("error resolving the secrets: %w, config file:%s", err, path)
+	}
+	return cfg, nil
+}
+
+// configSection returns the section of the service in the config file in JSON, empty if it has none.
+func configSection(cfg map[string]any, name comm.MoLingServerType) string {
+	section, ok := cfg[string(name)].(map[string]any)
+	if !ok {
+		return ""
+	}
+	data, _ := json.Marshal(section)
+	return string(data)
+}
+
+// settingsSection is the section of the MoLingConfig settings in the config file.
+const settingsSection = "MoLingConfig"
+
+// runtimeSettings are the MoLingConfig settings applied by Reload, the other ones need a restart.
+var runtimeSettings = []string{"tool_timeouts", "debug"}
+
+// configSettings returns the MoLingConfig settings of the config file in JSON, by key.
+func configSettings(cfg map[string]any) map[string]string {
+	section, _ := cfg[settingsSection].(map[string]any)
+	settings := make(map[string]string, len(section))
+	for key, v := range section {
+		data, _ := json.Marshal(v)
+		settings[key] = string(data)
+	}
+	return settings
+}
+
+// reloadSettings applies the MoLingConfig settings changed since the last load that are safe at runtime, the
+// timeouts of the tools and the log level, and returns the keys of the changed ones needing a restart.
+func (m *MoLingServer) reloadSettings(settings map[string]string) (bool, []string, error) {
+	var changed []string
+	for key, v := range settings {
+		if m.settings[key] != v {
+			changed = append(changed, key)
+		}
+	}
+	for key := range m.settings {
+		if _, ok := settings[key]; !ok {
+			changed = append(changed, key)
+		}
+	}
+	slices.Sort(changed)
+	applied := false
+	var restart []string
+	for _, key := range changed {
+		v, ok := settings[key]
+		if !ok || !slices.Contains(runtimeSettings, key) {
+			// A setting removed is kept until the restart, its value may come from the command line.
+			restart = append(restart, key)
+			continue
+		}
+		switch key {
+		case "tool_timeouts":
+			var timeouts map[string]int
+			if err := json.Unmarshal([]byte(v), &timeouts); err != nil {
+				return applied, restart, fmt.Errorf("invalid %s: %w", key, err)
+			}
+			m.setToolTimeouts(timeouts)
+		case "debug":
+			var debug bool
+			if err := json.Unmarshal([]byte(v), &debug); err != nil {
+				return applied, restart, fmt.Errorf("invalid %s: %w", key, err)
+			}
+			zerolog.SetGlobalLevel(zerolog.InfoLevel)
+			if debug {
+				zerolog.SetGlobalLevel(zerolog.DebugLevel)
+			}
+		}
+		m.settings[key] = v
+		applied = true
+		m.logger.Info().Str("setting", key).Str("value", v).Msg("Setting reloaded")
+	}
+	return applied, restart, nil
+}
+
+// setToolTimeouts replaces tool_timeouts and resolves again the timeouts of the tools loaded.
+func (m *MoLingServer) setToolTimeouts(timeouts map[string]int) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	m.mlConfig.ToolTimeouts = timeouts
+	for name, service := range m.toolServices {
+		i := slices.IndexFunc(m.services, func(srv abstract.Service) bool { return string(srv.Name()) == service })
+		if i < 0 {
+			continue
+		}
+		tool := name
+		if unprefixed, ok := m.toolNames[name]; ok {
+			tool = unprefixed
+		}
+		m.toolTimeouts[name] = m.toolTimeout(m.services[i], tool)
+	}
+}
+
+// Reload re-reads the config file and applies the sections of the services that changed. The keys of the
+// RuntimeConfigurer services are applied to the running service, the other changes re-create the service, with
+// its tools, resources and prompts, closing its state, e.g. the jobs and the sessions. The clients are notified by the list_changed notifications of MCP, their transports
+// are kept. Of the settings of MoLingConfig, the runtimeSettings are applied, the other ones need a restart, they
+// are logged. It returns the names of the services reloaded, and MoLingConfig if settings were applied.
+func (m *MoLingServer) Reload() ([]string, error) {
+	m.reloadMu.Lock()
+	defer m.reloadMu.Unlock()
+	cfg, err := m.readConfigFile()
+	if err != nil {
+		return nil, err
+	}
+	var reloaded []string
+	var errs []error
+	for _, old := range m.Services() {
+		section := configSection(cfg, old.Name())
+		if section == m.serviceConfigs[old.Name()] {
+			continue
+		}
+		if enabled, ok := config.SectionEnabled(cfg[string(old.Name())]); ok && !enabled {
+			m.logger.Warn().Str("serviceName", string(old.Name())).Msg("Service disabled in the config file, unloaded at the next restart")
+		}
+		if keys := restartKeys(old, m.serviceConfigs[old.Name()], section); len(keys) > 0 {
+			m.logger.Warn().Str("serviceName", string(old.Name())).Strs("settings", keys).Msg("Settings changed that need a restart of the service, restarting it, its jobs and sessions are closed")
+			err = m.reloadService(old, cfg[string(old.Name())])
+		} else {
+			m.logger.Info().Str("serviceName", string(old.Name())).Msg("Applying the settings to the running service")
+			err = m.configureService(old, cfg[string(old.Name())])
+		}
+		if err != nil {
+			errs = append(errs, fmt.Errorf("failed to reload service %s: %w", old.Name(), err))
+			continue
+		}
+		m.serviceConfigs[old.Name()] = section
+		reloaded = append(reloaded, string(old.Name()))
+	}
+	applied, restart, err := m.reloadSettings(configSettings(cfg))
+	if err != nil {
+		errs = append(errs, err)
+	}
+	if applied {
+		reloaded = append(reloaded, settingsSection)
+	}
+	if len(restart) > 0 {
+		m.logger.Warn().Strs("settings", restart).Msg("Settings changed in the config file, applied at the next restart")
+	}
+	return reloaded, errors.Join(errs...)
+}
+
+// restartKeys returns the keys of the section of the service, in JSON, changed from the old one that can't be
+// applied to the running service: the keys out of its RuntimeConfigKeys, and the ones removed, LoadConfig keeps
+// their value.
+func restartKeys(srv abstract.Service, old, section string) []string {
+	var before, after map[string]any
+	_ = json.Unmarshal([]byte(old), &before)
+	_ = json.Unmarshal([]byte(section), &after)
+	var runtime []string
+	if rc, ok := srv.(abstract.RuntimeConfigurer); ok {
+		runtime = rc.RuntimeConfigKeys()
+	}
+	var keys []string
+	for key, v := range after {
+		prev, ok := before[key]
+		if ok && reflect.DeepEqual(prev, v) {
+			continue
+		}
+		if !slices.Contains(runtime, key) {
+			keys = append(keys, key)
+		}
+	}
+	for key := range before {
+		if _, ok := after[key]; !ok {
+			keys = append(keys, key)
+		}
+	}
+	slices.Sort(keys)
+	return keys
+}
+
+// configureService applies the section of the config file to the running service, keeping its tools and its
+// state, and resolves again the timeouts of its tools, e.g. for a new max_timeout.
+func (m *MoLingServer) configureService(srv abstract.Service, section any) error {
+	cfg, _ := section.(map[string]any)
+	if err := srv.LoadConfig(cfg); err != nil {
+		return err
+	}
+	m.setToolTimeouts(m.mlConfig.ToolTimeouts)
+	return nil
+}
+
+// reloadService replaces the service by a new one with the section of the config file. The new configuration
+// is loaded before the old service is closed, so an invalid one keeps it. The old service is closed before the
+// new one is initialized, they may share resources, e.g. the profile of the browser.
+func (m *MoLingServer) reloadService(old abstract.Service, section any) error {
+	factory, ok := services.ServiceList()[old.Name()]
+	if !ok {
+		return fmt.Errorf("unknown service")
+	}
+	srv, err := factory(m.ctx)
+	if err != nil {
+		return err
+	}
+	if cfg, ok := section.(map[string]any); ok {
+		if err = srv.LoadConfig(cfg); err != nil {
+			return err
+		}
+	}
+
+	m.unloadService(old)
+	if err = old.Close(); err != nil {
+		m.logger.Warn().Err(err).Str("serviceName", string(old.Name())).Msg("Failed to close the reloaded service")
+	}
+	err = srv.Init()
+	m.mu.Lock()
+	i := slices.Index(m.services, old)
+	if err != nil {
+		m.services = slices.Delete(m.services, i, i+1)
+	} else {
+		m.services[i] = srv
+	}
+	m.mu.Unlock()
+	if err != nil {
+		return fmt.Errorf("%w, the service is unloaded", err)
+	}
+	return m.loadService(srv)
+}
+
+// unloadService removes the tools, resources and prompts of the service from the MCP server. The resource
+// templates are kept, mcp-go can't remove them, the ones of the new service replace them.
+func (m *MoLingServer) unloadService(srv abstract.Service) {
+	var tools []string
+	m.mu.Lock()
+	for tool, service := range m.toolServices {
+		if service != string(srv.Name()) {
+			continue
+		}
+		tools = append(tools, tool)
+		delete(m.toolServices, tool)
+		delete(m.toolTimeouts, tool)
+		delete(m.toolNames, tool)
+		delete(m.toolAliases, tool)
+	}
+	m.mu.Unlock()
+	m.server.DeleteTools(tools...)
+
+	var uris []string
+	m.mu.Lock()
+	for r := range srv.Resources() {
+		uris = append(uris, r.URI)
+		delete(m.resourceServices, r.URI)
+	}
+	m.mu.Unlock()
+	m.server.DeleteResources(uris...)
+
+	var prompts []string
+	m.mu.Lock()
+	for _, pe := range srv.Prompts() {
+		prompts = append(prompts, pe.Prompt().Name)
+		delete(m.promptServices, pe.Prompt().Name)
+	}
+	m.mu.Unlock()
+	m.server.DeletePrompts(prompts...)
+}
+
+// handleReload is the handler of ReloadToolName.
+func (m *MoLingServer) handleReload(ctx context.Context, request mcp.CallToolRequest) (*mcp.CallToolResult, error) {
+	reloaded, err := m.Reload()
+	if err != nil {
+		return comm.NewToolResultErrorFor(err, err.Error()), nil
+	}
+	if len(reloaded) == 0 {
+		return mcp.NewToolResultText("No service configuration changed"), nil
+	}
+	return mcp.NewToolResultText("Reloaded services: " + strings.Join(reloaded, ", ")), nil
+}
+
+// addReloadTool registers ReloadToolName, unless disabled by the configuration.
+func (m *MoLingServer) addReloadTool() {
+	if !m.toolExposed(ReloadToolName) {
+		return
+	}
+	m.server.AddTool(mcp.NewTool(ReloadToolName,
+		mcp.WithDescription("Reload the config file of MoLing: re-create the services whose configuration changed, with their tools, resources and prompts. Returns the services reloaded."),
+		mcp.WithDestructiveHintAnnotation(false),
+		mcp.WithIdempotentHintAnnotation(true),
+	), m.handleReload)
+}
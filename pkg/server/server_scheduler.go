@@ -0,0 +1,90 @@
+// Copyright 2025 CFC4N <cfc4n.cs@gmail.com>. All Rights Reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//	http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+//
+// Repository: https://github.com/gojue/moling
+package server
+
+import (
+	"context"
+	"sync"
+
+	"github.com/mark3labs/mcp-go/mcp"
+	"github.com/mark3labs/mcp-go/server"
+)
+
+// stdioWorkers are the tool calls of the STDIO client run at once, enough for the calls queued on a serialized
+// service not to hold up the calls of the other services.
+const stdioWorkers = 20
+
+// scheduler limits the tool calls run at once by service, the calls of different services run in parallel.
+type scheduler struct {
+	mu sync.Mutex
+	// slots are the semaphores of the services, by service name, nil for the services without limit.
+	slots map[string]chan struct{}
+}
+
+// serviceConcurrency returns the most tool calls of the service run at once: the one of service_concurrency by
+// service name, else the one of the service. 0: no limit
+func (m *MoLingServer) serviceConcurrency(name string) int {
+	if n, ok := m.mlConfig.ServiceConcurrency[name]; ok {
+		return max(n, 0)
+	}
+	for _, srv := range m.Services() {
+		if string(srv.Name()) == name {
+			return srv.MaxConcurrency()
+		}
+	}
+	return 0
+}
+
+// slot returns the semaphore of the service, created on its first call, nil without limit.
+func (m *MoLingServer) slot(service string) chan struct{} {
+	m.scheduler.mu.Lock()
+	defer m.scheduler.mu.Unlock()
+	slot, ok := m.scheduler.slots[service]
+	if !ok {
+		if n := m.serviceConcurrency(service); n > 0 {
+			slot = make(chan struct{}, n)
+		}
+		m.scheduler.slots[service] = slot
+	}
+	return slot
+}
+
+// scheduleMiddleware runs the tool calls of a service once it has a free slot, e.g. one at a time for the
+// browser. The wait counts in the timeout of the call, a call cancelled while waiting doesn't run.
+func (m *MoLingServer) scheduleMiddleware(next server.ToolHandlerFunc) server.ToolHandlerFunc {
+	return func(ctx context.Context, request mcp.CallToolRequest) (*mcp.CallToolResult, error) {
+		service := m.toolService(request.Params.Name)
+		if service == "" {
+			return next(ctx, request)
+		}
+		slot := m.slot(service)
+		if slot == nil {
+			return next(ctx, request)
+		}
+		select {
+		case slot <- struct{}{}:
+		default:
+			m.logger.Debug().Str("tool", request.Params.Name).Str("serviceName", service).Msg("Waiting for a slot of the service")
+			select {
+			case slot <- struct{}{}:
+			case <-ctx.Done():
+				return nil, ctx.Err()
+			}
+		}
+		defer func() { <-slot }()
+		return next(ctx, request)
+	}
+}
@@ -0,0 +1,201 @@
+// Copyright 2025 CFC4N <cfc4n.cs@gmail.com>. All Rights Reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//	http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+//
+// Repository: https://github.com/gojue/moling
+package server
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"sort"
+	"sync"
+	"time"
+
+	"github.com/mark3labs/mcp-go/mcp"
+	"github.com/mark3labs/mcp-go/server"
+
+	"github.com/gojue/moling/pkg/comm"
+	"github.com/gojue/moling/pkg/services/abstract"
+)
+
+// SessionsToolName is the admin tool listing the client sessions, only allowed to the tokens with the "*" scope
+// or its name.
+const SessionsToolName = "list_sessions"
+
+// Transports of the client sessions.
+const (
+	TransportStdio = "stdio"
+	TransportSSE   = "sse"
+)
+
+// clientSession is the state of a connected client, listed by SessionsToolName.
+type clientSession struct {
+	ID         string           `json:"id"`
+	Transport  string           `json:"transport"`
+	Token      string           `json:"token,omitempty"` // Token is the name of the API token of the client, none in STDIO mode.
+	Started    time.Time        `json:"started"`
+	LastActive time.Time        `json:"last_active"`
+	ToolCalls  int64            `json:"tool_calls"`
+	Tools      map[string]int64 `json:"tools,omitempty"`     // Tools are the numbers of calls by tool name.
+	Resources  []string         `json:"resources,omitempty"` // Resources are held by the services for the session, e.g. its browser sessions.
+}
+
+// sessionRegistry tracks the client sessions, registered by the hooks of the MCP server.
+type sessionRegistry struct {
+	mu       sync.Mutex
+	sessions map[string]*clientSession
+}
+
+// transportOf returns the transport of the session, STDIO has a single session of fixed ID.
+func transportOf(sessionID string) string {
+	if sessionID == TransportStdio {
+		return TransportStdio
+	}
+	return TransportSSE
+}
+
+// register records the session, with the token that authenticated its connection.
+func (r *sessionRegistry) register(ctx context.Context, sessionID string) *clientSession {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	if s, ok := r.sessions[sessionID]; ok {
+		return s
+	}
+	now := time.Now()
+	s := &clientSession{ID: sessionID, Transport: transportOf(sessionID), Started: now, LastActive: now, Tools: make(map[string]int64)}
+	if token := tokenFromContext(ctx); token != nil {
+		s.Token = token.Name
+	}
+	r.sessions[sessionID] = s
+	return s
+}
+
+// unregister drops the session, it reports whether it was registered.
+func (r *sessionRegistry) unregister(sessionID string) bool {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	_, ok := r.sessions[sessionID]
+	delete(r.sessions, sessionID)
+	return ok
+}
+
+// toolCalled counts a call of the tool by the session, registered on its first call if it was dropped as idle.
+func (r *sessionRegistry) toolCalled(ctx context.Context, sessionID, tool string) {
+	s := r.register(ctx, sessionID)
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	s.LastActive = time.Now()
+	s.ToolCalls++
+	s.Tools[tool]++
+}
+
+// idle returns the network sessions inactive since before the time, the STDIO session ends with the process.
+func (r *sessionRegistry) idle(before time.Time) []string {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	var ids []string
+	for id, s := range r.sessions {
+		if s.Transport != TransportStdio && s.LastActive.Before(before) {
+			ids = append(ids, id)
+		}
+	}
+	return ids
+}
+
+// list returns copies of the sessions, by start time.
+func (r *sessionRegistry) list() []clientSession {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	list := make([]clientSession, 0, len(r.sessions))
+	for _, s := range r.sessions {
+		c := *s
+		c.Tools = make(map[string]int64, len(s.Tools))
+		for tool, calls := range s.Tools {
+			c.Tools[tool] = calls
+		}
+		list = append(list, c)
+	}
+	sort.Slice(list, func(i, j int) bool { return list[i].Started.Before(list[j].Started) })
+	return list
+}
+
+// releaseSession releases the state of the session held by the services and the server, e.g. its browser and
+// shell sessions and its roots, when it ends or is idle.
+func (m *MoLingServer) releaseSession(sessionID string) {
+	m.sessions.unregister(sessionID)
+	abstract.ForgetRoots(sessionID)
+	for _, srv := range m.Services() {
+		if closer, ok := srv.(abstract.ClientSessionCloser); ok {
+			closer.CloseClientSession(sessionID)
+		}
+	}
+}
+
+// sessionMiddleware counts the tool calls of the client sessions.
+func (m *MoLingServer) sessionMiddleware(next server.ToolHandlerFunc) server.ToolHandlerFunc {
+	return func(ctx context.Context, request mcp.CallToolRequest) (*mcp.CallToolResult, error) {
+		if session := server.ClientSessionFromContext(ctx); session != nil {
+			m.sessions.toolCalled(ctx, session.SessionID(), m.baseToolName(request.Params.Name))
+		}
+		return next(ctx, request)
+	}
+}
+
+// sweepSessions releases the state of the network sessions idle for SessionIdleTimeout, until the server
+// stops. A session active again is registered anew, with the resources it asks for.
+func (m *MoLingServer) sweepSessions() {
+	timeout := time.Duration(m.mlConfig.SessionIdleTimeout) * time.Second
+	ticker := time.NewTicker(max(timeout/4, time.Second))
+	defer ticker.Stop()
+	for {
+		select {
+		case <-m.ctx.Done():
+			return
+		case now := <-ticker.C:
+			for _, id := range m.sessions.idle(now.Add(-timeout)) {
+				m.logger.Info().Str("session", id).Dur("idle", timeout).Msg("Releasing the idle client session")
+				m.releaseSession(id)
+			}
+		}
+	}
+}
+
+// handleListSessions is the handler of SessionsToolName.
+func (m *MoLingServer) handleListSessions(ctx context.Context, request mcp.CallToolRequest) (*mcp.CallToolResult, error) {
+	list := m.sessions.list()
+	for i := range list {
+		for _, srv := range m.Services() {
+			if closer, ok := srv.(abstract.ClientSessionCloser); ok {
+				list[i].Resources = append(list[i].Resources, closer.ClientResources(list[i].ID)...)
+			}
+		}
+	}
+	data, err := json.Marshal(list)
+	if err != nil {
+		return comm.NewToolResultErrorFor(err, fmt.Sprintf("failed to marshal sessions: %s", err.Error())), nil
+	}
+	return mcp.NewToolResultText(fmt.Sprintf("%d client sessions: %s", len(list), data)), nil
+}
+
+// addSessionsTool registers SessionsToolName, unless disabled by the configuration.
+func (m *MoLingServer) addSessionsTool() {
+	if !m.toolExposed(SessionsToolName) {
+		return
+	}
+	m.server.AddTool(mcp.NewTool(SessionsToolName,
+		mcp.WithDescription("List the client sessions connected to MoLing: transport, token, start time, last activity, tool calls by tool, and the resources the services hold for them, e.g. browser and shell sessions."),
+		mcp.WithReadOnlyHintAnnotation(true),
+	), m.handleListSessions)
+}
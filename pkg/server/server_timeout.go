@@ -0,0 +1,83 @@
+// Copyright 2025 CFC4N <cfc4n.cs@gmail.com>. All Rights Reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//	http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+//
+// Repository: https://github.com/gojue/moling
+package server
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"github.com/mark3labs/mcp-go/mcp"
+	"github.com/mark3labs/mcp-go/server"
+
+	"github.com/gojue/moling/pkg/comm"
+	"github.com/gojue/moling/pkg/services/abstract"
+)
+
+// toolTimeout returns the timeout of the tool of the service: the one of tool_timeouts by tool name, then by
+// service name, else the tool timeout of the server raised to the longest call of the service. 0: none
+func (m *MoLingServer) toolTimeout(srv abstract.Service, tool string) time.Duration {
+	if seconds, ok := m.mlConfig.ToolTimeouts[tool]; ok {
+		return time.Duration(max(seconds, 0)) * time.Second
+	}
+	if seconds, ok := m.mlConfig.ToolTimeouts[string(srv.Name())]; ok {
+		return time.Duration(max(seconds, 0)) * time.Second
+	}
+	if m.mlConfig.ToolTimeout <= 0 {
+		return 0
+	}
+	return max(time.Duration(m.mlConfig.ToolTimeout)*time.Second, srv.ToolTimeout())
+}
+
+// toolCall is the outcome of a tool handler run by timeoutMiddleware.
+type toolCall struct {
+	result *mcp.CallToolResult
+	err    error
+}
+
+// timeoutMiddleware cancels the context of the tool calls at their timeout, and answers the client with a
+// timeout error if the handler is still stuck, e.g. on a hung browser or a blocked I/O, instead of wedging
+// the session. The stuck handler is left to return on its own, its result is dropped.
+func (m *MoLingServer) timeoutMiddleware(next server.ToolHandlerFunc) server.ToolHandlerFunc {
+	return func(ctx context.Context, request mcp.CallToolRequest) (*mcp.CallToolResult, error) {
+		m.mu.RLock()
+		timeout := m.toolTimeouts[request.Params.Name]
+		m.mu.RUnlock()
+		if timeout <= 0 {
+			return next(ctx, request)
+		}
+		ctx, cancel := context.WithTimeout(ctx, timeout)
+		defer cancel()
+
+		done := make(chan toolCall, 1)
+		go func() {
+			// The panics of the handler are recovered by recoveryMiddleware, inside this goroutine.
+			result, err := next(ctx, request)
+			done <- toolCall{result, err}
+		}()
+		select {
+		case call := <-done:
+			return call.result, call.err
+		case <-ctx.Done():
+		}
+		if ctx.Err() != context.DeadlineExceeded {
+			// The client cancelled the call or the server is shutting down.
+			return nil, ctx.Err()
+		}
+		m.logger.Warn().Str("tool", request.Params.Name).Dur("timeout", timeout).Msg("tool call timed out")
+		return comm.NewToolResultError(comm.CodeTimeout, fmt.Sprintf("%s timed out after %s", request.Params.Name, timeout)), nil
+	}
+}
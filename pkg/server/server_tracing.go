@@ -0,0 +1,162 @@
+// Copyright 2025 CFC4N <cfc4n.cs@gmail.com>. All Rights Reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//	http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+//
+// Repository: https://github.com/gojue/moling
+package server
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+
+	"github.com/mark3labs/mcp-go/mcp"
+	"github.com/mark3labs/mcp-go/server"
+	"go.opentelemetry.io/otel"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/codes"
+	"go.opentelemetry.io/otel/exporters/otlp/otlptrace/otlptracegrpc"
+	"go.opentelemetry.io/otel/exporters/otlp/otlptrace/otlptracehttp"
+	"go.opentelemetry.io/otel/propagation"
+	"go.opentelemetry.io/otel/sdk/resource"
+	sdktrace "go.opentelemetry.io/otel/sdk/trace"
+	"go.opentelemetry.io/otel/trace"
+
+	"github.com/gojue/moling/pkg/config"
+)
+
+// tracerName is the instrumentation scope of the spans of MoLing.
+const tracerName = "github.com/gojue/moling"
+
+// OTLP protocols of OtelProtocol.
+const (
+	OtelProtocolGRPC = "grpc"
+	OtelProtocolHTTP = "http/protobuf"
+)
+
+// propagator carries the trace context of the host application, in the HTTP headers of the SSE mode or in the
+// _meta of the tool calls, e.g. {"traceparent": "00-..."}.
+var propagator = propagation.NewCompositeTextMapPropagator(propagation.TraceContext{}, propagation.Baggage{})
+
+// newTracerProvider returns the tracer provider exporting the spans to the OTLP collector of the configuration,
+// or nil if tracing is disabled.
+func newTracerProvider(ctx context.Context, cfg config.MoLingConfig) (*sdktrace.TracerProvider, error) {
+	if cfg.OtelEndpoint == "" {
+		return nil, nil
+	}
+	var exporter sdktrace.SpanExporter
+	var err error
+	switch cfg.OtelProtocol {
+	case "", OtelProtocolGRPC:
+		opts := []otlptracegrpc.Option{otlptracegrpc.WithEndpoint(cfg.OtelEndpoint)}
+		if cfg.OtelInsecure {
+			opts = append(opts, otlptracegrpc.WithInsecure())
+		}
+		exporter, err = otlptracegrpc.New(ctx, opts...)
+	case OtelProtocolHTTP:
+		opts := []otlptracehttp.Option{otlptracehttp.WithEndpoint(cfg.OtelEndpoint)}
+		if cfg.OtelInsecure {
+			opts = append(opts, otlptracehttp.WithInsecure())
+		}
+		exporter, err = otlptracehttp.New(ctx, opts...)
+	default:
+		return nil, fmt.Errorf("otel_protocol must be %s or %s, got %s", OtelProtocolGRPC, OtelProtocolHTTP, cfg.OtelProtocol)
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to create the OTLP exporter: %w", err)
+	}
+	ratio := cfg.OtelSampleRatio
+	if ratio <= 0 || ratio > 1 {
+		ratio = 1
+	}
+	res, err := resource.Merge(resource.Default(), resource.NewSchemaless(
+		attribute.String("service.name", cfg.ServerName),
+		attribute.String("service.version", cfg.Version),
+	))
+	if err != nil {
+		return nil, err
+	}
+	return sdktrace.NewTracerProvider(
+		sdktrace.WithBatcher(exporter),
+		sdktrace.WithResource(res),
+		sdktrace.WithSampler(sdktrace.ParentBased(sdktrace.TraceIDRatioBased(ratio))),
+	), nil
+}
+
+// tracingMiddleware records a span of each tool call, with the tool, its service, its duration and its error.
+// The span is the child of the trace context of the request, if the host application passed one.
+func (m *MoLingServer) tracingMiddleware(next server.ToolHandlerFunc) server.ToolHandlerFunc {
+	return func(ctx context.Context, request mcp.CallToolRequest) (*mcp.CallToolResult, error) {
+		if m.tracer == nil {
+			return next(ctx, request)
+		}
+		if meta := request.Params.Meta; meta != nil && len(meta.AdditionalFields) > 0 {
+			carrier := propagation.MapCarrier{}
+			for k, v := range meta.AdditionalFields {
+				if s, ok := v.(string); ok {
+					carrier[k] = s
+				}
+			}
+			ctx = propagator.Extract(ctx, carrier)
+		}
+		name := request.Params.Name
+		attrs := []attribute.KeyValue{
+			attribute.String("mcp.tool.name", name),
+			attribute.String("moling.service", m.toolService(name)),
+		}
+		if session := server.ClientSessionFromContext(ctx); session != nil {
+			attrs = append(attrs, attribute.String("mcp.session.id", session.SessionID()))
+		}
+		if token := tokenFromContext(ctx); token != nil {
+			attrs = append(attrs, attribute.String("moling.token", token.Name))
+		}
+		ctx, span := m.tracer.Start(ctx, "tools/call "+name, trace.WithSpanKind(trace.SpanKindServer), trace.WithAttributes(attrs...))
+		defer span.End()
+
+		result, err := next(ctx, request)
+		switch {
+		case err != nil:
+			span.RecordError(err)
+			span.SetStatus(codes.Error, err.Error())
+		case result != nil && result.IsError:
+			span.SetStatus(codes.Error, toolResultText(result))
+		}
+		return result, err
+	}
+}
+
+// toolResultText returns the text of the first text content of the result, e.g. the message of an error result.
+func toolResultText(result *mcp.CallToolResult) string {
+	for _, content := range result.Content {
+		if text, ok := mcp.AsTextContent(content); ok {
+			return text.Text
+		}
+	}
+	return "tool error"
+}
+
+// tracingHandler passes the trace context of the HTTP headers of the requests to their tool calls.
+func tracingHandler(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		ctx := propagator.Extract(r.Context(), propagation.HeaderCarrier(r.Header))
+		next.ServeHTTP(w, r.WithContext(ctx))
+	})
+}
+
+// setTracerProvider sets the tracer of the tool calls, and the global provider for the services.
+func (m *MoLingServer) setTracerProvider(tp *sdktrace.TracerProvider) {
+	m.tracerProvider = tp
+	m.tracer = tp.Tracer(tracerName)
+	otel.SetTracerProvider(tp)
+	otel.SetTextMapPropagator(propagator)
+}
@@ -0,0 +1,89 @@
+// Copyright 2025 CFC4N <cfc4n.cs@gmail.com>. All Rights Reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//	http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+//
+// Repository: https://github.com/gojue/moling
+package server
+
+import (
+	"context"
+	"fmt"
+	"path/filepath"
+	"slices"
+	"time"
+
+	"github.com/fsnotify/fsnotify"
+
+	"github.com/gojue/moling/pkg/config"
+)
+
+// watchDebounce is the delay between the last write of the config file and its reload, the editors write a
+// file in several steps.
+const watchDebounce = 500 * time.Millisecond
+
+// WatchConfig reloads the config file when it or a file it includes is written, until ctx is done, like Reload
+// does on SIGHUP. The directories of the files are watched, the editors replace the files rather than writing them
+// in place. The files included are listed again at every reload.
+func (m *MoLingServer) WatchConfig(ctx context.Context) error {
+	path := m.mlConfig.ConfigFilePath()
+	watcher, err := fsnotify.NewWatcher()
+	if err != nil {
+		return fmt.Errorf("failed to watch the config file: %w", err)
+	}
+	defer func() { _ = watcher.Close() }()
+	files := config.ConfigFiles(path)
+	for _, file := range files {
+		if err = watcher.Add(filepath.Dir(file)); err != nil {
+			return fmt.Errorf("failed to watch the config file %s: %w", file, err)
+		}
+	}
+	m.logger.Info().Str("config_file", path).Strs("files", files).Msg("Watching the config file")
+
+	timer := time.NewTimer(watchDebounce)
+	timer.Stop()
+	defer timer.Stop()
+	for {
+		select {
+		case <-ctx.Done():
+			return nil
+		case event, ok := <-watcher.Events:
+			if !ok {
+				return nil
+			}
+			// A file removed is kept loaded, it's replaced by the next write.
+			name, _ := filepath.Abs(event.Name)
+			if !slices.Contains(files, name) || !(event.Has(fsnotify.Write) || event.Has(fsnotify.Create)) {
+				continue
+			}
+			timer.Reset(watchDebounce)
+		case err, ok := <-watcher.Errors:
+			if !ok {
+				return nil
+			}
+			m.logger.Warn().Err(err).Msg("Error watching the config file")
+		case <-timer.C:
+			reloaded, err := m.Reload()
+			if err != nil {
+				m.logger.Error().Err(err).Strs("services", reloaded).Msg("failed to reload the config file")
+			} else {
+				m.logger.Info().Strs("services", reloaded).Msg("config file changed, reloaded")
+			}
+			files = config.ConfigFiles(path)
+			for _, file := range files {
+				if err = watcher.Add(filepath.Dir(file)); err != nil {
+					m.logger.Warn().Err(err).Str("file", file).Msg("failed to watch the included config file")
+				}
+			}
+		}
+	}
+}
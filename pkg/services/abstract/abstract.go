@@ -18,6 +18,7 @@ package abstract
 
 import (
 	"context"
+	"time"
 
 	"github.com/mark3labs/mcp-go/mcp"
 	"github.com/mark3labs/mcp-go/server"
@@ -55,6 +56,34 @@ type Service interface {
 	// Name returns the name of the service.
 	Name() comm.MoLingServerType
 
+	// ToolTimeout returns the longest a tool call of the service may take, e.g. a command run with the maximum
+	// timeout, 0 to use the tool timeout of the server.
+	ToolTimeout() time.Duration
+
+	// MaxConcurrency returns the most tool calls of the service run at once, e.g. 1 for a service serializing
+	// its calls on a single resource, 0 for no limit. The calls of the other services still run in parallel.
+	MaxConcurrency() int
+
+	// Health runs the readiness checks of the service, e.g. the browser is running, and returns their errors
+	// by check name, nil for the checks that passed.
+	Health(ctx context.Context) map[string]error
+
 	// Close closes the service and releases any resources it holds.
 	Close() error
 }
+
+// ClientSessionCloser is implemented by the services holding state for the client sessions, e.g. the browser
+// sessions or the shell sessions opened by their tool calls, released when the client session ends.
+type ClientSessionCloser interface {
+	// ClientResources returns the resources held for the client session, e.g. "shell session sh-1".
+	ClientResources(sessionID string) []string
+	// CloseClientSession releases the resources held for the client session.
+	CloseClientSession(sessionID string)
+}
+
+// RuntimeConfigurer is implemented by the services applying some keys of their section of the config file to the
+// running service, e.g. the allowlists or the timeouts, so a reload keeps their state, e.g. the running jobs.
+type RuntimeConfigurer interface {
+	// RuntimeConfigKeys returns the keys of the section LoadConfig applies to the running service.
+	RuntimeConfigKeys() []string
+}
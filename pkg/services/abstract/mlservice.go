@@ -18,7 +18,15 @@ package abstract
 
 import (
 	"context"
+	"errors"
+	"fmt"
+	"net/url"
+	"os"
+	"path/filepath"
+	"runtime"
+	"strings"
 	"sync"
+	"time"
 
 	"github.com/mark3labs/mcp-go/mcp"
 	"github.com/mark3labs/mcp-go/server"
@@ -28,6 +36,21 @@ import (
 	"github.com/gojue/moling/pkg/utils"
 )
 
+// ErrSamplingUnsupported is returned by Sample when the client of the request can't sample its LLM, e.g. an
+// SSE client or one without the sampling capability.
+var ErrSamplingUnsupported = errors.New("the client does not support sampling")
+
+// ErrElicitationUnsupported is returned by Elicit when the client of the request can't ask its user, without a
+// session or the elicitation capability.
+var ErrElicitationUnsupported = errors.New("the client does not support elicitation")
+
+// ErrNotConfirmed is returned by Confirm when the user didn't confirm the action.
+var ErrNotConfirmed = errors.New("not confirmed")
+
+// sessionRoots are the directories of the roots of the client sessions, by session ID, cached until the client
+// notifies they changed.
+var sessionRoots sync.Map
+
 type PromptEntry struct {
 	PromptVar   mcp.Prompt
 	HandlerFunc server.PromptHandlerFunc
@@ -165,6 +188,21 @@ func (mls *MLService) Name() string {
 	panic("not implemented yet") // TODO: Implement
 }
 
+// ToolTimeout returns 0, the tool calls of the service use the tool timeout of the server.
+func (mls *MLService) ToolTimeout() time.Duration {
+	return 0
+}
+
+// MaxConcurrency returns 0, the tool calls of the service run in parallel.
+func (mls *MLService) MaxConcurrency() int {
+	return 0
+}
+
+// Health returns no checks, the service is ready once initialized.
+func (mls *MLService) Health(ctx context.Context) map[string]error {
+	return nil
+}
+
 // LoadConfig loads the configuration for the service from a map.
 func (mls *MLService) LoadConfig(jsonData map[string]any) error {
 	//panic("not implemented yet") // TODO: Implement
@@ -174,3 +212,162 @@ func (mls *MLService) LoadConfig(jsonData map[string]any) error {
 	}
 	return mls.mlConfig.Check()
 }
+
+// Sample asks the LLM of the client of the tool call of ctx to answer the prompt, through MCP sampling, with
+// the system prompt and at most maxTokens tokens. It returns the text of the answer, or ErrSamplingUnsupported.
+func (mls *MLService) Sample(ctx context.Context, systemPrompt, prompt string, maxTokens int) (string, error) {
+	session, ok := server.ClientSessionFromContext(ctx).(server.SessionWithSampling)
+	if !ok {
+		return "", ErrSamplingUnsupported
+	}
+	if info, ok := session.(server.SessionWithClientInfo); ok && info.GetClientCapabilities().Sampling == nil {
+		return "", ErrSamplingUnsupported
+	}
+	request := mcp.CreateMessageRequest{
+		CreateMessageParams: mcp.CreateMessageParams{
+			Messages:     []mcp.SamplingMessage{{Role: mcp.RoleUser, Content: mcp.NewTextContent(prompt)}},
+			SystemPrompt: systemPrompt,
+			MaxTokens:    maxTokens,
+		},
+	}
+	result, err := session.RequestSampling(ctx, request)
+	if err != nil {
+		return "", fmt.Errorf("sampling failed: %w", err)
+	}
+	// The content is decoded as a map from the JSON of the remote clients.
+	switch content := result.Content.(type) {
+	case mcp.TextContent:
+		return content.Text, nil
+	case *mcp.TextContent:
+		return content.Text, nil
+	case map[string]any:
+		if text, ok := content["text"].(string); ok {
+			return text, nil
+		}
+	}
+	return "", fmt.Errorf("sampling returned no text content: %T", result.Content)
+}
+
+// SessionRoots returns the local directories of the workspace roots of the client of the tool call of ctx, asked
+// once per session through MCP roots. None if the client doesn't support roots.
+func (mls *MLService) SessionRoots(ctx context.Context) []string {
+	session, ok := server.ClientSessionFromContext(ctx).(server.SessionWithRoots)
+	if !ok {
+		return nil
+	}
+	if info, ok := session.(server.SessionWithClientInfo); ok && info.GetClientCapabilities().Roots == nil {
+		return nil
+	}
+	if dirs, ok := sessionRoots.Load(session.SessionID()); ok {
+		return dirs.([]string)
+	}
+	result, err := session.ListRoots(ctx, mcp.ListRootsRequest{})
+	if err != nil {
+		mls.Logger.Warn().Err(err).Str("session", session.SessionID()).Msg("failed to list the roots of the client")
+		return nil
+	}
+	dirs := make([]string, 0, len(result.Roots))
+	for _, root := range result.Roots {
+		dir, err := rootDir(root.URI)
+		if err != nil {
+			mls.Logger.Warn().Err(err).Str("root", root.URI).Msg("ignored the root of the client")
+			continue
+		}
+		dirs = append(dirs, dir)
+	}
+	mls.Logger.Info().Str("session", session.SessionID()).Strs("roots", dirs).Msg("roots of the client")
+	sessionRoots.Store(session.SessionID(), dirs)
+	return dirs
+}
+
+// ForgetRoots drops the cached roots of the session, on notifications/roots/list_changed or when it ends.
+func ForgetRoots(sessionID string) {
+	sessionRoots.Delete(sessionID)
+}
+
+// ClientSessionID returns the ID of the client session of the tool call of ctx, empty without a session.
+func ClientSessionID(ctx context.Context) string {
+	if session := server.ClientSessionFromContext(ctx); session != nil {
+		return session.SessionID()
+	}
+	return ""
+}
+
+// rootDir returns the directory of a file:// root URI, cleaned with a trailing separator like the allowed
+// directories of the services.
+func rootDir(uri string) (string, error) {
+	u, err := url.Parse(uri)
+	if err != nil {
+		return "", err
+	}
+	if u.Scheme != "file" {
+		return "", fmt.Errorf("not a file URI")
+	}
+	path := u.Path
+	if runtime.GOOS == "windows" {
+		// file:///C:/work is /C:/work, file://server/share a UNC path.
+		path = strings.TrimPrefix(path, "/")
+		if u.Host != "" {
+			path = `\\` + u.Host + `\` + path
+		}
+	}
+	path, err = filepath.Abs(filepath.FromSlash(path))
+	if err != nil {
+		return "", err
+	}
+	info, err := os.Stat(path)
+	if err != nil {
+		return "", err
+	}
+	if !info.IsDir() {
+		return "", fmt.Errorf("not a directory")
+	}
+	return filepath.Clean(path) + string(filepath.Separator), nil
+}
+
+// Elicit asks the user of the client of the tool call of ctx for the input described by the JSON schema, through
+// MCP elicitation. It returns ErrElicitationUnsupported if the client can't ask its user.
+func (mls *MLService) Elicit(ctx context.Context, message string, schema map[string]any) (*mcp.ElicitationResult, error) {
+	session, ok := server.ClientSessionFromContext(ctx).(server.SessionWithElicitation)
+	if !ok {
+		return nil, ErrElicitationUnsupported
+	}
+	if info, ok := session.(server.SessionWithClientInfo); ok && info.GetClientCapabilities().Elicitation == nil {
+		return nil, ErrElicitationUnsupported
+	}
+	return session.RequestElicitation(ctx, mcp.ElicitationRequest{
+		Params: mcp.ElicitationParams{Message: message, RequestedSchema: schema},
+	})
+}
+
+// Confirm asks the user to confirm the action of the message through MCP elicitation, with a "confirm" checkbox
+// titled title. It returns nil if the user confirmed it, an error wrapping ErrNotConfirmed otherwise. If the
+// client can't ask its user, the elicitation_fallback of the configuration confirms or refuses it.
+func (mls *MLService) Confirm(ctx context.Context, message, title string) error {
+	result, err := mls.Elicit(ctx, message, map[string]any{
+		"type": "object",
+		"properties": map[string]any{
+			"confirm": map[string]any{
+				"type":  "boolean",
+				"title": title,
+			},
+		},
+		"required": []string{"confirm"},
+	})
+	switch {
+	case errors.Is(err, ErrElicitationUnsupported):
+		if mls.mlConfig != nil && mls.mlConfig.ElicitationFallback == config.ElicitationAllow {
+			mls.Logger.Warn().Str("action", title).Msg("confirmed by the elicitation_fallback, the client does not support elicitation")
+			return nil
+		}
+		return fmt.Errorf("%w: the user's confirmation is needed, but %w", ErrNotConfirmed, err)
+	case err != nil:
+		return fmt.Errorf("%w: failed to ask the user: %v", ErrNotConfirmed, err)
+	case result.Action != mcp.ElicitationResponseActionAccept:
+		return fmt.Errorf("%w: the user chose to %s", ErrNotConfirmed, result.Action)
+	}
+	if content, ok := result.Content.(map[string]any); !ok || content["confirm"] != true {
+		return fmt.Errorf("%w: the user did not confirm", ErrNotConfirmed)
+	}
+	return nil
+}
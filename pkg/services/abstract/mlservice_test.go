@@ -18,9 +18,15 @@ package abstract
 
 import (
 	"context"
+	"errors"
+	"net/url"
+	"path/filepath"
+	"slices"
 	"testing"
 
 	"github.com/mark3labs/mcp-go/mcp"
+	"github.com/mark3labs/mcp-go/server"
+	"github.com/rs/zerolog"
 )
 
 func TestMLService_AddResource(t *testing.T) {
@@ -164,3 +170,72 @@ func TestMLService_AddNotificationHandler(t *testing.T) {
 		t.Errorf("Handler for notification not found")
 	}
 }
+
+// samplingFunc answers the sampling requests of an in-process session.
+type samplingFunc func(ctx context.Context, request mcp.CreateMessageRequest) (*mcp.CreateMessageResult, error)
+
+func (f samplingFunc) CreateMessage(ctx context.Context, request mcp.CreateMessageRequest) (*mcp.CreateMessageResult, error) {
+	return f(ctx, request)
+}
+
+func TestMLService_Sample(t *testing.T) {
+	service := &MLService{}
+	if _, err := service.Sample(context.Background(), "", "hello", 10); !errors.Is(err, ErrSamplingUnsupported) {
+		t.Fatalf("Expected ErrSamplingUnsupported without a session, got %v", err)
+	}
+
+	session := server.NewInProcessSession("test", samplingFunc(func(ctx context.Context, request mcp.CreateMessageRequest) (*mcp.CreateMessageResult, error) {
+		prompt, _ := mcp.AsTextContent(request.Messages[0].Content)
+		return &mcp.CreateMessageResult{SamplingMessage: mcp.SamplingMessage{Role: mcp.RoleAssistant, Content: map[string]any{"type": "text", "text": "echo: " + prompt.Text}}}, nil
+	}))
+	ctx := server.NewMCPServer("test", "1.0").WithContext(context.Background(), session)
+	if _, err := service.Sample(ctx, "", "hello", 10); !errors.Is(err, ErrSamplingUnsupported) {
+		t.Fatalf("Expected ErrSamplingUnsupported without the sampling capability, got %v", err)
+	}
+	session.SetClientCapabilities(mcp.ClientCapabilities{Sampling: &struct{}{}})
+	answer, err := service.Sample(ctx, "", "hello", 10)
+	if err != nil || answer != "echo: hello" {
+		t.Errorf("Expected the answer of the client, got %q, %v", answer, err)
+	}
+}
+
+// rootsFunc answers the roots requests of an in-process session.
+type rootsFunc func(ctx context.Context, request mcp.ListRootsRequest) (*mcp.ListRootsResult, error)
+
+func (f rootsFunc) ListRoots(ctx context.Context, request mcp.ListRootsRequest) (*mcp.ListRootsResult, error) {
+	return f(ctx, request)
+}
+
+func TestMLService_SessionRoots(t *testing.T) {
+	service := &MLService{Logger: zerolog.Nop()}
+	dir := t.TempDir()
+	calls := 0
+	session := server.NewInProcessSessionWithHandlers("roots", nil, nil, rootsFunc(func(ctx context.Context, request mcp.ListRootsRequest) (*mcp.ListRootsResult, error) {
+		calls++
+		return &mcp.ListRootsResult{Roots: []mcp.Root{
+			{URI: (&url.URL{Scheme: "file", Path: filepath.ToSlash(dir)}).String(), Name: "workspace"},
+			{URI: "https://example.com/repo"},
+		}}, nil
+	}))
+	ctx := server.NewMCPServer("test", "1.0").WithContext(context.Background(), session)
+	if roots := service.SessionRoots(ctx); roots != nil {
+		t.Fatalf("Expected no roots without the roots capability, got %v", roots)
+	}
+	session.SetClientCapabilities(mcp.ClientCapabilities{Roots: &struct {
+		ListChanged bool `json:"listChanged,omitempty"`
+	}{}})
+	want := []string{filepath.Clean(dir) + string(filepath.Separator)}
+	for i := 0; i < 2; i++ {
+		if roots := service.SessionRoots(ctx); !slices.Equal(roots, want) {
+			t.Fatalf("Expected the roots %v, got %v", want, roots)
+		}
+	}
+	if calls != 1 {
+		t.Errorf("Expected the roots cached for the session, got %d requests", calls)
+	}
+	ForgetRoots("roots")
+	service.SessionRoots(ctx)
+	if calls != 2 {
+		t.Errorf("Expected the roots asked again once forgotten, got %d requests", calls)
+	}
+}
@@ -0,0 +1,220 @@
+// Copyright 2025 CFC4N <cfc4n.cs@gmail.com>. All Rights Reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//   http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+//
+// Repository: https://github.com/gojue/moling
+
+// Package aggregate mounts external MCP servers and re-exposes their tools through MoLing, so the clients
+// get a single endpoint fronting several servers.
+package aggregate
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"sort"
+	"sync"
+	"time"
+
+	"github.com/mark3labs/mcp-go/client"
+	"github.com/mark3labs/mcp-go/client/transport"
+	"github.com/mark3labs/mcp-go/mcp"
+	"github.com/rs/zerolog"
+
+	"github.com/gojue/moling/pkg/comm"
+	"github.com/gojue/moling/pkg/config"
+	"github.com/gojue/moling/pkg/services/abstract"
+	"github.com/gojue/moling/pkg/utils"
+)
+
+const (
+	AggregateServerName comm.MoLingServerType = "Aggregate"
+)
+
+// upstream is a connected upstream server.
+type upstream struct {
+	config UpstreamServer
+	client *client.Client
+}
+
+type AggregateServer struct {
+	abstract.MLService
+	config *AggregateConfig
+	mu     sync.Mutex
+	// upstreams are the upstream servers connected, by name.
+	upstreams map[string]*upstream
+	// failed are the errors of the upstream servers that couldn't be connected, by name.
+	failed map[string]error
+}
+
+func NewAggregateServer(ctx context.Context) (abstract.Service, error) {
+	gConf, ok := ctx.Value(comm.MoLingConfigKey).(*config.MoLingConfig)
+	if !ok {
+		return nil, fmt.Errorf("AggregateServer: invalid config type")
+	}
+
+	lger, ok := ctx.Value(comm.MoLingLoggerKey).(zerolog.Logger)
+	if !ok {
+		return nil, fmt.Errorf("AggregateServer: invalid logger type")
+	}
+
+	loggerNameHook := zerolog.HookFunc(func(e *zerolog.Event, level zerolog.Level, msg string) {
+		e.Str("Service", string(AggregateServerName))
+	})
+
+	as := &AggregateServer{
+		MLService: abstract.NewMLService(ctx, lger.Hook(loggerNameHook), gConf),
+		config:    NewAggregateConfig(),
+		upstreams: make(map[string]*upstream),
+		failed:    make(map[string]error),
+	}
+
+	if err := as.InitResources(); err != nil {
+		return nil, fmt.Errorf("failed to initialize aggregate server: %w", err)
+	}
+	return as, nil
+}
+
+// Init connects the upstream servers and registers their tools, prefixed with the name of their server. An
+// upstream server that can't be connected is skipped, and reported by Health.
+func (as *AggregateServer) Init() error {
+	for _, cfg := range as.config.Servers {
+		if err := as.mount(cfg); err != nil {
+			as.Logger.Error().Err(err).Str("upstream", cfg.Name).Msg("failed to mount the upstream MCP server")
+			as.failed[cfg.Name] = err
+		}
+	}
+	return nil
+}
+
+// connect starts the client of the upstream server and initializes the MCP session.
+func (as *AggregateServer) connect(ctx context.Context, cfg UpstreamServer) (*client.Client, error) {
+	var c *client.Client
+	var err error
+	if cfg.Command != "" {
+		env := make([]string, 0, len(cfg.Env))
+		for k, v := range cfg.Env {
+			env = append(env, k+"="+v)
+		}
+		c, err = client.NewStdioMCPClient(cfg.Command, env, cfg.Args...)
+	} else {
+		c, err = client.NewSSEMCPClient(cfg.URL, transport.WithHeaders(cfg.Headers))
+		if err == nil {
+			// The SSE stream lives until the client is closed, not bound to the timeout of the connection.
+			err = c.Start(as.Ctx())
+		}
+	}
+	if err != nil {
+		return nil, err
+	}
+	request := mcp.InitializeRequest{}
+	request.Params.ProtocolVersion = mcp.LATEST_PROTOCOL_VERSION
+	request.Params.ClientInfo = mcp.Implementation{Name: as.MlConfig().ServerName, Version: as.MlConfig().Version}
+	if _, err = c.Initialize(ctx, request); err != nil {
+		_ = c.Close()
+		return nil, fmt.Errorf("failed to initialize: %w", err)
+	}
+	return c, nil
+}
+
+// mount connects the upstream server and adds its tools.
+func (as *AggregateServer) mount(cfg UpstreamServer) error {
+	ctx, cancel := context.WithTimeout(as.Ctx(), time.Duration(as.config.ConnectTimeout)*time.Second)
+	defer cancel()
+	c, err := as.connect(ctx, cfg)
+	if err != nil {
+		return err
+	}
+	tools, err := c.ListTools(ctx, mcp.ListToolsRequest{})
+	if err != nil {
+		_ = c.Close()
+		return fmt.Errorf("failed to list the tools: %w", err)
+	}
+	c.OnConnectionLost(func(err error) {
+		as.Logger.Warn().Err(err).Str("upstream", cfg.Name).Msg("lost the connection to the upstream MCP server")
+	})
+	u := &upstream{config: cfg, client: c}
+	as.mu.Lock()
+	as.upstreams[cfg.Name] = u
+	as.mu.Unlock()
+	for _, tool := range tools.Tools {
+		name := tool.Name
+		tool.Name = cfg.Name + "." + name
+		as.AddTool(tool, func(ctx context.Context, request mcp.CallToolRequest) (*mcp.CallToolResult, error) {
+			request.Params.Name = name
+			result, err := u.client.CallTool(ctx, request)
+			if err != nil {
+				return comm.NewToolResultErrorFor(err, fmt.Sprintf("upstream server %s failed: %s", cfg.Name, err.Error())), nil
+			}
+			return result, nil
+		})
+	}
+	as.Logger.Info().Str("upstream", cfg.Name).Int("tools", len(tools.Tools)).Msg("mounted the upstream MCP server")
+	return nil
+}
+
+// Config returns the configuration of the service as a string.
+func (as *AggregateServer) Config() string {
+	cfg, err := json.Marshal(as.config)
+	if err != nil {
+		as.Logger.Err(err).Msg("failed to marshal config")
+		return "{}"
+	}
+	return string(cfg)
+}
+
+func (as *AggregateServer) Name() comm.MoLingServerType {
+	return AggregateServerName
+}
+
+// Health pings the upstream servers, by name, the ones that couldn't be mounted included.
+func (as *AggregateServer) Health(ctx context.Context) map[string]error {
+	as.mu.Lock()
+	defer as.mu.Unlock()
+	checks := make(map[string]error, len(as.upstreams)+len(as.failed))
+	for name, err := range as.failed {
+		checks[name] = err
+	}
+	for name, u := range as.upstreams {
+		checks[name] = u.client.Ping(ctx)
+	}
+	return checks
+}
+
+// Close closes the connections to the upstream servers, the STDIO ones are stopped.
+func (as *AggregateServer) Close() error {
+	as.mu.Lock()
+	defer as.mu.Unlock()
+	names := make([]string, 0, len(as.upstreams))
+	for name := range as.upstreams {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	for _, name := range names {
+		if err := as.upstreams[name].client.Close(); err != nil {
+			as.Logger.Warn().Err(err).Str("upstream", name).Msg("failed to close the upstream MCP server")
+		}
+		delete(as.upstreams, name)
+	}
+	as.Logger.Debug().Msg("AggregateServer closed")
+	return nil
+}
+
+// LoadConfig loads the configuration from a JSON object.
+func (as *AggregateServer) LoadConfig(jsonData map[string]any) error {
+	err := utils.MergeJSONToStruct(as.config, jsonData)
+	if err != nil {
+		return err
+	}
+	return as.config.Check()
+}
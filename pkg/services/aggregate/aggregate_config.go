@@ -0,0 +1,78 @@
+// Copyright 2025 CFC4N <cfc4n.cs@gmail.com>. All Rights Reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//   http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+//
+// Repository: https://github.com/gojue/moling
+
+package aggregate
+
+import (
+	"fmt"
+	"net/url"
+	"regexp"
+)
+
+// upstreamNamePattern is the pattern of the names of the upstream servers, the prefix of their tools.
+var upstreamNamePattern = regexp.MustCompile(`^[A-Za-z0-9_-]{1,32}$`)
+
+// UpstreamServer is an external MCP server mounted by the Aggregate service, run as a STDIO subprocess or
+// reached on its SSE endpoint.
+type UpstreamServer struct {
+	Name    string            `json:"name"`              // Name prefixes the tools of the server, e.g. github.create_issue.
+	Command string            `json:"command,omitempty"` // Command runs the server over STDIO, e.g. npx.
+	Args    []string          `json:"args,omitempty"`    // Args are the arguments of the command.
+	Env     map[string]string `json:"env,omitempty"`     // Env is added to the environment of the command.
+	URL     string            `json:"url,omitempty"`     // URL is the SSE endpoint of the server, e.g. http://127.0.0.1:8080/sse.
+	Headers map[string]string `json:"headers,omitempty"` // Headers are sent to the SSE endpoint, e.g. an Authorization header.
+}
+
+// AggregateConfig represents the configuration of the upstream MCP servers.
+type AggregateConfig struct {
+	Servers        []UpstreamServer `json:"servers"`         // Servers are the upstream MCP servers, none by default.
+	ConnectTimeout int              `json:"connect_timeout"` // ConnectTimeout is the timeout in seconds to connect a server and list its tools. default: 30
+}
+
+// NewAggregateConfig creates a new AggregateConfig without upstream servers.
+func NewAggregateConfig() *AggregateConfig {
+	return &AggregateConfig{
+		Servers:        []UpstreamServer{},
+		ConnectTimeout: 30,
+	}
+}
+
+// Check validates the upstream servers: unique names, and either a command or an SSE URL.
+func (ac *AggregateConfig) Check() error {
+	if ac.ConnectTimeout <= 0 {
+		return fmt.Errorf("connect_timeout must be positive")
+	}
+	names := make(map[string]bool, len(ac.Servers))
+	for _, s := range ac.Servers {
+		if !upstreamNamePattern.MatchString(s.Name) {
+			return fmt.Errorf("upstream server name must be 1 to 32 letters, digits, '_' or '-', got %q", s.Name)
+		}
+		if names[s.Name] {
+			return fmt.Errorf("duplicate upstream server name %s", s.Name)
+		}
+		names[s.Name] = true
+		if (s.Command == "") == (s.URL == "") {
+			return fmt.Errorf("upstream server %s must have either a command or a url", s.Name)
+		}
+		if s.URL != "" {
+			u, err := url.Parse(s.URL)
+			if err != nil || (u.Scheme != "http" && u.Scheme != "https") || u.Host == "" {
+				return fmt.Errorf("upstream server %s: invalid url %q, expected an http(s) SSE endpoint", s.Name, s.URL)
+			}
+		}
+	}
+	return nil
+}
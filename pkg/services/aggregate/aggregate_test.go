@@ -0,0 +1,106 @@
+// Copyright 2025 CFC4N <cfc4n.cs@gmail.com>. All Rights Reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//   http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+//
+// Repository: https://github.com/gojue/moling
+
+package aggregate
+
+import (
+	"context"
+	"testing"
+
+	"github.com/mark3labs/mcp-go/mcp"
+	"github.com/mark3labs/mcp-go/server"
+
+	"github.com/gojue/moling/pkg/comm"
+)
+
+// TestAggregateConfigCheck verifies the upstream servers need a unique name and either a command or a URL.
+func TestAggregateConfigCheck(t *testing.T) {
+	tests := []struct {
+		name    string
+		servers []UpstreamServer
+		wantErr bool
+	}{
+		{"none", nil, false},
+		{"stdio", []UpstreamServer{{Name: "github", Command: "github-mcp-server", Args: []string{"stdio"}}}, false},
+		{"sse", []UpstreamServer{{Name: "docs", URL: "http://127.0.0.1:8080/sse"}}, false},
+		{"both", []UpstreamServer{{Name: "x", Command: "x", URL: "http://127.0.0.1/sse"}}, true},
+		{"neither", []UpstreamServer{{Name: "x"}}, true},
+		{"bad name", []UpstreamServer{{Name: "a.b", Command: "x"}}, true},
+		{"duplicate", []UpstreamServer{{Name: "x", Command: "x"}, {Name: "x", Command: "y"}}, true},
+		{"bad url", []UpstreamServer{{Name: "x", URL: "ftp://127.0.0.1/sse"}}, true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			cfg := NewAggregateConfig()
+			cfg.Servers = tt.servers
+			if err := cfg.Check(); (err != nil) != tt.wantErr {
+				t.Errorf("Check() error = %v, wantErr %v", err, tt.wantErr)
+			}
+		})
+	}
+}
+
+// TestAggregateServer verifies the tools of an SSE upstream server are re-exposed with its prefix, and their
+// calls forwarded to it.
+func TestAggregateServer(t *testing.T) {
+	upstreamServer := server.NewMCPServer("upstream", "1.0.0")
+	upstreamServer.AddTool(mcp.NewTool("echo", mcp.WithString("text", mcp.Required())),
+		func(ctx context.Context, request mcp.CallToolRequest) (*mcp.CallToolResult, error) {
+			return mcp.NewToolResultText("echo: " + request.GetString("text", "")), nil
+		})
+	ts := server.NewTestServer(upstreamServer)
+	defer ts.Close()
+
+	_, ctx, err := comm.InitTestEnv()
+	if err != nil {
+		t.Fatalf("InitTestEnv: %v", err)
+	}
+	srv, err := NewAggregateServer(ctx)
+	if err != nil {
+		t.Fatalf("NewAggregateServer: %v", err)
+	}
+	defer srv.Close()
+	err = srv.LoadConfig(map[string]any{"servers": []any{
+		map[string]any{"name": "remote", "url": ts.URL + "/sse"},
+		map[string]any{"name": "missing", "command": "moling-no-such-mcp-server"},
+	}})
+	if err != nil {
+		t.Fatalf("LoadConfig: %v", err)
+	}
+	if err = srv.Init(); err != nil {
+		t.Fatalf("Init: %v", err)
+	}
+
+	tools := srv.Tools()
+	if len(tools) != 1 || tools[0].Tool.Name != "remote.echo" {
+		t.Fatalf("expected the remote.echo tool, got %v", tools)
+	}
+	request := mcp.CallToolRequest{}
+	request.Params.Name = "remote.echo"
+	request.Params.Arguments = map[string]any{"text": "hi"}
+	result, err := tools[0].Handler(ctx, request)
+	if err != nil || result.IsError {
+		t.Fatalf("expected the call forwarded, got %v, %v", result, err)
+	}
+	if text := result.Content[0].(mcp.TextContent).Text; text != "echo: hi" {
+		t.Errorf("expected the result of the upstream server, got %q", text)
+	}
+
+	checks := srv.Health(ctx)
+	if checks["remote"] != nil || checks["missing"] == nil {
+		t.Errorf("expected remote healthy and missing failed, got %v", checks)
+	}
+}
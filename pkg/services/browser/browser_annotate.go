@@ -0,0 +1,186 @@
+// Copyright 2025 CFC4N <cfc4n.cs@gmail.com>. All Rights Reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//   http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+//
+// Repository: https://github.com/gojue/moling
+
+package browser
+
+import (
+	"context"
+	"encoding/base64"
+	"encoding/json"
+	"fmt"
+	"math/rand"
+	"os"
+	"path/filepath"
+	"strings"
+	"time"
+
+	"github.com/chromedp/cdproto/cdp"
+	"github.com/chromedp/chromedp"
+	"github.com/mark3labs/mcp-go/mcp"
+
+	"github.com/gojue/moling/pkg/comm"
+)
+
+const (
+	defaultAnnotations = 100 // defaultAnnotations is the number of elements browser_annotated_screenshot numbers by default.
+	maxAnnotations     = 500
+)
+
+// annotateFunction numbers the interactable elements visible in the viewport, draws their numbered boxes
+// in an overlay, and returns them with a unique CSS selector. The overlay is removed by removeAnnotationsScript.
+const annotateFunction = `function(max) {
+	const interactable = 'a[href], button, input:not([type=hidden]), select, textarea, summary, [role=button], [role=link], ' +
+		'[role=checkbox], [role=radio], [role=tab], [role=menuitem], [onclick], [contenteditable=""], [contenteditable=true], ' +
+		'[tabindex]:not([tabindex="-1"])';
+	const selectorOf = (el) => {
+		const parts = [];
+		for (; el && el.nodeType === 1; el = el.parentElement) {
+			if (el.id && document.querySelectorAll('#' + CSS.escape(el.id)).length === 1) {
+				parts.unshift('#' + CSS.escape(el.id));
+				break;
+			}
+			let part = el.localName;
+			const parent = el.parentElement;
+			if (parent) {
+				const same = Array.from(parent.children).filter((c) => c.localName === el.localName);
+				if (same.length > 1) {
+					part += ':nth-of-type(' + (same.indexOf(el) + 1) + ')';
+				}
+			}
+			parts.unshift(part);
+		}
+		return parts.join(' > ');
+	};
+	const old = document.getElementById('__moling_annotations');
+	if (old) {
+		old.remove();
+	}
+	const overlay = document.createElement('div');
+	overlay.id = '__moling_annotations';
+	overlay.style.cssText = 'position:fixed;left:0;top:0;width:0;height:0;z-index:2147483647;pointer-events:none;';
+	const colors = ['#e6194b', '#3cb44b', '#4363d8', '#f58231', '#911eb4', '#008080', '#9a6324', '#800000'];
+	const result = [];
+	for (const el of this.querySelectorAll(interactable)) {
+		if (result.length >= max) {
+			break;
+		}
+		const r = el.getBoundingClientRect(), style = window.getComputedStyle(el);
+		if (r.width <= 0 || r.height <= 0 || style.visibility === 'hidden' || style.display === 'none' ||
+			r.bottom < 0 || r.right < 0 || r.top > window.innerHeight || r.left > window.innerWidth) {
+			continue;
+		}
+		const number = result.length + 1, color = colors[result.length % colors.length];
+		const box = document.createElement('div');
+		box.style.cssText = 'position:fixed;box-sizing:border-box;border:2px solid ' + color + ';' +
+			'left:' + r.left + 'px;top:' + r.top + 'px;width:' + r.width + 'px;height:' + r.height + 'px;';
+		const label = document.createElement('span');
+		label.textContent = number;
+		label.style.cssText = 'position:absolute;left:-2px;top:-2px;transform:translateY(-100%);padding:0 3px;' +
+			'font:bold 12px/16px monospace;color:#fff;background:' + color + ';';
+		if (r.top < 16) {
+			label.style.transform = 'none';
+		}
+		box.appendChild(label);
+		overlay.appendChild(box);
+		result.push({
+			number: number,
+			selector: selectorOf(el),
+			tag: el.localName,
+			text: (el.innerText || el.value || el.getAttribute('aria-label') || el.getAttribute('placeholder') || el.title || '')
+				.replace(/\s+/g, ' ').trim(),
+			box: {x: r.left, y: r.top, width: r.width, height: r.height},
+		});
+	}
+	document.documentElement.appendChild(overlay);
+	return result;
+}`
+
+// removeAnnotationsScript removes the overlay of annotateFunction.
+const removeAnnotationsScript = `document.getElementById('__moling_annotations')?.remove()`
+
+// annotatedElement is a numbered element of the annotated screenshot.
+type annotatedElement struct {
+	Number   int        `json:"number"`
+	Selector string     `json:"selector"`
+	Tag      string     `json:"tag"`
+	Text     string     `json:"text,omitempty"`
+	Box      elementBox `json:"box"`
+}
+
+// annotateElements returns the action that numbers at most limit interactable elements of the viewport
+// into res and draws their boxes.
+func annotateElements(limit int, res *[]annotatedElement) chromedp.Action {
+	var nodes []*cdp.Node
+	return chromedp.Tasks{
+		chromedp.Nodes("html", &nodes, chromedp.ByQuery),
+		chromedp.ActionFunc(func(ctx context.Context) error {
+			if len(nodes) == 0 {
+				return fmt.Errorf("the page has no document")
+			}
+			if err := callOnNode(ctx, nodes[0], annotateFunction, res, limit); err != nil {
+				return err
+			}
+			for i := range *res {
+				if runes := []rune((*res)[i].Text); len(runes) > maxQueryText {
+					(*res)[i].Text = string(runes[:maxQueryText]) + "..."
+				}
+			}
+			return nil
+		}),
+	}
+}
+
+// handleAnnotatedScreenshot takes a screenshot of the viewport with the interactable elements boxed and
+// numbered, and returns it with the index of the numbers to the selectors of the elements.
+func (bs *BrowserServer) handleAnnotatedScreenshot(ctx context.Context, request mcp.CallToolRequest) (*mcp.CallToolResult, error) {
+	args := request.GetArguments()
+	s, err := bs.session(ctx, args)
+	if err != nil {
+		return comm.NewToolResultErrorFor(err, err.Error()), nil
+	}
+	name, _ := args["name"].(string)
+	if name == "" {
+		name = "annotated"
+	}
+	limit := defaultAnnotations
+	if v, ok := args["limit"].(float64); ok && v > 0 {
+		limit = min(int(v), maxAnnotations)
+	}
+
+	var elements []annotatedElement
+	var buf []byte
+	runCtx, cancelFunc := context.WithTimeout(s.ctx, time.Duration(bs.config.SelectorQueryTimeout)*time.Second)
+	defer cancelFunc()
+	err = chromedp.Run(runCtx, annotateElements(limit, &elements), chromedp.CaptureScreenshot(&buf))
+	// The overlay is removed even if the screenshot failed, the page must be left as it was.
+	if rmErr := chromedp.Run(runCtx, chromedp.Evaluate(removeAnnotationsScript, nil)); rmErr != nil {
+		bs.Logger.Debug().Err(rmErr).Msg("failed to remove the annotations")
+	}
+	if err != nil {
+		return comm.NewToolResultErrorFor(err, fmt.Sprintf("failed to take annotated screenshot: %s", err.Error())), nil
+	}
+
+	newName := filepath.Join(bs.config.DataPath, fmt.Sprintf("%s_%d.png", strings.TrimSuffix(filepath.Base(name), filepath.Ext(name)), rand.Int()))
+	if err = os.WriteFile(newName, buf, 0644); err != nil {
+		return comm.NewToolResultErrorFor(err, fmt.Sprintf("failed to save screenshot: %s", err.Error())), nil
+	}
+	index, err := json.Marshal(elements)
+	if err != nil {
+		return comm.NewToolResultErrorFor(err, fmt.Sprintf("failed to marshal elements: %s", err.Error())), nil
+	}
+	text := fmt.Sprintf("Annotated screenshot of %d elements saved to:%s\nElements by number: %s", len(elements), newName, index)
+	return mcp.NewToolResultImage(text, base64.StdEncoding.EncodeToString(buf), "image/png"), nil
+}
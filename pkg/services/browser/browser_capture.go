@@ -0,0 +1,363 @@
+// Copyright 2025 CFC4N <cfc4n.cs@gmail.com>. All Rights Reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//   http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+//
+// Repository: https://github.com/gojue/moling
+
+package browser
+
+import (
+	"context"
+	"encoding/base64"
+	"encoding/json"
+	"fmt"
+	"net/url"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+	"unicode/utf8"
+
+	"github.com/chromedp/cdproto/cdp"
+	"github.com/chromedp/cdproto/network"
+	"github.com/chromedp/chromedp"
+	"github.com/mark3labs/mcp-go/mcp"
+
+	"github.com/gojue/moling/pkg/comm"
+)
+
+const (
+	maxCaptureEntries  = 5000    // maxCaptureEntries is the number of requests a capture records, later requests are dropped.
+	maxCaptureBodySize = 1 << 20 // maxCaptureBodySize is the largest response body a capture with bodies stores.
+)
+
+// captureEntry is a request recorded by a network capture.
+type captureEntry struct {
+	id       network.RequestID
+	request  *network.Request
+	response *network.Response
+	started  time.Time // started is the wall time the request was sent.
+	sentAt   time.Time // sentAt and endedAt are monotonic timestamps of the browser.
+	endedAt  time.Time
+	size     float64
+	errText  string
+	postData string
+	body     []byte
+}
+
+// networkCapture records the requests of the page from the CDP Network events, between
+// browser_start_capture and browser_stop_capture.
+type networkCapture struct {
+	mu      sync.Mutex
+	active  bool
+	bodies  bool
+	entries []*captureEntry
+	pending map[network.RequestID]*captureEntry
+}
+
+// start drops the previous capture and starts recording.
+func (nc *networkCapture) start(bodies bool) {
+	nc.mu.Lock()
+	defer nc.mu.Unlock()
+	nc.active = true
+	nc.bodies = bodies
+	nc.entries = nil
+	nc.pending = make(map[network.RequestID]*captureEntry)
+}
+
+// stop stops recording and returns the recorded requests, and whether the bodies were requested.
+func (nc *networkCapture) stop() ([]*captureEntry, bool, error) {
+	nc.mu.Lock()
+	defer nc.mu.Unlock()
+	if !nc.active {
+		return nil, false, fmt.Errorf("no capture is running, call browser_start_capture first")
+	}
+	nc.active = false
+	entries := nc.entries
+	nc.entries = nil
+	nc.pending = nil
+	return entries, nc.bodies, nil
+}
+
+// handleEvent records the Network events while a capture is running.
+func (nc *networkCapture) handleEvent(ev any) {
+	nc.mu.Lock()
+	defer nc.mu.Unlock()
+	if !nc.active {
+		return
+	}
+	switch ev := ev.(type) {
+	case *network.EventRequestWillBeSent:
+		// A redirect reuses the request ID, the previous hop ends with the redirect response.
+		if prev, ok := nc.pending[ev.RequestID]; ok && ev.RedirectResponse != nil {
+			prev.response = ev.RedirectResponse
+			prev.endedAt = monotonic(ev.Timestamp)
+			delete(nc.pending, ev.RequestID)
+		}
+		if len(nc.entries) >= maxCaptureEntries {
+			return
+		}
+		e := &captureEntry{id: ev.RequestID, request: ev.Request, sentAt: monotonic(ev.Timestamp)}
+		if ev.WallTime != nil {
+			e.started = ev.WallTime.Time()
+		}
+		nc.entries = append(nc.entries, e)
+		nc.pending[ev.RequestID] = e
+	case *network.EventResponseReceived:
+		if e, ok := nc.pending[ev.RequestID]; ok {
+			e.response = ev.Response
+		}
+	case *network.EventLoadingFinished:
+		if e, ok := nc.pending[ev.RequestID]; ok {
+			e.endedAt = monotonic(ev.Timestamp)
+			e.size = ev.EncodedDataLength
+			delete(nc.pending, ev.RequestID)
+		}
+	case *network.EventLoadingFailed:
+		if e, ok := nc.pending[ev.RequestID]; ok {
+			e.endedAt = monotonic(ev.Timestamp)
+			e.errText = ev.ErrorText
+			delete(nc.pending, ev.RequestID)
+		}
+	}
+}
+
+// handleStartCapture starts recording the network traffic of the page.
+func (bs *BrowserServer) handleStartCapture(ctx context.Context, request mcp.CallToolRequest) (*mcp.CallToolResult, error) {
+	s, err := bs.session(ctx, request.GetArguments())
+	if err != nil {
+		return comm.NewToolResultErrorFor(err, err.Error()), nil
+	}
+	bodies, _ := request.GetArguments()["bodies"].(bool)
+	// Start the browser if needed, the events are only received from a running page.
+	if err := chromedp.Run(s.ctx, network.Enable()); err != nil {
+		return comm.NewToolResultErrorFor(err, fmt.Sprintf("failed to enable network events: %s", err.Error())), nil
+	}
+	s.capture.start(bodies)
+	return mcp.NewToolResultText("Network capture started, call browser_stop_capture to export it as a HAR file"), nil
+}
+
+// handleStopCapture stops recording and writes the captured requests to a HAR file.
+func (bs *BrowserServer) handleStopCapture(ctx context.Context, request mcp.CallToolRequest) (*mcp.CallToolResult, error) {
+	s, err := bs.session(ctx, request.GetArguments())
+	if err != nil {
+		return comm.NewToolResultErrorFor(err, err.Error()), nil
+	}
+	name, _ := request.GetArguments()["name"].(string)
+	if name == "" {
+		name = "capture"
+	}
+	entries, bodies, err := s.capture.stop()
+	if err != nil {
+		return comm.NewToolResultErrorFor(err, err.Error()), nil
+	}
+	if bodies {
+		// The bodies are fetched after the capture, Chrome keeps them in its network buffer.
+		_ = chromedp.Run(s.ctx, chromedp.ActionFunc(func(ctx context.Context) error {
+			for _, e := range entries {
+				if e.request.HasPostData {
+					e.postData, _ = network.GetRequestPostData(e.id).Do(ctx)
+				}
+				if e.response != nil && e.errText == "" && e.size <= maxCaptureBodySize {
+					e.body, _ = network.GetResponseBody(e.id).Do(ctx)
+				}
+			}
+			return nil
+		}))
+	}
+	data, err := json.MarshalIndent(buildHAR(entries, bs.MlConfig().Version), "", "  ")
+	if err != nil {
+		return comm.NewToolResultErrorFor(err, fmt.Sprintf("failed to marshal HAR: %s", err.Error())), nil
+	}
+	newName := filepath.Join(bs.config.DataPath, fmt.Sprintf("%s_%d.har", strings.TrimSuffix(name, ".har"), time.Now().UnixNano()))
+	if err = os.WriteFile(newName, data, 0644); err != nil {
+		return comm.NewToolResultErrorFor(err, fmt.Sprintf("failed to save HAR: %s", err.Error())), nil
+	}
+	return mcp.NewToolResultText(fmt.Sprintf("Captured %d requests, HAR saved to:%s", len(entries), newName)), nil
+}
+
+// monotonic returns the time of a monotonic timestamp of the browser, the zero time if it is not set.
+func monotonic(t *cdp.MonotonicTime) time.Time {
+	if t == nil {
+		return time.Time{}
+	}
+	return t.Time()
+}
+
+// HAR 1.2 types, see http://www.softwareishard.com/blog/har-12-spec/
+type (
+	har struct {
+		Log harLog `json:"log"`
+	}
+	harLog struct {
+		Version string     `json:"version"`
+		Creator harCreator `json:"creator"`
+		Entries []harEntry `json:"entries"`
+	}
+	harCreator struct {
+		Name    string `json:"name"`
+		Version string `json:"version"`
+	}
+	harEntry struct {
+		StartedDateTime string      `json:"startedDateTime"`
+		Time            float64     `json:"time"`
+		Request         harRequest  `json:"request"`
+		Response        harResponse `json:"response"`
+		Cache           struct{}    `json:"cache"`
+		Timings         harTimings  `json:"timings"`
+		ServerIPAddress string      `json:"serverIPAddress,omitempty"`
+		Comment         string      `json:"comment,omitempty"`
+	}
+	harRequest struct {
+		Method      string         `json:"method"`
+		URL         string         `json:"url"`
+		HTTPVersion string         `json:"httpVersion"`
+		Cookies     []harNameValue `json:"cookies"`
+		Headers     []harNameValue `json:"headers"`
+		QueryString []harNameValue `json:"queryString"`
+		PostData    *harPostData   `json:"postData,omitempty"`
+		HeadersSize int            `json:"headersSize"`
+		BodySize    int            `json:"bodySize"`
+	}
+	harResponse struct {
+		Status      int64          `json:"status"`
+		StatusText  string         `json:"statusText"`
+		HTTPVersion string         `json:"httpVersion"`
+		Cookies     []harNameValue `json:"cookies"`
+		Headers     []harNameValue `json:"headers"`
+		Content     harContent     `json:"content"`
+		RedirectURL string         `json:"redirectURL"`
+		HeadersSize int            `json:"headersSize"`
+		BodySize    int            `json:"bodySize"`
+	}
+	harNameValue struct {
+		Name  string `json:"name"`
+		Value string `json:"value"`
+	}
+	harPostData struct {
+		MimeType string `json:"mimeType"`
+		Text     string `json:"text"`
+	}
+	harContent struct {
+		Size     int    `json:"size"`
+		MimeType string `json:"mimeType"`
+		Text     string `json:"text,omitempty"`
+		Encoding string `json:"encoding,omitempty"`
+	}
+	harTimings struct {
+		Send    float64 `json:"send"`
+		Wait    float64 `json:"wait"`
+		Receive float64 `json:"receive"`
+	}
+)
+
+// buildHAR converts the captured requests to a HAR log.
+func buildHAR(entries []*captureEntry, version string) har {
+	log := harLog{Version: "1.2", Creator: harCreator{Name: "MoLing", Version: version}, Entries: make([]harEntry, 0, len(entries))}
+	for _, e := range entries {
+		entry := harEntry{
+			StartedDateTime: e.started.Format(time.RFC3339Nano),
+			Request: harRequest{
+				Method:      e.request.Method,
+				URL:         e.request.URL + e.request.URLFragment,
+				HTTPVersion: "HTTP/1.1",
+				Cookies:     []harNameValue{},
+				Headers:     harHeaders(e.request.Headers),
+				QueryString: harQuery(e.request.URL),
+				HeadersSize: -1,
+				BodySize:    len(e.postData),
+			},
+			Response: harResponse{
+				Cookies:     []harNameValue{},
+				Headers:     []harNameValue{},
+				HTTPVersion: "HTTP/1.1",
+				HeadersSize: -1,
+				BodySize:    int(e.size),
+			},
+			Comment: e.errText,
+		}
+		if e.postData != "" {
+			entry.Request.PostData = &harPostData{MimeType: headerValue(e.request.Headers, "Content-Type"), Text: e.postData}
+		}
+		if !e.endedAt.IsZero() && !e.sentAt.IsZero() {
+			entry.Time = float64(e.endedAt.Sub(e.sentAt).Microseconds()) / 1000
+		}
+		entry.Timings.Wait = entry.Time
+		if r := e.response; r != nil {
+			entry.Response.Status = r.Status
+			entry.Response.StatusText = r.StatusText
+			entry.Response.Headers = harHeaders(r.Headers)
+			entry.Response.RedirectURL = headerValue(r.Headers, "Location")
+			entry.Response.Content.MimeType = r.MimeType
+			entry.ServerIPAddress = r.RemoteIPAddress
+			if r.Protocol != "" {
+				entry.Request.HTTPVersion = strings.ToUpper(r.Protocol)
+				entry.Response.HTTPVersion = entry.Request.HTTPVersion
+			}
+			if t := r.Timing; t != nil && t.SendStart >= 0 {
+				entry.Timings.Send = t.SendEnd - t.SendStart
+				entry.Timings.Wait = t.ReceiveHeadersEnd - t.SendEnd
+				entry.Timings.Receive = max(entry.Time-t.ReceiveHeadersEnd, 0)
+			}
+		}
+		if e.body != nil {
+			entry.Response.Content.Size = len(e.body)
+			if utf8.Valid(e.body) {
+				entry.Response.Content.Text = string(e.body)
+			} else {
+				entry.Response.Content.Text = base64.StdEncoding.EncodeToString(e.body)
+				entry.Response.Content.Encoding = "base64"
+			}
+		}
+		log.Entries = append(log.Entries, entry)
+	}
+	return har{Log: log}
+}
+
+// harHeaders converts CDP headers to HAR name/value pairs, sorted by name.
+func harHeaders(headers network.Headers) []harNameValue {
+	pairs := make([]harNameValue, 0, len(headers))
+	for name, value := range headers {
+		pairs = append(pairs, harNameValue{Name: name, Value: fmt.Sprint(value)})
+	}
+	sort.Slice(pairs, func(i, j int) bool { return pairs[i].Name < pairs[j].Name })
+	return pairs
+}
+
+// harQuery returns the query string parameters of the URL.
+func harQuery(rawURL string) []harNameValue {
+	pairs := []harNameValue{}
+	u, err := url.Parse(rawURL)
+	if err != nil {
+		return pairs
+	}
+	for name, values := range u.Query() {
+		for _, value := range values {
+			pairs = append(pairs, harNameValue{Name: name, Value: value})
+		}
+	}
+	sort.Slice(pairs, func(i, j int) bool { return pairs[i].Name < pairs[j].Name })
+	return pairs
+}
+
+// headerValue returns the value of a header, matching its name case-insensitively.
+func headerValue(headers network.Headers, name string) string {
+	for k, v := range headers {
+		if strings.EqualFold(k, name) {
+			return fmt.Sprint(v)
+		}
+	}
+	return ""
+}
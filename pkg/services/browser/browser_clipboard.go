@@ -0,0 +1,212 @@
+// Copyright 2025 CFC4N <cfc4n.cs@gmail.com>. All Rights Reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//   http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+//
+// Repository: https://github.com/gojue/moling
+
+package browser
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"os/exec"
+	"strings"
+	"sync"
+	"time"
+	"unicode/utf8"
+
+	"github.com/chromedp/cdproto/cdp"
+	"github.com/chromedp/cdproto/input"
+	"github.com/chromedp/chromedp"
+	"github.com/mark3labs/mcp-go/mcp"
+
+	"github.com/gojue/moling/pkg/comm"
+)
+
+// maxClipboardLength is the number of characters the clipboard holds, so a copy can't exhaust the memory.
+const maxClipboardLength = 1 << 20
+
+// copyFunction returns the text of the element: the value of a form field, the rendered text of the others,
+// e.g. a table as tab-separated rows. With selection, it returns the text selected in the document of the element.
+const copyFunction = `function(selection) {
+	if (!selection) {
+		return ['INPUT', 'TEXTAREA', 'SELECT'].includes(this.tagName) ? String(this.value) : this.innerText;
+	}
+	const doc = this.ownerDocument || this, el = doc.activeElement;
+	if (el && (el.tagName === 'INPUT' || el.tagName === 'TEXTAREA') && el.selectionStart != null && el.selectionStart !== el.selectionEnd) {
+		return el.value.substring(el.selectionStart, el.selectionEnd);
+	}
+	return doc.defaultView.getSelection().toString();
+}`
+
+// pasteFunction focuses the element before the text is inserted, and selects its content to be replaced if replace is set.
+const pasteFunction = `function(replace) {
+	this.focus();
+	if (!replace) {
+		return true;
+	}
+	if (this.tagName === 'INPUT' || this.tagName === 'TEXTAREA') {
+		this.select();
+	} else {
+		const range = this.ownerDocument.createRange();
+		range.selectNodeContents(this);
+		const selection = this.ownerDocument.defaultView.getSelection();
+		selection.removeAllRanges();
+		selection.addRange(range);
+	}
+	return true;
+}`
+
+// clipboardBuffer is the clipboard of the server, shared by the sessions to move text between their pages.
+type clipboardBuffer struct {
+	mu   sync.Mutex
+	text string
+}
+
+func (cb *clipboardBuffer) set(text string) {
+	cb.mu.Lock()
+	defer cb.mu.Unlock()
+	cb.text = text
+}
+
+func (cb *clipboardBuffer) get() string {
+	cb.mu.Lock()
+	defer cb.mu.Unlock()
+	return cb.text
+}
+
+// runClipboardCommand runs the first of the commands found in the PATH, with stdin as its input, and returns its output.
+func runClipboardCommand(ctx context.Context, commands [][]string, stdin string) (string, error) {
+	for _, command := range commands {
+		if _, err := exec.LookPath(command[0]); err != nil {
+			continue
+		}
+		cmd := exec.CommandContext(ctx, command[0], command[1:]...)
+		cmd.Stdin = strings.NewReader(stdin)
+		var stdout, stderr bytes.Buffer
+		cmd.Stdout, cmd.Stderr = &stdout, &stderr
+		if err := cmd.Run(); err != nil {
+			return "", fmt.Errorf("%s failed: %w, %s", command[0], err, strings.TrimSpace(stderr.String()))
+		}
+		return stdout.String(), nil
+	}
+	names := make([]string, 0, len(commands))
+	for _, command := range commands {
+		names = append(names, command[0])
+	}
+	return "", fmt.Errorf("no clipboard command found, install one of %s", strings.Join(names, ", "))
+}
+
+// copyText returns the action that copies the text of the element matching the selector into res, or the
+// selected text of the page if selector is empty.
+func copyText(selector string, res *string, opts ...chromedp.QueryOption) chromedp.Action {
+	var nodes []*cdp.Node
+	query := "html"
+	if selector != "" {
+		query = selector
+	}
+	return chromedp.Tasks{
+		chromedp.Nodes(query, &nodes, append([]chromedp.QueryOption{chromedp.ByQuery}, opts...)...),
+		chromedp.ActionFunc(func(ctx context.Context) error {
+			return callOnNode(ctx, nodes[0], copyFunction, res, selector == "")
+		}),
+	}
+}
+
+// handleCopySelection copies the selected text of the page, or the text of the element matching the selector,
+// into the clipboard of the server, and into the clipboard of the OS if os_clipboard is set.
+func (bs *BrowserServer) handleCopySelection(ctx context.Context, request mcp.CallToolRequest) (*mcp.CallToolResult, error) {
+	args := request.GetArguments()
+	s, err := bs.session(ctx, args)
+	if err != nil {
+		return comm.NewToolResultErrorFor(err, err.Error()), nil
+	}
+	selector, _ := args["selector"].(string)
+	osClipboard, _ := args["os_clipboard"].(bool)
+	if osClipboard && !bs.config.AllowOSClipboard {
+		return comm.NewToolResultError(comm.CodePolicyDenied, "the OS clipboard is disabled, set allow_os_clipboard to enable it"), nil
+	}
+
+	var text string
+	runCtx, cancelFunc := context.WithTimeout(s.ctx, time.Duration(bs.config.SelectorQueryTimeout)*time.Second)
+	defer cancelFunc()
+	frame, err := findFrame(runCtx, args)
+	if err != nil {
+		return comm.NewToolResultErrorFor(err, err.Error()), nil
+	}
+	if err = chromedp.Run(runCtx, copyText(selector, &text, inFrame(frame)...)); err != nil {
+		return comm.NewToolResultErrorFor(err, fmt.Sprintf("failed to copy: %s", err.Error())), nil
+	}
+	if text == "" {
+		return comm.NewToolResultError(comm.CodeInvalidArgument, "nothing to copy, select some text or pass the selector of an element"), nil
+	}
+	if n := utf8.RuneCountInString(text); n > maxClipboardLength {
+		return comm.NewToolResultError(comm.CodeTooLarge, fmt.Sprintf("the text of %d characters is longer than the clipboard, at most %d", n, maxClipboardLength)), nil
+	}
+	bs.clipboard.set(text)
+	if osClipboard {
+		if _, err = runClipboardCommand(runCtx, osCopyCommands, text); err != nil {
+			return comm.NewToolResultErrorFor(err, fmt.Sprintf("copied to the server clipboard, but failed to copy to the OS clipboard: %s", err.Error())), nil
+		}
+	}
+	return mcp.NewToolResultText(fmt.Sprintf("Copied %d characters: %s", utf8.RuneCountInString(text), truncate(text, maxQueryText))), nil
+}
+
+// handlePasteInto inserts the text of the clipboard of the server, or of the OS if os_clipboard is set, into the
+// element matching the selector, as a paste would.
+func (bs *BrowserServer) handlePasteInto(ctx context.Context, request mcp.CallToolRequest) (*mcp.CallToolResult, error) {
+	args := request.GetArguments()
+	s, err := bs.session(ctx, args)
+	if err != nil {
+		return comm.NewToolResultErrorFor(err, err.Error()), nil
+	}
+	selector, _ := args["selector"].(string)
+	if selector == "" {
+		return comm.NewToolResultError(comm.CodeInvalidArgument, "selector must be a non-empty string"), nil
+	}
+	replace, _ := args["replace"].(bool)
+	osClipboard, _ := args["os_clipboard"].(bool)
+	if osClipboard && !bs.config.AllowOSClipboard {
+		return comm.NewToolResultError(comm.CodePolicyDenied, "the OS clipboard is disabled, set allow_os_clipboard to enable it"), nil
+	}
+
+	runCtx, cancelFunc := context.WithTimeout(s.ctx, time.Duration(bs.config.SelectorQueryTimeout)*time.Second)
+	defer cancelFunc()
+	text := bs.clipboard.get()
+	if osClipboard {
+		if text, err = runClipboardCommand(runCtx, osPasteCommands, ""); err != nil {
+			return comm.NewToolResultErrorFor(err, fmt.Sprintf("failed to read the OS clipboard: %s", err.Error())), nil
+		}
+	}
+	if text == "" {
+		return comm.NewToolResultError(comm.CodeInvalidArgument, "the clipboard is empty, copy some text with browser_copy_selection first"), nil
+	}
+	frame, err := findFrame(runCtx, args)
+	if err != nil {
+		return comm.NewToolResultErrorFor(err, err.Error()), nil
+	}
+	var nodes []*cdp.Node
+	err = chromedp.Run(runCtx,
+		chromedp.Nodes(selector, &nodes, append(querySelector(inFrame(frame)...), chromedp.AtLeast(1))...),
+		chromedp.ActionFunc(func(ctx context.Context) error {
+			var ok bool
+			return callOnNode(ctx, nodes[0], pasteFunction, &ok, replace)
+		}),
+		input.InsertText(text),
+	)
+	if err != nil {
+		return comm.NewToolResultErrorFor(err, fmt.Sprintf("failed to paste into %s: %s", selector, err.Error())), nil
+	}
+	return mcp.NewToolResultText(fmt.Sprintf("Pasted %d characters into %s", utf8.RuneCountInString(text), selector)), nil
+}
@@ -0,0 +1,171 @@
+// Copyright 2025 CFC4N <cfc4n.cs@gmail.com>. All Rights Reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//   http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+//
+// Repository: https://github.com/gojue/moling
+
+package browser
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"image"
+	"image/color"
+	"image/png"
+	"os"
+	"path/filepath"
+	"strings"
+	"time"
+
+	"github.com/chromedp/cdproto/page"
+	"github.com/chromedp/chromedp"
+	"github.com/mark3labs/mcp-go/mcp"
+
+	"github.com/gojue/moling/pkg/comm"
+	"github.com/gojue/moling/pkg/utils"
+)
+
+const (
+	BaselineDir             = "baselines" // BaselineDir is the directory of the screenshot baselines, in the data directory.
+	defaultCompareThreshold = 0.1         // defaultCompareThreshold is the color distance two pixels may differ by and still match.
+)
+
+// diffColor marks the mismatching pixels in the diff image.
+var diffColor = color.RGBA{R: 255, A: 255}
+
+// compareImages compares the images pixel by pixel, two pixels match if no channel differs by more than
+// threshold, between 0 and 1. It returns the diff image, the baseline faded with the mismatching pixels in red,
+// and the number of mismatching pixels. The pixels outside one of the images are mismatching.
+func compareImages(baseline, current image.Image, threshold float64) (*image.RGBA, int) {
+	bounds := baseline.Bounds().Union(current.Bounds())
+	diff := image.NewRGBA(bounds)
+	limit := uint32(threshold * 0xffff)
+	mismatch := 0
+	for y := bounds.Min.Y; y < bounds.Max.Y; y++ {
+		for x := bounds.Min.X; x < bounds.Max.X; x++ {
+			p := image.Pt(x, y)
+			if !p.In(baseline.Bounds()) || !p.In(current.Bounds()) {
+				diff.Set(x, y, diffColor)
+				mismatch++
+				continue
+			}
+			r1, g1, b1, a1 := baseline.At(x, y).RGBA()
+			r2, g2, b2, a2 := current.At(x, y).RGBA()
+			if max(absDiff(r1, r2), absDiff(g1, g2), absDiff(b1, b2), absDiff(a1, a2)) > limit {
+				diff.Set(x, y, diffColor)
+				mismatch++
+				continue
+			}
+			// The matching pixels are faded, so the mismatching ones stand out.
+			gray := uint8((r1*299+g1*587+b1*114)/1000>>8)/4 + 192
+			diff.Set(x, y, color.RGBA{R: gray, G: gray, B: gray, A: 255})
+		}
+	}
+	return diff, mismatch
+}
+
+func absDiff(a, b uint32) uint32 {
+	if a > b {
+		return a - b
+	}
+	return b - a
+}
+
+// baselinePath returns the path of the baseline of the name, in the baseline directory.
+func (bs *BrowserServer) baselinePath(name string) (string, error) {
+	name = strings.TrimSuffix(filepath.Base(name), ".png")
+	if name == "" || name == "." || name == string(filepath.Separator) {
+		return "", comm.Errorf(comm.CodeInvalidArgument, "name must be a file name")
+	}
+	return filepath.Join(bs.config.DataPath, BaselineDir, name+".png"), nil
+}
+
+// handleCompareScreenshots takes a screenshot of the page and compares it to its baseline. The first
+// screenshot of a name is saved as its baseline.
+func (bs *BrowserServer) handleCompareScreenshots(ctx context.Context, request mcp.CallToolRequest) (*mcp.CallToolResult, error) {
+	args := request.GetArguments()
+	s, err := bs.session(ctx, args)
+	if err != nil {
+		return comm.NewToolResultErrorFor(err, err.Error()), nil
+	}
+	name, _ := args["name"].(string)
+	path, err := bs.baselinePath(name)
+	if err != nil {
+		return comm.NewToolResultErrorFor(err, err.Error()), nil
+	}
+	threshold := defaultCompareThreshold
+	if v, ok := args["threshold"].(float64); ok {
+		if v < 0 || v > 1 {
+			return comm.NewToolResultError(comm.CodeInvalidArgument, fmt.Sprintf("threshold must be between 0 and 1, got %v", v)), nil
+		}
+		threshold = v
+	}
+	updateBaseline, _ := args["update_baseline"].(bool)
+	opts := screenshotOptions{format: page.CaptureScreenshotFormatPng}
+	opts.selector, _ = args["selector"].(string)
+	opts.fullPage, _ = args["full_page"].(bool)
+
+	var buf []byte
+	runCtx, cancelFunc := context.WithTimeout(s.ctx, time.Duration(bs.config.SelectorQueryTimeout)*time.Second)
+	defer cancelFunc()
+	opts.frame, err = findFrame(runCtx, args)
+	if err != nil {
+		return comm.NewToolResultErrorFor(err, err.Error()), nil
+	}
+	if err = chromedp.Run(runCtx, opts.action(&buf)); err != nil {
+		return comm.NewToolResultErrorFor(err, fmt.Sprintf("failed to take screenshot: %s", err.Error())), nil
+	}
+	if err = utils.CreateDirectory(filepath.Dir(path)); err != nil {
+		return comm.NewToolResultErrorFor(err, fmt.Sprintf("failed to create baseline directory: %s", err.Error())), nil
+	}
+
+	data, err := os.ReadFile(path)
+	if os.IsNotExist(err) {
+		if err = os.WriteFile(path, buf, 0644); err != nil {
+			return comm.NewToolResultErrorFor(err, fmt.Sprintf("failed to save baseline: %s", err.Error())), nil
+		}
+		return mcp.NewToolResultText(fmt.Sprintf("No baseline %s yet, saved the screenshot as baseline to:%s", name, path)), nil
+	}
+	if err != nil {
+		return comm.NewToolResultErrorFor(err, fmt.Sprintf("failed to read baseline: %s", err.Error())), nil
+	}
+	baseline, err := png.Decode(bytes.NewReader(data))
+	if err != nil {
+		return comm.NewToolResultErrorFor(err, fmt.Sprintf("failed to decode baseline: %s", err.Error())), nil
+	}
+	current, err := png.Decode(bytes.NewReader(buf))
+	if err != nil {
+		return comm.NewToolResultErrorFor(err, fmt.Sprintf("failed to decode screenshot: %s", err.Error())), nil
+	}
+
+	diff, mismatch := compareImages(baseline, current, threshold)
+	var out bytes.Buffer
+	if err = png.Encode(&out, diff); err != nil {
+		return comm.NewToolResultErrorFor(err, fmt.Sprintf("failed to encode diff: %s", err.Error())), nil
+	}
+	diffPath := filepath.Join(bs.config.DataPath, BaselineDir, fmt.Sprintf("%s_diff_%d.png", strings.TrimSuffix(filepath.Base(path), ".png"), time.Now().UnixNano()))
+	if err = os.WriteFile(diffPath, out.Bytes(), 0644); err != nil {
+		return comm.NewToolResultErrorFor(err, fmt.Sprintf("failed to save diff: %s", err.Error())), nil
+	}
+	result := fmt.Sprintf("Mismatch %.2f%% (%d of %d pixels), baseline %dx%d, screenshot %dx%d, diff saved to:%s",
+		float64(mismatch)*100/float64(diff.Bounds().Dx()*diff.Bounds().Dy()), mismatch, diff.Bounds().Dx()*diff.Bounds().Dy(),
+		baseline.Bounds().Dx(), baseline.Bounds().Dy(), current.Bounds().Dx(), current.Bounds().Dy(), diffPath)
+	if updateBaseline {
+		if err = os.WriteFile(path, buf, 0644); err != nil {
+			return comm.NewToolResultErrorFor(err, fmt.Sprintf("failed to update baseline: %s", err.Error())), nil
+		}
+		result += ", baseline updated"
+	}
+	return mcp.NewToolResultText(result), nil
+}
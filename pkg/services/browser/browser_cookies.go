@@ -0,0 +1,185 @@
+// Copyright 2025 CFC4N <cfc4n.cs@gmail.com>. All Rights Reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//   http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+//
+// Repository: https://github.com/gojue/moling
+
+package browser
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/chromedp/cdproto/cdp"
+	"github.com/chromedp/cdproto/network"
+	"github.com/chromedp/cdproto/storage"
+	"github.com/chromedp/chromedp"
+	"github.com/mark3labs/mcp-go/mcp"
+
+	"github.com/gojue/moling/pkg/comm"
+)
+
+// cookie is a browser cookie as returned by browser_get_cookies, its fields are the arguments of browser_set_cookie.
+type cookie struct {
+	Name     string  `json:"name"`
+	Value    string  `json:"value"`
+	Domain   string  `json:"domain"`
+	Path     string  `json:"path"`
+	Expires  float64 `json:"expires,omitempty"` // Expires is the number of seconds since the UNIX epoch, empty for a session cookie.
+	HTTPOnly bool    `json:"http_only"`
+	Secure   bool    `json:"secure"`
+	SameSite string  `json:"same_site,omitempty"`
+}
+
+// matchCookieDomain reports whether the cookie belongs to the domain or one of its subdomains.
+func matchCookieDomain(cookieDomain, domain string) bool {
+	cookieDomain = strings.ToLower(strings.TrimPrefix(cookieDomain, "."))
+	domain = strings.ToLower(strings.TrimPrefix(domain, "."))
+	return cookieDomain == domain || strings.HasSuffix(cookieDomain, "."+domain)
+}
+
+// browserCookies returns the cookies of the browser context of the session, only the ones of the domain if it is not empty.
+func browserCookies(s *browserSession, domain string) ([]*network.Cookie, error) {
+	var cookies []*network.Cookie
+	err := chromedp.Run(s.ctx, chromedp.ActionFunc(func(ctx context.Context) error {
+		var err error
+		cookies, err = storage.GetCookies().Do(ctx)
+		return err
+	}))
+	if err != nil || domain == "" {
+		return cookies, err
+	}
+	var matched []*network.Cookie
+	for _, c := range cookies {
+		if matchCookieDomain(c.Domain, domain) {
+			matched = append(matched, c)
+		}
+	}
+	return matched, nil
+}
+
+// handleGetCookies returns the cookies of the browser as a JSON array.
+func (bs *BrowserServer) handleGetCookies(ctx context.Context, request mcp.CallToolRequest) (*mcp.CallToolResult, error) {
+	s, err := bs.session(ctx, request.GetArguments())
+	if err != nil {
+		return comm.NewToolResultErrorFor(err, err.Error()), nil
+	}
+	if !bs.config.AllowCookieRead {
+		return comm.NewToolResultError(comm.CodePolicyDenied, "reading cookies is disabled, set allow_cookie_read to enable it"), nil
+	}
+	domain, _ := request.GetArguments()["domain"].(string)
+	cookies, err := browserCookies(s, domain)
+	if err != nil {
+		return comm.NewToolResultErrorFor(err, fmt.Sprintf("failed to get cookies: %s", err.Error())), nil
+	}
+	list := make([]cookie, 0, len(cookies))
+	for _, c := range cookies {
+		ck := cookie{
+			Name:     c.Name,
+			Value:    c.Value,
+			Domain:   c.Domain,
+			Path:     c.Path,
+			HTTPOnly: c.HTTPOnly,
+			Secure:   c.Secure,
+			SameSite: string(c.SameSite),
+		}
+		if !c.Session {
+			ck.Expires = c.Expires
+		}
+		list = append(list, ck)
+	}
+	data, err := json.Marshal(list)
+	if err != nil {
+		return comm.NewToolResultErrorFor(err, fmt.Sprintf("failed to marshal cookies: %s", err.Error())), nil
+	}
+	return mcp.NewToolResultText(string(data)), nil
+}
+
+// handleSetCookie sets a cookie in the browser, e.g. to restore a session.
+func (bs *BrowserServer) handleSetCookie(ctx context.Context, request mcp.CallToolRequest) (*mcp.CallToolResult, error) {
+	args := request.GetArguments()
+	s, err := bs.session(ctx, args)
+	if err != nil {
+		return comm.NewToolResultErrorFor(err, err.Error()), nil
+	}
+	name, ok := args["name"].(string)
+	if !ok || name == "" {
+		return comm.NewToolResultError(comm.CodeInvalidArgument, "name must be a non-empty string"), nil
+	}
+	value, ok := args["value"].(string)
+	if !ok {
+		return comm.NewToolResultError(comm.CodeInvalidArgument, "value must be a string"), nil
+	}
+	params := network.SetCookie(name, value)
+	url, _ := args["url"].(string)
+	domain, _ := args["domain"].(string)
+	if url == "" && domain == "" {
+		return comm.NewToolResultError(comm.CodeInvalidArgument, "url or domain is required"), nil
+	}
+	if url != "" {
+		params = params.WithURL(url)
+	}
+	if domain != "" {
+		params = params.WithDomain(domain)
+	}
+	if path, _ := args["path"].(string); path != "" {
+		params = params.WithPath(path)
+	}
+	if expires, ok := args["expires"].(float64); ok && expires > 0 {
+		t := cdp.TimeSinceEpoch(time.Unix(int64(expires), 0))
+		params = params.WithExpires(&t)
+	}
+	if httpOnly, ok := args["http_only"].(bool); ok {
+		params = params.WithHTTPOnly(httpOnly)
+	}
+	if secure, ok := args["secure"].(bool); ok {
+		params = params.WithSecure(secure)
+	}
+	if sameSite, _ := args["same_site"].(string); sameSite != "" {
+		params = params.WithSameSite(network.CookieSameSite(sameSite))
+	}
+	if err := chromedp.Run(s.ctx, params); err != nil {
+		return comm.NewToolResultErrorFor(err, fmt.Sprintf("failed to set cookie: %s", err.Error())), nil
+	}
+	return mcp.NewToolResultText(fmt.Sprintf("Cookie %s set", name)), nil
+}
+
+// handleClearCookies deletes the cookies of the browser, only the ones of the domain if it is set.
+func (bs *BrowserServer) handleClearCookies(ctx context.Context, request mcp.CallToolRequest) (*mcp.CallToolResult, error) {
+	s, err := bs.session(ctx, request.GetArguments())
+	if err != nil {
+		return comm.NewToolResultErrorFor(err, err.Error()), nil
+	}
+	domain, _ := request.GetArguments()["domain"].(string)
+	if domain == "" {
+		if err = chromedp.Run(s.ctx, storage.ClearCookies()); err != nil {
+			return comm.NewToolResultErrorFor(err, fmt.Sprintf("failed to clear cookies: %s", err.Error())), nil
+		}
+		return mcp.NewToolResultText("Cleared all cookies"), nil
+	}
+	cookies, err := browserCookies(s, domain)
+	if err != nil {
+		return comm.NewToolResultErrorFor(err, fmt.Sprintf("failed to get cookies: %s", err.Error())), nil
+	}
+	actions := make([]chromedp.Action, 0, len(cookies))
+	for _, c := range cookies {
+		actions = append(actions, network.DeleteCookies(c.Name).WithDomain(c.Domain).WithPath(c.Path))
+	}
+	if err = chromedp.Run(s.ctx, actions...); err != nil {
+		return comm.NewToolResultErrorFor(err, fmt.Sprintf("failed to clear cookies: %s", err.Error())), nil
+	}
+	return mcp.NewToolResultText(fmt.Sprintf("Cleared %d cookies of %s", len(cookies), domain)), nil
+}
@@ -25,18 +25,23 @@ import (
 	"github.com/chromedp/cdproto/target"
 	"github.com/chromedp/chromedp"
 	"github.com/mark3labs/mcp-go/mcp"
+
+	"github.com/gojue/moling/pkg/comm"
 )
 
 // handleDebugEnable handles the enabling and disabling of debugging in the browser.
 func (bs *BrowserServer) handleDebugEnable(ctx context.Context, request mcp.CallToolRequest) (*mcp.CallToolResult, error) {
 	args := request.GetArguments()
+	s, err := bs.session(ctx, args)
+	if err != nil {
+		return comm.NewToolResultErrorFor(err, err.Error()), nil
+	}
 	enabled, ok := args["enabled"].(bool)
 	if !ok {
-		return mcp.NewToolResultError("enabled must be a boolean"), nil
+		return comm.NewToolResultError(comm.CodeInvalidArgument, "enabled must be a boolean"), nil
 	}
 
-	var err error
-	rctx, cancel := context.WithCancel(bs.Context)
+	rctx, cancel := context.WithCancel(s.ctx)
 	defer cancel()
 
 	if enabled {
@@ -56,7 +61,7 @@ func (bs *BrowserServer) handleDebugEnable(ctx context.Context, request mcp.Call
 	}
 
 	if err != nil {
-		return mcp.NewToolResultError(fmt.Sprintf("failed to %s debugging: %v",
+		return comm.NewToolResultErrorFor(err, fmt.Sprintf("failed to %s debugging: %v",
 			map[bool]string{true: "enable", false: "disable"}[enabled], err)), nil
 	}
 	return mcp.NewToolResultText(fmt.Sprintf("Debugging %s",
@@ -66,23 +71,27 @@ func (bs *BrowserServer) handleDebugEnable(ctx context.Context, request mcp.Call
 // handleSetBreakpoint handles setting a breakpoint in the browser.
 func (bs *BrowserServer) handleSetBreakpoint(ctx context.Context, request mcp.CallToolRequest) (*mcp.CallToolResult, error) {
 	args := request.GetArguments()
+	s, err := bs.session(ctx, args)
+	if err != nil {
+		return comm.NewToolResultErrorFor(err, err.Error()), nil
+	}
 	url, ok := args["url"].(string)
 	if !ok {
-		return mcp.NewToolResultError("url must be a string"), nil
+		return comm.NewToolResultError(comm.CodeInvalidArgument, "url must be a string"), nil
 	}
 
 	line, ok := args["line"].(float64)
 	if !ok {
-		return mcp.NewToolResultError("line must be a number"), nil
+		return comm.NewToolResultError(comm.CodeInvalidArgument, "line must be a number"), nil
 	}
 
 	column, _ := args["column"].(float64)
 	condition, _ := args["condition"].(string)
 
 	var breakpointID string
-	rctx, cancel := context.WithCancel(bs.Context)
+	rctx, cancel := context.WithCancel(s.ctx)
 	defer cancel()
-	err := chromedp.Run(rctx, chromedp.ActionFunc(func(ctx context.Context) error {
+	err = chromedp.Run(rctx, chromedp.ActionFunc(func(ctx context.Context) error {
 		t := chromedp.FromContext(ctx).Target
 		params := map[string]any{
 			"url":       url,
@@ -106,7 +115,7 @@ func (bs *BrowserServer) handleSetBreakpoint(ctx context.Context, request mcp.Ca
 	}))
 
 	if err != nil {
-		return mcp.NewToolResultError(fmt.Sprintf("failed to set breakpoint: %s", err.Error())), nil
+		return comm.NewToolResultErrorFor(err, fmt.Sprintf("failed to set breakpoint: %s", err.Error())), nil
 	}
 	return mcp.NewToolResultText(fmt.Sprintf("Breakpoint set with ID: %s", breakpointID)), nil
 }
@@ -114,13 +123,17 @@ func (bs *BrowserServer) handleSetBreakpoint(ctx context.Context, request mcp.Ca
 // handleRemoveBreakpoint handles removing a breakpoint in the browser.
 func (bs *BrowserServer) handleRemoveBreakpoint(ctx context.Context, request mcp.CallToolRequest) (*mcp.CallToolResult, error) {
 	args := request.GetArguments()
+	s, err := bs.session(ctx, args)
+	if err != nil {
+		return comm.NewToolResultErrorFor(err, err.Error()), nil
+	}
 	breakpointID, ok := args["breakpointId"].(string)
 	if !ok {
-		return mcp.NewToolResultError("breakpointId must be a string"), nil
+		return comm.NewToolResultError(comm.CodeInvalidArgument, "breakpointId must be a string"), nil
 	}
-	rctx, cancel := context.WithCancel(bs.Context)
+	rctx, cancel := context.WithCancel(s.ctx)
 	defer cancel()
-	err := chromedp.Run(rctx, chromedp.ActionFunc(func(ctx context.Context) error {
+	err = chromedp.Run(rctx, chromedp.ActionFunc(func(ctx context.Context) error {
 		t := chromedp.FromContext(ctx).Target
 		// 使用Execute方法执行Debugger.removeBreakpoint命令
 		return t.Execute(ctx, "Debugger.removeBreakpoint", map[string]any{
@@ -129,61 +142,73 @@ func (bs *BrowserServer) handleRemoveBreakpoint(ctx context.Context, request mcp
 	}))
 
 	if err != nil {
-		return mcp.NewToolResultError(fmt.Sprintf("failed to remove breakpoint: %s", err.Error())), nil
+		return comm.NewToolResultErrorFor(err, fmt.Sprintf("failed to remove breakpoint: %s", err.Error())), nil
 	}
 	return mcp.NewToolResultText(fmt.Sprintf("Breakpoint %s removed", breakpointID)), nil
 }
 
 // handlePause handles pausing the JavaScript execution in the browser.
 func (bs *BrowserServer) handlePause(ctx context.Context, request mcp.CallToolRequest) (*mcp.CallToolResult, error) {
-	rctx, cancel := context.WithCancel(bs.Context)
+	s, err := bs.session(ctx, request.GetArguments())
+	if err != nil {
+		return comm.NewToolResultErrorFor(err, err.Error()), nil
+	}
+	rctx, cancel := context.WithCancel(s.ctx)
 	defer cancel()
-	err := chromedp.Run(rctx, chromedp.ActionFunc(func(ctx context.Context) error {
+	err = chromedp.Run(rctx, chromedp.ActionFunc(func(ctx context.Context) error {
 		t := chromedp.FromContext(ctx).Target
 		// 使用Execute方法执行Debugger.pause命令
 		return t.Execute(ctx, "Debugger.pause", nil, nil)
 	}))
 
 	if err != nil {
-		return mcp.NewToolResultError(fmt.Sprintf("failed to pause execution: %s", err.Error())), nil
+		return comm.NewToolResultErrorFor(err, fmt.Sprintf("failed to pause execution: %s", err.Error())), nil
 	}
 	return mcp.NewToolResultText("JavaScript execution paused"), nil
 }
 
 // handleResume handles resuming the JavaScript execution in the browser.
 func (bs *BrowserServer) handleResume(ctx context.Context, request mcp.CallToolRequest) (*mcp.CallToolResult, error) {
-	rctx, cancel := context.WithCancel(bs.Context)
+	s, err := bs.session(ctx, request.GetArguments())
+	if err != nil {
+		return comm.NewToolResultErrorFor(err, err.Error()), nil
+	}
+	rctx, cancel := context.WithCancel(s.ctx)
 	defer cancel()
-	err := chromedp.Run(rctx, chromedp.ActionFunc(func(ctx context.Context) error {
+	err = chromedp.Run(rctx, chromedp.ActionFunc(func(ctx context.Context) error {
 		t := chromedp.FromContext(ctx).Target
 		// 使用Execute方法执行Debugger.resume命令
 		return t.Execute(ctx, "Debugger.resume", nil, nil)
 	}))
 
 	if err != nil {
-		return mcp.NewToolResultError(fmt.Sprintf("failed to resume execution: %s", err.Error())), nil
+		return comm.NewToolResultErrorFor(err, fmt.Sprintf("failed to resume execution: %s", err.Error())), nil
 	}
 	return mcp.NewToolResultText("JavaScript execution resumed"), nil
 }
 
 // handleStepOver handles stepping over the next line of JavaScript code in the browser.
 func (bs *BrowserServer) handleGetCallstack(ctx context.Context, request mcp.CallToolRequest) (*mcp.CallToolResult, error) {
+	s, err := bs.session(ctx, request.GetArguments())
+	if err != nil {
+		return comm.NewToolResultErrorFor(err, err.Error()), nil
+	}
 	var callstack any
-	rctx, cancel := context.WithCancel(bs.Context)
+	rctx, cancel := context.WithCancel(s.ctx)
 	defer cancel()
-	err := chromedp.Run(rctx, chromedp.ActionFunc(func(ctx context.Context) error {
+	err = chromedp.Run(rctx, chromedp.ActionFunc(func(ctx context.Context) error {
 		t := chromedp.FromContext(ctx).Target
 		// 使用Execute方法执行Debugger.getStackTrace命令
 		return t.Execute(ctx, "Debugger.getStackTrace", nil, &callstack)
 	}))
 
 	if err != nil {
-		return mcp.NewToolResultError(fmt.Sprintf("failed to get call stack: %s", err.Error())), nil
+		return comm.NewToolResultErrorFor(err, fmt.Sprintf("failed to get call stack: %s", err.Error())), nil
 	}
 
 	callstackJSON, err := json.Marshal(callstack)
 	if err != nil {
-		return mcp.NewToolResultError(fmt.Sprintf("failed to marshal call stack: %s", err.Error())), nil
+		return comm.NewToolResultErrorFor(err, fmt.Sprintf("failed to marshal call stack: %s", err.Error())), nil
 	}
 
 	return mcp.NewToolResultText(fmt.Sprintf("Current call stack: %s", string(callstackJSON))), nil
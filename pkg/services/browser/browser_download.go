@@ -0,0 +1,258 @@
+// Copyright 2025 CFC4N <cfc4n.cs@gmail.com>. All Rights Reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//   http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+//
+// Repository: https://github.com/gojue/moling
+
+package browser
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"mime"
+	"net/http"
+	"net/url"
+	"os"
+	"path"
+	"path/filepath"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/chromedp/cdproto/browser"
+	"github.com/chromedp/cdproto/network"
+	"github.com/chromedp/chromedp"
+	"github.com/mark3labs/mcp-go/mcp"
+
+	"github.com/gojue/moling/pkg/comm"
+)
+
+const (
+	DownloadDir  = "downloads" // DownloadDir is the directory under the browser data path the downloaded files are saved to.
+	maxDownloads = 100         // maxDownloads is the number of link-triggered downloads browser_download lists.
+)
+
+// download is a file downloaded by the page, e.g. after a click on a link.
+type download struct {
+	URL   string
+	Path  string // Path is the local file, set once the download is completed.
+	State browser.DownloadProgressState
+}
+
+// downloadManager keeps track of the downloads captured through the CDP download events.
+type downloadManager struct {
+	mu        sync.Mutex
+	dir       string
+	downloads map[string]*download // downloads by GUID
+	order     []string
+}
+
+func newDownloadManager(dir string) *downloadManager {
+	return &downloadManager{dir: dir, downloads: make(map[string]*download)}
+}
+
+// handleEvent records the download events of the browser. Chrome saves a download under its GUID,
+// it is renamed to its suggested file name when completed.
+func (dm *downloadManager) handleEvent(ev any) {
+	switch ev := ev.(type) {
+	case *browser.EventDownloadWillBegin:
+		dm.mu.Lock()
+		defer dm.mu.Unlock()
+		dm.downloads[ev.GUID] = &download{URL: ev.URL, Path: ev.SuggestedFilename, State: browser.DownloadProgressStateInProgress}
+		dm.order = append(dm.order, ev.GUID)
+		if len(dm.order) > maxDownloads {
+			delete(dm.downloads, dm.order[0])
+			dm.order = dm.order[1:]
+		}
+	case *browser.EventDownloadProgress:
+		if ev.State == browser.DownloadProgressStateInProgress {
+			return
+		}
+		dm.mu.Lock()
+		defer dm.mu.Unlock()
+		d, ok := dm.downloads[ev.GUID]
+		if !ok {
+			return
+		}
+		d.State = ev.State
+		if ev.State != browser.DownloadProgressStateCompleted {
+			d.Path = ""
+			return
+		}
+		f, err := createUnique(dm.dir, d.Path)
+		if err != nil {
+			d.Path = filepath.Join(dm.dir, ev.GUID)
+			return
+		}
+		_ = f.Close()
+		if err = os.Rename(filepath.Join(dm.dir, ev.GUID), f.Name()); err != nil {
+			_ = os.Remove(f.Name())
+			d.Path = filepath.Join(dm.dir, ev.GUID)
+			return
+		}
+		d.Path = f.Name()
+	}
+}
+
+// list returns the captured downloads, oldest first.
+func (dm *downloadManager) list() []download {
+	dm.mu.Lock()
+	defer dm.mu.Unlock()
+	list := make([]download, 0, len(dm.order))
+	for _, guid := range dm.order {
+		list = append(list, *dm.downloads[guid])
+	}
+	return list
+}
+
+// downloadBehavior makes the browser save the downloads triggered by the page into dir, and report them as events.
+// The behavior is set on the browser context of the page, the one of a session isn't the default one.
+func downloadBehavior(dir string) chromedp.Action {
+	return chromedp.ActionFunc(func(ctx context.Context) error {
+		return browser.SetDownloadBehavior(browser.SetDownloadBehaviorBehaviorAllowAndName).
+			WithDownloadPath(dir).
+			WithEventsEnabled(true).
+			WithBrowserContextID(chromedp.FromContext(ctx).BrowserContextID).
+			Do(ctx)
+	})
+}
+
+// handleDownload downloads a file by URL with the cookies of the browser, or lists the downloads triggered by the page.
+func (bs *BrowserServer) handleDownload(ctx context.Context, request mcp.CallToolRequest) (*mcp.CallToolResult, error) {
+	args := request.GetArguments()
+	s, err := bs.session(ctx, args)
+	if err != nil {
+		return comm.NewToolResultErrorFor(err, err.Error()), nil
+	}
+	rawURL, _ := args["url"].(string)
+	if rawURL == "" {
+		return mcp.NewToolResultText(formatDownloads(bs.downloads.list())), nil
+	}
+	name, _ := args["name"].(string)
+
+	var cookies []*network.Cookie
+	err = chromedp.Run(s.ctx, chromedp.ActionFunc(func(ctx context.Context) error {
+		var err error
+		cookies, err = network.GetCookies().WithURLs([]string{rawURL}).Do(ctx)
+		return err
+	}))
+	if err != nil {
+		return comm.NewToolResultErrorFor(err, fmt.Sprintf("failed to read the browser cookies: %s", err.Error())), nil
+	}
+	header := http.Header{}
+	header.Set("User-Agent", bs.config.UserAgent)
+	if len(cookies) > 0 {
+		pairs := make([]string, 0, len(cookies))
+		for _, c := range cookies {
+			pairs = append(pairs, c.Name+"="+c.Value)
+		}
+		header.Set("Cookie", strings.Join(pairs, "; "))
+	}
+
+	runCtx, cancelFunc := context.WithTimeout(ctx, time.Duration(bs.config.Timeout)*time.Second)
+	defer cancelFunc()
+	file, err := fetchFile(runCtx, rawURL, header, bs.config.DownloadPath, name)
+	if err != nil {
+		return comm.NewToolResultErrorFor(err, fmt.Sprintf("failed to download %s: %s", rawURL, err.Error())), nil
+	}
+	return mcp.NewToolResultText(fmt.Sprintf("Downloaded %s to:%s", rawURL, file)), nil
+}
+
+// fetchFile downloads the URL into dir and returns the path of the file. The file is named after name,
+// or else after the Content-Disposition header or the URL path.
+func fetchFile(ctx context.Context, rawURL string, header http.Header, dir, name string) (string, error) {
+	u, err := url.Parse(rawURL)
+	if err != nil {
+		return "", err
+	}
+	if u.Scheme != "http" && u.Scheme != "https" {
+		return "", fmt.Errorf("unsupported URL scheme: %s", u.Scheme)
+	}
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, rawURL, nil)
+	if err != nil {
+		return "", err
+	}
+	req.Header = header
+	resp, err := http.DefaultClient.Do(req)
+	if err != nil {
+		return "", err
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode != http.StatusOK {
+		return "", fmt.Errorf("unexpected status: %s", resp.Status)
+	}
+
+	if name == "" {
+		if _, params, err := mime.ParseMediaType(resp.Header.Get("Content-Disposition")); err == nil {
+			name = params["filename"]
+		}
+	}
+	if name == "" {
+		name = path.Base(u.Path)
+	}
+	f, err := createUnique(dir, name)
+	if err != nil {
+		return "", err
+	}
+	if _, err = io.Copy(f, resp.Body); err != nil {
+		_ = f.Close()
+		_ = os.Remove(f.Name())
+		return "", err
+	}
+	if err = f.Close(); err != nil {
+		_ = os.Remove(f.Name())
+		return "", err
+	}
+	return f.Name(), nil
+}
+
+// createUnique creates a new file in dir named after name, adding a counter when the name is taken,
+// e.g. report (1).pdf. Only the base name is used, so name cannot point outside dir.
+func createUnique(dir, name string) (*os.File, error) {
+	name = filepath.Base(filepath.FromSlash(strings.ReplaceAll(name, `\`, "/")))
+	if name == "." || name == ".." || name == string(filepath.Separator) {
+		name = "download"
+	}
+	ext := filepath.Ext(name)
+	stem := strings.TrimSuffix(name, ext)
+	for i := 0; ; i++ {
+		candidate := name
+		if i > 0 {
+			candidate = fmt.Sprintf("%s (%d)%s", stem, i, ext)
+		}
+		f, err := os.OpenFile(filepath.Join(dir, candidate), os.O_CREATE|os.O_EXCL|os.O_WRONLY, 0o644)
+		if errors.Is(err, os.ErrExist) {
+			continue
+		}
+		return f, err
+	}
+}
+
+// formatDownloads returns the list of downloads as the text of the browser_download result.
+func formatDownloads(list []download) string {
+	if len(list) == 0 {
+		return "No downloads, pass a url to download a file"
+	}
+	var sb strings.Builder
+	sb.WriteString(fmt.Sprintf("%d downloads, oldest first:\n", len(list)))
+	for _, d := range list {
+		if d.State == browser.DownloadProgressStateCompleted {
+			sb.WriteString(fmt.Sprintf("- %s %s, saved to:%s\n", d.State, d.URL, d.Path))
+		} else {
+			sb.WriteString(fmt.Sprintf("- %s %s\n", d.State, d.URL))
+		}
+	}
+	return sb.String()
+}
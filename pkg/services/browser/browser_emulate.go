@@ -0,0 +1,370 @@
+// Copyright 2025 CFC4N <cfc4n.cs@gmail.com>. All Rights Reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//   http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+//
+// Repository: https://github.com/gojue/moling
+
+package browser
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+
+	"github.com/chromedp/cdproto/browser"
+	"github.com/chromedp/cdproto/emulation"
+	"github.com/chromedp/chromedp"
+	"github.com/chromedp/chromedp/device"
+	"github.com/mark3labs/mcp-go/mcp"
+
+	"github.com/gojue/moling/pkg/comm"
+	"github.com/gojue/moling/pkg/utils"
+)
+
+// devices are the emulated devices of browser_emulate_device, by normalized name.
+var devices = func() map[string]device.Info {
+	m := make(map[string]device.Info)
+	for _, d := range []chromedp.Device{
+		device.IPhoneSE, device.IPhone12, device.IPhone13, device.IPhone14, device.IPhone14ProMax,
+		device.IPhone15, device.IPhone15Pro, device.IPhone15ProMax,
+		device.Pixel4, device.Pixel5, device.GalaxyS8, device.GalaxyS9,
+		device.IPad, device.IPadMini, device.IPadPro, device.IPadPro11, device.GalaxyTabS4,
+	} {
+		info := d.Device()
+		m[deviceKey(info.Name)] = info
+	}
+	return m
+}()
+
+// deviceKey normalizes a device name, e.g. "iPhone 15 Pro Max" and "iphone15promax" are the same device.
+func deviceKey(name string) string {
+	return strings.ToLower(strings.NewReplacer(" ", "", "-", "", "_", "").Replace(name))
+}
+
+// deviceNames returns the names of the emulated devices, sorted.
+func deviceNames() []string {
+	names := make([]string, 0, len(devices))
+	for _, d := range devices {
+		names = append(names, d.Name)
+	}
+	sort.Strings(names)
+	return names
+}
+
+// viewport is the emulated screen of the page.
+type viewport struct {
+	Device    string  `json:"device,omitempty"`
+	Width     int64   `json:"width"`
+	Height    int64   `json:"height"`
+	Scale     float64 `json:"scale"`
+	Mobile    bool    `json:"mobile"`
+	Touch     bool    `json:"touch"`
+	Landscape bool    `json:"landscape"`
+	UserAgent string  `json:"user_agent,omitempty"`
+}
+
+// newDeviceViewport returns the viewport of the device, in landscape with the width and height swapped.
+func newDeviceViewport(name string, landscape bool) (*viewport, error) {
+	info, ok := devices[deviceKey(name)]
+	if !ok {
+		return nil, comm.Errorf(comm.CodeInvalidArgument, "unknown device %s, must be one of %s", name, strings.Join(deviceNames(), ", "))
+	}
+	vp := &viewport{
+		Device:    info.Name,
+		Width:     info.Width,
+		Height:    info.Height,
+		Scale:     info.Scale,
+		Mobile:    info.Mobile,
+		Touch:     info.Touch,
+		UserAgent: info.UserAgent,
+	}
+	if landscape {
+		vp.Width, vp.Height, vp.Landscape = info.Height, info.Width, true
+	}
+	return vp, nil
+}
+
+// action returns the action that emulates the viewport, with the user agent if the viewport has none.
+func (vp *viewport) action(userAgent string) chromedp.Action {
+	if vp.UserAgent != "" {
+		userAgent = vp.UserAgent
+	}
+	return chromedp.Emulate(device.Info{
+		Name:      vp.Device,
+		UserAgent: userAgent,
+		Width:     vp.Width,
+		Height:    vp.Height,
+		Scale:     vp.Scale,
+		Landscape: vp.Landscape,
+		Mobile:    vp.Mobile,
+		Touch:     vp.Touch,
+	})
+}
+
+// geolocation is the emulated position of the device.
+type geolocation struct {
+	Latitude  float64 `json:"latitude"`
+	Longitude float64 `json:"longitude"`
+	Accuracy  float64 `json:"accuracy"` // Accuracy is in meters.
+}
+
+// parseGeolocation parses a position of the form latitude,longitude[,accuracy].
+func parseGeolocation(s string) (*geolocation, error) {
+	parts := utils.SplitTrim(s, ",")
+	if len(parts) != 2 && len(parts) != 3 {
+		return nil, comm.Errorf(comm.CodeInvalidArgument, "geolocation must be latitude,longitude[,accuracy], got %s", s)
+	}
+	values := []float64{0, 0, defaultAccuracy}
+	for i, part := range parts {
+		v, err := strconv.ParseFloat(part, 64)
+		if err != nil {
+			return nil, comm.Errorf(comm.CodeInvalidArgument, "geolocation must be latitude,longitude[,accuracy], got %s", s)
+		}
+		values[i] = v
+	}
+	geo := &geolocation{Latitude: values[0], Longitude: values[1], Accuracy: values[2]}
+	return geo, geo.check()
+}
+
+// check reports whether the position is on Earth.
+func (g *geolocation) check() error {
+	if g.Latitude < -90 || g.Latitude > 90 || g.Longitude < -180 || g.Longitude > 180 || g.Accuracy < 0 {
+		return comm.Errorf(comm.CodeInvalidArgument, "latitude must be in [-90, 90], longitude in [-180, 180] and accuracy not negative")
+	}
+	return nil
+}
+
+// defaultAccuracy is the accuracy of an emulated position in meters, when it is not set.
+const defaultAccuracy = 100
+
+// locale is the emulated locale, timezone and position of the page.
+type locale struct {
+	Locale      string       `json:"locale,omitempty"`   // Locale is used by Intl and sent as Accept-Language.
+	Timezone    string       `json:"timezone,omitempty"` // Timezone is an IANA timezone ID. e.g. Europe/Paris
+	Geolocation *geolocation `json:"geolocation,omitempty"`
+}
+
+// acceptLanguage returns the Accept-Language header of the locale, e.g. fr-FR,fr;q=0.9 for fr-FR.
+func acceptLanguage(locale string) string {
+	if lang, _, ok := strings.Cut(locale, "-"); ok {
+		return locale + "," + lang + ";q=0.9"
+	}
+	return locale
+}
+
+// action returns the action that applies the locale, with the user agent the Accept-Language is sent with.
+// The locale and the timezone are cleared before they are set, Chrome refuses to override them twice.
+func (l *locale) action(userAgent string) chromedp.Action {
+	var tasks chromedp.Tasks
+	if l.Locale != "" {
+		tasks = append(tasks,
+			emulation.SetLocaleOverride(),
+			emulation.SetLocaleOverride().WithLocale(l.Locale),
+			emulation.SetUserAgentOverride(userAgent).WithAcceptLanguage(acceptLanguage(l.Locale)),
+		)
+	}
+	if l.Timezone != "" {
+		tasks = append(tasks, emulation.SetTimezoneOverride(""), emulation.SetTimezoneOverride(l.Timezone))
+	}
+	if l.Geolocation != nil {
+		tasks = append(tasks,
+			browser.GrantPermissions([]browser.PermissionType{browser.PermissionTypeGeolocation}),
+			emulation.SetGeolocationOverride().
+				WithLatitude(l.Geolocation.Latitude).
+				WithLongitude(l.Geolocation.Longitude).
+				WithAccuracy(l.Geolocation.Accuracy),
+		)
+	}
+	return tasks
+}
+
+// resetLocale returns the action that stops the emulation of the locale, the timezone and the position.
+func resetLocale(userAgent string) chromedp.Action {
+	return chromedp.Tasks{
+		emulation.SetLocaleOverride(),
+		emulation.SetUserAgentOverride(userAgent),
+		emulation.SetTimezoneOverride(""),
+		emulation.ClearGeolocationOverride(),
+		browser.ResetPermissions(),
+	}
+}
+
+// pageEmulation holds the viewport and the locale emulated on the page, applied again on every navigation.
+type pageEmulation struct {
+	mu       sync.Mutex
+	viewport *viewport // viewport is nil when the window of the browser is not overridden.
+	locale   *locale   // locale is nil when the locale of the browser is not overridden.
+}
+
+// set replaces the emulated viewport, nil stops the emulation.
+func (e *pageEmulation) set(vp *viewport) {
+	e.mu.Lock()
+	defer e.mu.Unlock()
+	e.viewport = vp
+}
+
+// setLocale replaces the emulated locale, nil stops the emulation.
+func (e *pageEmulation) setLocale(l *locale) {
+	e.mu.Lock()
+	defer e.mu.Unlock()
+	e.locale = l
+}
+
+// userAgent returns the user agent of the emulated device, or userAgent.
+func (e *pageEmulation) userAgent(userAgent string) string {
+	e.mu.Lock()
+	defer e.mu.Unlock()
+	if e.viewport != nil && e.viewport.UserAgent != "" {
+		return e.viewport.UserAgent
+	}
+	return userAgent
+}
+
+// action returns the action that applies the emulated viewport and locale, if any.
+func (e *pageEmulation) action(userAgent string) chromedp.Action {
+	e.mu.Lock()
+	defer e.mu.Unlock()
+	var tasks chromedp.Tasks
+	if e.viewport != nil {
+		tasks = append(tasks, e.viewport.action(userAgent))
+		if e.viewport.UserAgent != "" {
+			userAgent = e.viewport.UserAgent
+		}
+	}
+	if e.locale != nil {
+		tasks = append(tasks, e.locale.action(userAgent))
+	}
+	return tasks
+}
+
+// handleEmulateDevice emulates a device or a custom viewport on the page, or resets it to the window of the browser.
+func (bs *BrowserServer) handleEmulateDevice(ctx context.Context, request mcp.CallToolRequest) (*mcp.CallToolResult, error) {
+	args := request.GetArguments()
+	s, err := bs.session(ctx, args)
+	if err != nil {
+		return comm.NewToolResultErrorFor(err, err.Error()), nil
+	}
+	if reset, _ := args["reset"].(bool); reset {
+		s.emulation.set(nil)
+		// The reset clears the user agent override, the one of an emulated locale is applied again.
+		if err := chromedp.Run(s.ctx, chromedp.EmulateReset(), s.emulation.action(bs.config.UserAgent)); err != nil {
+			return comm.NewToolResultErrorFor(err, fmt.Sprintf("failed to reset the viewport: %s", err.Error())), nil
+		}
+		return mcp.NewToolResultText("Viewport reset to the browser window"), nil
+	}
+
+	landscape, _ := args["landscape"].(bool)
+	vp := &viewport{
+		Width:     int64(bs.config.ViewportWidth),
+		Height:    int64(bs.config.ViewportHeight),
+		Scale:     bs.config.DeviceScaleFactor,
+		Landscape: landscape,
+	}
+	if name, _ := args["device"].(string); name != "" {
+		var err error
+		vp, err = newDeviceViewport(name, landscape)
+		if err != nil {
+			return comm.NewToolResultErrorFor(err, err.Error()), nil
+		}
+	}
+	if v, ok := args["width"].(float64); ok {
+		vp.Width = int64(v)
+	}
+	if v, ok := args["height"].(float64); ok {
+		vp.Height = int64(v)
+	}
+	if v, ok := args["scale"].(float64); ok {
+		vp.Scale = v
+	}
+	if v, ok := args["mobile"].(bool); ok {
+		vp.Mobile = v
+	}
+	if v, ok := args["touch"].(bool); ok {
+		vp.Touch = v
+	}
+	if v, ok := args["user_agent"].(string); ok && v != "" {
+		vp.UserAgent = v
+	}
+	if vp.Width <= 0 || vp.Height <= 0 || vp.Scale <= 0 {
+		return comm.NewToolResultError(comm.CodeInvalidArgument, "width, height and scale must be greater than 0"), nil
+	}
+
+	s.emulation.set(vp)
+	if err := chromedp.Run(s.ctx, s.emulation.action(bs.config.UserAgent)); err != nil {
+		return comm.NewToolResultErrorFor(err, fmt.Sprintf("failed to emulate the device: %s", err.Error())), nil
+	}
+	data, err := json.Marshal(vp)
+	if err != nil {
+		return comm.NewToolResultErrorFor(err, fmt.Sprintf("failed to marshal viewport: %s", err.Error())), nil
+	}
+	return mcp.NewToolResultText(fmt.Sprintf("Emulating %s", data)), nil
+}
+
+// handleEmulateLocale overrides the locale, the timezone or the position of the page, or resets them.
+// The arguments not passed are left unchanged.
+func (bs *BrowserServer) handleEmulateLocale(ctx context.Context, request mcp.CallToolRequest) (*mcp.CallToolResult, error) {
+	args := request.GetArguments()
+	s, err := bs.session(ctx, args)
+	if err != nil {
+		return comm.NewToolResultErrorFor(err, err.Error()), nil
+	}
+	if reset, _ := args["reset"].(bool); reset {
+		s.emulation.setLocale(nil)
+		if err := chromedp.Run(s.ctx, resetLocale(s.emulation.userAgent(bs.config.UserAgent))); err != nil {
+			return comm.NewToolResultErrorFor(err, fmt.Sprintf("failed to reset the locale: %s", err.Error())), nil
+		}
+		return mcp.NewToolResultText("Locale, timezone and geolocation reset to the ones of the browser"), nil
+	}
+
+	l := &locale{}
+	s.emulation.mu.Lock()
+	if s.emulation.locale != nil {
+		*l = *s.emulation.locale
+	}
+	s.emulation.mu.Unlock()
+	if v, ok := args["locale"].(string); ok {
+		l.Locale = v
+	}
+	if v, ok := args["timezone"].(string); ok {
+		l.Timezone = v
+	}
+	latitude, hasLatitude := args["latitude"].(float64)
+	longitude, hasLongitude := args["longitude"].(float64)
+	if hasLatitude != hasLongitude {
+		return comm.NewToolResultError(comm.CodeInvalidArgument, "latitude and longitude must be set together"), nil
+	}
+	if hasLatitude {
+		l.Geolocation = &geolocation{Latitude: latitude, Longitude: longitude, Accuracy: defaultAccuracy}
+		if v, ok := args["accuracy"].(float64); ok {
+			l.Geolocation.Accuracy = v
+		}
+		if err := l.Geolocation.check(); err != nil {
+			return comm.NewToolResultErrorFor(err, err.Error()), nil
+		}
+	}
+
+	userAgent := s.emulation.userAgent(bs.config.UserAgent)
+	if err := chromedp.Run(s.ctx, l.action(userAgent)); err != nil {
+		return comm.NewToolResultErrorFor(err, fmt.Sprintf("failed to emulate the locale: %s", err.Error())), nil
+	}
+	s.emulation.setLocale(l)
+	data, err := json.Marshal(l)
+	if err != nil {
+		return comm.NewToolResultErrorFor(err, fmt.Sprintf("failed to marshal locale: %s", err.Error())), nil
+	}
+	return mcp.NewToolResultText(fmt.Sprintf("Emulating %s", data)), nil
+}
@@ -0,0 +1,286 @@
+// Copyright 2025 CFC4N <cfc4n.cs@gmail.com>. All Rights Reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//   http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+//
+// Repository: https://github.com/gojue/moling
+
+package browser
+
+import (
+	"context"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/chromedp/cdproto/cdp"
+	"github.com/chromedp/chromedp"
+	"github.com/mark3labs/mcp-go/mcp"
+
+	"github.com/gojue/moling/pkg/comm"
+)
+
+// Formats of browser_extract_content.
+const (
+	ContentMarkdown = "markdown"
+	ContentText     = "text"
+)
+
+// extractSystemPrompt is the system prompt of the sampling of browser_extract_content with instructions.
+const extractSystemPrompt = "You process the content of a web page, given after the instructions of the user. Follow the instructions, answer from the content only, and say so if it doesn't hold the answer."
+
+// extractSampleMaxTokens is the length of the answer of the sampling of browser_extract_content.
+const extractSampleMaxTokens = 2048
+
+// defaultMaxHTMLLength is the number of characters browser_get_html and browser_get_text return at most by default.
+const defaultMaxHTMLLength = 50000
+
+// extractFunction finds the main content of the document, like readability: the paragraphs score their
+// ancestors by the text they hold, the best ancestor with few links wins. It is converted to Markdown or text.
+const extractFunction = `function(format) {
+	const doc = this.ownerDocument;
+	const md = format === 'markdown';
+	const attr = (sel, name) => {
+		const el = doc.querySelector(sel);
+		return el ? (el.getAttribute(name) || el.textContent || '').trim() : '';
+	};
+	const title = attr('meta[property="og:title"]', 'content') || doc.title.trim();
+	const byline = attr('meta[name="author"]', 'content') || attr('[rel="author"], [itemprop="author"], .byline', 'content');
+	const skip = 'script, style, noscript, template, iframe, svg, canvas, form, button, input, select, textarea, nav, footer, aside, [hidden], [aria-hidden="true"], [role="navigation"], [role="banner"], [role="contentinfo"], [role="complementary"]';
+	const unlikely = /comment|footer|sidebar|sponsor|promo|related|share|social|popup|cookie|banner|menu|\bnav|\bads?\b/i;
+
+	const scores = new Map();
+	for (const p of doc.body.querySelectorAll('p, pre, td, blockquote')) {
+		const text = p.textContent.trim();
+		if (text.length < 25 || p.closest(skip)) continue;
+		const score = 1 + text.split(',').length + Math.min(Math.floor(text.length / 100), 3);
+		let node = p.parentElement;
+		for (let level = 0; node && node !== doc.documentElement && level < 3; level++, node = node.parentElement) {
+			scores.set(node, (scores.get(node) || 0) + score / (level === 0 ? 1 : level * 2));
+		}
+	}
+	let root = null, best = 0;
+	for (const [node, score] of scores) {
+		const text = node.textContent.length || 1;
+		const links = [...node.querySelectorAll('a')].reduce((n, a) => n + a.textContent.length, 0);
+		const names = (node.id || '') + ' ' + (node.getAttribute('class') || '');
+		const s = score * (1 - links / text) * (unlikely.test(names) ? 0.3 : 1);
+		if (s > best) {
+			root = node;
+			best = s;
+		}
+	}
+	root = root || doc.querySelector('article, main, [role="main"]') || doc.body;
+
+	const walk = (node, list) => {
+		if (node.nodeType === Node.TEXT_NODE) return node.textContent.replace(/\s+/g, ' ');
+		if (node.nodeType !== Node.ELEMENT_NODE || node.matches(skip)) return '';
+		const children = (l) => [...node.childNodes].map((c) => walk(c, l)).join('');
+		const tag = node.tagName.toLowerCase();
+		switch (tag) {
+		case 'h1': case 'h2': case 'h3': case 'h4': case 'h5': case 'h6':
+			return '\n\n' + (md ? '#'.repeat(+tag[1]) + ' ' : '') + children().trim() + '\n\n';
+		case 'p': case 'section': case 'article': case 'main': case 'header': case 'figure':
+			return '\n\n' + children() + '\n\n';
+		case 'div': case 'figcaption': case 'dt': case 'dd':
+			return '\n' + children() + '\n';
+		case 'br':
+			return '\n';
+		case 'hr':
+			return md ? '\n\n---\n\n' : '\n\n';
+		case 'pre':
+			return md ? '\n\n` + "```" + `\n' + node.textContent.replace(/\n$/, '') + '\n` + "```" + `\n\n' : '\n\n' + node.textContent + '\n\n';
+		case 'code':
+			return md ? '` + "`" + `' + node.textContent + '` + "`" + `' : node.textContent;
+		case 'strong': case 'b': {
+			const t = children();
+			return md && t.trim() ? '**' + t.trim() + '**' : t;
+		}
+		case 'em': case 'i': {
+			const t = children();
+			return md && t.trim() ? '*' + t.trim() + '*' : t;
+		}
+		case 'a': {
+			const t = children().trim();
+			const href = node.href || '';
+			return md && t && href && !href.startsWith('javascript:') ? '[' + t + '](' + href + ')' : t;
+		}
+		case 'img': {
+			const alt = (node.getAttribute('alt') || '').trim();
+			return md && node.src ? '![' + alt + '](' + node.src + ')' : alt;
+		}
+		case 'ul': case 'ol':
+			return '\n' + children({ordered: tag === 'ol', index: 0}) + '\n';
+		case 'li': {
+			const prefix = list && list.ordered ? (++list.index) + '. ' : '- ';
+			return '\n' + prefix + children().trim().replace(/\n+/g, '\n  ');
+		}
+		case 'blockquote':
+			return '\n\n' + children().trim().split('\n').map((l) => (md ? '> ' : '  ') + l).join('\n') + '\n\n';
+		case 'table': {
+			const rows = [...node.querySelectorAll('tr')].map((tr) =>
+				[...tr.children].map((c) => walk(c).replace(/\s+/g, ' ').trim().replace(/\|/g, '\\|')));
+			if (!rows.length) return '';
+			if (!md) return '\n\n' + rows.map((r) => r.join('\t')).join('\n') + '\n\n';
+			const head = '| ' + rows[0].join(' | ') + ' |\n|' + rows[0].map(() => ' --- |').join('') + '\n';
+			return '\n\n' + head + rows.slice(1).map((r) => '| ' + r.join(' | ') + ' |').join('\n') + '\n\n';
+		}
+		default:
+			return children(list);
+		}
+	};
+	const content = walk(root).split('\n').map((l) => l.replace(/\s+$/, '')).join('\n').replace(/\n{3,}/g, '\n\n').trim();
+	return {title: title, byline: byline, url: doc.location.href, content: content};
+}`
+
+// extractedContent is the main content of a page returned by extractFunction.
+type extractedContent struct {
+	Title   string `json:"title"`
+	Byline  string `json:"byline"`
+	URL     string `json:"url"`
+	Content string `json:"content"`
+}
+
+// format returns the content as Markdown or text, with its title, byline and URL, truncated to maxLength characters if set.
+func (c extractedContent) format(format string, maxLength int) string {
+	content := truncate(c.Content, maxLength)
+	var sb strings.Builder
+	if format == ContentMarkdown {
+		if c.Title != "" {
+			sb.WriteString("# " + c.Title + "\n\n")
+		}
+		if c.Byline != "" {
+			sb.WriteString("*By " + c.Byline + "*\n\n")
+		}
+		sb.WriteString("Source: " + c.URL + "\n\n")
+	} else {
+		if c.Title != "" {
+			sb.WriteString(c.Title + "\n")
+		}
+		if c.Byline != "" {
+			sb.WriteString("By " + c.Byline + "\n")
+		}
+		sb.WriteString("Source: " + c.URL + "\n\n")
+	}
+	sb.WriteString(content)
+	return sb.String()
+}
+
+// truncate cuts s to maxLength characters, with an indicator of the truncation. A maxLength of 0 is unlimited.
+func truncate(s string, maxLength int) string {
+	if runes := []rune(s); maxLength > 0 && len(runes) > maxLength {
+		return string(runes[:maxLength]) + fmt.Sprintf("\n\n[truncated, %d of %d characters]", maxLength, len(runes))
+	}
+	return s
+}
+
+// extractContent returns the action that extracts the main content of the page into res,
+// of the document of an iframe with the options of inFrame.
+func extractContent(format string, res *extractedContent, opts ...chromedp.QueryOption) chromedp.Action {
+	var nodes []*cdp.Node
+	return chromedp.Tasks{
+		chromedp.Nodes("html", &nodes, append([]chromedp.QueryOption{chromedp.ByQuery}, opts...)...),
+		chromedp.ActionFunc(func(ctx context.Context) error {
+			if len(nodes) == 0 {
+				return fmt.Errorf("the page has no document")
+			}
+			return callOnNode(ctx, nodes[0], extractFunction, res, format)
+		}),
+	}
+}
+
+// handleExtractContent returns the readable main content of the current page, instead of its raw HTML.
+func (bs *BrowserServer) handleExtractContent(ctx context.Context, request mcp.CallToolRequest) (*mcp.CallToolResult, error) {
+	args := request.GetArguments()
+	s, err := bs.session(ctx, args)
+	if err != nil {
+		return comm.NewToolResultErrorFor(err, err.Error()), nil
+	}
+	format, _ := args["format"].(string)
+	if format == "" {
+		format = ContentMarkdown
+	}
+	if format != ContentMarkdown && format != ContentText {
+		return comm.NewToolResultError(comm.CodeInvalidArgument, fmt.Sprintf("format must be %s or %s, got %s", ContentMarkdown, ContentText, format)), nil
+	}
+	maxLength := 0
+	if v, ok := args["max_length"].(float64); ok && v > 0 {
+		maxLength = int(v)
+	}
+
+	var content extractedContent
+	runCtx, cancelFunc := context.WithTimeout(s.ctx, time.Duration(bs.config.SelectorQueryTimeout)*time.Second)
+	defer cancelFunc()
+	frame, err := findFrame(runCtx, args)
+	if err != nil {
+		return comm.NewToolResultErrorFor(err, err.Error()), nil
+	}
+	if err := chromedp.Run(runCtx, extractContent(format, &content, inFrame(frame)...)); err != nil {
+		return comm.NewToolResultErrorFor(err, fmt.Sprintf("failed to extract content: %s", err.Error())), nil
+	}
+	text := content.format(format, maxLength)
+	if instructions, _ := args["instructions"].(string); instructions != "" {
+		answer, err := bs.Sample(ctx, extractSystemPrompt, instructions+"\n\n"+text, extractSampleMaxTokens)
+		if err != nil {
+			return comm.NewToolResultErrorFor(err, fmt.Sprintf("failed to process the content with the instructions: %s", err.Error())), nil
+		}
+		return mcp.NewToolResultText(answer), nil
+	}
+	return mcp.NewToolResultText(text), nil
+}
+
+// handleGetHTML returns the outerHTML of the page or of the element matching the selector.
+func (bs *BrowserServer) handleGetHTML(ctx context.Context, request mcp.CallToolRequest) (*mcp.CallToolResult, error) {
+	return bs.pageContent(ctx, request, "html", func(selector string, res *string, opts ...chromedp.QueryOption) chromedp.Action {
+		return chromedp.OuterHTML(selector, res, append([]chromedp.QueryOption{chromedp.ByQuery}, opts...)...)
+	})
+}
+
+// handleGetText returns the innerText of the page or of the visible element matching the selector.
+func (bs *BrowserServer) handleGetText(ctx context.Context, request mcp.CallToolRequest) (*mcp.CallToolResult, error) {
+	return bs.pageContent(ctx, request, "body", func(selector string, res *string, opts ...chromedp.QueryOption) chromedp.Action {
+		return chromedp.Text(selector, res, querySelector(opts...)...)
+	})
+}
+
+// pageContent runs the action of browser_get_html or browser_get_text on the selector, the whole page
+// selected by defaultSelector if it is not set, in the frame argument, and truncates the result to max_length.
+func (bs *BrowserServer) pageContent(ctx context.Context, request mcp.CallToolRequest, defaultSelector string, action func(selector string, res *string, opts ...chromedp.QueryOption) chromedp.Action) (*mcp.CallToolResult, error) {
+	args := request.GetArguments()
+	s, err := bs.session(ctx, args)
+	if err != nil {
+		return comm.NewToolResultErrorFor(err, err.Error()), nil
+	}
+	selector, _ := args["selector"].(string)
+	if selector == "" {
+		selector = defaultSelector
+	}
+	maxLength := defaultMaxHTMLLength
+	if v, ok := args["max_length"].(float64); ok {
+		if v < 0 {
+			return comm.NewToolResultError(comm.CodeInvalidArgument, "max_length must not be negative"), nil
+		}
+		maxLength = int(v)
+	}
+
+	var res string
+	runCtx, cancelFunc := context.WithTimeout(s.ctx, time.Duration(bs.config.SelectorQueryTimeout)*time.Second)
+	defer cancelFunc()
+	frame, err := findFrame(runCtx, args)
+	if err != nil {
+		return comm.NewToolResultErrorFor(err, err.Error()), nil
+	}
+	if err := chromedp.Run(runCtx, action(selector, &res, inFrame(frame)...)); err != nil {
+		return comm.NewToolResultErrorFor(err, fmt.Sprintf("failed to get the content of %s: %s", selector, err.Error())), nil
+	}
+	return mcp.NewToolResultText(truncate(res, maxLength)), nil
+}
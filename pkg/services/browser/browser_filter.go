@@ -0,0 +1,152 @@
+// Copyright 2025 CFC4N <cfc4n.cs@gmail.com>. All Rights Reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//   http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+//
+// Repository: https://github.com/gojue/moling
+
+package browser
+
+import (
+	"bufio"
+	"fmt"
+	"io"
+	"net"
+	"net/url"
+	"os"
+	"strings"
+
+	"github.com/chromedp/cdproto/network"
+)
+
+// blockableResourceTypes are the resource types blocked_resource_types accepts, by lower-case name.
+// The documents can't be blocked, the page wouldn't load.
+var blockableResourceTypes = map[string]network.ResourceType{
+	"stylesheet":  network.ResourceTypeStylesheet,
+	"image":       network.ResourceTypeImage,
+	"media":       network.ResourceTypeMedia,
+	"font":        network.ResourceTypeFont,
+	"script":      network.ResourceTypeScript,
+	"texttrack":   network.ResourceTypeTextTrack,
+	"xhr":         network.ResourceTypeXHR,
+	"fetch":       network.ResourceTypeFetch,
+	"prefetch":    network.ResourceTypePrefetch,
+	"eventsource": network.ResourceTypeEventSource,
+	"websocket":   network.ResourceTypeWebSocket,
+	"manifest":    network.ResourceTypeManifest,
+	"ping":        network.ResourceTypePing,
+	"other":       network.ResourceTypeOther,
+}
+
+// requestFilter blocks the requests of resource types, or matching the rules of filter lists.
+// It is read-only once parsed, and shared by the sessions.
+type requestFilter struct {
+	types    map[network.ResourceType]bool
+	domains  map[string]bool // domains are blocked with their subdomains.
+	patterns []string        // patterns are URL patterns, '*' matches zero or more characters.
+}
+
+// newRequestFilter returns the filter of the resource types and of the rules of the filter list files,
+// or nil if there is nothing to block.
+func newRequestFilter(types, files []string) (*requestFilter, error) {
+	f := &requestFilter{types: make(map[network.ResourceType]bool), domains: make(map[string]bool)}
+	for _, name := range types {
+		rt, ok := blockableResourceTypes[strings.ToLower(name)]
+		if !ok {
+			return nil, fmt.Errorf("unknown resource type %s, e.g. image, media, font or stylesheet", name)
+		}
+		f.types[rt] = true
+	}
+	for _, file := range files {
+		fp, err := os.Open(file)
+		if err != nil {
+			return nil, fmt.Errorf("failed to open filter list: %w", err)
+		}
+		err = f.parse(fp)
+		_ = fp.Close()
+		if err != nil {
+			return nil, fmt.Errorf("failed to read filter list %s: %w", file, err)
+		}
+	}
+	if len(f.types) == 0 && len(f.domains) == 0 && len(f.patterns) == 0 {
+		return nil, nil
+	}
+	return f, nil
+}
+
+// parse adds the rules of a filter list: hosts file lines, e.g. "0.0.0.0 ads.example.com", the domain rules
+// of EasyList, e.g. "||ads.example.com^", and URL patterns, e.g. "/banner/*.gif". Comments, element hiding
+// and exception rules are skipped, and the options after '$' are ignored.
+func (f *requestFilter) parse(r io.Reader) error {
+	scanner := bufio.NewScanner(r)
+	for scanner.Scan() {
+		line := strings.TrimSpace(scanner.Text())
+		if line == "" || line[0] == '!' || line[0] == '#' || line[0] == '[' || strings.HasPrefix(line, "@@") ||
+			strings.Contains(line, "##") || strings.Contains(line, "#@#") || strings.Contains(line, "#?#") {
+			continue
+		}
+		if fields := strings.Fields(line); len(fields) >= 2 && net.ParseIP(fields[0]) != nil {
+			// A hosts file line, the localhost entries of the file aren't blocked.
+			for _, host := range fields[1:] {
+				if host[0] == '#' {
+					break
+				}
+				if host != "localhost" && !strings.HasPrefix(host, "localhost.") {
+					f.domains[strings.ToLower(host)] = true
+				}
+			}
+			continue
+		}
+		rule, _, _ := strings.Cut(line, "$")
+		if domain, ok := strings.CutPrefix(rule, "||"); ok {
+			if end := strings.IndexAny(domain, "^/"); end < 0 || domain[end:] == "^" {
+				f.domains[strings.ToLower(strings.TrimSuffix(domain, "^"))] = true
+				continue
+			}
+			// A rule of a path of the domain, e.g. ||example.com/ads/*, of any scheme.
+			f.patterns = append(f.patterns, "*://"+strings.ReplaceAll(domain, "^", "*")+"*")
+			continue
+		}
+		if rule = strings.Trim(strings.ReplaceAll(rule, "^", "*"), "|"); rule != "" {
+			// The rules match anywhere in the URL, like a substring.
+			f.patterns = append(f.patterns, "*"+strings.Trim(rule, "*")+"*")
+		}
+	}
+	return scanner.Err()
+}
+
+// blocks reports whether the request of the URL and the resource type is blocked.
+func (f *requestFilter) blocks(rawURL string, resourceType network.ResourceType) bool {
+	if f.types[resourceType] {
+		return true
+	}
+	if len(f.domains) > 0 {
+		if u, err := url.Parse(rawURL); err == nil {
+			for host := strings.ToLower(u.Hostname()); host != ""; {
+				if f.domains[host] {
+					return true
+				}
+				_, parent, ok := strings.Cut(host, ".")
+				if !ok {
+					break
+				}
+				host = parent
+			}
+		}
+	}
+	for _, pattern := range f.patterns {
+		if matchURLPattern(pattern, rawURL) {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,89 @@
+// Copyright 2025 CFC4N <cfc4n.cs@gmail.com>. All Rights Reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//   http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+//
+// Repository: https://github.com/gojue/moling
+
+package browser
+
+import (
+	"context"
+	"fmt"
+	"strconv"
+
+	"github.com/chromedp/cdproto/cdp"
+	"github.com/chromedp/chromedp"
+	"github.com/mark3labs/mcp-go/mcp"
+)
+
+// frameDescription is the description of the frame argument of the element tools.
+const frameDescription = "iframe to run in (optional, default: the main frame): its index among the iframes of the page starting at 0, its name or id, or a URL pattern of its src where '*' matches any characters"
+
+// withFrame returns the frame argument of the element tools.
+func withFrame() mcp.ToolOption {
+	return mcp.WithString("frame", mcp.Description(frameDescription))
+}
+
+// matchFrame returns the frame of the nodes matching frame: its index, its name or id, or a URL pattern of
+// its document or its src, in this order.
+func matchFrame(nodes []*cdp.Node, frame string) (*cdp.Node, error) {
+	if i, err := strconv.Atoi(frame); err == nil {
+		if i < 0 || i >= len(nodes) {
+			return nil, fmt.Errorf("frame index %d out of range, the page has %d iframes", i, len(nodes))
+		}
+		return nodes[i], nil
+	}
+	for _, n := range nodes {
+		if n.AttributeValue("name") == frame || n.AttributeValue("id") == frame {
+			return n, nil
+		}
+	}
+	for _, n := range nodes {
+		if n.ContentDocument != nil && matchURLPattern(frame, n.ContentDocument.DocumentURL) {
+			return n, nil
+		}
+		if src := n.AttributeValue("src"); src != "" && matchURLPattern(frame, src) {
+			return n, nil
+		}
+	}
+	return nil, fmt.Errorf("no iframe matches %s", frame)
+}
+
+// findFrame returns the iframe selected by the frame argument, nil for the main frame.
+func findFrame(ctx context.Context, args map[string]any) (*cdp.Node, error) {
+	frame, _ := args["frame"].(string)
+	if frame == "" {
+		return nil, nil
+	}
+	var nodes []*cdp.Node
+	if err := chromedp.Run(ctx, chromedp.Nodes("iframe, frame", &nodes, chromedp.ByQueryAll, chromedp.AtLeast(0))); err != nil {
+		return nil, fmt.Errorf("failed to list the iframes: %w", err)
+	}
+	node, err := matchFrame(nodes, frame)
+	if err != nil {
+		return nil, err
+	}
+	// A cross-origin iframe may be rendered by another process, its document is not part of the DOM of the page then.
+	if node.ContentDocument == nil {
+		return nil, fmt.Errorf("the document of iframe %s is not available, it may be cross-origin and isolated in another process", frame)
+	}
+	return node, nil
+}
+
+// inFrame returns the query options that run a query in the document of the frame, none for the main frame.
+func inFrame(frame *cdp.Node) []chromedp.QueryOption {
+	if frame == nil {
+		return nil
+	}
+	return []chromedp.QueryOption{chromedp.FromNode(frame)}
+}
@@ -0,0 +1,249 @@
+// Copyright 2025 CFC4N <cfc4n.cs@gmail.com>. All Rights Reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//   http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+//
+// Repository: https://github.com/gojue/moling
+
+package browser
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"os"
+	"sync"
+	"time"
+
+	"github.com/chromedp/cdproto/browser"
+	"github.com/chromedp/cdproto/runtime"
+	"github.com/chromedp/chromedp"
+	"github.com/mark3labs/mcp-go/mcp"
+
+	"github.com/gojue/moling/pkg/comm"
+)
+
+const (
+	minRelaunchDelay = time.Second // minRelaunchDelay is the wait after the first failed relaunch, doubled after each failure.
+	maxRelaunchDelay = time.Minute
+)
+
+// browserHealth is the state of the recovery of the browser, when its process died or the connection to it was lost.
+type browserHealth struct {
+	mu        sync.Mutex // mu guards the relaunch, and the contexts of the browser while it is replaced.
+	closed    bool
+	restarts  int
+	failures  int // failures is the number of relaunches that failed in a row.
+	nextRetry time.Time
+	lastCrash time.Time
+	lastError error
+}
+
+// relaunchDelay returns the wait before the next relaunch after failures failed relaunches in a row.
+func relaunchDelay(failures int) time.Duration {
+	if failures <= 0 {
+		return 0
+	}
+	if failures > 6 {
+		return maxRelaunchDelay
+	}
+	return min(minRelaunchDelay<<(failures-1), maxRelaunchDelay)
+}
+
+// browserLost reports whether the browser was started and its process died or the connection to it was lost.
+func (bs *BrowserServer) browserLost() bool {
+	if bs.Context == nil {
+		return false
+	}
+	if bs.Context.Err() != nil {
+		return true
+	}
+	c := chromedp.FromContext(bs.Context)
+	if c == nil || c.Browser == nil {
+		// The browser starts with the first tool that runs.
+		return false
+	}
+	select {
+	case <-c.Browser.LostConnection:
+		return true
+	default:
+		return false
+	}
+}
+
+// ensureBrowser relaunches the browser if it was lost, with the same profile, and returns the default session.
+// The relaunches that fail are retried after an exponential backoff, the calls in between fail fast.
+func (bs *BrowserServer) ensureBrowser() (*browserSession, error) {
+	bs.health.mu.Lock()
+	defer bs.health.mu.Unlock()
+	if bs.health.closed {
+		return nil, fmt.Errorf("the browser server is closed")
+	}
+	if !bs.browserLost() {
+		return bs.main, nil
+	}
+	if wait := time.Until(bs.health.nextRetry); wait > 0 {
+		return nil, fmt.Errorf("the browser was lost and failed to relaunch: %v, next attempt in %s", bs.health.lastError, wait.Round(time.Second))
+	}
+	if bs.health.failures == 0 {
+		bs.health.lastCrash = time.Now()
+		bs.Logger.Warn().Msg("Browser lost, relaunching it")
+	}
+
+	// The pages of the sessions were lost with the browser.
+	bs.sessions.mu.Lock()
+	for id, s := range bs.sessions.sessions {
+		s.cancel()
+		delete(bs.sessions.sessions, id)
+	}
+	bs.sessions.mu.Unlock()
+	bs.cancelChrome()
+	bs.cancelAlloc()
+	if bs.config.RemoteDebuggingURL == "" {
+		if err := bs.initBrowser(bs.config.BrowserDataPath); err != nil {
+			bs.Logger.Warn().Err(err).Msg("failed to prepare the user data directory")
+		}
+	}
+	bs.launch()
+	if err := chromedp.Run(bs.Context); err != nil {
+		bs.health.failures++
+		bs.health.lastError = err
+		bs.health.nextRetry = time.Now().Add(relaunchDelay(bs.health.failures))
+		bs.Logger.Error().Err(err).Int("failures", bs.health.failures).Msg("failed to relaunch the browser")
+		return nil, fmt.Errorf("the browser was lost and failed to relaunch: %w", err)
+	}
+	bs.health.restarts++
+	bs.health.failures, bs.health.lastError, bs.health.nextRetry = 0, nil, time.Time{}
+	bs.Logger.Info().Int("restarts", bs.health.restarts).Msg("Browser relaunched")
+	return bs.main, nil
+}
+
+// Health checks the browser is running, or not started yet, and the data directory is accessible.
+// It doesn't relaunch a lost browser, the next tool call does.
+func (bs *BrowserServer) Health(ctx context.Context) map[string]error {
+	checks := make(map[string]error, 2)
+	bs.health.mu.Lock()
+	switch {
+	case bs.health.closed:
+		checks["browser"] = fmt.Errorf("the browser server is closed")
+	case bs.health.failures > 0:
+		checks["browser"] = fmt.Errorf("the browser was lost and failed to relaunch %d times: %v", bs.health.failures, bs.health.lastError)
+	case bs.browserLost():
+		checks["browser"] = fmt.Errorf("the browser was lost, the next tool call relaunches it")
+	default:
+		checks["browser"] = nil
+	}
+	bs.health.mu.Unlock()
+	if info, err := os.Stat(bs.config.DataPath); err != nil {
+		checks["data_path"] = err
+	} else if !info.IsDir() {
+		checks["data_path"] = fmt.Errorf("%s is not a directory", bs.config.DataPath)
+	} else {
+		checks["data_path"] = nil
+	}
+	return checks
+}
+
+// pageStatus is the page and the memory of the JavaScript heap of a session.
+type pageStatus struct {
+	Session     string  `json:"session_id,omitempty"`
+	URL         string  `json:"url"`
+	JSHeapUsed  float64 `json:"js_heap_used"`
+	JSHeapTotal float64 `json:"js_heap_total"`
+}
+
+// browserStatus is the result of browser_status.
+type browserStatus struct {
+	Alive     bool         `json:"alive"`
+	Remote    bool         `json:"remote"`
+	Product   string       `json:"product,omitempty"`
+	Protocol  string       `json:"protocol,omitempty"`
+	UserAgent string       `json:"user_agent,omitempty"`
+	Tabs      int          `json:"tabs"`
+	Pages     []pageStatus `json:"pages,omitempty"`
+	Restarts  int          `json:"restarts"`
+	LastCrash *time.Time   `json:"last_crash,omitempty"`
+	Error     string       `json:"error,omitempty"`
+}
+
+// handleStatus reports whether the browser is alive, relaunching it if it was lost, its version, the number
+// of open tabs and the JavaScript heap of the page of each session.
+func (bs *BrowserServer) handleStatus(ctx context.Context, request mcp.CallToolRequest) (*mcp.CallToolResult, error) {
+	status := browserStatus{Remote: bs.config.RemoteDebuggingURL != ""}
+	main, err := bs.ensureBrowser()
+	bs.health.mu.Lock()
+	status.Restarts = bs.health.restarts
+	if !bs.health.lastCrash.IsZero() {
+		lastCrash := bs.health.lastCrash
+		status.LastCrash = &lastCrash
+	}
+	bs.health.mu.Unlock()
+	if err == nil {
+		err = bs.pageStatus(main, &status)
+	}
+	if err != nil {
+		status.Error = err.Error()
+	} else {
+		status.Alive = true
+	}
+	data, err := json.Marshal(status)
+	if err != nil {
+		return comm.NewToolResultErrorFor(err, fmt.Sprintf("failed to marshal status: %s", err.Error())), nil
+	}
+	return mcp.NewToolResultText(string(data)), nil
+}
+
+// pageStatus fills the version of the browser, the number of its tabs and the pages of the sessions into status.
+func (bs *BrowserServer) pageStatus(main *browserSession, status *browserStatus) error {
+	runCtx, cancelFunc := context.WithTimeout(main.ctx, time.Duration(bs.config.SelectorQueryTimeout)*time.Second)
+	defer cancelFunc()
+	err := chromedp.Run(runCtx, chromedp.ActionFunc(func(ctx context.Context) error {
+		protocol, product, _, userAgent, _, err := browser.GetVersion().Do(ctx)
+		status.Protocol, status.Product, status.UserAgent = protocol, product, userAgent
+		return err
+	}))
+	if err != nil {
+		return fmt.Errorf("the browser doesn't answer: %w", err)
+	}
+	targets, err := chromedp.Targets(runCtx)
+	if err != nil {
+		return fmt.Errorf("failed to list the tabs: %w", err)
+	}
+	for _, t := range targets {
+		if t.Type == "page" {
+			status.Tabs++
+		}
+	}
+
+	sessions := []*browserSession{main}
+	bs.sessions.mu.Lock()
+	for _, s := range bs.sessions.sessions {
+		sessions = append(sessions, s)
+	}
+	bs.sessions.mu.Unlock()
+	for _, s := range sessions {
+		page := pageStatus{Session: s.id}
+		pageCtx, cancel := context.WithTimeout(s.ctx, time.Duration(bs.config.SelectorQueryTimeout)*time.Second)
+		err = chromedp.Run(pageCtx, chromedp.Location(&page.URL), chromedp.ActionFunc(func(ctx context.Context) error {
+			var err error
+			page.JSHeapUsed, page.JSHeapTotal, _, _, err = runtime.GetHeapUsage().Do(ctx)
+			return err
+		}))
+		cancel()
+		if err != nil {
+			bs.Logger.Debug().Err(err).Str("session", s.id).Msg("failed to get the status of the page")
+			continue
+		}
+		status.Pages = append(status.Pages, page)
+	}
+	return nil
+}
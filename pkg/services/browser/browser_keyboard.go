@@ -0,0 +1,185 @@
+// Copyright 2025 CFC4N <cfc4n.cs@gmail.com>. All Rights Reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//   http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+//
+// Repository: https://github.com/gojue/moling
+
+package browser
+
+import (
+	"context"
+	"fmt"
+	"strings"
+	"time"
+	"unicode/utf8"
+
+	"github.com/chromedp/cdproto/input"
+	"github.com/chromedp/chromedp"
+	"github.com/chromedp/chromedp/kb"
+	"github.com/mark3labs/mcp-go/mcp"
+
+	"github.com/gojue/moling/pkg/comm"
+)
+
+// maxKeyDelay is the longest delay between two keystrokes, so a call can't hang the browser.
+const maxKeyDelay = 5 * time.Second
+
+// modifierKeys are the modifiers of a key combination, by lower-case name.
+var modifierKeys = map[string]input.Modifier{
+	"ctrl":    input.ModifierCtrl,
+	"control": input.ModifierCtrl,
+	"alt":     input.ModifierAlt,
+	"option":  input.ModifierAlt,
+	"shift":   input.ModifierShift,
+	"meta":    input.ModifierMeta,
+	"cmd":     input.ModifierMeta,
+	"command": input.ModifierMeta,
+}
+
+// namedKeys are the special keys of a key combination, by lower-case name.
+var namedKeys = map[string]string{
+	"enter":      kb.Enter,
+	"return":     kb.Enter,
+	"tab":        kb.Tab,
+	"escape":     kb.Escape,
+	"esc":        kb.Escape,
+	"backspace":  kb.Backspace,
+	"delete":     kb.Delete,
+	"insert":     kb.Insert,
+	"space":      " ",
+	"plus":       "+",
+	"arrowup":    kb.ArrowUp,
+	"up":         kb.ArrowUp,
+	"arrowdown":  kb.ArrowDown,
+	"down":       kb.ArrowDown,
+	"arrowleft":  kb.ArrowLeft,
+	"left":       kb.ArrowLeft,
+	"arrowright": kb.ArrowRight,
+	"right":      kb.ArrowRight,
+	"home":       kb.Home,
+	"end":        kb.End,
+	"pageup":     kb.PageUp,
+	"pagedown":   kb.PageDown,
+	"f1":         kb.F1,
+	"f2":         kb.F2,
+	"f3":         kb.F3,
+	"f4":         kb.F4,
+	"f5":         kb.F5,
+	"f6":         kb.F6,
+	"f7":         kb.F7,
+	"f8":         kb.F8,
+	"f9":         kb.F9,
+	"f10":        kb.F10,
+	"f11":        kb.F11,
+	"f12":        kb.F12,
+}
+
+// keyCombo is a key pressed with modifiers, e.g. Ctrl+A.
+type keyCombo struct {
+	key       string
+	modifiers []input.Modifier
+}
+
+// parseKeys parses key combinations separated by spaces, e.g. "Ctrl+A Backspace Enter". The key is the
+// last part of a combination, a single character or the name of a special key; the others are modifiers.
+func parseKeys(keys string) ([]keyCombo, error) {
+	var combos []keyCombo
+	for _, field := range strings.Fields(keys) {
+		parts := strings.Split(field, "+")
+		name := parts[len(parts)-1]
+		var combo keyCombo
+		if key, ok := namedKeys[strings.ToLower(name)]; ok {
+			combo.key = key
+		} else if utf8.RuneCountInString(name) == 1 {
+			combo.key = name
+		} else {
+			return nil, fmt.Errorf("unknown key %q in %s", name, field)
+		}
+		for _, part := range parts[:len(parts)-1] {
+			modifier, ok := modifierKeys[strings.ToLower(part)]
+			if !ok {
+				return nil, comm.Errorf(comm.CodeInvalidArgument, "unknown modifier %q in %s, must be Ctrl, Alt, Shift or Meta", part, field)
+			}
+			combo.modifiers = append(combo.modifiers, modifier)
+		}
+		// A letter pressed with a modifier is the lower-case key, Shift makes it upper-case.
+		if len(combo.modifiers) > 0 && utf8.RuneCountInString(combo.key) == 1 && combo.key != strings.ToLower(combo.key) {
+			combo.key = strings.ToLower(combo.key)
+		}
+		combos = append(combos, combo)
+	}
+	return combos, nil
+}
+
+// pressKeys returns the action that types the text, then presses the key combinations, waiting delay between two keystrokes.
+func pressKeys(text string, combos []keyCombo, delay time.Duration) chromedp.Action {
+	var tasks chromedp.Tasks
+	keystroke := func(action chromedp.Action) {
+		if delay > 0 && len(tasks) > 0 {
+			tasks = append(tasks, chromedp.Sleep(delay))
+		}
+		tasks = append(tasks, action)
+	}
+	if delay > 0 {
+		for _, r := range text {
+			keystroke(chromedp.KeyEvent(string(r)))
+		}
+	} else if text != "" {
+		keystroke(chromedp.KeyEvent(text))
+	}
+	for _, combo := range combos {
+		keystroke(chromedp.KeyEvent(combo.key, chromedp.KeyModifiers(combo.modifiers...)))
+	}
+	return tasks
+}
+
+// handlePressKeys types text and presses key combinations, in the element matching the selector if it is set,
+// otherwise in the focused element of the page.
+func (bs *BrowserServer) handlePressKeys(ctx context.Context, request mcp.CallToolRequest) (*mcp.CallToolResult, error) {
+	args := request.GetArguments()
+	s, err := bs.session(ctx, args)
+	if err != nil {
+		return comm.NewToolResultErrorFor(err, err.Error()), nil
+	}
+	keys, _ := args["keys"].(string)
+	text, _ := args["text"].(string)
+	if keys == "" && text == "" {
+		return comm.NewToolResultError(comm.CodeInvalidArgument, "keys or text is required"), nil
+	}
+	combos, err := parseKeys(keys)
+	if err != nil {
+		return comm.NewToolResultErrorFor(err, err.Error()), nil
+	}
+	var delay time.Duration
+	if v, ok := args["delay_ms"].(float64); ok && v > 0 {
+		delay = min(time.Duration(v)*time.Millisecond, maxKeyDelay)
+	}
+
+	// The timeout covers the delays between the keystrokes.
+	timeout := time.Duration(bs.config.SelectorQueryTimeout)*time.Second + time.Duration(utf8.RuneCountInString(text)+len(combos))*delay
+	runCtx, cancelFunc := context.WithTimeout(s.ctx, timeout)
+	defer cancelFunc()
+	var actions []chromedp.Action
+	if selector, _ := args["selector"].(string); selector != "" {
+		frame, err := findFrame(runCtx, args)
+		if err != nil {
+			return comm.NewToolResultErrorFor(err, err.Error()), nil
+		}
+		actions = append(actions, chromedp.Focus(selector, querySelector(inFrame(frame)...)...))
+	}
+	actions = append(actions, pressKeys(text, combos, delay))
+	if err = chromedp.Run(runCtx, actions...); err != nil {
+		return comm.NewToolResultErrorFor(err, fmt.Sprintf("failed to press keys: %s", err.Error())), nil
+	}
+	return mcp.NewToolResultText(fmt.Sprintf("Typed %d characters and pressed %d keys", utf8.RuneCountInString(text), len(combos))), nil
+}
@@ -0,0 +1,134 @@
+// Copyright 2025 CFC4N <cfc4n.cs@gmail.com>. All Rights Reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//   http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+//
+// Repository: https://github.com/gojue/moling
+
+package browser
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/chromedp/cdproto/cdp"
+	"github.com/chromedp/chromedp"
+	"github.com/mark3labs/mcp-go/mcp"
+
+	"github.com/gojue/moling/pkg/comm"
+)
+
+const (
+	defaultQueryLimit = 20  // defaultQueryLimit is the number of matching elements browser_query describes by default.
+	maxQueryText      = 200 // maxQueryText is the number of characters of the text of an element browser_query returns.
+)
+
+// describeFunction returns the visible text, the box in the viewport and the visibility of the element.
+const describeFunction = `function() {
+	const r = this.getBoundingClientRect(), style = window.getComputedStyle(this);
+	return {
+		text: (this.innerText || this.textContent || '').replace(/\s+/g, ' ').trim(),
+		box: {x: r.left, y: r.top, width: r.width, height: r.height},
+		visible: r.width > 0 && r.height > 0 && style.visibility !== 'hidden' && style.display !== 'none',
+	};
+}`
+
+// elementBox is the position and size of an element in the viewport, in CSS pixels.
+type elementBox struct {
+	X      float64 `json:"x"`
+	Y      float64 `json:"y"`
+	Width  float64 `json:"width"`
+	Height float64 `json:"height"`
+}
+
+// elementInfo describes an element matching the selector of browser_query.
+type elementInfo struct {
+	Tag        string            `json:"tag"`
+	Attributes map[string]string `json:"attributes,omitempty"`
+	Text       string            `json:"text"`
+	Box        elementBox        `json:"box"`
+	Visible    bool              `json:"visible"`
+}
+
+// queryResult is the result of browser_query.
+type queryResult struct {
+	Exists   bool          `json:"exists"`
+	Count    int           `json:"count"`
+	Elements []elementInfo `json:"elements"`
+}
+
+// queryElements returns the action that describes the first limit elements matching the selector into res,
+// without waiting for them to appear.
+func queryElements(selector string, limit int, res *queryResult, opts ...chromedp.QueryOption) chromedp.Action {
+	var nodes []*cdp.Node
+	return chromedp.Tasks{
+		chromedp.Nodes(selector, &nodes, append([]chromedp.QueryOption{chromedp.ByQueryAll, chromedp.AtLeast(0)}, opts...)...),
+		chromedp.ActionFunc(func(ctx context.Context) error {
+			res.Count, res.Exists = len(nodes), len(nodes) > 0
+			res.Elements = make([]elementInfo, 0, min(len(nodes), limit))
+			for _, node := range nodes[:min(len(nodes), limit)] {
+				info := elementInfo{Tag: strings.ToLower(node.NodeName)}
+				if len(node.Attributes) > 0 {
+					info.Attributes = make(map[string]string, len(node.Attributes)/2)
+					for i := 0; i+1 < len(node.Attributes); i += 2 {
+						info.Attributes[node.Attributes[i]] = node.Attributes[i+1]
+					}
+				}
+				if err := callOnNode(ctx, node, describeFunction, &info); err != nil {
+					return err
+				}
+				if runes := []rune(info.Text); len(runes) > maxQueryText {
+					info.Text = string(runes[:maxQueryText]) + "..."
+				}
+				res.Elements = append(res.Elements, info)
+			}
+			return nil
+		}),
+	}
+}
+
+// handleQuery returns whether the selector matches, how many elements match, and the attributes,
+// visible text and bounding box of each of them.
+func (bs *BrowserServer) handleQuery(ctx context.Context, request mcp.CallToolRequest) (*mcp.CallToolResult, error) {
+	args := request.GetArguments()
+	s, err := bs.session(ctx, args)
+	if err != nil {
+		return comm.NewToolResultErrorFor(err, err.Error()), nil
+	}
+	selector, _ := args["selector"].(string)
+	if selector == "" {
+		return comm.NewToolResultError(comm.CodeInvalidArgument, "selector must be a non-empty string"), nil
+	}
+	limit := defaultQueryLimit
+	if v, ok := args["limit"].(float64); ok && v > 0 {
+		limit = int(v)
+	}
+
+	var res queryResult
+	runCtx, cancelFunc := context.WithTimeout(s.ctx, time.Duration(bs.config.SelectorQueryTimeout)*time.Second)
+	defer cancelFunc()
+	frame, err := findFrame(runCtx, args)
+	if err != nil {
+		return comm.NewToolResultErrorFor(err, err.Error()), nil
+	}
+	if err = chromedp.Run(runCtx, queryElements(selector, limit, &res, inFrame(frame)...)); err != nil {
+		return comm.NewToolResultErrorFor(err, fmt.Sprintf("failed to query %s: %s", selector, err.Error())), nil
+	}
+	data, err := json.Marshal(res)
+	if err != nil {
+		return comm.NewToolResultErrorFor(err, fmt.Sprintf("failed to marshal elements: %s", err.Error())), nil
+	}
+	return mcp.NewToolResultText(string(data)), nil
+}
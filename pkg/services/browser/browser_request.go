@@ -0,0 +1,297 @@
+// Copyright 2025 CFC4N <cfc4n.cs@gmail.com>. All Rights Reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//   http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+//
+// Repository: https://github.com/gojue/moling
+
+package browser
+
+import (
+	"context"
+	"fmt"
+	"maps"
+	"net/url"
+	"strings"
+	"sync"
+
+	"github.com/chromedp/cdproto/fetch"
+	"github.com/chromedp/cdproto/network"
+	"github.com/chromedp/chromedp"
+	"github.com/mark3labs/mcp-go/mcp"
+
+	"github.com/gojue/moling/pkg/comm"
+	"github.com/gojue/moling/pkg/utils"
+)
+
+// maxAuthAttempts is the number of requests whose credentials were sent that are remembered, to cancel
+// the authentication instead of retrying it forever when the credentials are wrong.
+const maxAuthAttempts = 1024
+
+// requestPolicy holds the extra headers, the basic-auth credentials and the blocked URL patterns
+// applied to the requests of the page, and the domains the page may navigate to.
+type requestPolicy struct {
+	mu             sync.Mutex
+	headers        map[string]string
+	username       string
+	password       string
+	blocked        []string
+	allowedDomains []string
+	blockedDomains []string
+	filter         *requestFilter
+	proxyUsername  string
+	proxyPassword  string
+	attempts       map[authAttempt]bool // attempts are the challenges of the requests the credentials were sent for.
+}
+
+// authAttempt is an authentication challenge of a request, of the proxy or of the server.
+type authAttempt struct {
+	id    fetch.RequestID
+	proxy bool
+}
+
+// set replaces the policy.
+func (rp *requestPolicy) set(headers map[string]string, username, password string, blocked []string) {
+	rp.mu.Lock()
+	defer rp.mu.Unlock()
+	rp.headers = maps.Clone(headers)
+	rp.username = username
+	rp.password = password
+	rp.blocked = blocked
+}
+
+// setDomains replaces the domain patterns of the navigation, an empty allowed list allows any domain.
+func (rp *requestPolicy) setDomains(allowed, blocked []string) {
+	rp.mu.Lock()
+	defer rp.mu.Unlock()
+	rp.allowedDomains = allowed
+	rp.blockedDomains = blocked
+}
+
+// checkNavigation returns an error if the page may not navigate to the URL: its domain is blocked, or
+// not allowed when there are allowed domains. about:blank is always allowed, the other URLs without a host,
+// e.g. file: or data:, only when there are no allowed domains.
+func (rp *requestPolicy) checkNavigation(rawURL string) error {
+	rp.mu.Lock()
+	defer rp.mu.Unlock()
+	if len(rp.allowedDomains) == 0 && len(rp.blockedDomains) == 0 {
+		return nil
+	}
+	u, err := url.Parse(rawURL)
+	if err != nil {
+		return comm.Errorf(comm.CodeInvalidArgument, "invalid URL %s: %w", rawURL, err)
+	}
+	host := strings.ToLower(u.Hostname())
+	if host == "" {
+		if u.Scheme == "about" || len(rp.allowedDomains) == 0 {
+			return nil
+		}
+		return comm.Errorf(comm.CodePolicyDenied, "navigation to %s is not allowed, only to the allowed domains", rawURL)
+	}
+	for _, pattern := range rp.blockedDomains {
+		if matchDomain(pattern, host) {
+			return comm.Errorf(comm.CodePolicyDenied, "navigation to %s is not allowed, the domain %s is blocked", rawURL, host)
+		}
+	}
+	if len(rp.allowedDomains) == 0 {
+		return nil
+	}
+	for _, pattern := range rp.allowedDomains {
+		if matchDomain(pattern, host) {
+			return nil
+		}
+	}
+	return comm.Errorf(comm.CodePolicyDenied, "navigation to %s is not allowed, the domain %s is not in allowed_domains", rawURL, host)
+}
+
+// setProxyAuth replaces the credentials answered to the authentication challenges of the proxy.
+func (rp *requestPolicy) setProxyAuth(username, password string) {
+	rp.mu.Lock()
+	defer rp.mu.Unlock()
+	rp.proxyUsername = username
+	rp.proxyPassword = password
+}
+
+// setFilter replaces the filter of the resource types and of the filter lists, nil blocks nothing.
+func (rp *requestPolicy) setFilter(filter *requestFilter) {
+	rp.mu.Lock()
+	defer rp.mu.Unlock()
+	rp.filter = filter
+}
+
+// isBlocked reports whether the URL matches one of the blocked URL patterns, or the request of the resource type
+// is blocked by the filter.
+func (rp *requestPolicy) isBlocked(url string, resourceType network.ResourceType) bool {
+	rp.mu.Lock()
+	defer rp.mu.Unlock()
+	for _, pattern := range rp.blocked {
+		if matchURLPattern(pattern, url) {
+			return true
+		}
+	}
+	return rp.filter != nil && rp.filter.blocks(url, resourceType)
+}
+
+// action returns the action that applies the policy to the page: the extra headers are set by
+// Network.setExtraHTTPHeaders, the requests are intercepted by Fetch only for basic-auth, proxy auth, blocked URLs, domains or filters.
+func (rp *requestPolicy) action() chromedp.Action {
+	rp.mu.Lock()
+	defer rp.mu.Unlock()
+	headers := make(network.Headers, len(rp.headers))
+	for name, value := range rp.headers {
+		headers[name] = value
+	}
+	intercept := rp.username != "" || rp.proxyUsername != "" || len(rp.blocked) > 0 || len(rp.allowedDomains) > 0 || len(rp.blockedDomains) > 0 || rp.filter != nil
+	return chromedp.ActionFunc(func(ctx context.Context) error {
+		if err := network.SetExtraHTTPHeaders(headers).Do(ctx); err != nil {
+			return err
+		}
+		if !intercept {
+			return fetch.Disable().Do(ctx)
+		}
+		return fetch.Enable().
+			WithPatterns([]*fetch.RequestPattern{{URLPattern: "*"}}).
+			WithHandleAuthRequests(true).
+			Do(ctx)
+	})
+}
+
+// authResponse returns the answer to an authentication challenge of the request, of the proxy or of the server:
+// the credentials the first time, the cancellation when they were already refused.
+func (rp *requestPolicy) authResponse(id fetch.RequestID, source fetch.AuthChallengeSource) *fetch.AuthChallengeResponse {
+	rp.mu.Lock()
+	defer rp.mu.Unlock()
+	attempt := authAttempt{id: id, proxy: source == fetch.AuthChallengeSourceProxy}
+	username, password := rp.username, rp.password
+	if attempt.proxy {
+		username, password = rp.proxyUsername, rp.proxyPassword
+	}
+	if username == "" {
+		return &fetch.AuthChallengeResponse{Response: fetch.AuthChallengeResponseResponseDefault}
+	}
+	if rp.attempts[attempt] {
+		return &fetch.AuthChallengeResponse{Response: fetch.AuthChallengeResponseResponseCancelAuth}
+	}
+	if rp.attempts == nil || len(rp.attempts) >= maxAuthAttempts {
+		rp.attempts = make(map[authAttempt]bool)
+	}
+	rp.attempts[attempt] = true
+	return &fetch.AuthChallengeResponse{
+		Response: fetch.AuthChallengeResponseResponseProvideCredentials,
+		Username: username,
+		Password: password,
+	}
+}
+
+// handleFetchEvent answers the requests paused by the Fetch interception of the page of the session.
+// The answers are sent from a goroutine, a listener must not block on CDP calls.
+func (bs *BrowserServer) handleFetchEvent(s *browserSession, ev any) {
+	switch ev := ev.(type) {
+	case *fetch.EventRequestPaused:
+		go func() {
+			var action chromedp.Action = fetch.ContinueRequest(ev.RequestID)
+			if s.requests.isBlocked(ev.Request.URL, ev.ResourceType) {
+				action = fetch.FailRequest(ev.RequestID, network.ErrorReasonBlockedByClient)
+			} else if ev.ResourceType == network.ResourceTypeDocument {
+				// The documents are the navigations of the page and of its frames, the redirects included.
+				if err := s.requests.checkNavigation(ev.Request.URL); err != nil {
+					bs.Logger.Info().Str("url", ev.Request.URL).Msg("Blocked the navigation out of the allowed domains")
+					action = fetch.FailRequest(ev.RequestID, network.ErrorReasonBlockedByClient)
+				}
+			}
+			if err := chromedp.Run(s.ctx, action); err != nil {
+				bs.Logger.Debug().Err(err).Str("url", ev.Request.URL).Msg("failed to continue the request")
+			}
+		}()
+	case *fetch.EventAuthRequired:
+		go func() {
+			if err := chromedp.Run(s.ctx, fetch.ContinueWithAuth(ev.RequestID, s.requests.authResponse(ev.RequestID, ev.AuthChallenge.Source))); err != nil {
+				bs.Logger.Debug().Err(err).Str("url", ev.Request.URL).Msg("failed to answer the authentication")
+			}
+		}()
+	}
+}
+
+// handleSetHeaders replaces the extra headers, the basic-auth credentials or the blocked URL patterns.
+// The arguments not passed are left unchanged.
+func (bs *BrowserServer) handleSetHeaders(ctx context.Context, request mcp.CallToolRequest) (*mcp.CallToolResult, error) {
+	args := request.GetArguments()
+	s, err := bs.session(ctx, args)
+	if err != nil {
+		return comm.NewToolResultErrorFor(err, err.Error()), nil
+	}
+	s.requests.mu.Lock()
+	headers, username, password, blocked := s.requests.headers, s.requests.username, s.requests.password, s.requests.blocked
+	s.requests.mu.Unlock()
+
+	if v, ok := args["headers"]; ok {
+		values, ok := v.(map[string]any)
+		if !ok {
+			return comm.NewToolResultError(comm.CodeInvalidArgument, "headers must be an object of header names to values"), nil
+		}
+		headers = make(map[string]string, len(values))
+		for name, value := range values {
+			str, ok := value.(string)
+			if !ok {
+				return comm.NewToolResultError(comm.CodeInvalidArgument, fmt.Sprintf("value of header %s must be a string", name)), nil
+			}
+			headers[name] = str
+		}
+	}
+	if v, ok := args["username"].(string); ok {
+		username = v
+	}
+	if v, ok := args["password"].(string); ok {
+		password = v
+	}
+	if v, ok := args["blocked_urls"].(string); ok {
+		blocked = utils.SplitTrim(v, ",")
+	}
+	s.requests.set(headers, username, password, blocked)
+
+	if err := chromedp.Run(s.ctx, s.requests.action()); err != nil {
+		return comm.NewToolResultErrorFor(err, fmt.Sprintf("failed to apply the request settings: %s", err.Error())), nil
+	}
+	return mcp.NewToolResultText(fmt.Sprintf("Applied %d extra headers, basic-auth %t and %d blocked URL patterns", len(headers), username != "", len(blocked))), nil
+}
+
+// matchDomain reports whether the host matches the domain pattern: a domain matches itself and its
+// subdomains, e.g. example.com matches www.example.com, and '*' matches zero or more characters.
+func matchDomain(pattern, host string) bool {
+	pattern = strings.ToLower(strings.TrimPrefix(pattern, "."))
+	if strings.Contains(pattern, "*") {
+		return matchURLPattern(pattern, host)
+	}
+	return host == pattern || strings.HasSuffix(host, "."+pattern)
+}
+
+// matchURLPattern reports whether the URL matches the pattern, where '*' matches zero or more characters,
+// like the URL patterns of Chrome.
+func matchURLPattern(pattern, url string) bool {
+	parts := strings.Split(pattern, "*")
+	if len(parts) == 1 {
+		return pattern == url
+	}
+	if !strings.HasPrefix(url, parts[0]) {
+		return false
+	}
+	url = url[len(parts[0]):]
+	last := parts[len(parts)-1]
+	for _, part := range parts[1 : len(parts)-1] {
+		i := strings.Index(url, part)
+		if i < 0 {
+			return false
+		}
+		url = url[i+len(part):]
+	}
+	return strings.HasSuffix(url, last)
+}
@@ -0,0 +1,151 @@
+// Copyright 2025 CFC4N <cfc4n.cs@gmail.com>. All Rights Reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//   http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+//
+// Repository: https://github.com/gojue/moling
+
+package browser
+
+import (
+	"context"
+	"fmt"
+	"math"
+
+	"github.com/chromedp/cdproto/cdp"
+	"github.com/chromedp/cdproto/page"
+	"github.com/chromedp/chromedp"
+
+	"github.com/gojue/moling/pkg/comm"
+)
+
+// defaultScreenshotQuality is the compression quality of the jpeg and webp screenshots.
+const defaultScreenshotQuality = 90
+
+// clientRectFunction returns the position and size of the element in its document, as a page.Viewport.
+const clientRectFunction = `function() {
+	const e = this.getBoundingClientRect(), d = this.ownerDocument.documentElement.getBoundingClientRect();
+	return {x: e.left - d.left, y: e.top - d.top, width: e.width, height: e.height};
+}`
+
+// frameOffsetFunction returns the position of the content of the iframe in its document, inside its border.
+const frameOffsetFunction = `function() {
+	const e = this.getBoundingClientRect(), d = this.ownerDocument.documentElement.getBoundingClientRect();
+	return {x: e.left - d.left + this.clientLeft, y: e.top - d.top + this.clientTop};
+}`
+
+// viewportRectFunction returns the position and size of the element in the viewport of its frame, as a page.Viewport.
+const viewportRectFunction = `function() {
+	const e = this.getBoundingClientRect();
+	return {x: e.left, y: e.top, width: e.width, height: e.height};
+}`
+
+// screenshotOptions are the arguments of browser_screenshot.
+type screenshotOptions struct {
+	selector string    // selector captures one element, full_page is ignored then.
+	frame    *cdp.Node // frame is the iframe the selector is queried in, nil for the main frame.
+	fullPage bool
+	format   page.CaptureScreenshotFormat
+	quality  int64
+}
+
+// parseScreenshotOptions reads the screenshot options from the tool arguments.
+func parseScreenshotOptions(args map[string]any) (screenshotOptions, error) {
+	opts := screenshotOptions{format: page.CaptureScreenshotFormatPng, quality: defaultScreenshotQuality}
+	opts.selector, _ = args["selector"].(string)
+	opts.fullPage, _ = args["full_page"].(bool)
+	if format, ok := args["format"].(string); ok && format != "" {
+		switch page.CaptureScreenshotFormat(format) {
+		case page.CaptureScreenshotFormatPng, page.CaptureScreenshotFormatJpeg, page.CaptureScreenshotFormatWebp:
+			opts.format = page.CaptureScreenshotFormat(format)
+		default:
+			return opts, comm.Errorf(comm.CodeInvalidArgument, "format must be png, jpeg or webp, got %s", format)
+		}
+	}
+	if quality, ok := args["quality"].(float64); ok {
+		if quality < 0 || quality > 100 {
+			return opts, comm.Errorf(comm.CodeInvalidArgument, "quality must be between 0 and 100, got %v", quality)
+		}
+		opts.quality = int64(quality)
+	}
+	return opts, nil
+}
+
+// ext returns the file extension of the screenshot.
+func (o screenshotOptions) ext() string {
+	if o.format == page.CaptureScreenshotFormatJpeg {
+		return ".jpg"
+	}
+	return "." + string(o.format)
+}
+
+// params returns the CaptureScreenshot parameters of the format, quality and page size.
+func (o screenshotOptions) params() *page.CaptureScreenshotParams {
+	p := page.CaptureScreenshot().WithFormat(o.format).WithFromSurface(true)
+	if o.format != page.CaptureScreenshotFormatPng {
+		p = p.WithQuality(o.quality)
+	}
+	if o.fullPage {
+		p = p.WithCaptureBeyondViewport(true)
+	}
+	return p
+}
+
+// action returns the action that takes the screenshot into buf: the element matching the selector,
+// the full page or the viewport.
+func (o screenshotOptions) action(buf *[]byte) chromedp.Action {
+	if o.selector != "" {
+		return screenshotSelector(o.selector, o.frame, o.params(), buf)
+	}
+	return chromedp.ActionFunc(func(ctx context.Context) error {
+		var err error
+		*buf, err = o.params().Do(ctx)
+		return err
+	})
+}
+
+// screenshotSelector takes a screenshot of the element matching the selector, clipped to its box.
+// In an iframe, the box is the one in the viewport of the iframe, moved by the position of the iframe in the page.
+func screenshotSelector(selector string, frame *cdp.Node, params *page.CaptureScreenshotParams, buf *[]byte) chromedp.Action {
+	var nodes []*cdp.Node
+	return chromedp.Tasks{
+		chromedp.Nodes(selector, &nodes, append(querySelector(inFrame(frame)...), chromedp.AtLeast(1))...),
+		chromedp.ActionFunc(func(ctx context.Context) error {
+			if len(nodes) == 0 {
+				return fmt.Errorf("no element matches selector %s", selector)
+			}
+			var clip page.Viewport
+			if frame == nil {
+				if err := callOnNode(ctx, nodes[0], clientRectFunction, &clip); err != nil {
+					return err
+				}
+			} else {
+				var offset page.Viewport
+				if err := callOnNode(ctx, frame, frameOffsetFunction, &offset); err != nil {
+					return err
+				}
+				if err := callOnNode(ctx, nodes[0], viewportRectFunction, &clip); err != nil {
+					return err
+				}
+				clip.X += offset.X
+				clip.Y += offset.Y
+			}
+			// CaptureScreenshot does not handle fractional clips, round them like chromedp.Screenshot does.
+			x, y := math.Round(clip.X), math.Round(clip.Y)
+			clip.Width, clip.Height = math.Round(clip.Width+clip.X-x), math.Round(clip.Height+clip.Y-y)
+			clip.X, clip.Y, clip.Scale = x, y, 1
+			var err error
+			*buf, err = params.WithCaptureBeyondViewport(true).WithClip(&clip).Do(ctx)
+			return err
+		}),
+	}
+}
@@ -0,0 +1,141 @@
+// Copyright 2025 CFC4N <cfc4n.cs@gmail.com>. All Rights Reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//   http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+//
+// Repository: https://github.com/gojue/moling
+
+package browser
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"github.com/chromedp/chromedp"
+	"github.com/mark3labs/mcp-go/mcp"
+
+	"github.com/gojue/moling/pkg/comm"
+)
+
+// Modes of browser_scroll.
+const (
+	ScrollElement = "element" // ScrollElement scrolls the element matching the selector into view.
+	ScrollBy      = "by"      // ScrollBy scrolls the page by x and y pixels.
+	ScrollBottom  = "bottom"  // ScrollBottom scrolls to the bottom until no new content is loaded.
+)
+
+const (
+	defaultScrollIterations = 10   // defaultScrollIterations is the number of scrolls to the bottom by default.
+	maxScrollIterations     = 50   // maxScrollIterations bounds the scrolls to the bottom of an endless feed.
+	defaultScrollWaitMs     = 1000 // defaultScrollWaitMs is the time given to the page to load new content after a scroll.
+	maxScrollWaitMs         = 10000
+)
+
+// scrollPosition is the scroll position and the size of the page.
+type scrollPosition struct {
+	X      float64 `json:"x"`
+	Y      float64 `json:"y"`
+	Height float64 `json:"height"`
+}
+
+// scrollPositionScript returns the scroll position and the height of the page.
+const scrollPositionScript = `({x: window.scrollX, y: window.scrollY, height: document.scrollingElement.scrollHeight})`
+
+// scrollToBottom returns the action that scrolls to the bottom of the page until its height stops growing,
+// at most iterations times, waiting wait after each scroll for the new content. The scrolls are counted in n.
+func scrollToBottom(iterations int, wait time.Duration, n *int) chromedp.Action {
+	return chromedp.ActionFunc(func(ctx context.Context) error {
+		var height float64
+		if err := chromedp.Evaluate(`document.scrollingElement.scrollHeight`, &height).Do(ctx); err != nil {
+			return err
+		}
+		for *n = 0; *n < iterations; {
+			if err := chromedp.Evaluate(`window.scrollTo(0, document.scrollingElement.scrollHeight)`, nil).Do(ctx); err != nil {
+				return err
+			}
+			*n++
+			if err := chromedp.Sleep(wait).Do(ctx); err != nil {
+				return err
+			}
+			var next float64
+			if err := chromedp.Evaluate(`document.scrollingElement.scrollHeight`, &next).Do(ctx); err != nil {
+				return err
+			}
+			if next <= height {
+				return nil
+			}
+			height = next
+		}
+		return nil
+	})
+}
+
+// handleScroll scrolls an element into view, scrolls the page by pixels, or scrolls to the bottom
+// of an infinite-scroll page until no new content is loaded.
+func (bs *BrowserServer) handleScroll(ctx context.Context, request mcp.CallToolRequest) (*mcp.CallToolResult, error) {
+	args := request.GetArguments()
+	s, err := bs.session(ctx, args)
+	if err != nil {
+		return comm.NewToolResultErrorFor(err, err.Error()), nil
+	}
+	mode, _ := args["mode"].(string)
+	selector, _ := args["selector"].(string)
+	timeout := time.Duration(bs.config.SelectorQueryTimeout) * time.Second
+	var action chromedp.Action
+	var scrolls int
+	switch mode {
+	case ScrollElement:
+		if selector == "" {
+			return comm.NewToolResultError(comm.CodeInvalidArgument, fmt.Sprintf("selector is required for the %s mode", mode)), nil
+		}
+	case ScrollBy:
+		x, _ := args["x"].(float64)
+		y, _ := args["y"].(float64)
+		if x == 0 && y == 0 {
+			return comm.NewToolResultError(comm.CodeInvalidArgument, fmt.Sprintf("x or y is required for the %s mode", mode)), nil
+		}
+		action = chromedp.Evaluate(fmt.Sprintf(`window.scrollBy(%d, %d)`, int64(x), int64(y)), nil)
+	case ScrollBottom:
+		iterations := defaultScrollIterations
+		if v, ok := args["max_iterations"].(float64); ok && v > 0 {
+			iterations = min(int(v), maxScrollIterations)
+		}
+		wait := defaultScrollWaitMs * time.Millisecond
+		if v, ok := args["wait_ms"].(float64); ok && v >= 0 {
+			wait = min(time.Duration(v), maxScrollWaitMs) * time.Millisecond
+		}
+		timeout += time.Duration(iterations) * wait
+		action = scrollToBottom(iterations, wait, &scrolls)
+	default:
+		return comm.NewToolResultError(comm.CodeInvalidArgument, fmt.Sprintf("unknown mode %s, must be one of %s, %s or %s", mode, ScrollElement, ScrollBy, ScrollBottom)), nil
+	}
+
+	var pos scrollPosition
+	runCtx, cancelFunc := context.WithTimeout(s.ctx, timeout)
+	defer cancelFunc()
+	if mode == ScrollElement {
+		// The element is queried in its frame, which is found in the page first.
+		frame, err := findFrame(runCtx, args)
+		if err != nil {
+			return comm.NewToolResultErrorFor(err, err.Error()), nil
+		}
+		action = chromedp.ScrollIntoView(selector, querySelector(inFrame(frame)...)...)
+	}
+	if err := chromedp.Run(runCtx, action, chromedp.Evaluate(scrollPositionScript, &pos)); err != nil {
+		return comm.NewToolResultErrorFor(err, fmt.Sprintf("failed to scroll: %s", err.Error())), nil
+	}
+	if mode == ScrollBottom {
+		return mcp.NewToolResultText(fmt.Sprintf("Scrolled to the bottom %d times, at x=%.0f y=%.0f of a page of height %.0f", scrolls, pos.X, pos.Y, pos.Height)), nil
+	}
+	return mcp.NewToolResultText(fmt.Sprintf("Scrolled to x=%.0f y=%.0f of a page of height %.0f", pos.X, pos.Y, pos.Height)), nil
+}
@@ -0,0 +1,197 @@
+// Copyright 2025 CFC4N <cfc4n.cs@gmail.com>. All Rights Reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//   http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+//
+// Repository: https://github.com/gojue/moling
+
+package browser
+
+import (
+	"context"
+	"encoding/base64"
+	"encoding/json"
+	"fmt"
+	"net/url"
+	"strings"
+	"time"
+
+	"github.com/chromedp/cdproto/cdp"
+	"github.com/chromedp/chromedp"
+	"github.com/mark3labs/mcp-go/mcp"
+
+	"github.com/gojue/moling/pkg/comm"
+)
+
+// Search engines of web_search.
+const (
+	EngineDuckDuckGo = "duckduckgo"
+	EngineBing       = "bing"
+	EngineGoogle     = "google"
+)
+
+const (
+	defaultSearchLimit = 10 // defaultSearchLimit is the number of results web_search returns by default.
+	maxSearchLimit     = 50
+)
+
+// searchEngine is the URL of the results page of a search engine, and the selectors of the parts of
+// a result in it, relative to the result.
+type searchEngine struct {
+	url     string // url is the URL of the results page, with %s for the escaped query.
+	result  string
+	title   string // title is the title of the result, in its link or containing it.
+	snippet string
+}
+
+// searchEngines are the engines of web_search, by name.
+var searchEngines = map[string]searchEngine{
+	EngineDuckDuckGo: {
+		url:     "https://html.duckduckgo.com/html/?q=%s",
+		result:  ".result:not(.result--ad)",
+		title:   ".result__a",
+		snippet: ".result__snippet",
+	},
+	EngineBing: {
+		url:     "https://www.bing.com/search?q=%s",
+		result:  "#b_results > li.b_algo",
+		title:   "h2 a",
+		snippet: ".b_caption p, p",
+	},
+	EngineGoogle: {
+		url:     "https://www.google.com/search?q=%s",
+		result:  "#search .g",
+		title:   "h3",
+		snippet: ".VwiC3b, [data-sncf]",
+	},
+}
+
+// searchFunction returns the title, the link and the snippet of the results of the page.
+const searchFunction = `function(result, title, snippet) {
+	return Array.from(this.querySelectorAll(result)).map((r) => {
+		const t = r.querySelector(title);
+		const a = t && (t.closest('a') || t.querySelector('a'));
+		const s = r.querySelector(snippet);
+		return {
+			title: t ? t.innerText.replace(/\s+/g, ' ').trim() : '',
+			url: a ? a.href : '',
+			snippet: s ? s.innerText.replace(/\s+/g, ' ').trim() : '',
+		};
+	});
+}`
+
+// searchResult is a result of web_search.
+type searchResult struct {
+	Title   string `json:"title"`
+	URL     string `json:"url"`
+	Snippet string `json:"snippet,omitempty"`
+}
+
+// searchResults returns the action that parses at most limit results of the results page of the engine into res.
+// The results without a title or an http(s) URL, and the duplicates, are skipped.
+func searchResults(engine searchEngine, limit int, res *[]searchResult) chromedp.Action {
+	var nodes []*cdp.Node
+	return chromedp.Tasks{
+		chromedp.Nodes("html", &nodes, chromedp.ByQuery),
+		chromedp.ActionFunc(func(ctx context.Context) error {
+			if len(nodes) == 0 {
+				return fmt.Errorf("the page has no document")
+			}
+			var parsed []searchResult
+			if err := callOnNode(ctx, nodes[0], searchFunction, &parsed, engine.result, engine.title, engine.snippet); err != nil {
+				return err
+			}
+			seen := make(map[string]bool, len(parsed))
+			*res = make([]searchResult, 0, min(len(parsed), limit))
+			for _, r := range parsed {
+				r.URL = resultURL(r.URL)
+				if r.Title == "" || r.URL == "" || seen[r.URL] || len(*res) >= limit {
+					continue
+				}
+				seen[r.URL] = true
+				*res = append(*res, r)
+			}
+			return nil
+		}),
+	}
+}
+
+// resultURL returns the target of the redirect links of the engines, e.g. duckduckgo.com/l/?uddg=,
+// google.com/url?q= or bing.com/ck/a?u=, or the link itself. It returns "" if the link isn't http(s).
+func resultURL(link string) string {
+	u, err := url.Parse(link)
+	if err != nil {
+		return ""
+	}
+	host := strings.TrimPrefix(u.Hostname(), "www.")
+	switch {
+	case strings.HasSuffix(host, "duckduckgo.com") && u.Path == "/l/" && u.Query().Get("uddg") != "":
+		return resultURL(u.Query().Get("uddg"))
+	case strings.HasPrefix(host, "google.") && u.Path == "/url" && u.Query().Get("q") != "":
+		return resultURL(u.Query().Get("q"))
+	case host == "bing.com" && u.Path == "/ck/a" && strings.HasPrefix(u.Query().Get("u"), "a1"):
+		target, err := base64.RawURLEncoding.DecodeString(strings.TrimRight(u.Query().Get("u")[2:], "="))
+		if err == nil {
+			return resultURL(string(target))
+		}
+	}
+	if u.Scheme != "http" && u.Scheme != "https" {
+		return ""
+	}
+	return link
+}
+
+// handleWebSearch searches the query with the engine in the page, and returns the titles, URLs and snippets of the results.
+func (bs *BrowserServer) handleWebSearch(ctx context.Context, request mcp.CallToolRequest) (*mcp.CallToolResult, error) {
+	args := request.GetArguments()
+	s, err := bs.session(ctx, args)
+	if err != nil {
+		return comm.NewToolResultErrorFor(err, err.Error()), nil
+	}
+	query, _ := args["query"].(string)
+	if strings.TrimSpace(query) == "" {
+		return comm.NewToolResultError(comm.CodeInvalidArgument, "query must be a non-empty string"), nil
+	}
+	name, _ := args["engine"].(string)
+	if name == "" {
+		name = bs.config.SearchEngine
+	}
+	engine, ok := searchEngines[strings.ToLower(name)]
+	if !ok {
+		return comm.NewToolResultError(comm.CodeInvalidArgument, fmt.Sprintf("unknown engine %s, must be one of %s, %s or %s", name, EngineDuckDuckGo, EngineBing, EngineGoogle)), nil
+	}
+	limit := defaultSearchLimit
+	if v, ok := args["limit"].(float64); ok && v > 0 {
+		limit = min(int(v), maxSearchLimit)
+	}
+
+	searchURL := fmt.Sprintf(engine.url, url.QueryEscape(query))
+	runCtx, cancelFunc := context.WithTimeout(s.ctx, time.Duration(bs.config.URLTimeout+bs.config.SelectorQueryTimeout)*time.Second)
+	defer cancelFunc()
+	if err = bs.navigate(runCtx, s, searchURL); err != nil {
+		return comm.NewToolResultErrorFor(err, fmt.Sprintf("failed to search: %s", err.Error())), nil
+	}
+	var results []searchResult
+	var title string
+	if err = chromedp.Run(runCtx, searchResults(engine, limit, &results), chromedp.Title(&title)); err != nil {
+		return comm.NewToolResultErrorFor(err, fmt.Sprintf("failed to parse the results: %s", err.Error())), nil
+	}
+	if len(results) == 0 {
+		// The engine may have answered with a consent page or a captcha instead of the results.
+		return mcp.NewToolResultText(fmt.Sprintf("No results for %q on %s, the page is %q at %s, take a screenshot to check it", query, name, title, searchURL)), nil
+	}
+	data, err := json.Marshal(results)
+	if err != nil {
+		return comm.NewToolResultErrorFor(err, fmt.Sprintf("failed to marshal results: %s", err.Error())), nil
+	}
+	return mcp.NewToolResultText(string(data)), nil
+}
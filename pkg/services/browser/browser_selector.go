@@ -0,0 +1,123 @@
+// Copyright 2025 CFC4N <cfc4n.cs@gmail.com>. All Rights Reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//   http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+//
+// Repository: https://github.com/gojue/moling
+
+package browser
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/chromedp/cdproto/cdp"
+	"github.com/chromedp/cdproto/dom"
+	"github.com/chromedp/cdproto/runtime"
+	"github.com/chromedp/chromedp"
+)
+
+// setInputFunction sets the value of a form element and dispatches the events a user input would.
+const setInputFunction = `function(value) {
+	this.focus();
+	if (this.type === 'checkbox' || this.type === 'radio') {
+		const checked = ['true', 'on', '1', 'yes', 'checked'].includes(String(value).toLowerCase());
+		if (this.checked !== checked) {
+			this.click();
+		}
+		return true;
+	}
+	const proto = this instanceof HTMLTextAreaElement ? HTMLTextAreaElement.prototype
+		: this instanceof HTMLSelectElement ? HTMLSelectElement.prototype
+		: HTMLInputElement.prototype;
+	Object.getOwnPropertyDescriptor(proto, 'value').set.call(this, value);
+	this.dispatchEvent(new Event('input', {bubbles: true}));
+	this.dispatchEvent(new Event('change', {bubbles: true}));
+	this.blur();
+	return true;
+}`
+
+// hoverFunction is called on the hovered element, the selector is never part of the JavaScript source.
+const hoverFunction = `function() { return this.dispatchEvent(new Event('mouseover')); }`
+
+// querySelector returns the options of a query for a visible element matching the CSS selector.
+// The browser tools reach elements only through it: selectors are resolved by DOM.querySelector
+// over CDP, and scripts run on the resolved node, so a selector can never inject code.
+func querySelector(opts ...chromedp.QueryOption) []chromedp.QueryOption {
+	return append([]chromedp.QueryOption{chromedp.ByQuery, chromedp.NodeVisible}, opts...)
+}
+
+// clickSelector clicks the element matching the selector once it is visible.
+func clickSelector(selector string, opts ...chromedp.QueryOption) chromedp.Action {
+	return chromedp.Tasks{
+		chromedp.WaitReady("body", append([]chromedp.QueryOption{chromedp.ByQuery}, opts...)...),
+		chromedp.Click(selector, querySelector(opts...)...),
+	}
+}
+
+// fillSelector types the value into the element matching the selector.
+func fillSelector(selector, value string, opts ...chromedp.QueryOption) chromedp.Action {
+	return chromedp.SendKeys(selector, value, querySelector(opts...)...)
+}
+
+// selectSelector sets the value of the element matching the selector, e.g. a select or an input.
+func selectSelector(selector, value string, opts ...chromedp.QueryOption) chromedp.Action {
+	return chromedp.SetValue(selector, value, querySelector(opts...)...)
+}
+
+// setInputSelector sets the value of the input, textarea or select matching the selector the way a user
+// would: through the native value setter, followed by the input and change events, so frameworks such as
+// React and Vue see the new value. Checkboxes and radio buttons are clicked when their state must change.
+func setInputSelector(selector, value string, opts ...chromedp.QueryOption) chromedp.Action {
+	var nodes []*cdp.Node
+	return chromedp.Tasks{
+		chromedp.Nodes(selector, &nodes, append(querySelector(opts...), chromedp.AtLeast(1))...),
+		chromedp.ActionFunc(func(ctx context.Context) error {
+			if len(nodes) == 0 {
+				return fmt.Errorf("no element matches selector %s", selector)
+			}
+			var ok bool
+			return callOnNode(ctx, nodes[0], setInputFunction, &ok, value)
+		}),
+	}
+}
+
+// hoverSelector dispatches a mouseover event to the element matching the selector,
+// res is set to the return value of dispatchEvent.
+func hoverSelector(selector string, res *bool, opts ...chromedp.QueryOption) chromedp.Action {
+	var nodes []*cdp.Node
+	return chromedp.Tasks{
+		chromedp.Nodes(selector, &nodes, append(querySelector(opts...), chromedp.AtLeast(1))...),
+		chromedp.ActionFunc(func(ctx context.Context) error {
+			if len(nodes) == 0 {
+				return fmt.Errorf("no element matches selector %s", selector)
+			}
+			return callOnNode(ctx, nodes[0], hoverFunction, res)
+		}),
+	}
+}
+
+// callOnNode calls the JavaScript function with the node as this, unmarshaling its result to res.
+// The arguments are passed to the function as JSON values, never as JavaScript source.
+func callOnNode(ctx context.Context, node *cdp.Node, function string, res any, args ...any) error {
+	obj, err := dom.ResolveNode().WithNodeID(node.NodeID).Do(ctx)
+	if err != nil {
+		return err
+	}
+	defer func() {
+		// The object is gone if the page navigated, there is nothing left to release then.
+		_ = runtime.ReleaseObject(obj.ObjectID).Do(ctx)
+	}()
+	return chromedp.CallFunctionOn(function, res, func(p *runtime.CallFunctionOnParams) *runtime.CallFunctionOnParams {
+		return p.WithObjectID(obj.ObjectID)
+	}, args...).Do(ctx)
+}
@@ -0,0 +1,335 @@
+// Copyright 2025 CFC4N <cfc4n.cs@gmail.com>. All Rights Reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//   http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+//
+// Repository: https://github.com/gojue/moling
+
+package browser
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"regexp"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/chromedp/chromedp"
+	"github.com/mark3labs/mcp-go/mcp"
+
+	"github.com/gojue/moling/pkg/comm"
+	"github.com/gojue/moling/pkg/services/abstract"
+)
+
+// sessionIDPattern is the pattern of the session IDs, they are used in logs and tool results.
+var sessionIDPattern = regexp.MustCompile(`^[A-Za-z0-9_.-]{1,64}$`)
+
+// withSession returns the session_id argument of the page tools.
+func withSession() mcp.ToolOption {
+	return mcp.WithString("session_id",
+		mcp.Description("Session to run in, each session is a page with its own cookies, storage and state, created on first use (optional, default: the default session of the client)"),
+	)
+}
+
+// browserSession is a page of the browser with its own state. The sessions other than the default one
+// have their own browser context, isolated like an incognito window, so clients don't share pages or cookies.
+type browserSession struct {
+	id        string
+	ctx       context.Context
+	cancel    context.CancelFunc
+	capture   networkCapture
+	requests  requestPolicy
+	activity  networkActivity
+	emulation pageEmulation
+	proxy     *proxy    // proxy overrides the proxy of the browser, set when the session is created.
+	client    string    // client is the ID of the client session that created it, closed when that one ends.
+	lastUsed  time.Time // lastUsed is guarded by the mutex of the sessionPool.
+}
+
+// sessionPool holds the sessions created by session_id, and the default sessions of the clients by
+// defaultSessionKey. The main page of the browser is not part of it.
+type sessionPool struct {
+	mu       sync.Mutex
+	sessions map[string]*browserSession
+}
+
+// sessionLocks holds a lock by session_id, the default session has the empty one. The lock is freed once no call
+// holds or waits for it.
+type sessionLocks struct {
+	mu    sync.Mutex
+	locks map[string]*sessionLock
+}
+
+type sessionLock struct {
+	sync.Mutex
+	refs int
+}
+
+// lock locks the session, and returns its unlock.
+func (sl *sessionLocks) lock(id string) func() {
+	sl.mu.Lock()
+	if sl.locks == nil {
+		sl.locks = make(map[string]*sessionLock)
+	}
+	l, ok := sl.locks[id]
+	if !ok {
+		l = &sessionLock{}
+		sl.locks[id] = l
+	}
+	l.refs++
+	sl.mu.Unlock()
+	l.Lock()
+	return func() {
+		l.Unlock()
+		sl.mu.Lock()
+		if l.refs--; l.refs == 0 {
+			delete(sl.locks, id)
+		}
+		sl.mu.Unlock()
+	}
+}
+
+// defaultSessionPrefix prefixes the keys of the default sessions of the clients in the sessionPool, it doesn't
+// match sessionIDPattern, so no session_id gets the default session of a client.
+const defaultSessionPrefix = "default:"
+
+// defaultSessionKey returns the key of the default session of the client in the sessionPool.
+func defaultSessionKey(client string) string {
+	return defaultSessionPrefix + client
+}
+
+// isDefaultSession tells whether the key of the sessionPool is the one of the default session of a client.
+func isDefaultSession(key string) bool {
+	return strings.HasPrefix(key, defaultSessionPrefix)
+}
+
+// sessionLockKey returns the key of the lock of the session of the session_id of the call, the default session of
+// its client without one.
+func sessionLockKey(ctx context.Context, id string) string {
+	if id == "" {
+		return defaultSessionKey(abstract.ClientSessionID(ctx))
+	}
+	return id
+}
+
+// sharedDefault tells whether the calls without session_id all run in the main page of the browser, with the
+// profile of the browser: in STDIO mode, with a single client.
+func (bs *BrowserServer) sharedDefault() bool {
+	cfg := bs.MlConfig()
+	return cfg == nil || cfg.ListenAddr == ""
+}
+
+// newSession returns a session of the page of ctx, with the request policy and the emulation of the config.
+func (bs *BrowserServer) newSession(id string, ctx context.Context, cancel context.CancelFunc) *browserSession {
+	s := &browserSession{id: id, ctx: ctx, cancel: cancel, lastUsed: time.Now()}
+	chromedp.ListenTarget(ctx, bs.downloads.handleEvent)
+	chromedp.ListenTarget(ctx, s.capture.handleEvent)
+	chromedp.ListenTarget(ctx, func(ev any) { bs.handleFetchEvent(s, ev) })
+	chromedp.ListenTarget(ctx, s.activity.handleEvent)
+	s.requests.set(bs.config.ExtraHeaders, bs.config.username, bs.config.password, bs.config.blockedURLs)
+	s.requests.setDomains(bs.config.allowedDomains, bs.config.blockedDomains)
+	s.requests.setFilter(bs.config.filter)
+	if bs.config.proxy != nil {
+		s.requests.setProxyAuth(bs.config.proxy.username, bs.config.proxy.password)
+	}
+	s.emulation.set(bs.config.viewport())
+	s.emulation.setLocale(bs.config.locale())
+	return s
+}
+
+// session returns the session of the session_id argument, created on first use, or the default session of the
+// client, created on its first call too. The calls without client session, and all of them in STDIO mode, share the
+// main page of the browser instead. The proxy argument routes a new session through its own proxy. A lost browser
+// is relaunched first. The sessions are used by the client session that created them only.
+func (bs *BrowserServer) session(ctx context.Context, args map[string]any) (*browserSession, error) {
+	id, _ := args["session_id"].(string)
+	rawProxy, _ := args["proxy"].(string)
+	if id == "" && rawProxy != "" {
+		return nil, comm.Errorf(comm.CodeInvalidArgument, "proxy requires a session_id, the default session uses the proxy of the configuration")
+	}
+	main, err := bs.ensureBrowser()
+	if err != nil {
+		return nil, err
+	}
+	client := abstract.ClientSessionID(ctx)
+	if id == "" {
+		if client == "" || bs.sharedDefault() {
+			return main, nil
+		}
+		return bs.openSession(main, defaultSessionKey(client), client, nil)
+	}
+	if !sessionIDPattern.MatchString(id) {
+		return nil, comm.Errorf(comm.CodeInvalidArgument, "session_id must be 1 to 64 letters, digits, '.', '_' or '-', got %q", id)
+	}
+	var px *proxy
+	if rawProxy != "" {
+		var err error
+		if px, err = parseProxy(rawProxy); err != nil {
+			return nil, err
+		}
+	}
+	return bs.openSession(main, id, client, px)
+}
+
+// openSession returns the session of the key in the sessionPool, created for the client if there is none, in a
+// browser context of its own, through the proxy px if not nil.
+func (bs *BrowserServer) openSession(main *browserSession, id, client string, px *proxy) (*browserSession, error) {
+	bs.sessions.mu.Lock()
+	defer bs.sessions.mu.Unlock()
+	if s, ok := bs.sessions.sessions[id]; ok {
+		if s.client != client {
+			return nil, comm.Errorf(comm.CodePolicyDenied, "session %s is used by another client, choose another session_id", id)
+		}
+		if px != nil && !sameProxy(px, s.proxy) {
+			return nil, comm.Errorf(comm.CodeInvalidArgument, "session %s uses another proxy, close it to change its proxy", id)
+		}
+		s.lastUsed = time.Now()
+		return s, nil
+	}
+	named := 0
+	for key := range bs.sessions.sessions {
+		if !isDefaultSession(key) {
+			named++
+		}
+	}
+	if !isDefaultSession(id) && named >= bs.config.MaxSessions {
+		return nil, comm.Errorf(comm.CodeTooLarge, "too many sessions, at most %d, close one with browser_close_session", bs.config.MaxSessions)
+	}
+	// The new page must be opened in the running browser, start it if no tool has run yet.
+	if err := chromedp.Run(main.ctx); err != nil {
+		return nil, fmt.Errorf("failed to start the browser: %w", err)
+	}
+	var opts []chromedp.CreateBrowserContextOption
+	if px != nil {
+		opts = append(opts, browserContextProxy(px, bs.config.proxyBypass))
+	} else if bs.config.proxy != nil && bs.config.RemoteDebuggingURL != "" {
+		// An attached browser wasn't launched with the proxy, its browser contexts are given it instead.
+		opts = append(opts, browserContextProxy(bs.config.proxy, bs.config.proxyBypass))
+	}
+	ctx, cancel := chromedp.NewContext(main.ctx, chromedp.WithNewBrowserContext(opts...))
+	s := bs.newSession(id, ctx, cancel)
+	s.client = client
+	if px != nil {
+		s.proxy = px
+		s.requests.setProxyAuth(px.username, px.password)
+	}
+	if bs.sessions.sessions == nil {
+		bs.sessions.sessions = make(map[string]*browserSession)
+	}
+	bs.sessions.sessions[id] = s
+	bs.Logger.Debug().Str("session", id).Msg("Created browser session")
+	return s, nil
+}
+
+// closeSession closes the page and the browser context of the session.
+func (bs *BrowserServer) closeSession(id string) bool {
+	bs.sessions.mu.Lock()
+	s, ok := bs.sessions.sessions[id]
+	delete(bs.sessions.sessions, id)
+	bs.sessions.mu.Unlock()
+	if !ok {
+		return false
+	}
+	ctx, cancel := context.WithTimeout(s.ctx, 3*time.Second)
+	defer cancel()
+	if err := chromedp.Cancel(ctx); err != nil {
+		bs.Logger.Debug().Err(err).Str("session", id).Msg("failed to close the browser session")
+	}
+	s.cancel()
+	return true
+}
+
+// ClientResources returns the browser sessions created by the client session, its default one too.
+func (bs *BrowserServer) ClientResources(sessionID string) []string {
+	bs.sessions.mu.Lock()
+	defer bs.sessions.mu.Unlock()
+	var resources []string
+	for id, s := range bs.sessions.sessions {
+		switch {
+		case s.client != sessionID:
+		case isDefaultSession(id):
+			resources = append(resources, "default browser session")
+		default:
+			resources = append(resources, "browser session "+id)
+		}
+	}
+	sort.Strings(resources)
+	return resources
+}
+
+// CloseClientSession closes the browser sessions created by the client session, its default one too. The main page
+// of the browser is kept.
+func (bs *BrowserServer) CloseClientSession(sessionID string) {
+	var ids []string
+	bs.sessions.mu.Lock()
+	for id, s := range bs.sessions.sessions {
+		if s.client == sessionID {
+			ids = append(ids, id)
+		}
+	}
+	bs.sessions.mu.Unlock()
+	for _, id := range ids {
+		if bs.closeSession(id) {
+			bs.Logger.Debug().Str("session", id).Str("client", sessionID).Msg("Closed the browser session of the client")
+		}
+	}
+}
+
+// handleListSessions returns the sessions created by session_id by the client, with the time they were last used.
+func (bs *BrowserServer) handleListSessions(ctx context.Context, request mcp.CallToolRequest) (*mcp.CallToolResult, error) {
+	type sessionInfo struct {
+		ID       string    `json:"session_id"`
+		Proxy    string    `json:"proxy,omitempty"`
+		LastUsed time.Time `json:"last_used"`
+	}
+	client := abstract.ClientSessionID(ctx)
+	bs.sessions.mu.Lock()
+	list := make([]sessionInfo, 0, len(bs.sessions.sessions))
+	for id, s := range bs.sessions.sessions {
+		if s.client != client || isDefaultSession(id) {
+			continue
+		}
+		info := sessionInfo{ID: id, LastUsed: s.lastUsed}
+		if s.proxy != nil {
+			info.Proxy = s.proxy.server
+		}
+		list = append(list, info)
+	}
+	bs.sessions.mu.Unlock()
+	sort.Slice(list, func(i, j int) bool { return list[i].ID < list[j].ID })
+	data, err := json.Marshal(list)
+	if err != nil {
+		return comm.NewToolResultErrorFor(err, fmt.Sprintf("failed to marshal sessions: %s", err.Error())), nil
+	}
+	return mcp.NewToolResultText(fmt.Sprintf("%d of at most %d sessions: %s", len(list), bs.config.MaxSessions, data)), nil
+}
+
+// handleCloseSession closes a session created by session_id by the client, freeing its page and browser context.
+func (bs *BrowserServer) handleCloseSession(ctx context.Context, request mcp.CallToolRequest) (*mcp.CallToolResult, error) {
+	id, _ := request.GetArguments()["session_id"].(string)
+	if id == "" {
+		return comm.NewToolResultError(comm.CodeInvalidArgument, "session_id is required, the default session can't be closed"), nil
+	}
+	bs.sessions.mu.Lock()
+	s, ok := bs.sessions.sessions[id]
+	bs.sessions.mu.Unlock()
+	if ok && s.client != abstract.ClientSessionID(ctx) {
+		return comm.NewToolResultError(comm.CodePolicyDenied, fmt.Sprintf("session %s is used by another client", id)), nil
+	}
+	if !bs.closeSession(id) {
+		return comm.NewToolResultError(comm.CodeNotFound, fmt.Sprintf("no session %s", id)), nil
+	}
+	return mcp.NewToolResultText(fmt.Sprintf("Closed session %s", id)), nil
+}
@@ -0,0 +1,183 @@
+// Copyright 2025 CFC4N <cfc4n.cs@gmail.com>. All Rights Reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//   http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+//
+// Repository: https://github.com/gojue/moling
+
+package browser
+
+import (
+	"context"
+	"fmt"
+	"sync"
+	"time"
+
+	"github.com/chromedp/cdproto/cdp"
+	"github.com/chromedp/cdproto/network"
+	"github.com/chromedp/chromedp"
+	"github.com/mark3labs/mcp-go/mcp"
+
+	"github.com/gojue/moling/pkg/comm"
+)
+
+// Conditions of browser_wait_for.
+const (
+	WaitVisible     = "visible"      // WaitVisible waits until an element matching the selector is visible.
+	WaitAttached    = "attached"     // WaitAttached waits until an element matching the selector is in the DOM.
+	WaitURL         = "url"          // WaitURL waits until the URL of the page matches the pattern.
+	WaitNavigation  = "navigation"   // WaitNavigation waits until the document is completely loaded.
+	WaitNetworkIdle = "network_idle" // WaitNetworkIdle waits until no request has been in flight for idle_ms.
+)
+
+const (
+	defaultIdleMs = 500                    // defaultIdleMs is the quiet period of network_idle.
+	pollInterval  = 100 * time.Millisecond // pollInterval is the interval of the conditions checked by polling.
+)
+
+// networkActivity tracks the requests in flight, for network_idle.
+type networkActivity struct {
+	mu       sync.Mutex
+	inflight map[network.RequestID]struct{}
+	last     time.Time // last is the time a request started or ended.
+}
+
+// handleEvent records the start and end of the requests of the page.
+func (na *networkActivity) handleEvent(ev any) {
+	switch ev := ev.(type) {
+	case *network.EventRequestWillBeSent:
+		na.update(ev.RequestID, true)
+	case *network.EventLoadingFinished:
+		na.update(ev.RequestID, false)
+	case *network.EventLoadingFailed:
+		na.update(ev.RequestID, false)
+	}
+}
+
+func (na *networkActivity) update(id network.RequestID, started bool) {
+	na.mu.Lock()
+	defer na.mu.Unlock()
+	if na.inflight == nil {
+		na.inflight = make(map[network.RequestID]struct{})
+	}
+	if started {
+		na.inflight[id] = struct{}{}
+	} else {
+		delete(na.inflight, id)
+	}
+	na.last = time.Now()
+}
+
+// idleFor reports whether no request has been in flight for the duration.
+func (na *networkActivity) idleFor(d time.Duration) bool {
+	na.mu.Lock()
+	defer na.mu.Unlock()
+	return len(na.inflight) == 0 && time.Since(na.last) >= d
+}
+
+// poll calls cond every pollInterval until it returns true, it fails, or ctx is done.
+func poll(ctx context.Context, cond func(ctx context.Context) (bool, error)) error {
+	ticker := time.NewTicker(pollInterval)
+	defer ticker.Stop()
+	for {
+		ok, err := cond(ctx)
+		if err != nil || ok {
+			return err
+		}
+		select {
+		case <-ctx.Done():
+			return ctx.Err()
+		case <-ticker.C:
+		}
+	}
+}
+
+// waitAction returns the action that waits for the condition in the page of the session, the selector is queried in the frame if it is not nil.
+func (s *browserSession) waitAction(condition, selector, urlPattern string, idle time.Duration, frame *cdp.Node) (chromedp.Action, error) {
+	switch condition {
+	case WaitVisible, WaitAttached:
+		if selector == "" {
+			return nil, comm.Errorf(comm.CodeInvalidArgument, "selector is required for the %s condition", condition)
+		}
+		opts := append([]chromedp.QueryOption{chromedp.ByQuery}, inFrame(frame)...)
+		if condition == WaitVisible {
+			return chromedp.WaitVisible(selector, opts...), nil
+		}
+		return chromedp.WaitReady(selector, opts...), nil
+	case WaitURL:
+		if urlPattern == "" {
+			return nil, comm.Errorf(comm.CodeInvalidArgument, "url is required for the %s condition", condition)
+		}
+		return chromedp.ActionFunc(func(ctx context.Context) error {
+			return poll(ctx, func(ctx context.Context) (bool, error) {
+				var location string
+				if err := chromedp.Location(&location).Do(ctx); err != nil {
+					return false, err
+				}
+				return matchURLPattern(urlPattern, location), nil
+			})
+		}), nil
+	case WaitNavigation:
+		return chromedp.ActionFunc(func(ctx context.Context) error {
+			return poll(ctx, func(ctx context.Context) (bool, error) {
+				var state string
+				if err := chromedp.Evaluate(`document.readyState`, &state).Do(ctx); err != nil {
+					return false, err
+				}
+				return state == "complete", nil
+			})
+		}), nil
+	case WaitNetworkIdle:
+		return chromedp.ActionFunc(func(ctx context.Context) error {
+			return poll(ctx, func(context.Context) (bool, error) {
+				return s.activity.idleFor(idle), nil
+			})
+		}), nil
+	}
+	return nil, comm.Errorf(comm.CodeInvalidArgument, "unknown condition %s, must be one of %s, %s, %s, %s or %s", condition, WaitVisible, WaitAttached, WaitURL, WaitNavigation, WaitNetworkIdle)
+}
+
+// handleWaitFor waits for a condition on the page, up to the timeout.
+func (bs *BrowserServer) handleWaitFor(ctx context.Context, request mcp.CallToolRequest) (*mcp.CallToolResult, error) {
+	args := request.GetArguments()
+	s, err := bs.session(ctx, args)
+	if err != nil {
+		return comm.NewToolResultErrorFor(err, err.Error()), nil
+	}
+	condition, _ := args["condition"].(string)
+	selector, _ := args["selector"].(string)
+	urlPattern, _ := args["url"].(string)
+	idle := defaultIdleMs * time.Millisecond
+	if v, ok := args["idle_ms"].(float64); ok && v > 0 {
+		idle = time.Duration(v) * time.Millisecond
+	}
+	timeout := time.Duration(bs.config.Timeout) * time.Second
+	if v, ok := args["timeout"].(float64); ok && v > 0 {
+		timeout = time.Duration(v * float64(time.Second))
+	}
+
+	start := time.Now()
+	runCtx, cancelFunc := context.WithTimeout(s.ctx, timeout)
+	defer cancelFunc()
+	frame, err := findFrame(runCtx, args)
+	if err != nil {
+		return comm.NewToolResultErrorFor(err, err.Error()), nil
+	}
+	action, err := s.waitAction(condition, selector, urlPattern, idle, frame)
+	if err != nil {
+		return comm.NewToolResultErrorFor(err, err.Error()), nil
+	}
+	if err = chromedp.Run(runCtx, action); err != nil {
+		return comm.NewToolResultErrorFor(err, fmt.Sprintf("failed to wait for %s: %s", condition, err.Error())), nil
+	}
+	return mcp.NewToolResultText(fmt.Sprintf("Condition %s met after %s", condition, time.Since(start).Round(time.Millisecond))), nil
+}
@@ -20,9 +20,15 @@ package command
 import (
 	"context"
 	"encoding/json"
+	"errors"
 	"fmt"
+	"maps"
+	"os"
+	"os/exec"
 	"path/filepath"
+	"slices"
 	"strings"
+	"time"
 
 	"github.com/mark3labs/mcp-go/mcp"
 	"github.com/rs/zerolog"
@@ -35,19 +41,78 @@ import (
 
 var (
 	// ErrCommandNotFound is returned when the command is not found.
-	ErrCommandNotFound = fmt.Errorf("command not found")
+	ErrCommandNotFound = comm.Errorf(comm.CodeNotFound, "command not found")
 	// ErrCommandNotAllowed is returned when the command is not allowed.
-	ErrCommandNotAllowed = fmt.Errorf("command not allowed")
+	ErrCommandNotAllowed = comm.Errorf(comm.CodePolicyDenied, "command not allowed")
+	// ErrCommandTimeout is returned together with the partial output when the command exceeds its timeout.
+	ErrCommandTimeout = comm.Errorf(comm.CodeTimeout, "command timed out")
+	// ErrCommandNotConfirmed is returned when a command that needs confirmation was not confirmed by the user.
+	ErrCommandNotConfirmed = comm.Errorf(comm.CodePolicyDenied, "command not confirmed")
 )
 
 const (
 	CommandServerName comm.MoLingServerType = "Command"
+
+	// DefaultExecTimeout is the timeout used when ExecOptions.Timeout is not set.
+	DefaultExecTimeout = 10 * time.Second
+	// waitDelay is how long to wait for the output pipes to close after the command has been killed.
+	waitDelay = 2 * time.Second
 )
 
+// ExecOptions controls how a command is executed.
+type ExecOptions struct {
+	Timeout time.Duration // Timeout after which the command and its children are killed. default: DefaultExecTimeout
+	Dir     string        // Dir is the working directory of the command. default: the current directory of the server
+	Env     []string      // Env is the environment of the command, in KEY=VALUE form. nil: inherit the server environment
+
+	MaxOutput int              // MaxOutput is the number of bytes kept of each of stdout and stderr. default: DefaultMaxOutputBytes
+	Truncate  TruncateStrategy // Truncate selects which part of a larger output is kept. default: TruncateHead
+	Shell     string           // Shell is the shell the command runs through. default: sh, on Windows: cmd
+	Login     bool             // Login runs the shell as a login shell, or with its profile for PowerShell.
+	Limits    ResourceLimits   // Limits are the resource limits applied to the command and its children.
+	Sandbox   Sandbox          // Sandbox restricts the filesystem and network access of the command, not supported on Windows.
+}
+
+// ResourceLimits are the limits applied to a command and the processes it starts, 0 means unlimited.
+// On Unix they are set with ulimit before the command runs, on Windows with a job object.
+type ResourceLimits struct {
+	CPUSeconds int // CPUSeconds is the CPU time limit of each process. time.Second
+	MemoryMB   int // MemoryMB is the address space limit of each process, the memory limit of each process on Windows.
+	OpenFiles  int // OpenFiles is the limit of open files of each process, not supported on Windows.
+	Processes  int // Processes is the limit of processes of the user on Unix, of processes started by the command on Windows.
+}
+
+func (opts ExecOptions) timeout() time.Duration {
+	if opts.Timeout <= 0 {
+		return DefaultExecTimeout
+	}
+	return opts.Timeout
+}
+
+func (opts ExecOptions) shell() string {
+	if opts.Shell == "" {
+		return defaultShell
+	}
+	return opts.Shell
+}
+
+func (opts ExecOptions) maxOutput() int {
+	if opts.MaxOutput <= 0 {
+		return DefaultMaxOutputBytes
+	}
+	return opts.MaxOutput
+}
+
 // CommandServer implements the Service interface and provides methods to execute named commands.
 type CommandServer struct {
 	abstract.MLService
 	config    *CommandConfig
+	jobs      *JobManager
+	slots     chan struct{} // slots limits the commands running in the foreground at the same time.
+	auditLog  auditLog
+	cache     resultCache
+	stats     commandStats
+	sessions  *SessionManager
 	osName    string
 	osVersion string
 }
@@ -73,7 +138,10 @@ func NewCommandServer(ctx context.Context) (abstract.Service, error) {
 	cs := &CommandServer{
 		MLService: abstract.NewMLService(ctx, lger.Hook(loggerNameHook), gConf),
 		config:    cc,
+		slots:     make(chan struct{}, cc.MaxConcurrent),
 	}
+	cs.osName, cs.osVersion = detectOS()
+	cs.stats.publish()
 
 	err = cs.InitResources()
 	if err != nil {
@@ -94,20 +162,183 @@ func (cs *CommandServer) Init() error {
 		HandlerFunc: cs.handlePrompt,
 	}
 	cs.AddPrompt(pe)
+	cs.Logger.Debug().Str("shell", cs.config.Shell).Bool("login_shell", cs.config.LoginShell).Msg("commands run through the configured shell")
 	cs.AddTool(mcp.NewTool(
 		"execute_command",
-		mcp.WithDescription("Execute a named command.Only support command execution on macOS and will strictly follow safety guidelines, ensuring that commands are safe and secure"),
+		mcp.WithDescription(fmt.Sprintf("Execute a named command through %s and will strictly follow safety guidelines, ensuring that commands are safe and secure. Destructive commands such as rm -rf or shutdown run only after the user confirms them. Returns a JSON object with exit_code, stdout, stderr, duration_ms, truncated, timed_out and cached.", cs.config.Shell)),
 		mcp.WithTitleAnnotation("Execute Command"),
 		mcp.WithDestructiveHintAnnotation(true),
 		mcp.WithString("command",
 			mcp.Description("The command to execute"),
 			mcp.Required(),
 		),
+		mcp.WithString("working_dir",
+			mcp.Description("Directory to run the command in, must be within the allowed directories (default: the server's current directory)"),
+		),
+		mcp.WithObject("env",
+			mcp.Description(fmt.Sprintf("Environment variables to set for the command, e.g. {\"GIT_PAGER\": \"cat\"}. Allowed keys: %s", strings.Join(cs.config.allowedEnvKeys, ","))),
+			mcp.AdditionalProperties(map[string]any{"type": "string"}),
+		),
+		mcp.WithNumber("timeout_seconds",
+			mcp.Description(fmt.Sprintf("Timeout in seconds, the command is killed when it expires (default: %d, max: %d)", cs.config.Timeout, cs.config.MaxTimeout)),
+		),
 	), cs.handleExecuteCommand)
+	cs.AddTool(mcp.NewTool(
+		"run_script",
+		mcp.WithDescription("Run a multi-line shell script. Every command in the script is subject to the same restrictions as execute_command. Without confirm, only a dry-run summary of the commands is returned; call again with confirm set to true to execute it. Returns the same JSON object as execute_command."),
+		mcp.WithTitleAnnotation("Run Script"),
+		mcp.WithDestructiveHintAnnotation(true),
+		mcp.WithString("script",
+			mcp.Description("The shell script to run"),
+			mcp.Required(),
+		),
+		mcp.WithBoolean("confirm",
+			mcp.Description("Set to true to execute the script, otherwise only a dry run is done (default: false)"),
+		),
+		mcp.WithString("working_dir",
+			mcp.Description("Directory to run the script in, must be within the allowed directories (default: the server's current directory)"),
+		),
+		mcp.WithObject("env",
+			mcp.Description(fmt.Sprintf("Environment variables to set for the script. Allowed keys: %s", strings.Join(cs.config.allowedEnvKeys, ","))),
+			mcp.AdditionalProperties(map[string]any{"type": "string"}),
+		),
+		mcp.WithNumber("timeout_seconds",
+			mcp.Description(fmt.Sprintf("Timeout in seconds, the script is killed when it expires (default: %d, max: %d)", cs.config.Timeout, cs.config.MaxTimeout)),
+		),
+	), cs.handleRunScript)
+
+	// Background jobs
+	cs.jobs = NewJobManager(cs.Ctx(), cs.config.MaxJobs)
+	cs.AddTool(mcp.NewTool(
+		"start_background_command",
+		mcp.WithDescription("Start a command in the background and return its job ID immediately, for servers, watchers and other long-running commands. Use get_job_output to poll it and kill_job to stop it."),
+		mcp.WithTitleAnnotation("Start Background Command"),
+		mcp.WithDestructiveHintAnnotation(true),
+		mcp.WithString("command",
+			mcp.Description("The command to execute"),
+			mcp.Required(),
+		),
+		mcp.WithString("working_dir",
+			mcp.Description("Directory to run the command in, must be within the allowed directories (default: the server's current directory)"),
+		),
+		mcp.WithObject("env",
+			mcp.Description(fmt.Sprintf("Environment variables to set for the command. Allowed keys: %s", strings.Join(cs.config.allowedEnvKeys, ","))),
+			mcp.AdditionalProperties(map[string]any{"type": "string"}),
+		),
+	), cs.handleStartBackgroundCommand)
+	cs.AddTool(mcp.NewTool(
+		"get_job_output",
+		mcp.WithDescription("Get the status and output of a background job. Pass the returned next_offset as offset to only get new output."),
+		mcp.WithTitleAnnotation("Get Job Output"),
+		mcp.WithReadOnlyHintAnnotation(true),
+		mcp.WithString("job_id",
+			mcp.Description("The job ID returned by start_background_command"),
+			mcp.Required(),
+		),
+		mcp.WithNumber("offset",
+			mcp.Description("Output offset to read from (default: 0)"),
+		),
+	), cs.handleGetJobOutput)
+	cs.AddTool(mcp.NewTool(
+		"list_jobs",
+		mcp.WithDescription("List the background jobs started by this client and their status."),
+		mcp.WithTitleAnnotation("List Jobs"),
+		mcp.WithReadOnlyHintAnnotation(true),
+	), cs.handleListJobs)
+	cs.AddTool(mcp.NewTool(
+		"kill_job",
+		mcp.WithDescription("Kill a running background job and its child processes."),
+		mcp.WithTitleAnnotation("Kill Job"),
+		mcp.WithDestructiveHintAnnotation(true),
+		mcp.WithString("job_id",
+			mcp.Description("The job ID returned by start_background_command"),
+			mcp.Required(),
+		),
+	), cs.handleKillJob)
+
+	// Interactive shell sessions
+	cs.sessions = NewSessionManager(cs.Ctx(), cs.config.MaxSessions)
+	cs.AddTool(mcp.NewTool(
+		"open_shell_session",
+		mcp.WithDescription("Open an interactive shell session on a pseudo terminal and return its session ID. The working directory and environment are kept between the commands sent to the session, e.g. after cd. Not supported on Windows."),
+		mcp.WithTitleAnnotation("Open Shell Session"),
+		mcp.WithDestructiveHintAnnotation(true),
+		mcp.WithString("working_dir",
+			mcp.Description("Directory to start the shell in, must be within the allowed directories (default: the server's current directory)"),
+		),
+		mcp.WithObject("env",
+			mcp.Description(fmt.Sprintf("Environment variables to set for the shell. Allowed keys: %s", strings.Join(cs.config.allowedEnvKeys, ","))),
+			mcp.AdditionalProperties(map[string]any{"type": "string"}),
+		),
+	), cs.handleOpenShellSession)
+	cs.AddTool(mcp.NewTool(
+		"send_to_session",
+		mcp.WithDescription("Send a command line to a shell session. The command is subject to the same restrictions as execute_command. Returns the output offset to pass to read_session_output."),
+		mcp.WithTitleAnnotation("Send To Session"),
+		mcp.WithDestructiveHintAnnotation(true),
+		mcp.WithString("session_id",
+			mcp.Description("The session ID returned by open_shell_session"),
+			mcp.Required(),
+		),
+		mcp.WithString("input",
+			mcp.Description("The command line to run in the session"),
+			mcp.Required(),
+		),
+	), cs.handleSendToSession)
+	cs.AddTool(mcp.NewTool(
+		"read_session_output",
+		mcp.WithDescription("Read the terminal output of a shell session. Pass the returned next_offset as offset to only get new output."),
+		mcp.WithTitleAnnotation("Read Session Output"),
+		mcp.WithReadOnlyHintAnnotation(true),
+		mcp.WithString("session_id",
+			mcp.Description("The session ID returned by open_shell_session"),
+			mcp.Required(),
+		),
+		mcp.WithNumber("offset",
+			mcp.Description("Output offset to read from (default: 0)"),
+		),
+		mcp.WithNumber("wait_seconds",
+			mcp.Description(fmt.Sprintf("Seconds to wait for new output if there is none yet (default: 1, max: %d)", cs.config.MaxTimeout)),
+		),
+	), cs.handleReadSessionOutput)
+	cs.AddTool(mcp.NewTool(
+		"close_session",
+		mcp.WithDescription("Close a shell session, killing the shell and the processes started from it."),
+		mcp.WithTitleAnnotation("Close Session"),
+		mcp.WithDestructiveHintAnnotation(true),
+		mcp.WithString("session_id",
+			mcp.Description("The session ID returned by open_shell_session"),
+			mcp.Required(),
+		),
+	), cs.handleCloseSession)
+
+	// Audit log
+	cs.AddTool(mcp.NewTool(
+		"command_history",
+		mcp.WithDescription("Show the most recent entries of the command audit log: every command the agent ran or tried to run, with its time, exit code and status."),
+		mcp.WithTitleAnnotation("Command History"),
+		mcp.WithReadOnlyHintAnnotation(true),
+		mcp.WithNumber("limit",
+			mcp.Description(fmt.Sprintf("Number of most recent entries to return (default: %d, max: %d)", defaultHistoryLimit, maxHistoryLimit)),
+		),
+	), cs.handleCommandHistory)
+	cs.AddTool(mcp.NewTool(
+		"command_stats",
+		mcp.WithDescription("Show the execution statistics per binary since the server started: invocations, failures, timeouts, rejections, failure rate and mean duration."),
+		mcp.WithTitleAnnotation("Command Statistics"),
+		mcp.WithReadOnlyHintAnnotation(true),
+		mcp.WithString("binary",
+			mcp.Description("Only show the statistics of this binary, e.g. git"),
+		),
+	), cs.handleCommandStats)
 	return err
 }
 
 func (cs *CommandServer) handlePrompt(ctx context.Context, request mcp.GetPromptRequest) (*mcp.GetPromptResult, error) {
+	prompt, err := cs.renderPrompt()
+	if err != nil {
+		return nil, fmt.Errorf("failed to render the command prompt: %w", err)
+	}
 	return &mcp.GetPromptResult{
 		Description: "",
 		Messages: []mcp.PromptMessage{
@@ -115,7 +346,7 @@ func (cs *CommandServer) handlePrompt(ctx context.Context, request mcp.GetPrompt
 				Role: mcp.RoleUser,
 				Content: mcp.TextContent{
 					Type: "text",
-					Text: fmt.Sprintf(cs.config.prompt, cs.MlConfig().SystemInfo),
+					Text: prompt,
 				},
 			},
 		},
@@ -125,106 +356,624 @@ func (cs *CommandServer) handlePrompt(ctx context.Context, request mcp.GetPrompt
 // handleExecuteCommand handles the execution of a named command.
 func (cs *CommandServer) handleExecuteCommand(ctx context.Context, request mcp.CallToolRequest) (*mcp.CallToolResult, error) {
 	args := request.GetArguments()
+	command, opts, err := cs.parseExecArgs(ctx, args)
+	if err == nil {
+		err = cs.confirmCommand(ctx, command)
+	}
+	if err != nil {
+		cs.auditRejected(ctx, "execute_command", args["command"], err)
+		return comm.NewToolResultErrorFor(err, fmt.Sprintf("Error: %v", err)), nil
+	}
+
+	// Execute the command
+	return cs.runWithLimits(ctx, command, args, opts, AuditEntry{Tool: "execute_command", Command: command}), nil
+}
+
+// acquireSlot waits up to queue_timeout for one of the max_concurrent slots for running a command.
+// The returned function releases the slot.
+func (cs *CommandServer) acquireSlot(ctx context.Context) (func(), error) {
+	slots := cs.slots
+	release := func() { <-slots }
+	select {
+	case slots <- struct{}{}:
+		return release, nil
+	default:
+	}
+
+	timer := time.NewTimer(time.Duration(cs.config.QueueTimeout) * time.Second)
+	defer timer.Stop()
+	select {
+	case slots <- struct{}{}:
+		return release, nil
+	case <-ctx.Done():
+		return nil, ctx.Err()
+	case <-timer.C:
+		return nil, comm.Errorf(comm.CodeTimeout, "%d commands are already running, no slot became free within %ds", cs.config.MaxConcurrent, cs.config.QueueTimeout)
+	}
+}
+
+// runWithLimits runs the command with the timeout_seconds argument and the output limits of the configuration,
+// records it in the audit log as entry, and returns its structured result.
+func (cs *CommandServer) runWithLimits(ctx context.Context, command string, args map[string]any, opts ExecOptions, entry AuditEntry) *mcp.CallToolResult {
+	entry.Dir = opts.Dir
+	timeout := cs.config.Timeout
+	if v, ok := args["timeout_seconds"].(float64); ok && v > 0 {
+		timeout = min(int(v), cs.config.MaxTimeout)
+	}
+	opts.Timeout = time.Duration(timeout) * time.Second
+	opts.MaxOutput = cs.config.MaxOutputBytes
+	opts.Truncate = TruncateStrategy(cs.config.Truncation)
+
+	key, cacheable := cs.cacheKey(command, opts)
+	if cacheable {
+		if result, ok := cs.cache.Get(key); ok {
+			entry.Status, entry.ExitCode, entry.OutputHash = AuditCached, result.ExitCode, outputHash(result)
+			cs.audit(ctx, entry)
+			return mcp.NewToolResultText(result.String())
+		}
+	}
+
+	release, err := cs.acquireSlot(ctx)
+	if err != nil {
+		cs.Logger.Warn().Err(err).Str("command", command).Msg("no free slot to run the command")
+		entry.Status, entry.Error = AuditFailed, err.Error()
+		cs.audit(ctx, entry)
+		return comm.NewToolResultErrorFor(err, fmt.Sprintf("Error: %v", err))
+	}
+	defer release()
+
+	result, err := RunCommand(ctx, command, opts)
+	entry.ExitCode, entry.DurationMs, entry.OutputHash = result.ExitCode, result.DurationMs, outputHash(result)
+	if errors.Is(err, ErrCommandTimeout) {
+		cs.Logger.Warn().Str("command", command).Int("timeout", timeout).Msg("command timed out and was killed")
+		entry.Status = AuditTimeout
+		cs.audit(ctx, entry)
+		return comm.NewToolResultError(comm.CodeTimeout, result.String())
+	}
+	if err != nil {
+		entry.Status, entry.Error = AuditFailed, err.Error()
+		cs.audit(ctx, entry)
+		return comm.NewToolResultErrorFor(err, fmt.Sprintf("Error executing command: %v", err))
+	}
+	entry.Status = AuditExecuted
+	cs.audit(ctx, entry)
+	if cacheable && result.ExitCode == 0 {
+		cs.cache.Put(key, result, time.Duration(cs.config.CacheTTL)*time.Second)
+	}
+	return mcp.NewToolResultText(result.String())
+}
+
+// auditRejected records a tool call that was rejected before running anything.
+func (cs *CommandServer) auditRejected(ctx context.Context, tool string, command any, err error) {
+	s, _ := command.(string)
+	cs.audit(ctx, AuditEntry{Tool: tool, Command: s, Status: AuditRejected, ExitCode: -1, Error: err.Error()})
+}
+
+// handleStartBackgroundCommand starts a command in the background.
+func (cs *CommandServer) handleStartBackgroundCommand(ctx context.Context, request mcp.CallToolRequest) (*mcp.CallToolResult, error) {
+	args := request.GetArguments()
+	command, opts, err := cs.parseExecArgs(ctx, args)
+	if err == nil {
+		err = cs.confirmCommand(ctx, command)
+	}
+	if err != nil {
+		cs.auditRejected(ctx, "start_background_command", args["command"], err)
+		return comm.NewToolResultErrorFor(err, fmt.Sprintf("Error: %v", err)), nil
+	}
+	entry := AuditEntry{Tool: "start_background_command", Command: command, Dir: opts.Dir, ExitCode: -1}
+	job, err := cs.jobs.Start(abstract.ClientSessionID(ctx), command, opts)
+	if err != nil {
+		entry.Status, entry.Error = AuditFailed, err.Error()
+		cs.audit(ctx, entry)
+		return comm.NewToolResultErrorFor(err, fmt.Sprintf("Error starting background command: %v", err)), nil
+	}
+	entry.Status = AuditStarted
+	cs.audit(ctx, entry)
+	cs.Logger.Info().Str("job", job.ID).Str("command", command).Msg("background job started")
+	return mcp.NewToolResultText(fmt.Sprintf("Started background job %s: %s", job.ID, command)), nil
+}
+
+// handleGetJobOutput returns the status and output of a background job.
+func (cs *CommandServer) handleGetJobOutput(ctx context.Context, request mcp.CallToolRequest) (*mcp.CallToolResult, error) {
+	args := request.GetArguments()
+	id, ok := args["job_id"].(string)
+	if !ok {
+		return comm.NewToolResultError(comm.CodeInvalidArgument, "job_id must be a string"), nil
+	}
+	job, err := cs.clientJob(ctx, id)
+	if err != nil {
+		return comm.NewToolResultErrorFor(err, fmt.Sprintf("Error: %v", err)), nil
+	}
+	offset, _ := args["offset"].(float64)
+	output, next, truncated := job.Output(int64(offset), cs.config.MaxOutputBytes)
+
+	var sb strings.Builder
+	sb.WriteString(job.describe())
+	sb.WriteString(fmt.Sprintf("\nnext_offset: %d\n", next))
+	if truncated {
+		sb.WriteString("[earlier output was discarded]\n")
+	}
+	if more, _, _ := job.Output(next, 1); more != "" {
+		sb.WriteString("[more output available, continue from next_offset]\n")
+	}
+	sb.WriteString("\n")
+	sb.WriteString(output)
+	return mcp.NewToolResultText(sb.String()), nil
+}
+
+// handleListJobs lists the background jobs started by the client.
+func (cs *CommandServer) handleListJobs(ctx context.Context, request mcp.CallToolRequest) (*mcp.CallToolResult, error) {
+	client := abstract.ClientSessionID(ctx)
+	jobs := slices.DeleteFunc(cs.jobs.List(), func(job *Job) bool { return job.Client != client })
+	if len(jobs) == 0 {
+		return mcp.NewToolResultText("No background jobs"), nil
+	}
+	var sb strings.Builder
+	sb.WriteString(fmt.Sprintf("Found %d background jobs:\n\n", len(jobs)))
+	for _, job := range jobs {
+		sb.WriteString(job.describe())
+		sb.WriteString("\n")
+	}
+	return mcp.NewToolResultText(sb.String()), nil
+}
+
+// handleKillJob kills a background job.
+func (cs *CommandServer) handleKillJob(ctx context.Context, request mcp.CallToolRequest) (*mcp.CallToolResult, error) {
+	id, ok := request.GetArguments()["job_id"].(string)
+	if !ok {
+		return comm.NewToolResultError(comm.CodeInvalidArgument, "job_id must be a string"), nil
+	}
+	_, err := cs.clientJob(ctx, id)
+	if err == nil {
+		err = cs.jobs.Kill(id)
+	}
+	if err != nil {
+		return comm.NewToolResultErrorFor(err, fmt.Sprintf("Error: %v", err)), nil
+	}
+	cs.Logger.Info().Str("job", id).Msg("background job killed")
+	return mcp.NewToolResultText(fmt.Sprintf("Killed background job %s", id)), nil
+}
+
+// clientJob returns the background job of the ID, denied to the clients other than the one that started it.
+func (cs *CommandServer) clientJob(ctx context.Context, id string) (*Job, error) {
+	job, ok := cs.jobs.Get(id)
+	if !ok {
+		return nil, comm.Errorf(comm.CodeNotFound, "job %s not found", id)
+	}
+	if job.Client != abstract.ClientSessionID(ctx) {
+		return nil, comm.Errorf(comm.CodePolicyDenied, "job %s was started by another client", id)
+	}
+	return job, nil
+}
+
+// parseExecArgs validates the command, working_dir and env arguments shared by the command execution tools.
+func (cs *CommandServer) parseExecArgs(ctx context.Context, args map[string]any) (string, ExecOptions, error) {
 	command, ok := args["command"].(string)
 	if !ok {
-		return mcp.NewToolResultError(fmt.Errorf("command must be a string").Error()), nil
+		return "", ExecOptions{}, comm.Errorf(comm.CodeInvalidArgument, "command must be a string")
 	}
+	if err := cs.validateCommand(command); err != nil {
+		return "", ExecOptions{}, err
+	}
+	opts, err := cs.parseExecOptions(ctx, args)
+	if err != nil {
+		return "", opts, err
+	}
+	opts.Sandbox = cs.sandboxFor(command)
+	return command, opts, nil
+}
 
-	// Check if the command is allowed
-	if !cs.isAllowedCommand(command) {
-		cs.Logger.Err(ErrCommandNotAllowed).Str("command", command).Msgf("If you want to allow this command, add it to %s", filepath.Join(cs.MlConfig().BasePath, "config", cs.MlConfig().ConfigFile))
-		return mcp.NewToolResultError(fmt.Sprintf("Error: Command '%s' is not allowed", command)), nil
+// validateCommand checks if the command is allowed, and logs how to allow it if not.
+func (cs *CommandServer) validateCommand(command string) error {
+	if err := cs.checkCommand(command); err != nil {
+		cs.Logger.Err(err).Str("command", command).Msgf("If you want to allow this command, add it to %s", cs.MlConfig().ConfigFilePath())
+		return fmt.Errorf("command '%s' is not allowed: %w", command, err)
 	}
+	return nil
+}
 
-	// Execute the command
-	output, err := ExecCommand(command)
+// parseExecOptions validates the working_dir and env arguments.
+func (cs *CommandServer) parseExecOptions(ctx context.Context, args map[string]any) (ExecOptions, error) {
+	opts := ExecOptions{
+		Shell: cs.config.Shell,
+		Login: cs.config.LoginShell,
+		Limits: ResourceLimits{
+			CPUSeconds: cs.config.MaxCPUSeconds,
+			MemoryMB:   cs.config.MaxMemoryMB,
+			OpenFiles:  cs.config.MaxOpenFiles,
+			Processes:  cs.config.MaxProcesses,
+		},
+		Sandbox: cs.sandboxFor(""),
+	}
+	if dir, ok := args["working_dir"].(string); ok && dir != "" {
+		workingDir, err := cs.validateWorkingDir(ctx, dir)
+		if err != nil {
+			return opts, fmt.Errorf("invalid working_dir: %w", err)
+		}
+		opts.Dir = workingDir
+	}
+
+	env, err := cs.buildEnv(args["env"])
+	if err != nil {
+		return opts, fmt.Errorf("invalid env: %w", err)
+	}
+	opts.Env = env
+	return opts, nil
+}
+
+// handleOpenShellSession opens an interactive shell session.
+func (cs *CommandServer) handleOpenShellSession(ctx context.Context, request mcp.CallToolRequest) (*mcp.CallToolResult, error) {
+	opts, err := cs.parseExecOptions(ctx, request.GetArguments())
+	if err != nil {
+		return comm.NewToolResultErrorFor(err, fmt.Sprintf("Error: %v", err)), nil
+	}
+	session, err := cs.sessions.Open(abstract.ClientSessionID(ctx), opts)
+	if err != nil {
+		return comm.NewToolResultErrorFor(err, fmt.Sprintf("Error opening shell session: %v", err)), nil
+	}
+	cs.Logger.Info().Str("session", session.ID).Str("dir", opts.Dir).Msg("shell session opened")
+	return mcp.NewToolResultText(fmt.Sprintf("Opened shell session %s", session.ID)), nil
+}
+
+// handleSendToSession sends a command line to a shell session.
+func (cs *CommandServer) handleSendToSession(ctx context.Context, request mcp.CallToolRequest) (*mcp.CallToolResult, error) {
+	args := request.GetArguments()
+	id, ok := args["session_id"].(string)
+	if !ok {
+		return comm.NewToolResultError(comm.CodeInvalidArgument, "session_id must be a string"), nil
+	}
+	input, ok := args["input"].(string)
+	if !ok {
+		return comm.NewToolResultError(comm.CodeInvalidArgument, "input must be a string"), nil
+	}
+	session, err := cs.clientSession(ctx, id)
+	if err != nil {
+		return comm.NewToolResultErrorFor(err, fmt.Sprintf("Error: %v", err)), nil
+	}
+	err = cs.validateCommand(input)
+	if err == nil {
+		err = cs.confirmCommand(ctx, input)
+	}
+	if err != nil {
+		cs.auditRejected(ctx, "send_to_session", input, err)
+		return comm.NewToolResultErrorFor(err, fmt.Sprintf("Error: %v", err)), nil
+	}
+	entry := AuditEntry{Tool: "send_to_session", Command: input, Dir: session.Dir, ExitCode: -1, Status: AuditStarted}
+	offset := session.Offset()
+	if err := session.Send(input); err != nil {
+		entry.Status, entry.Error = AuditFailed, err.Error()
+		cs.audit(ctx, entry)
+		return comm.NewToolResultErrorFor(err, fmt.Sprintf("Error sending to session: %v", err)), nil
+	}
+	cs.audit(ctx, entry)
+	return mcp.NewToolResultText(fmt.Sprintf("Sent to session %s, read its output with offset %d", id, offset)), nil
+}
+
+// handleReadSessionOutput returns the terminal output of a shell session.
+func (cs *CommandServer) handleReadSessionOutput(ctx context.Context, request mcp.CallToolRequest) (*mcp.CallToolResult, error) {
+	args := request.GetArguments()
+	id, ok := args["session_id"].(string)
+	if !ok {
+		return comm.NewToolResultError(comm.CodeInvalidArgument, "session_id must be a string"), nil
+	}
+	session, err := cs.clientSession(ctx, id)
+	if err != nil {
+		return comm.NewToolResultErrorFor(err, fmt.Sprintf("Error: %v", err)), nil
+	}
+	offset, _ := args["offset"].(float64)
+	wait := 1.0
+	if v, ok := args["wait_seconds"].(float64); ok && v >= 0 {
+		wait = min(v, float64(cs.config.MaxTimeout))
+	}
+	output, next, truncated := session.Output(ctx, int64(offset), cs.config.MaxOutputBytes, time.Duration(wait*float64(time.Second)))
+
+	var sb strings.Builder
+	status := "running"
+	if !session.Running() {
+		status = "exited"
+	}
+	sb.WriteString(fmt.Sprintf("%s [%s]\nnext_offset: %d\n", id, status, next))
+	if truncated {
+		sb.WriteString("[earlier output was discarded]\n")
+	}
+	if more, _, _ := session.Output(ctx, next, 1, 0); more != "" {
+		sb.WriteString("[more output available, continue from next_offset]\n")
+	}
+	sb.WriteString("\n")
+	sb.WriteString(output)
+	return mcp.NewToolResultText(sb.String()), nil
+}
+
+// handleCloseSession closes a shell session.
+func (cs *CommandServer) handleCloseSession(ctx context.Context, request mcp.CallToolRequest) (*mcp.CallToolResult, error) {
+	id, ok := request.GetArguments()["session_id"].(string)
+	if !ok {
+		return comm.NewToolResultError(comm.CodeInvalidArgument, "session_id must be a string"), nil
+	}
+	_, err := cs.clientSession(ctx, id)
+	if err == nil {
+		err = cs.sessions.Close(id)
+	}
 	if err != nil {
-		return mcp.NewToolResultError(fmt.Sprintf("Error executing command: %v", err)), nil
+		return comm.NewToolResultErrorFor(err, fmt.Sprintf("Error: %v", err)), nil
+	}
+	cs.Logger.Info().Str("session", id).Msg("shell session closed")
+	return mcp.NewToolResultText(fmt.Sprintf("Closed shell session %s", id)), nil
+}
+
+// clientSession returns the shell session of the ID, denied to the clients other than the one that opened it.
+func (cs *CommandServer) clientSession(ctx context.Context, id string) (*Session, error) {
+	session, ok := cs.sessions.Get(id)
+	if !ok {
+		return nil, comm.Errorf(comm.CodeNotFound, "session %s not found", id)
+	}
+	if session.Client != abstract.ClientSessionID(ctx) {
+		return nil, comm.Errorf(comm.CodePolicyDenied, "session %s was opened by another client", id)
 	}
+	return session, nil
+}
 
-	return mcp.NewToolResultText(output), nil
+// allowedDirs returns the directories allowed as working_dir, those of the FileSystem service if allowed_dir is empty.
+func (cs *CommandServer) allowedDirs() []string {
+	if len(cs.config.allowedDirs) > 0 {
+		return cs.config.allowedDirs
+	}
+	return cs.MlConfig().AllowedDirs()
+}
+
+// validateWorkingDir resolves the directory and checks it against the allowed directories, and with use_roots
+// the roots of the client of the request of ctx.
+func (cs *CommandServer) validateWorkingDir(ctx context.Context, dir string) (string, error) {
+	allowedDirs := cs.allowedDirs()
+	if cs.config.UseRoots {
+		allowedDirs = append(slices.Clone(allowedDirs), cs.SessionRoots(ctx)...)
+	}
+	if len(allowedDirs) == 0 {
+		return "", comm.Errorf(comm.CodePolicyDenied, "no allowed directories configured, set allowed_dir in the %s config", CommandServerName)
+	}
+	abs, err := filepath.Abs(dir)
+	if err != nil {
+		return "", err
+	}
+	// Resolve symlinks so that a link inside an allowed directory can't point outside of it
+	realPath, err := filepath.EvalSymlinks(abs)
+	if err != nil {
+		return "", err
+	}
+	if !utils.IsPathWithinAny(realPath, allowedDirs) {
+		return "", comm.Errorf(comm.CodePolicyDenied, "access denied - path outside allowed directories: %s", realPath)
+	}
+	info, err := os.Stat(realPath)
+	if err != nil {
+		return "", err
+	}
+	if !info.IsDir() {
+		return "", comm.Errorf(comm.CodeInvalidArgument, "not a directory: %s", realPath)
+	}
+	return realPath, nil
 }
 
-// shellInjectionPatterns are substrings that indicate potential command injection attempts.
-// Commands containing any of these patterns are rejected before allowlist evaluation.
-var shellInjectionPatterns = []string{";", "\n", "`", "$(", "${"}
+// buildEnv builds the command environment from the inherited server variables and the requested variables,
+// rejecting any key that is not in the allowed_env_keys list.
+func (cs *CommandServer) buildEnv(arg any) ([]string, error) {
+	env := make([]string, 0, len(cs.config.inheritEnv))
+	for _, key := range cs.config.inheritEnv {
+		if value, ok := os.LookupEnv(key); ok {
+			env = append(env, key+"="+value)
+		}
+	}
+	if arg == nil {
+		return env, nil
+	}
+	vars, ok := arg.(map[string]any)
+	if !ok {
+		return nil, comm.Errorf(comm.CodeInvalidArgument, "env must be an object of string values")
+	}
+	for key, v := range vars {
+		value, ok := v.(string)
+		if !ok {
+			return nil, comm.Errorf(comm.CodeInvalidArgument, "value of %s must be a string", key)
+		}
+		if !utils.StringInSlice(key, cs.config.allowedEnvKeys) {
+			cs.Logger.Warn().Str("key", key).Msg("environment variable not allowed")
+			return nil, comm.Errorf(comm.CodePolicyDenied, "environment variable %s is not allowed, allowed keys: %s", key, strings.Join(cs.config.allowedEnvKeys, ","))
+		}
+		if strings.ContainsRune(value, 0) {
+			return nil, comm.Errorf(comm.CodeInvalidArgument, "value of %s must not contain NUL", key)
+		}
+		// Later entries win in os/exec, so requested values override inherited ones
+		env = append(env, key+"="+value)
+	}
+	return env, nil
+}
 
 // isAllowedCommand checks if the command is allowed based on the configuration.
 func (cs *CommandServer) isAllowedCommand(command string) bool {
+	return cs.checkCommand(command) == nil
+}
+
+// checkCommand returns an error wrapping ErrCommandNotAllowed that describes the policy the command violates, if any.
+// The command line is parsed as a shell script, and every simple command in it, including those in
+// pipelines, lists and subshells, must be allowed and must not use a denied argument.
+func (cs *CommandServer) checkCommand(command string) error {
+	return cs.checkCommandLine(command, false)
+}
+
+// checkScript checks a multi-line script like checkCommand, its commands may be on separate lines even if
+// allow_chaining is disabled.
+func (cs *CommandServer) checkScript(script string) error {
+	return cs.checkCommandLine(script, true)
+}
+
+func (cs *CommandServer) checkCommandLine(command string, script bool) error {
 	command = strings.TrimSpace(command)
 	if command == "" {
-		return false
+		return fmt.Errorf("%w: empty command", ErrCommandNotAllowed)
 	}
 
-	// Reject commands containing shell injection metacharacters.
-	for _, pattern := range shellInjectionPatterns {
-		if strings.Contains(command, pattern) {
-			return false
+	// Reject commands matching a denied pattern, e.g. piping into a shell.
+	if pattern, denied := cs.matchDeniedPattern(command); denied {
+		return fmt.Errorf("%w: matches the denied pattern '%s'", ErrCommandNotAllowed, pattern)
+	}
+
+	// The command lines of cmd and PowerShell are checked with the POSIX grammar once free of their own syntax.
+	if !isPOSIXShell(cs.config.Shell) {
+		if err := checkNonPOSIXShell(command, cs.config.Shell); err != nil {
+			return fmt.Errorf("%w: %w", ErrCommandNotAllowed, err)
 		}
 	}
 
-	// Handle && (logical AND) — must be checked before single &.
-	if strings.Contains(command, "&&") {
-		parts := strings.Split(command, "&&")
-		for _, part := range parts {
-			if !cs.isAllowedCommand(strings.TrimSpace(part)) {
-				return false
+	policy := shellPolicy{
+		chaining:    cs.config.AllowChaining,
+		pipes:       cs.config.AllowPipes,
+		redirection: cs.config.AllowRedirect,
+		script:      script,
+	}
+	if err := checkShellOperators(command, policy); err != nil {
+		return fmt.Errorf("%w: %w", ErrCommandNotAllowed, err)
+	}
+
+	cmds, err := parseSimpleCommands(command)
+	if err != nil {
+		return fmt.Errorf("%w: %w", ErrCommandNotAllowed, err)
+	}
+	if len(cmds) == 0 {
+		return fmt.Errorf("%w: no command found", ErrCommandNotAllowed)
+	}
+	for _, cmd := range cmds {
+		// Point at the offending line of multi-line scripts.
+		var where string
+		if strings.Contains(command, "\n") {
+			where = fmt.Sprintf("line %d: ", cmd.line)
+		}
+		if cmd.name == sudoCommand {
+			if err := cs.checkSudo(cmd); err != nil {
+				return fmt.Errorf("%w: %s%w", ErrCommandNotAllowed, where, err)
 			}
+			continue
+		}
+		if !cs.isAllowedSimpleCommand(cmd) {
+			return fmt.Errorf("%w: %s'%s' does not match any entry of allowed_command", ErrCommandNotAllowed, where, cmd)
+		}
+		if arg, denied := cs.matchDeniedArgument(cmd.name, cmd.args); denied {
+			return fmt.Errorf("%w: %sargument '%s' of '%s' is denied by denied_arguments", ErrCommandNotAllowed, where, arg, cmd.name)
 		}
-		return true
 	}
+	return nil
+}
 
-	// Handle || (logical OR) — must be checked before single |.
-	if strings.Contains(command, "||") {
-		parts := strings.Split(command, "||")
-		for _, part := range parts {
-			if !cs.isAllowedCommand(strings.TrimSpace(part)) {
-				return false
-			}
+// isAllowedSimpleCommand checks if the command name is in the allowed command list,
+// or the whole command matches one of the allowed command patterns.
+func (cs *CommandServer) isAllowedSimpleCommand(cmd simpleCommand) bool {
+	for _, allowed := range cs.config.allowedCommands {
+		if cmd.name == strings.TrimSpace(allowed) {
+			return true
+		}
+	}
+	line := cmd.String()
+	for _, pattern := range cs.config.allowedPatterns {
+		if pattern.MatchString(line) {
+			return true
 		}
-		return true
 	}
+	return false
+}
 
-	// Handle | (pipe).
-	if strings.Contains(command, "|") {
-		parts := strings.Split(command, "|")
-		for _, part := range parts {
-			if !cs.isAllowedCommand(strings.TrimSpace(part)) {
-				return false
+// matchDeniedPattern returns the denied pattern the command matches, if any.
+// Whitespace and the spacing around shell operators are normalized, so "curl x|sh" matches "| sh".
+// A pattern that starts or ends with a word character only matches at a word boundary, so "| sh" does not match "| shasum".
+func (cs *CommandServer) matchDeniedPattern(command string) (string, bool) {
+	normalized := normalizeShellSpacing(command)
+	for _, pattern := range cs.config.deniedPatterns {
+		p := normalizeShellSpacing(pattern)
+		if p == "" {
+			continue
+		}
+		for i := strings.Index(normalized, p); i >= 0; {
+			end := i + len(p)
+			if (!isWordByte(p[0]) || i == 0 || !isWordByte(normalized[i-1])) &&
+				(!isWordByte(p[len(p)-1]) || end == len(normalized) || !isWordByte(normalized[end])) {
+				return pattern, true
+			}
+			next := strings.Index(normalized[i+1:], p)
+			if next < 0 {
+				break
 			}
+			i += next + 1
 		}
-		return true
 	}
+	return "", false
+}
 
-	// Handle & (background execution).
-	if strings.Contains(command, "&") {
-		parts := strings.Split(command, "&")
-		for _, part := range parts {
-			if !cs.isAllowedCommand(strings.TrimSpace(part)) {
-				return false
+// matchDeniedArgument returns the argument of cmdName that is denied by the denied_arguments policy, if any.
+// Besides exact matches, --flag=value, short flags with an attached value (-o/tmp/x) and clusters of short flags
+// (-sSo/tmp/x) are recognized.
+func (cs *CommandServer) matchDeniedArgument(cmdName string, args []string) (string, bool) {
+	denied := cs.config.DeniedArguments[cmdName]
+	if len(denied) == 0 {
+		return "", false
+	}
+	for _, arg := range args {
+		arg = strings.Trim(arg, `"'`)
+		for _, d := range denied {
+			isShortFlag := len(d) == 2 && d[0] == '-' && d[1] != '-'
+			if arg == d || strings.HasPrefix(arg, d+"=") || (isShortFlag && strings.HasPrefix(arg, d)) {
+				return arg, true
 			}
 		}
-		return true
+		if len(arg) > 2 && arg[0] == '-' && arg[1] != '-' && !slices.Contains(singleDashLongFlagCommands, cmdName) &&
+			matchDeniedShortFlag(cmdName, arg, denied) {
+			return arg, true
+		}
 	}
+	return "", false
+}
 
-	// Extract the command name (first token) and check it against the allowlist.
-	fields := strings.Fields(command)
-	if len(fields) == 0 {
-		return false
-	}
-	cmdName := fields[0]
-	for _, allowed := range cs.config.allowedCommands {
-		if cmdName == strings.TrimSpace(allowed) {
+// matchDeniedShortFlag reports whether the cluster of short flags has a denied one. The flags of cmdName taking
+// a value end the cluster, the rest is their value.
+func matchDeniedShortFlag(cmdName, cluster string, denied []string) bool {
+	for i := 1; i < len(cluster); i++ {
+		if slices.Contains(denied, "-"+cluster[i:i+1]) {
 			return true
 		}
+		if strings.IndexByte(shortValueFlags[cmdName], cluster[i]) >= 0 {
+			return false
+		}
 	}
-
 	return false
 }
 
+// normalizeShellSpacing collapses whitespace and puts exactly one space around the shell operators | & ; < >.
+func normalizeShellSpacing(s string) string {
+	var sb strings.Builder
+	for _, r := range s {
+		if strings.ContainsRune("|&;<>", r) {
+			sb.WriteRune(' ')
+			sb.WriteRune(r)
+			sb.WriteRune(' ')
+			continue
+		}
+		sb.WriteRune(r)
+	}
+	return strings.Join(strings.Fields(sb.String()), " ")
+}
+
+// isWordByte reports whether b is part of a word, for boundary checks of denied patterns.
+func isWordByte(b byte) bool {
+	return b == '_' || b == '-' || b == '.' || (b >= '0' && b <= '9') || (b >= 'a' && b <= 'z') || (b >= 'A' && b <= 'Z')
+}
+
 // Config returns the configuration of the service as a string.
 func (cs *CommandServer) Config() string {
 	cs.config.AllowedCommand = strings.Join(cs.config.allowedCommands, ",")
+	cs.config.AllowedDir = strings.Join(cs.config.allowedDirs, ",")
+	cs.config.DeniedPattern = strings.Join(cs.config.deniedPatterns, ",")
+	cs.config.ConfirmPattern = strings.Join(cs.config.confirmCommands, ",")
+	cs.config.CacheCommands = strings.Join(cs.config.cacheCommands, ",")
+	cs.config.SudoCommands = strings.Join(cs.config.sudoCommands, ",")
+	cs.config.AllowedEnvKeys = strings.Join(cs.config.allowedEnvKeys, ",")
+	cs.config.InheritEnv = strings.Join(cs.config.inheritEnv, ",")
+	cs.config.SandboxWritable = strings.Join(cs.config.sandboxWritable, ",")
 	cfg, err := json.Marshal(cs.config)
 	if err != nil {
 		cs.Logger.Err(err).Msg("failed to marshal config")
@@ -238,19 +987,106 @@ func (cs *CommandServer) Name() comm.MoLingServerType {
 	return CommandServerName
 }
 
+// ToolTimeout returns the longest a command may wait for a slot and run, with the maximum timeout.
+func (cs *CommandServer) ToolTimeout() time.Duration {
+	return time.Duration(cs.config.MaxTimeout+cs.config.QueueTimeout)*time.Second + 10*time.Second
+}
+
+// Health checks the shell of the commands is installed.
+func (cs *CommandServer) Health(ctx context.Context) map[string]error {
+	_, err := exec.LookPath(cs.config.Shell)
+	return map[string]error{"shell": err}
+}
+
+// ClientResources returns the shell sessions opened and the background jobs running for the client session.
+func (cs *CommandServer) ClientResources(sessionID string) []string {
+	var resources []string
+	if cs.sessions != nil {
+		for _, id := range cs.sessions.IDs(func(s *Session) bool { return s.Client == sessionID }) {
+			resources = append(resources, "shell session "+id)
+		}
+	}
+	for _, job := range cs.clientJobs(sessionID) {
+		resources = append(resources, "background job "+job.ID)
+	}
+	return resources
+}
+
+// CloseClientSession closes the shell sessions opened and kills the background jobs running for the client
+// session, no other client may access them.
+func (cs *CommandServer) CloseClientSession(sessionID string) {
+	if cs.sessions != nil {
+		for _, id := range cs.sessions.IDs(func(s *Session) bool { return s.Client == sessionID }) {
+			if err := cs.sessions.Close(id); err != nil {
+				cs.Logger.Warn().Err(err).Str("session", id).Msg("failed to close the shell session of the client")
+			}
+		}
+	}
+	for _, job := range cs.clientJobs(sessionID) {
+		if err := cs.jobs.Kill(job.ID); err != nil {
+			cs.Logger.Warn().Err(err).Str("job", job.ID).Msg("failed to kill the background job of the client")
+		}
+	}
+}
+
+// clientJobs returns the background jobs of the client session still running.
+func (cs *CommandServer) clientJobs(sessionID string) []*Job {
+	if cs.jobs == nil {
+		return nil
+	}
+	return slices.DeleteFunc(cs.jobs.List(), func(job *Job) bool {
+		status, _, _, _ := job.State()
+		return job.Client != sessionID || status != JobRunning
+	})
+}
+
 func (cs *CommandServer) Close() error {
-	// Cancel the context to stop the browser
+	if cs.jobs != nil {
+		cs.jobs.KillAll()
+	}
+	if cs.sessions != nil {
+		cs.sessions.CloseAll()
+	}
 	cs.Logger.Debug().Msg("CommandServer closed")
 	return nil
 }
 
-// LoadConfig loads the configuration from a JSON object.
+// LoadConfig loads the configuration from a JSON object. It is merged into a copy of the configuration, which
+// replaces it once checked, so an invalid one keeps the configuration of the running service.
 func (cs *CommandServer) LoadConfig(jsonData map[string]any) error {
-	err := utils.MergeJSONToStruct(cs.config, jsonData)
+	cc := *cs.config
+	cc.DeniedArguments = maps.Clone(cc.DeniedArguments)
+	cc.SandboxCommands = maps.Clone(cc.SandboxCommands)
+	err := utils.MergeJSONToStruct(&cc, jsonData)
 	if err != nil {
 		return err
 	}
 	// split the AllowedCommand string into a slice
-	cs.config.allowedCommands = strings.Split(cs.config.AllowedCommand, ",")
-	return cs.config.Check()
+	cc.allowedCommands = strings.Split(cc.AllowedCommand, ",")
+	cc.allowedDirs = strings.Split(cc.AllowedDir, ",")
+	cc.deniedPatterns = utils.SplitTrim(cc.DeniedPattern, ",")
+	cc.confirmCommands = utils.SplitTrim(cc.ConfirmPattern, ",")
+	cc.cacheCommands = utils.SplitTrim(cc.CacheCommands, ",")
+	cc.sudoCommands = utils.SplitTrim(cc.SudoCommands, ",")
+	cc.allowedEnvKeys = utils.SplitTrim(cc.AllowedEnvKeys, ",")
+	cc.inheritEnv = utils.SplitTrim(cc.InheritEnv, ",")
+	cc.sandboxWritable = utils.SplitTrim(cc.SandboxWritable, ",")
+	if err = cc.Check(); err != nil {
+		return err
+	}
+	if cs.slots == nil || cap(cs.slots) != cc.MaxConcurrent {
+		cs.slots = make(chan struct{}, cc.MaxConcurrent)
+	}
+	cs.config = &cc
+	return nil
+}
+
+// RuntimeConfigKeys returns the keys of the configuration applied to the running service on reload, its
+// allowlists, denied patterns and timeouts. The jobs and the shell sessions are kept.
+func (cs *CommandServer) RuntimeConfigKeys() []string {
+	return []string{
+		"allowed_command", "denied_pattern", "denied_arguments", "confirm_pattern", "sudo_commands", "sudo_confirm",
+		"allowed_env_keys", "timeout", "max_timeout", "queue_timeout", "max_output_bytes", "truncation",
+		"cache_commands", "cache_ttl", "allow_chaining", "allow_pipes", "allow_redirection",
+	}
 }
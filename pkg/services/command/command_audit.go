@@ -0,0 +1,179 @@
+// Copyright 2025 CFC4N <cfc4n.cs@gmail.com>. All Rights Reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//   http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+//
+// Repository: https://github.com/gojue/moling
+
+package command
+
+import (
+	"bufio"
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/mark3labs/mcp-go/mcp"
+	"github.com/mark3labs/mcp-go/server"
+
+	"github.com/gojue/moling/pkg/comm"
+	"github.com/gojue/moling/pkg/utils"
+)
+
+// AuditFileName is the audit log file of the commands run by the agent, under BasePath/logs.
+const AuditFileName = "command_audit.jsonl"
+
+const (
+	defaultHistoryLimit = 20  // defaultHistoryLimit is the number of entries command_history returns by default.
+	maxHistoryLimit     = 500 // maxHistoryLimit is the maximum number of entries command_history returns.
+)
+
+// AuditStatus is the outcome of a command recorded in the audit log.
+type AuditStatus string
+
+const (
+	AuditExecuted AuditStatus = "executed" // AuditExecuted means the command ran to completion, whatever its exit code.
+	AuditTimeout  AuditStatus = "timeout"  // AuditTimeout means the command was killed after its timeout.
+	AuditStarted  AuditStatus = "started"  // AuditStarted means the command was started in the background or sent to a session.
+	AuditFailed   AuditStatus = "failed"   // AuditFailed means the command could not be run.
+	AuditRejected AuditStatus = "rejected" // AuditRejected means the command was rejected by the command policy.
+	AuditCached   AuditStatus = "cached"   // AuditCached means a cached result of the command was returned.
+)
+
+// AuditEntry is one line of the audit log.
+type AuditEntry struct {
+	Time       time.Time   `json:"time"`
+	Session    string      `json:"session,omitempty"` // Session is the MCP session of the client that called the tool.
+	Tool       string      `json:"tool"`
+	Command    string      `json:"command"`
+	Dir        string      `json:"working_dir,omitempty"`
+	Status     AuditStatus `json:"status"`
+	ExitCode   int         `json:"exit_code"`
+	DurationMs int64       `json:"duration_ms"`
+	OutputHash string      `json:"output_sha256,omitempty"` // OutputHash is the SHA-256 of the returned, possibly truncated, stdout and stderr.
+	Error      string      `json:"error,omitempty"`
+}
+
+// auditLog appends command executions to a JSONL file.
+type auditLog struct {
+	mu sync.Mutex
+}
+
+// Append writes the entry as a new line of the audit file at path.
+func (a *auditLog) Append(path string, entry AuditEntry) error {
+	data, err := json.Marshal(entry)
+	if err != nil {
+		return err
+	}
+	a.mu.Lock()
+	defer a.mu.Unlock()
+	if err = utils.CreateDirectory(filepath.Dir(path)); err != nil {
+		return err
+	}
+	f, err := os.OpenFile(path, os.O_APPEND|os.O_CREATE|os.O_WRONLY, 0o600)
+	if err != nil {
+		return err
+	}
+	if _, err = f.Write(append(data, '\n')); err != nil {
+		_ = f.Close()
+		return err
+	}
+	return f.Close()
+}
+
+// Tail returns the last n entries of the audit file at path, oldest first.
+func (a *auditLog) Tail(path string, n int) ([]AuditEntry, error) {
+	a.mu.Lock()
+	defer a.mu.Unlock()
+	f, err := os.Open(path)
+	if errors.Is(err, os.ErrNotExist) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	defer f.Close()
+
+	var entries []AuditEntry
+	scanner := bufio.NewScanner(f)
+	scanner.Buffer(make([]byte, 0, 64*1024), 16*1024*1024)
+	for scanner.Scan() {
+		var entry AuditEntry
+		if err := json.Unmarshal(scanner.Bytes(), &entry); err != nil {
+			continue
+		}
+		entries = append(entries, entry)
+		if len(entries) > n {
+			entries = entries[1:]
+		}
+	}
+	return entries, scanner.Err()
+}
+
+// audit records a command in the audit log, filling in the time and the caller session.
+func (cs *CommandServer) audit(ctx context.Context, entry AuditEntry) {
+	entry.Time = time.Now()
+	if session := server.ClientSessionFromContext(ctx); session != nil {
+		entry.Session = session.SessionID()
+	}
+	cs.stats.Record(entry)
+	if err := cs.auditLog.Append(cs.auditFile(), entry); err != nil {
+		cs.Logger.Warn().Err(err).Str("command", entry.Command).Msg("failed to write the audit log")
+	}
+}
+
+// auditFile returns the path of the audit log file.
+func (cs *CommandServer) auditFile() string {
+	return filepath.Join(cs.MlConfig().BasePath, "logs", AuditFileName)
+}
+
+// handleCommandHistory returns the most recent entries of the audit log, one JSON object per line.
+func (cs *CommandServer) handleCommandHistory(ctx context.Context, request mcp.CallToolRequest) (*mcp.CallToolResult, error) {
+	limit := defaultHistoryLimit
+	if v, ok := request.GetArguments()["limit"].(float64); ok && v > 0 {
+		limit = min(int(v), maxHistoryLimit)
+	}
+	entries, err := cs.auditLog.Tail(cs.auditFile(), limit)
+	if err != nil {
+		return comm.NewToolResultErrorFor(err, fmt.Sprintf("Error reading the audit log: %v", err)), nil
+	}
+	if len(entries) == 0 {
+		return mcp.NewToolResultText("No commands in the audit log"), nil
+	}
+	var sb strings.Builder
+	sb.WriteString(fmt.Sprintf("Last %d commands, oldest first:\n\n", len(entries)))
+	for _, entry := range entries {
+		data, err := json.Marshal(entry)
+		if err != nil {
+			continue
+		}
+		sb.Write(data)
+		sb.WriteString("\n")
+	}
+	return mcp.NewToolResultText(sb.String()), nil
+}
+
+// outputHash returns the hex SHA-256 of the result output.
+func outputHash(result *ExecResult) string {
+	h := sha256.New()
+	h.Write([]byte(result.Stdout))
+	h.Write([]byte(result.Stderr))
+	return hex.EncodeToString(h.Sum(nil))
+}
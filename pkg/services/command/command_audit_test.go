@@ -0,0 +1,149 @@
+// Copyright 2025 CFC4N <cfc4n.cs@gmail.com>. All Rights Reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//   http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+//
+// Repository: https://github.com/gojue/moling
+
+package command
+
+import (
+	"context"
+	"encoding/json"
+	"expvar"
+	"runtime"
+	"strings"
+	"testing"
+
+	"github.com/mark3labs/mcp-go/mcp"
+
+	"github.com/gojue/moling/pkg/comm"
+)
+
+// TestAuditLog verifies that executed and rejected commands are recorded and returned by command_history.
+func TestAuditLog(t *testing.T) {
+	if runtime.GOOS == "windows" {
+		t.Skip("skipping on windows")
+	}
+	_, ctx, err := comm.InitTestEnv()
+	if err != nil {
+		t.Fatalf("Failed to initialize test environment: %v", err)
+	}
+	cs, err := NewCommandServer(ctx)
+	if err != nil {
+		t.Fatalf("Failed to create CommandServer: %v", err)
+	}
+	if err = cs.LoadConfig(StructToMap(NewCommandConfig())); err != nil {
+		t.Fatalf("Failed to load config: %v", err)
+	}
+	cs1 := cs.(*CommandServer)
+	cs1.MlConfig().BasePath = t.TempDir()
+
+	var request mcp.CallToolRequest
+	request.Params.Arguments = map[string]any{"limit": float64(10)}
+	result, err := cs1.handleCommandHistory(context.Background(), request)
+	if err != nil || result.IsError || result.Content[0].(mcp.TextContent).Text != "No commands in the audit log" {
+		t.Fatalf("Expected an empty history, got: %v %v", result, err)
+	}
+
+	for _, command := range []string{"echo audit", "id", "ls /nonexistent"} {
+		request.Params.Arguments = map[string]any{"command": command}
+		if _, err = cs1.handleExecuteCommand(context.Background(), request); err != nil {
+			t.Fatalf("Failed to execute command: %v", err)
+		}
+	}
+
+	entries, err := cs1.auditLog.Tail(cs1.auditFile(), 10)
+	if err != nil {
+		t.Fatalf("Failed to read audit log: %v", err)
+	}
+	if len(entries) != 3 {
+		t.Fatalf("Expected 3 audit entries, got %d", len(entries))
+	}
+	if e := entries[0]; e.Command != "echo audit" || e.Status != AuditExecuted || e.ExitCode != 0 || e.OutputHash != outputHash(&ExecResult{Stdout: "audit\n"}) {
+		t.Errorf("Unexpected entry for an executed command: %+v", e)
+	}
+	if e := entries[1]; e.Command != "id" || e.Status != AuditRejected || e.Error == "" {
+		t.Errorf("Unexpected entry for a rejected command: %+v", e)
+	}
+	if e := entries[2]; e.Status != AuditExecuted || e.ExitCode == 0 {
+		t.Errorf("Unexpected entry for a failing command: %+v", e)
+	}
+
+	request.Params.Arguments = map[string]any{"limit": float64(2)}
+	result, err = cs1.handleCommandHistory(context.Background(), request)
+	if err != nil || result.IsError {
+		t.Fatalf("Failed to get command history: %v", err)
+	}
+	text := result.Content[0].(mcp.TextContent).Text
+	if !strings.HasPrefix(text, "Last 2 commands") || strings.Contains(text, "echo audit") || !strings.Contains(text, `"command":"ls /nonexistent"`) {
+		t.Errorf("Unexpected command history: %s", text)
+	}
+}
+
+// TestCommandStats verifies the statistics per binary and the command_stats tool.
+func TestCommandStats(t *testing.T) {
+	if runtime.GOOS == "windows" {
+		t.Skip("skipping on windows")
+	}
+	_, ctx, err := comm.InitTestEnv()
+	if err != nil {
+		t.Fatalf("Failed to initialize test environment: %v", err)
+	}
+	cs, err := NewCommandServer(ctx)
+	if err != nil {
+		t.Fatalf("Failed to create CommandServer: %v", err)
+	}
+	if err = cs.LoadConfig(StructToMap(NewCommandConfig())); err != nil {
+		t.Fatalf("Failed to load config: %v", err)
+	}
+	cs1 := cs.(*CommandServer)
+	cs1.MlConfig().BasePath = t.TempDir()
+
+	var request mcp.CallToolRequest
+	for _, command := range []string{"echo a", "echo b | wc -l", "ls /nonexistent", "id"} {
+		request.Params.Arguments = map[string]any{"command": command}
+		if _, err = cs1.handleExecuteCommand(context.Background(), request); err != nil {
+			t.Fatalf("Failed to execute command: %v", err)
+		}
+	}
+
+	stats := make(map[string]BinaryStats)
+	for _, b := range cs1.stats.Snapshot() {
+		stats[b.Binary] = b
+	}
+	if b := stats["echo"]; b.Invocations != 2 || b.Failures != 0 || b.FailureRate != 0 {
+		t.Errorf("Unexpected stats for echo: %+v", b)
+	}
+	if b := stats["wc"]; b.Invocations != 1 {
+		t.Errorf("Unexpected stats for wc: %+v", b)
+	}
+	if b := stats["ls"]; b.Invocations != 1 || b.Failures != 1 || b.FailureRate != 1 {
+		t.Errorf("Unexpected stats for ls: %+v", b)
+	}
+	if b := stats["id"]; b.Invocations != 0 || b.Rejected != 1 {
+		t.Errorf("Unexpected stats for id: %+v", b)
+	}
+
+	request.Params.Arguments = map[string]any{"binary": "echo"}
+	result, err := cs1.handleCommandStats(context.Background(), request)
+	if err != nil || result.IsError {
+		t.Fatalf("Failed to get command stats: %v", err)
+	}
+	var filtered []BinaryStats
+	if err = json.Unmarshal([]byte(result.Content[0].(mcp.TextContent).Text), &filtered); err != nil || len(filtered) != 1 || filtered[0].Binary != "echo" {
+		t.Errorf("Unexpected command_stats result: %v %v", filtered, err)
+	}
+	if v := expvar.Get(StatsVarName); v == nil || !strings.Contains(v.String(), `"binary":"echo"`) {
+		t.Errorf("Expected the statistics to be published as %s", StatsVarName)
+	}
+}
@@ -0,0 +1,94 @@
+// Copyright 2025 CFC4N <cfc4n.cs@gmail.com>. All Rights Reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//   http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+//
+// Repository: https://github.com/gojue/moling
+
+package command
+
+import (
+	"strings"
+	"sync"
+	"time"
+)
+
+// maxCacheEntries is the maximum number of results kept in the cache of cache_commands.
+const maxCacheEntries = 256
+
+// resultCache keeps the results of read-only commands for a while, so that repeated diagnostics don't run again.
+type resultCache struct {
+	mu      sync.Mutex
+	entries map[string]cachedResult
+}
+
+type cachedResult struct {
+	result  ExecResult
+	expires time.Time
+}
+
+// Get returns a copy of the cached result for key, marked as cached, if it has not expired.
+func (c *resultCache) Get(key string) (*ExecResult, bool) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	entry, ok := c.entries[key]
+	if !ok || time.Now().After(entry.expires) {
+		return nil, false
+	}
+	result := entry.result
+	result.Cached = true
+	return &result, true
+}
+
+// Put caches the result for key for ttl. Expired entries are dropped first, then the ones expiring soonest
+// if the cache is full.
+func (c *resultCache) Put(key string, result *ExecResult, ttl time.Duration) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	if c.entries == nil {
+		c.entries = make(map[string]cachedResult)
+	}
+	now := time.Now()
+	for k, entry := range c.entries {
+		if now.After(entry.expires) {
+			delete(c.entries, k)
+		}
+	}
+	for len(c.entries) >= maxCacheEntries {
+		var oldest string
+		for k, entry := range c.entries {
+			if oldest == "" || entry.expires.Before(c.entries[oldest].expires) {
+				oldest = k
+			}
+		}
+		delete(c.entries, oldest)
+	}
+	c.entries[key] = cachedResult{result: *result, expires: now.Add(ttl)}
+}
+
+// cacheKey returns the cache key of the command, which is cacheable if every command in it is in cache_commands.
+// The key includes the working directory and the environment, both can change the output.
+func (cs *CommandServer) cacheKey(command string, opts ExecOptions) (string, bool) {
+	if cs.config.CacheTTL <= 0 || len(cs.config.cacheCommands) == 0 {
+		return "", false
+	}
+	cmds, err := parseSimpleCommands(command)
+	if err != nil || len(cmds) == 0 {
+		return "", false
+	}
+	for _, cmd := range cmds {
+		if !cmd.matches(cs.config.cacheCommands, cs.config.cachePatterns) {
+			return "", false
+		}
+	}
+	return strings.Join(append([]string{command, opts.Dir}, opts.Env...), "\x00"), true
+}
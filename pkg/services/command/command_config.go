@@ -19,68 +19,115 @@ package command
 import (
 	"fmt"
 	"os"
+	"path/filepath"
+	"regexp"
+	"slices"
 	"strings"
-)
-
-const (
-	CommandPromptDefault = `
-You are a powerful terminal command assistant capable of executing various command-line on %s operations and management tasks. Your capabilities include:
-
-1. **File and Directory Management**:
-    - List files and subdirectories in a directory
-    - Create new files or directories
-    - Delete specified files or directories
-    - Copy and move files and directories
-    - Rename files or directories
-
-2. **File Content Operations**:
-    - View the contents of text files
-    - Edit file contents
-    - Redirect output to a file
-    - Search file contents
-
-3. **System Information Retrieval**:
-    - Retrieve system information (e.g., CPU usage, memory usage, etc.)
-    - View the current user and their permissions
-    - Check the current working directory
-
-4. **Network Operations**:
-    - Check network connection status (e.g., using the ping command)
-    - Query domain information (e.g., using the whois command)
-    - Manage network services (e.g., start, stop, and restart services)
-
-5. **Process Management**:
-    - List currently running processes
-    - Terminate specified processes
-    - Adjust process priorities
-
-Before executing any actions, please provide clear instructions, including:
-- The specific command you want to execute
-- Required parameters (file paths, directory names, etc.)
-- Any optional parameters (e.g., modification options, output formats, etc.)
-- Relevant expected results or output
-
-When dealing with sensitive operations or destructive commands, please confirm before execution. Report back with clear status updates, success/failure indicators, and any relevant output or results.
-`
+	"text/template"
 )
 
 // CommandConfig represents the configuration for allowed commands.
 type CommandConfig struct {
 	PromptFile      string `json:"prompt_file"` // PromptFile is the prompt file for the command.
 	prompt          string
-	AllowedCommand  string `json:"allowed_command"` // AllowedCommand is a list of allowed command. split by comma. e.g. ls,cat,echo
+	promptTemplate  *template.Template
+	AllowedCommand  string `json:"allowed_command"` // AllowedCommand is a list of allowed command names or patterns. split by comma. e.g. ls,cat,git *,^docker (ps|logs)
 	allowedCommands []string
+	allowedPatterns []*regexp.Regexp
+	DeniedPattern   string `json:"denied_pattern"` // DeniedPattern is a list of patterns that are rejected anywhere in a command, even if the command is allowed. split by comma. e.g. | sh,> /etc
+	deniedPatterns  []string
+	ConfirmPattern  string `json:"confirm_pattern"` // ConfirmPattern is a list of command names or patterns that run only after the user confirms them. split by comma. e.g. shutdown,rm -rf *
+	confirmCommands []string
+	confirmPatterns []*regexp.Regexp
+	SudoCommands    string `json:"sudo_commands"` // SudoCommands is a list of command names or patterns that may be run with sudo, independent of allowed_command. split by comma. e.g. systemctl restart nginx. empty: sudo is refused
+	sudoCommands    []string
+	sudoPatterns    []*regexp.Regexp
+	SudoConfirm     bool                `json:"sudo_confirm"`     // SudoConfirm runs sudo only after the user confirms it through elicitation.
+	DeniedArguments map[string][]string `json:"denied_arguments"` // DeniedArguments maps a command to the arguments it may not be called with. e.g. {"curl": ["-o", "--output"]}
+	AllowedDir      string              `json:"allowed_dir"`      // AllowedDir is a list of directories allowed as working_dir. split by comma. empty: same as the FileSystem service.
+	allowedDirs     []string
+	UseRoots        bool   `json:"use_roots"`        // UseRoots allows the workspace roots of the clients as working_dir too, for their session, if they support MCP roots.
+	AllowedEnvKeys  string `json:"allowed_env_keys"` // AllowedEnvKeys is a list of environment variables that may be set by the env argument. split by comma. e.g. LANG,GIT_PAGER
+	allowedEnvKeys  []string
+	InheritEnv      string `json:"inherit_env"` // InheritEnv is a list of server environment variables passed to commands. split by comma. e.g. PATH,HOME
+	inheritEnv      []string
+	Timeout         int    `json:"timeout"`          // Timeout is the default timeout for a command. time.Second
+	MaxTimeout      int    `json:"max_timeout"`      // MaxTimeout is the upper bound of the per-call timeout_seconds argument. time.Second
+	MaxJobs         int    `json:"max_jobs"`         // MaxJobs is the maximum number of background jobs running at the same time.
+	MaxSessions     int    `json:"max_sessions"`     // MaxSessions is the maximum number of interactive shell sessions open at the same time.
+	MaxOutputBytes  int    `json:"max_output_bytes"` // MaxOutputBytes is the number of bytes of stdout and stderr returned per call, the rest is truncated or paged.
+	Truncation      string `json:"truncation"`       // Truncation is the part of a larger output that execute_command keeps. head, tail or head_tail
+	Shell           string `json:"shell"`            // Shell is the shell commands run through. sh, bash, zsh or fish, on Windows: cmd, powershell or pwsh
+	LoginShell      bool   `json:"login_shell"`      // LoginShell runs the shell as a login shell, so the user's profile sets up PATH. PowerShell: load the profile
+	MaxCPUSeconds   int    `json:"max_cpu_seconds"`  // MaxCPUSeconds is the CPU time limit of each process started by a command. time.Second, 0: unlimited
+	MaxMemoryMB     int    `json:"max_memory_mb"`    // MaxMemoryMB is the memory limit of each process started by a command. MB, 0: unlimited
+	MaxOpenFiles    int    `json:"max_open_files"`   // MaxOpenFiles is the open file limit of each process started by a command, not supported on Windows. 0: unlimited
+	MaxProcesses    int    `json:"max_processes"`    // MaxProcesses is the process limit, counted for the whole user on Unix, for the command on Windows. 0: unlimited
+	MaxConcurrent   int    `json:"max_concurrent"`   // MaxConcurrent is the maximum number of commands running in the foreground at the same time, further calls queue.
+	QueueTimeout    int    `json:"queue_timeout"`    // QueueTimeout is how long a queued command waits for a free slot before failing. time.Second
+	Sandbox         string `json:"sandbox"`          // Sandbox is the sandbox commands run in. none, sandbox-exec (macOS), bwrap or nsjail (Linux), docker or podman
+	SandboxWritable string `json:"sandbox_writable"` // SandboxWritable is a list of paths writable in the sandbox, everything else is read-only. split by comma. empty: the allowed directories
+	sandboxWritable []string
+	SandboxCommands map[string]string `json:"sandbox_commands"` // SandboxCommands selects the sandbox of a command by its name, overriding sandbox. e.g. {"curl": "docker", "git": "none"}
+	SandboxNetwork  bool              `json:"sandbox_network"`  // SandboxNetwork allows network access in the sandbox.
+	SandboxImage    string            `json:"sandbox_image"`    // SandboxImage is the container image of the docker and podman sandboxes.
+	CacheCommands   string            `json:"cache_commands"`   // CacheCommands is a list of read-only command names or patterns whose results are cached. split by comma. e.g. uname,sw_vers,ifconfig. empty: no caching
+	cacheCommands   []string
+	cachePatterns   []*regexp.Regexp
+	CacheTTL        int  `json:"cache_ttl"`         // CacheTTL is how long a cached result is returned for the same command and working directory. time.Second
+	AllowChaining   bool `json:"allow_chaining"`    // AllowChaining allows running several commands in one call with ; & && || or newlines. Scripts may always use newlines.
+	AllowPipes      bool `json:"allow_pipes"`       // AllowPipes allows piping the output of a command into another with |.
+	AllowRedirect   bool `json:"allow_redirection"` // AllowRedirect allows redirecting input and output with < > >> and here-documents. 2>&1 is always allowed.
 }
 
 var (
-	allowedCmdDefault = []string{
-		"ls", "cat", "echo", "pwd", "head", "tail", "grep", "find", "stat", "df",
-		"du", "free", "top", "ps", "uptime", "who", "w", "last", "uname", "hostname",
-		"ifconfig", "netstat", "ping", "traceroute", "route", "ip", "ss", "lsof", "vmstat",
-		"iostat", "mpstat", "sar", "uptime", "cut", "sort", "uniq", "wc", "awk", "sed",
-		"diff", "cmp", "comm", "file", "basename", "dirname", "chmod", "chown", "curl",
-		"nslookup", "dig", "host", "ssh", "scp", "sftp", "ftp", "wget", "tar", "gzip",
-		"scutil", "networksetup", "git", "cd",
+	// deniedPatternDefault are rejected anywhere in a command: piping into an interpreter and writing to system paths.
+	deniedPatternDefault = []string{
+		"| sh", "| bash", "| zsh", "| dash", "| python", "| python3", "| perl", "| ruby", "| node",
+		"> /etc", "> /dev/sd", "> /dev/nvme", "> /boot", "--output /etc",
+	}
+	// deniedArgumentsDefault are the arguments that allow an otherwise harmless command to write files or run other commands.
+	deniedArgumentsDefault = map[string][]string{
+		"curl": {"-o", "--output", "-O", "--remote-name", "-K", "--config", "-T", "--upload-file"},
+		"wget": {"-O", "--output-document", "-P", "--directory-prefix", "-i", "--input-file"},
+		"find": {"-exec", "-execdir", "-ok", "-okdir", "-delete", "-fprint", "-fprintf", "-fls"},
+		"git":  {"-c", "--config-env", "--upload-pack", "--receive-pack", "--exec"},
+		"sed":  {"-i", "--in-place"},
+		"tar":  {"--to-command", "--use-compress-program", "-I", "--checkpoint-action"},
+		"ssh":  {"-o", "-F"},
+	}
+	// shortValueFlags are the short flags taking a value, by command: in a cluster of short flags, the rest after
+	// them is their value, e.g. the header of curl -sHo:x.
+	shortValueFlags = map[string]string{
+		"curl": "AbcCdDeEFHKmoPQrTuUwxXyYz",
+		"wget": "aABDeiIloOPQRtTUwX",
+		"git":  "cC",
+		"sed":  "efl",
+		"tar":  "bCfgHIKLNTVX",
+		"ssh":  "bBcDeEFiIJlLmoOpQRSwW",
+	}
+	// singleDashLongFlagCommands take long flags after a single dash, e.g. find -delete, not clusters of short flags.
+	singleDashLongFlagCommands = []string{"find"}
+	// confirmPatternDefault are the destructive commands that need the user's confirmation, even if they are allowed.
+	confirmPatternDefault = []string{
+		"rm -rf *", "rm -fr *", "rm -r -f *", "rm -f -r *", "rm --recursive --force *",
+		"mkfs", "mkfs.*", "dd", "shutdown", "reboot", "halt", "poweroff",
+		"kill -9 *", "kill -KILL *", "kill -s KILL *", "pkill -9 *", "killall -9 *",
+		// Windows
+		"format", "Format-Volume", "Stop-Computer", "Restart-Computer", "Remove-Item * -Recurse*", "taskkill * /F*",
+	}
+	// allowedEnvKeysDefault are the environment variables an agent may set by default.
+	allowedEnvKeysDefault = []string{
+		"LANG", "LC_ALL", "LC_CTYPE", "LC_MESSAGES", "TZ", "TERM", "COLUMNS", "LINES", "NO_COLOR",
+		"PAGER", "GIT_PAGER", "GIT_TERMINAL_PROMPT", "LESS",
+	}
+	// inheritEnvDefault are the server environment variables passed to commands by default, everything else
+	// (tokens, credentials, ...) stays in the server process.
+	inheritEnvDefault = []string{
+		"PATH", "HOME", "USER", "LOGNAME", "SHELL", "TMPDIR", "LANG", "LC_ALL", "TERM",
+		// Windows
+		"SystemRoot", "SystemDrive", "windir", "ComSpec", "PATHEXT", "TEMP", "TMP", "USERPROFILE",
+		"USERNAME", "APPDATA", "LOCALAPPDATA", "ProgramData", "ProgramFiles", "ProgramFiles(x86)",
 	}
 )
 
@@ -89,6 +136,31 @@ func NewCommandConfig() *CommandConfig {
 	return &CommandConfig{
 		allowedCommands: allowedCmdDefault,
 		AllowedCommand:  strings.Join(allowedCmdDefault, ","),
+		DeniedPattern:   strings.Join(deniedPatternDefault, ","),
+		deniedPatterns:  deniedPatternDefault,
+		ConfirmPattern:  strings.Join(confirmPatternDefault, ","),
+		confirmCommands: confirmPatternDefault,
+		DeniedArguments: deniedArgumentsDefault,
+		AllowedEnvKeys:  strings.Join(allowedEnvKeysDefault, ","),
+		allowedEnvKeys:  allowedEnvKeysDefault,
+		InheritEnv:      strings.Join(inheritEnvDefault, ","),
+		inheritEnv:      inheritEnvDefault,
+		Timeout:         10,
+		MaxTimeout:      300,
+		MaxJobs:         8,
+		MaxSessions:     4,
+		MaxOutputBytes:  DefaultMaxOutputBytes,
+		Truncation:      string(TruncateHeadTail),
+		Shell:           defaultShell,
+		MaxConcurrent:   4,
+		QueueTimeout:    30,
+		Sandbox:         string(SandboxNone),
+		SandboxImage:    DefaultSandboxImage,
+		CacheTTL:        60,
+		AllowChaining:   true,
+		AllowPipes:      true,
+		AllowRedirect:   true,
+		SudoConfirm:     true,
 	}
 }
 
@@ -108,6 +180,98 @@ func (cc *CommandConfig) Check() error {
 	if cnt <= 0 {
 		return fmt.Errorf("no allowed commands specified")
 	}
+	cc.allowedPatterns = nil
+	for _, cmd := range cc.allowedCommands {
+		pattern, ok, err := compileCommandPattern(cmd)
+		if err != nil {
+			return fmt.Errorf("invalid allowed_command pattern '%s': %w", cmd, err)
+		}
+		if ok {
+			cc.allowedPatterns = append(cc.allowedPatterns, pattern)
+		}
+	}
+	var err error
+	if cc.confirmPatterns, err = compileCommandPatterns(cc.confirmCommands); err != nil {
+		return fmt.Errorf("invalid confirm_pattern: %w", err)
+	}
+	if cc.cachePatterns, err = compileCommandPatterns(cc.cacheCommands); err != nil {
+		return fmt.Errorf("invalid cache_commands: %w", err)
+	}
+	if cc.sudoPatterns, err = compileCommandPatterns(cc.sudoCommands); err != nil {
+		return fmt.Errorf("invalid sudo_commands: %w", err)
+	}
+	if cc.CacheTTL < 0 {
+		return fmt.Errorf("cache_ttl must not be negative")
+	}
+	if cc.Timeout <= 0 {
+		return fmt.Errorf("timeout must be greater than 0")
+	}
+	if cc.MaxTimeout < cc.Timeout {
+		return fmt.Errorf("max_timeout must be greater than or equal to timeout")
+	}
+	if cc.MaxJobs < 0 {
+		return fmt.Errorf("max_jobs must not be negative")
+	}
+	if cc.MaxSessions < 0 {
+		return fmt.Errorf("max_sessions must not be negative")
+	}
+	if cc.MaxCPUSeconds < 0 || cc.MaxMemoryMB < 0 || cc.MaxOpenFiles < 0 || cc.MaxProcesses < 0 {
+		return fmt.Errorf("max_cpu_seconds, max_memory_mb, max_open_files and max_processes must not be negative")
+	}
+	if cc.MaxConcurrent <= 0 {
+		return fmt.Errorf("max_concurrent must be greater than 0")
+	}
+	if cc.QueueTimeout < 0 {
+		return fmt.Errorf("queue_timeout must not be negative")
+	}
+	if cc.MaxOutputBytes <= 0 {
+		return fmt.Errorf("max_output_bytes must be greater than 0")
+	}
+	if !slices.Contains(supportedShells, cc.Shell) {
+		return fmt.Errorf("shell must be one of %s", strings.Join(supportedShells, ", "))
+	}
+	if cc.Sandbox == "" {
+		cc.Sandbox = string(SandboxNone)
+	}
+	if err := checkSandboxBackend(cc.Sandbox); err != nil {
+		return err
+	}
+	for name, backend := range cc.SandboxCommands {
+		if err := checkSandboxBackend(backend); err != nil {
+			return fmt.Errorf("invalid sandbox_commands entry '%s': %w", name, err)
+		}
+	}
+	if cc.SandboxImage == "" {
+		cc.SandboxImage = DefaultSandboxImage
+	}
+	writable := make([]string, 0, len(cc.sandboxWritable))
+	for _, p := range cc.sandboxWritable {
+		abs, err := filepath.Abs(p)
+		if err != nil {
+			return fmt.Errorf("failed to resolve path %s: %w", p, err)
+		}
+		writable = append(writable, filepath.Clean(abs))
+	}
+	cc.sandboxWritable = writable
+	switch TruncateStrategy(cc.Truncation) {
+	case TruncateHead, TruncateTail, TruncateHeadTail:
+	default:
+		return fmt.Errorf("truncation must be one of %s, %s or %s", TruncateHead, TruncateTail, TruncateHeadTail)
+	}
+	normalized := make([]string, 0, len(cc.allowedDirs))
+	for _, dir := range cc.allowedDirs {
+		dir = strings.TrimSpace(dir)
+		if dir == "" {
+			continue
+		}
+		abs, err := filepath.Abs(dir)
+		if err != nil {
+			return fmt.Errorf("failed to resolve path %s: %w", dir, err)
+		}
+		normalized = append(normalized, filepath.Clean(abs))
+	}
+	cc.allowedDirs = normalized
+
 	if cc.PromptFile != "" {
 		read, err := os.ReadFile(cc.PromptFile)
 		if err != nil {
@@ -115,5 +279,54 @@ func (cc *CommandConfig) Check() error {
 		}
 		cc.prompt = string(read)
 	}
+	tmpl, err := parsePrompt(cc.prompt)
+	if err != nil {
+		return fmt.Errorf("failed to parse prompt: %w", err)
+	}
+	cc.promptTemplate = tmpl
 	return nil
 }
+
+// compileCommandPatterns compiles the entries of a command list that are patterns, see compileCommandPattern.
+func compileCommandPatterns(entries []string) ([]*regexp.Regexp, error) {
+	var patterns []*regexp.Regexp
+	for _, entry := range entries {
+		pattern, ok, err := compileCommandPattern(entry)
+		if err != nil {
+			return nil, fmt.Errorf("'%s': %w", entry, err)
+		}
+		if ok {
+			patterns = append(patterns, pattern)
+		}
+	}
+	return patterns, nil
+}
+
+// compileCommandPattern compiles an allowed_command entry that is a pattern, matched against the whole command
+// with its arguments. Entries starting with ^ are regular expressions, entries containing a space, * or ? are
+// globs where * matches any text and ? a single character, e.g. "npm run *". ok is false for plain command names.
+func compileCommandPattern(entry string) (pattern *regexp.Regexp, ok bool, err error) {
+	entry = strings.TrimSpace(entry)
+	if strings.HasPrefix(entry, "^") {
+		pattern, err = regexp.Compile(entry)
+		return pattern, err == nil, err
+	}
+	if !strings.ContainsAny(entry, " *?") {
+		return nil, false, nil
+	}
+	var sb strings.Builder
+	sb.WriteString("(?s)^")
+	for _, r := range entry {
+		switch r {
+		case '*':
+			sb.WriteString(".*")
+		case '?':
+			sb.WriteString(".")
+		default:
+			sb.WriteString(regexp.QuoteMeta(string(r)))
+		}
+	}
+	sb.WriteString("$")
+	pattern, err = regexp.Compile(sb.String())
+	return pattern, err == nil, err
+}
@@ -0,0 +1,86 @@
+//go:build !windows
+
+// Copyright 2025 CFC4N <cfc4n.cs@gmail.com>. All Rights Reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//   http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+//
+// Repository: https://github.com/gojue/moling
+
+package command
+
+const (
+	CommandPromptDefault = `
+You are a powerful terminal command assistant capable of executing various command-line operations and management tasks on {{.OS}}. Your capabilities include:
+
+1. **File and Directory Management**:
+    - List files and subdirectories in a directory
+    - Create new files or directories
+    - Delete specified files or directories
+    - Copy and move files and directories
+    - Rename files or directories
+
+2. **File Content Operations**:
+    - View the contents of text files
+    - Edit file contents
+    - Redirect output to a file
+    - Search file contents
+
+3. **System Information Retrieval**:
+    - Retrieve system information (e.g., CPU usage, memory usage, etc.)
+    - View the current user and their permissions
+    - Check the current working directory
+
+4. **Network Operations**:
+    - Check network connection status (e.g., using the ping command)
+    - Query domain information (e.g., using the whois command)
+    - Manage network services (e.g., start, stop, and restart services)
+
+5. **Process Management**:
+    - List currently running processes
+    - Terminate specified processes
+    - Adjust process priorities
+
+6. **Commands Available on This Host**:
+    - Commands run through {{.Shell}}{{if .AllowedDirs}}, in one of these working directories: {{.AllowedDirs}}{{end}}
+    - Only these commands and patterns are allowed, anything else is rejected: {{.AllowedCommands}}
+{{- if .ConfirmCommands}}
+    - These commands run only after the user confirms them: {{.ConfirmCommands}}
+{{- end}}
+
+Before executing any actions, please provide clear instructions, including:
+- The specific command you want to execute
+- Required parameters (file paths, directory names, etc.)
+- Any optional parameters (e.g., modification options, output formats, etc.)
+- Relevant expected results or output
+
+When dealing with sensitive operations or destructive commands, please confirm before execution. Report back with clear status updates, success/failure indicators, and any relevant output or results.
+`
+)
+
+// defaultShell is the shell commands run through when CommandConfig.Shell is not set.
+const defaultShell = "sh"
+
+// supportedShells are the shells that can be configured as CommandConfig.Shell.
+var supportedShells = []string{"sh", "bash", "zsh", "fish"}
+
+var (
+	allowedCmdDefault = []string{
+		"ls", "cat", "echo", "pwd", "head", "tail", "grep", "find", "stat", "df",
+		"du", "free", "top", "ps", "uptime", "who", "w", "last", "uname", "hostname",
+		"ifconfig", "netstat", "ping", "traceroute", "route", "ip", "ss", "lsof", "vmstat",
+		"iostat", "mpstat", "sar", "uptime", "cut", "sort", "uniq", "wc", "awk", "sed",
+		"diff", "cmp", "comm", "file", "basename", "dirname", "chmod", "chown", "curl",
+		"nslookup", "dig", "host", "ssh", "scp", "sftp", "ftp", "wget", "tar", "gzip",
+		"scutil", "networksetup", "git", "cd",
+	}
+)
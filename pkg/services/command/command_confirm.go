@@ -0,0 +1,56 @@
+// Copyright 2025 CFC4N <cfc4n.cs@gmail.com>. All Rights Reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//   http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+//
+// Repository: https://github.com/gojue/moling
+
+package command
+
+import (
+	"context"
+	"fmt"
+	"strings"
+)
+
+// matchConfirmPattern returns the first simple command of command that needs the user's confirmation, if any.
+func (cs *CommandServer) matchConfirmPattern(command string) (simpleCommand, bool) {
+	cmds, err := parseSimpleCommands(command)
+	if err != nil {
+		return simpleCommand{}, false
+	}
+	for _, cmd := range cmds {
+		if cmd.name == sudoCommand && cs.config.SudoConfirm {
+			return cmd, true
+		}
+		if cmd.matches(cs.config.confirmCommands, cs.config.confirmPatterns) {
+			return cmd, true
+		}
+	}
+	return simpleCommand{}, false
+}
+
+// confirmCommand asks the user to confirm the command through MCP elicitation if it matches the confirm_pattern
+// list. It returns nil if the command needs no confirmation or was confirmed, and an error wrapping
+// ErrCommandNotConfirmed if it was declined or the client cannot ask the user, unless elicitation_fallback allows it.
+func (cs *CommandServer) confirmCommand(ctx context.Context, command string) error {
+	cmd, ok := cs.matchConfirmPattern(command)
+	if !ok {
+		return nil
+	}
+	message := fmt.Sprintf("The agent wants to run a potentially destructive command:\n\n%s\n\nDo you want to run it?", strings.TrimSpace(command))
+	if err := cs.Confirm(ctx, message, fmt.Sprintf("Run '%s'", cmd)); err != nil {
+		return fmt.Errorf("%w: '%s': %w", ErrCommandNotConfirmed, cmd, err)
+	}
+	cs.Logger.Info().Str("command", command).Msg("command confirmed by the user")
+	return nil
+}
@@ -0,0 +1,114 @@
+// Copyright 2025 CFC4N <cfc4n.cs@gmail.com>. All Rights Reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//   http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+//
+// Repository: https://github.com/gojue/moling
+
+package command
+
+import (
+	"context"
+	"errors"
+	"testing"
+
+	"github.com/mark3labs/mcp-go/mcp"
+	"github.com/mark3labs/mcp-go/server"
+
+	"github.com/gojue/moling/pkg/comm"
+	"github.com/gojue/moling/pkg/config"
+)
+
+// elicitationSession is a client session that answers elicitation requests with a fixed response.
+type elicitationSession struct {
+	response mcp.ElicitationResponse
+	message  string
+}
+
+func (s *elicitationSession) Initialize()                                         {}
+func (s *elicitationSession) Initialized() bool                                   { return true }
+func (s *elicitationSession) NotificationChannel() chan<- mcp.JSONRPCNotification { return nil }
+func (s *elicitationSession) SessionID() string                                   { return "test-session" }
+
+func (s *elicitationSession) RequestElicitation(ctx context.Context, request mcp.ElicitationRequest) (*mcp.ElicitationResult, error) {
+	s.message = request.Params.Message
+	return &mcp.ElicitationResult{ElicitationResponse: s.response}, nil
+}
+
+// TestConfirmCommand verifies which commands need confirmation and how the elicitation response is handled.
+func TestConfirmCommand(t *testing.T) {
+	_, ctx, err := comm.InitTestEnv()
+	if err != nil {
+		t.Fatalf("Failed to initialize test environment: %v", err)
+	}
+	cs, err := NewCommandServer(ctx)
+	if err != nil {
+		t.Fatalf("Failed to create CommandServer: %v", err)
+	}
+	if err = cs.LoadConfig(StructToMap(NewCommandConfig())); err != nil {
+		t.Fatalf("Failed to load config: %v", err)
+	}
+	cs1 := cs.(*CommandServer)
+
+	tests := map[string]bool{
+		"rm -rf /tmp/x":          true,
+		"ls && rm -fr build":     true,
+		"rm build.log":           false,
+		"kill -9 1234":           true,
+		"kill 1234":              false,
+		"mkfs.ext4 /dev/sdb1":    true,
+		"shutdown -h now":        true,
+		"echo shutdown":          false,
+		"ls -la | grep shutdown": false,
+	}
+	for command, want := range tests {
+		if _, got := cs1.matchConfirmPattern(command); got != want {
+			t.Errorf("matchConfirmPattern(%q) = %v, want %v", command, got, want)
+		}
+	}
+
+	if err = cs1.confirmCommand(context.Background(), "ls -la"); err != nil {
+		t.Errorf("Expected no confirmation for ls, got: %v", err)
+	}
+	if err = cs1.confirmCommand(context.Background(), "rm -rf /tmp/x"); !errors.Is(err, ErrCommandNotConfirmed) {
+		t.Errorf("Expected ErrCommandNotConfirmed without a client session, got: %v", err)
+	}
+	cs1.MlConfig().ElicitationFallback = config.ElicitationAllow
+	if err = cs1.confirmCommand(context.Background(), "rm -rf /tmp/x"); err != nil {
+		t.Errorf("Expected the command confirmed by the allow fallback, got: %v", err)
+	}
+	cs1.MlConfig().ElicitationFallback = config.ElicitationDeny
+
+	mcpServer := server.NewMCPServer("test", "1.0.0", server.WithElicitation())
+	responses := []struct {
+		response mcp.ElicitationResponse
+		ok       bool
+	}{
+		{mcp.ElicitationResponse{Action: mcp.ElicitationResponseActionAccept, Content: map[string]any{"confirm": true}}, true},
+		{mcp.ElicitationResponse{Action: mcp.ElicitationResponseActionAccept, Content: map[string]any{"confirm": false}}, false},
+		{mcp.ElicitationResponse{Action: mcp.ElicitationResponseActionDecline}, false},
+		{mcp.ElicitationResponse{Action: mcp.ElicitationResponseActionCancel}, false},
+	}
+	for _, r := range responses {
+		session := &elicitationSession{response: r.response}
+		err = cs1.confirmCommand(mcpServer.WithContext(context.Background(), session), "rm -rf /tmp/x")
+		if (err == nil) != r.ok {
+			t.Errorf("Unexpected result for response %+v: %v", r.response, err)
+		}
+		if err != nil && !errors.Is(err, ErrCommandNotConfirmed) {
+			t.Errorf("Expected ErrCommandNotConfirmed, got: %v", err)
+		}
+		if session.message == "" {
+			t.Errorf("Expected an elicitation request for response %+v", r.response)
+		}
+	}
+}
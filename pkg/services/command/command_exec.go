@@ -21,25 +21,32 @@ package command
 import (
 	"context"
 	"errors"
+	"fmt"
 	"os/exec"
-	"time"
+	"strings"
+	"syscall"
 )
 
-// ExecCommand executes a command and returns its output.
+// ExecCommand executes a command with the default options and returns its output.
 func ExecCommand(command string) (string, error) {
-	var cmd *exec.Cmd
-	ctx, cfunc := context.WithTimeout(context.Background(), time.Second*10)
+	return ExecCommandWithOptions(context.Background(), command, ExecOptions{})
+}
+
+// ExecCommandWithOptions executes a command through the shell and returns its combined output.
+// On timeout the whole process group is killed and the partial output is returned together with ErrCommandTimeout.
+func ExecCommandWithOptions(ctx context.Context, command string, opts ExecOptions) (string, error) {
+	ctx, cfunc := context.WithTimeout(ctx, opts.timeout())
 	defer cfunc()
-	cmd = exec.CommandContext(ctx, "sh", "-c", command)
-	output, err := cmd.CombinedOutput()
+	cmd := newShellCommand(ctx, command, opts)
+	output, err := combinedOutput(cmd, opts)
 	if err != nil {
 		switch {
 		case errors.Is(err, exec.ErrNotFound):
 			// 命令未找到
-			return "", errors.New("command not found")
+			return "", ErrCommandNotFound
 		case errors.Is(ctx.Err(), context.DeadlineExceeded):
-			// 超时时仅返回输出，不返回错误
-			return string(output), nil
+			// 超时时返回已有输出，并标记超时
+			return string(output), ErrCommandTimeout
 		default:
 			return string(output), nil
 		}
@@ -47,3 +54,76 @@ func ExecCommand(command string) (string, error) {
 
 	return string(output), nil
 }
+
+// newShellCommand creates a command that runs through the shell in its own process group, so that
+// cancelling ctx kills the children spawned by the shell as well.
+func newShellCommand(ctx context.Context, command string, opts ExecOptions) *exec.Cmd {
+	argv := append(append([]string{opts.shell()}, opts.shellFlags()...), "-c", opts.Limits.ulimitPrefix(opts.shell())+command)
+	argv, container := opts.Sandbox.wrap(argv, opts.Dir, opts.Env, false)
+	cmd := exec.CommandContext(ctx, argv[0], argv[1:]...)
+	cmd.Dir = opts.Dir
+	cmd.Env = opts.Env
+	cmd.SysProcAttr = &syscall.SysProcAttr{Setpgid: true}
+	cmd.Cancel = func() error {
+		if container != "" {
+			opts.Sandbox.removeContainer(container)
+		}
+		return syscall.Kill(-cmd.Process.Pid, syscall.SIGKILL)
+	}
+	// Don't wait forever for orphaned grandchildren that still hold the output pipe
+	cmd.WaitDelay = waitDelay
+	return cmd
+}
+
+// startCommand starts the command. The resource limits are already part of the shell command line on Unix.
+func startCommand(cmd *exec.Cmd, opts ExecOptions) error {
+	return cmd.Start()
+}
+
+// ulimitPrefix returns a line of shell commands that applies the limits, exiting with 126 if they can't be applied.
+// It is empty if no limit is set.
+func (l ResourceLimits) ulimitPrefix(shell string) string {
+	var limits []string
+	if l.CPUSeconds > 0 {
+		limits = append(limits, fmt.Sprintf("ulimit -t %d", l.CPUSeconds))
+	}
+	if l.MemoryMB > 0 {
+		limits = append(limits, fmt.Sprintf("ulimit -v %d", l.MemoryMB*1024))
+	}
+	if l.OpenFiles > 0 {
+		limits = append(limits, fmt.Sprintf("ulimit -n %d", l.OpenFiles))
+	}
+	if l.Processes > 0 {
+		if shell == "sh" {
+			// dash names the process limit -p instead of -u
+			limits = append(limits, fmt.Sprintf("{ ulimit -u %d || ulimit -p %d; } 2>/dev/null", l.Processes, l.Processes))
+		} else {
+			limits = append(limits, fmt.Sprintf("ulimit -u %d", l.Processes))
+		}
+	}
+	if len(limits) == 0 {
+		return ""
+	}
+	return strings.Join(limits, " && ") + " || exit 126\n"
+}
+
+// shellFlags returns the flags the shell is started with, before the command.
+func (opts ExecOptions) shellFlags() []string {
+	if opts.Login {
+		return []string{"-l"}
+	}
+	return nil
+}
+
+// scriptFilePattern returns the name pattern of the temporary files written by run_script.
+func scriptFilePattern(shell string) string {
+	if shell == "fish" {
+		return "script-*.fish"
+	}
+	return "script-*.sh"
+}
+
+// scriptCommand returns the command line that runs the script file through the shell.
+func scriptCommand(shell, path string) string {
+	return shell + " '" + strings.ReplaceAll(path, "'", `'\''`) + "'"
+}
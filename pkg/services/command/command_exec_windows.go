@@ -20,13 +20,129 @@
 package command
 
 import (
+	"context"
+	"errors"
+	"fmt"
 	"os/exec"
+	"strconv"
+	"strings"
+	"unsafe"
+
+	"golang.org/x/sys/windows"
 )
 
-// ExecCommand executes a command and returns its output.
+// ExecCommand executes a command with the default options and returns its output.
 func ExecCommand(command string) (string, error) {
-	var cmd *exec.Cmd
-	cmd = exec.Command("cmd", "/C", command)
-	output, err := cmd.CombinedOutput()
+	return ExecCommandWithOptions(context.Background(), command, ExecOptions{})
+}
+
+// ExecCommandWithOptions executes a command through cmd or PowerShell and returns its combined output.
+// On timeout the whole process tree is killed and the partial output is returned together with ErrCommandTimeout.
+func ExecCommandWithOptions(ctx context.Context, command string, opts ExecOptions) (string, error) {
+	ctx, cfunc := context.WithTimeout(ctx, opts.timeout())
+	defer cfunc()
+	cmd := newShellCommand(ctx, command, opts)
+	output, err := combinedOutput(cmd, opts)
+	if err != nil && errors.Is(ctx.Err(), context.DeadlineExceeded) {
+		return string(output), ErrCommandTimeout
+	}
 	return string(output), err
 }
+
+// newShellCommand creates a command that runs through cmd or PowerShell, cancelling ctx kills the whole process tree,
+// not only the shell.
+func newShellCommand(ctx context.Context, command string, opts ExecOptions) *exec.Cmd {
+	var cmd *exec.Cmd
+	if isPowerShell(opts.shell()) {
+		cmd = exec.CommandContext(ctx, opts.shell(), append(opts.shellFlags(), "-Command", command)...)
+	} else {
+		cmd = exec.CommandContext(ctx, "cmd", "/C", command)
+	}
+	cmd.Dir = opts.Dir
+	cmd.Env = opts.Env
+	cmd.Cancel = func() error {
+		return exec.Command("taskkill", "/T", "/F", "/PID", strconv.Itoa(cmd.Process.Pid)).Run()
+	}
+	cmd.WaitDelay = waitDelay
+	return cmd
+}
+
+// startCommand starts the command and assigns it to a job object that enforces the resource limits, so that
+// the processes it starts are limited as well. OpenFiles has no equivalent on Windows and is ignored.
+func startCommand(cmd *exec.Cmd, opts ExecOptions) error {
+	l := opts.Limits
+	if l.CPUSeconds <= 0 && l.MemoryMB <= 0 && l.Processes <= 0 {
+		return cmd.Start()
+	}
+	job, err := windows.CreateJobObject(nil, nil)
+	if err != nil {
+		return fmt.Errorf("failed to create job object: %w", err)
+	}
+	// The job lives on as long as processes are assigned to it.
+	defer windows.CloseHandle(job)
+
+	var info windows.JOBOBJECT_EXTENDED_LIMIT_INFORMATION
+	if l.CPUSeconds > 0 {
+		info.BasicLimitInformation.LimitFlags |= windows.JOB_OBJECT_LIMIT_PROCESS_TIME
+		// in 100-nanosecond ticks
+		info.BasicLimitInformation.PerProcessUserTimeLimit = int64(l.CPUSeconds) * 10_000_000
+	}
+	if l.MemoryMB > 0 {
+		info.BasicLimitInformation.LimitFlags |= windows.JOB_OBJECT_LIMIT_PROCESS_MEMORY
+		info.ProcessMemoryLimit = uintptr(l.MemoryMB) * 1024 * 1024
+	}
+	if l.Processes > 0 {
+		info.BasicLimitInformation.LimitFlags |= windows.JOB_OBJECT_LIMIT_ACTIVE_PROCESS
+		info.BasicLimitInformation.ActiveProcessLimit = uint32(l.Processes)
+	}
+	if _, err = windows.SetInformationJobObject(job, windows.JobObjectExtendedLimitInformation,
+		uintptr(unsafe.Pointer(&info)), uint32(unsafe.Sizeof(info))); err != nil {
+		return fmt.Errorf("failed to set job object limits: %w", err)
+	}
+
+	if err = cmd.Start(); err != nil {
+		return err
+	}
+	process, err := windows.OpenProcess(windows.PROCESS_SET_QUOTA|windows.PROCESS_TERMINATE, false, uint32(cmd.Process.Pid))
+	if err == nil {
+		defer windows.CloseHandle(process)
+		err = windows.AssignProcessToJobObject(job, process)
+	}
+	if err != nil {
+		// Don't let the command run without its limits.
+		_ = cmd.Process.Kill()
+		_ = cmd.Wait()
+		return fmt.Errorf("failed to apply resource limits: %w", err)
+	}
+	return nil
+}
+
+// shellFlags returns the flags PowerShell is started with, before the command. The profile is only loaded for login shells.
+func (opts ExecOptions) shellFlags() []string {
+	if opts.Login {
+		return []string{"-NoLogo", "-NonInteractive"}
+	}
+	return []string{"-NoLogo", "-NoProfile", "-NonInteractive"}
+}
+
+// isPowerShell reports whether the shell is Windows PowerShell or PowerShell Core.
+func isPowerShell(shell string) bool {
+	return shell == "powershell" || shell == "pwsh"
+}
+
+// scriptFilePattern returns the name pattern of the temporary files written by run_script.
+func scriptFilePattern(shell string) string {
+	if isPowerShell(shell) {
+		return "script-*.ps1"
+	}
+	return "script-*.cmd"
+}
+
+// scriptCommand returns the command line that runs the script file through the shell.
+func scriptCommand(shell, path string) string {
+	if isPowerShell(shell) {
+		// The script file is not signed, so bypass the execution policy for this file only.
+		return "& " + shell + " -NoLogo -NoProfile -NonInteractive -ExecutionPolicy Bypass -File '" + strings.ReplaceAll(path, "'", "''") + "'"
+	}
+	return `"` + path + `"`
+}
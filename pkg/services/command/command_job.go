@@ -0,0 +1,292 @@
+// Copyright 2025 CFC4N <cfc4n.cs@gmail.com>. All Rights Reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//   http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+//
+// Repository: https://github.com/gojue/moling
+
+package command
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"os/exec"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/gojue/moling/pkg/comm"
+)
+
+const (
+	// maxJobOutputSize is the number of output bytes kept per background job, older output is discarded.
+	maxJobOutputSize = 1024 * 1024
+	// maxFinishedJobs is the number of finished jobs kept in the registry for later inspection.
+	maxFinishedJobs = 32
+)
+
+// JobStatus is the state of a background job.
+type JobStatus string
+
+const (
+	JobRunning  JobStatus = "running"
+	JobExited   JobStatus = "exited"
+	JobKilled   JobStatus = "killed"
+	JobFailed   JobStatus = "failed"
+	jobIDPrefix           = "job-"
+)
+
+// jobOutput is a concurrency-safe writer that keeps the last maxJobOutputSize bytes of a job's output.
+type jobOutput struct {
+	mu      sync.Mutex
+	buf     []byte
+	dropped int64 // number of bytes discarded from the head of buf
+}
+
+func (o *jobOutput) Write(p []byte) (int, error) {
+	o.mu.Lock()
+	defer o.mu.Unlock()
+	o.buf = append(o.buf, p...)
+	if over := len(o.buf) - maxJobOutputSize; over > 0 {
+		o.buf = append(o.buf[:0], o.buf[over:]...)
+		o.dropped += int64(over)
+	}
+	return len(p), nil
+}
+
+// since returns at most limit bytes of output after the given offset and the offset to continue from,
+// limit <= 0 means no limit. truncated is true if part of the requested output has already been discarded.
+func (o *jobOutput) since(offset int64, limit int) (data string, next int64, truncated bool) {
+	o.mu.Lock()
+	defer o.mu.Unlock()
+	if offset < o.dropped {
+		truncated = true
+		offset = o.dropped
+	}
+	start := min(offset-o.dropped, int64(len(o.buf)))
+	end := int64(len(o.buf))
+	if limit > 0 {
+		end = min(end, start+int64(limit))
+	}
+	return string(o.buf[start:end]), o.dropped + end, truncated
+}
+
+// Job is a command running in the background.
+type Job struct {
+	ID        string
+	Command   string
+	Dir       string
+	StartedAt time.Time
+	Client    string // Client is the ID of the client session that started it, the only one allowed to access it.
+
+	cmd    *exec.Cmd
+	cancel context.CancelFunc
+	output *jobOutput
+	done   chan struct{}
+
+	mu       sync.Mutex
+	status   JobStatus
+	exitCode int
+	endedAt  time.Time
+	err      error
+}
+
+// State returns the status, the exit code and the end time of the job.
+func (j *Job) State() (JobStatus, int, time.Time, error) {
+	j.mu.Lock()
+	defer j.mu.Unlock()
+	return j.status, j.exitCode, j.endedAt, j.err
+}
+
+// Output returns at most limit bytes of the job output after the given offset, see jobOutput.since.
+func (j *Job) Output(offset int64, limit int) (string, int64, bool) {
+	return j.output.since(offset, limit)
+}
+
+// wait waits for the command to exit and records its final state.
+func (j *Job) wait() {
+	err := j.cmd.Wait()
+	j.mu.Lock()
+	defer j.mu.Unlock()
+	j.endedAt = time.Now()
+	j.exitCode = j.cmd.ProcessState.ExitCode()
+	switch {
+	case j.status == JobKilled:
+	case err == nil:
+		j.status = JobExited
+	default:
+		var exitErr *exec.ExitError
+		if errors.As(err, &exitErr) {
+			j.status = JobExited
+		} else {
+			j.status = JobFailed
+			j.err = err
+		}
+	}
+	j.cancel()
+	close(j.done)
+}
+
+// JobManager is an in-memory registry of background jobs.
+type JobManager struct {
+	mu      sync.Mutex
+	ctx     context.Context
+	jobs    map[string]*Job
+	seq     uint64
+	maxJobs int
+}
+
+// NewJobManager creates a JobManager. Jobs are killed when ctx is cancelled,
+// at most maxJobs jobs can run at the same time.
+func NewJobManager(ctx context.Context, maxJobs int) *JobManager {
+	return &JobManager{
+		ctx:     ctx,
+		jobs:    make(map[string]*Job),
+		maxJobs: maxJobs,
+	}
+}
+
+// Start starts the command in the background for the client session and registers it.
+func (jm *JobManager) Start(client, command string, opts ExecOptions) (*Job, error) {
+	jm.mu.Lock()
+	defer jm.mu.Unlock()
+	running := 0
+	for _, job := range jm.jobs {
+		if status, _, _, _ := job.State(); status == JobRunning {
+			running++
+		}
+	}
+	if running >= jm.maxJobs {
+		return nil, comm.Errorf(comm.CodeTooLarge, "too many background jobs running (%d), kill one first", running)
+	}
+
+	ctx, cancel := context.WithCancel(jm.ctx)
+	cmd := newShellCommand(ctx, command, opts)
+	output := &jobOutput{}
+	cmd.Stdout = output
+	cmd.Stderr = output
+	if err := startCommand(cmd, opts); err != nil {
+		cancel()
+		return nil, err
+	}
+
+	jm.seq++
+	job := &Job{
+		ID:        jobIDPrefix + strconv.FormatUint(jm.seq, 10),
+		Command:   command,
+		Dir:       opts.Dir,
+		StartedAt: time.Now(),
+		Client:    client,
+		cmd:       cmd,
+		cancel:    cancel,
+		output:    output,
+		done:      make(chan struct{}),
+		status:    JobRunning,
+	}
+	jm.jobs[job.ID] = job
+	go job.wait()
+	jm.pruneLocked()
+	return job, nil
+}
+
+// Get returns the job with the given ID.
+func (jm *JobManager) Get(id string) (*Job, bool) {
+	jm.mu.Lock()
+	defer jm.mu.Unlock()
+	job, ok := jm.jobs[id]
+	return job, ok
+}
+
+// List returns all jobs ordered by start time.
+func (jm *JobManager) List() []*Job {
+	jm.mu.Lock()
+	defer jm.mu.Unlock()
+	jobs := make([]*Job, 0, len(jm.jobs))
+	for _, job := range jm.jobs {
+		jobs = append(jobs, job)
+	}
+	sort.Slice(jobs, func(i, k int) bool {
+		return jobs[i].StartedAt.Before(jobs[k].StartedAt)
+	})
+	return jobs
+}
+
+// Kill kills the job and its children, and waits for it to exit.
+func (jm *JobManager) Kill(id string) error {
+	job, ok := jm.Get(id)
+	if !ok {
+		return comm.Errorf(comm.CodeNotFound, "job %s not found", id)
+	}
+	job.mu.Lock()
+	if job.status != JobRunning {
+		job.mu.Unlock()
+		return fmt.Errorf("job %s is not running", id)
+	}
+	job.status = JobKilled
+	job.mu.Unlock()
+	job.cancel()
+	select {
+	case <-job.done:
+	case <-time.After(waitDelay + time.Second):
+		return fmt.Errorf("job %s did not exit after being killed", id)
+	}
+	return nil
+}
+
+// KillAll kills all running jobs.
+func (jm *JobManager) KillAll() {
+	for _, job := range jm.List() {
+		if status, _, _, _ := job.State(); status == JobRunning {
+			_ = jm.Kill(job.ID)
+		}
+	}
+}
+
+// pruneLocked drops the oldest finished jobs beyond maxFinishedJobs. jm.mu must be held.
+func (jm *JobManager) pruneLocked() {
+	var finished []*Job
+	for _, job := range jm.jobs {
+		if status, _, _, _ := job.State(); status != JobRunning {
+			finished = append(finished, job)
+		}
+	}
+	if len(finished) <= maxFinishedJobs {
+		return
+	}
+	sort.Slice(finished, func(i, k int) bool {
+		return finished[i].StartedAt.Before(finished[k].StartedAt)
+	})
+	for _, job := range finished[:len(finished)-maxFinishedJobs] {
+		delete(jm.jobs, job.ID)
+	}
+}
+
+// describe returns a one-line summary of the job.
+func (j *Job) describe() string {
+	status, exitCode, endedAt, err := j.State()
+	var sb strings.Builder
+	sb.WriteString(fmt.Sprintf("%s [%s] %s", j.ID, status, j.Command))
+	if j.Dir != "" {
+		sb.WriteString(fmt.Sprintf(" (in %s)", j.Dir))
+	}
+	sb.WriteString(fmt.Sprintf(", started %s", j.StartedAt.Format(time.RFC3339)))
+	if status != JobRunning {
+		sb.WriteString(fmt.Sprintf(", ended %s, exit code %d", endedAt.Format(time.RFC3339), exitCode))
+	}
+	if err != nil {
+		sb.WriteString(fmt.Sprintf(", error: %s", err.Error()))
+	}
+	return sb.String()
+}
@@ -0,0 +1,87 @@
+// Copyright 2025 CFC4N <cfc4n.cs@gmail.com>. All Rights Reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//   http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+//
+// Repository: https://github.com/gojue/moling
+
+package command
+
+import (
+	"context"
+	"runtime"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestJobManager(t *testing.T) {
+	if runtime.GOOS == "windows" {
+		t.Skip("sleep is not available on windows")
+	}
+	jm := NewJobManager(context.Background(), 1)
+
+	job, err := jm.Start("", "echo started && sleep 30", ExecOptions{})
+	if err != nil {
+		t.Fatalf("Failed to start job: %v", err)
+	}
+	if _, err = jm.Start("", "echo second", ExecOptions{}); err == nil {
+		t.Errorf("Expected the job limit to be enforced")
+	}
+
+	// Wait for the first output
+	var output string
+	var next int64
+	for i := 0; i < 50 && output == ""; i++ {
+		time.Sleep(20 * time.Millisecond)
+		output, next, _ = job.Output(0, 0)
+	}
+	if output != "started\n" {
+		t.Fatalf("Expected output %q, got %q", "started\n", output)
+	}
+	if output, _, _ = job.Output(next, 0); output != "" {
+		t.Errorf("Expected no new output after offset %d, got %q", next, output)
+	}
+
+	if err = jm.Kill(job.ID); err != nil {
+		t.Fatalf("Failed to kill job: %v", err)
+	}
+	status, _, _, _ := job.State()
+	if status != JobKilled {
+		t.Errorf("Expected status %s, got %s", JobKilled, status)
+	}
+	if !strings.Contains(job.describe(), job.ID) {
+		t.Errorf("Expected description to contain the job ID: %s", job.describe())
+	}
+	if len(jm.List()) != 1 {
+		t.Errorf("Expected 1 job, got %d", len(jm.List()))
+	}
+}
+
+func TestJobOutputTruncation(t *testing.T) {
+	o := &jobOutput{}
+	_, _ = o.Write(make([]byte, maxJobOutputSize))
+	_, _ = o.Write([]byte("tail"))
+	data, next, truncated := o.since(0, 0)
+	if !truncated {
+		t.Errorf("Expected output to be reported as truncated")
+	}
+	if next != maxJobOutputSize+4 {
+		t.Errorf("Expected next offset %d, got %d", maxJobOutputSize+4, next)
+	}
+	if !strings.HasSuffix(data, "tail") || len(data) != maxJobOutputSize {
+		t.Errorf("Expected the last %d bytes to be kept, got %d bytes", maxJobOutputSize, len(data))
+	}
+	if data, next, _ = o.since(next-4, 2); data != "ta" || next != maxJobOutputSize+2 {
+		t.Errorf("Expected a page of 2 bytes, got %q with next offset %d", data, next)
+	}
+}
@@ -0,0 +1,240 @@
+// Copyright 2025 CFC4N <cfc4n.cs@gmail.com>. All Rights Reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//   http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+//
+// Repository: https://github.com/gojue/moling
+
+package command
+
+import (
+	"fmt"
+	"regexp"
+	"slices"
+	"strings"
+
+	"mvdan.cc/sh/v3/syntax"
+)
+
+// simpleCommand is a single command invocation found in a shell command line.
+type simpleCommand struct {
+	name string
+	args []string
+	line uint // line is the line of the command line the command starts on, from 1.
+}
+
+// matches reports whether the command name is one of names, or the whole command matches one of patterns.
+func (c simpleCommand) matches(names []string, patterns []*regexp.Regexp) bool {
+	if slices.Contains(names, c.name) {
+		return true
+	}
+	line := c.String()
+	for _, pattern := range patterns {
+		if pattern.MatchString(line) {
+			return true
+		}
+	}
+	return false
+}
+
+// String returns the command name and arguments joined by spaces, e.g. "git log --oneline".
+func (c simpleCommand) String() string {
+	return strings.Join(append([]string{c.name}, c.args...), " ")
+}
+
+// parseSimpleCommands parses command with POSIX shell grammar and returns every simple command it would run,
+// including those inside pipelines, lists (; && ||), subshells, blocks and control structures.
+// Constructs that could run code which is not visible as a plain command word are rejected: command and
+// process substitution, braced parameter expansion, variable assignments, function and variable declarations,
+// and command names that are not literal words.
+func parseSimpleCommands(command string) ([]simpleCommand, error) {
+	file, err := syntax.NewParser(syntax.Variant(syntax.LangPOSIX)).Parse(strings.NewReader(command), "")
+	if err != nil {
+		return nil, fmt.Errorf("failed to parse command: %w", err)
+	}
+
+	var cmds []simpleCommand
+	syntax.Walk(file, func(node syntax.Node) bool {
+		if err != nil {
+			return false
+		}
+		switch n := node.(type) {
+		case *syntax.CmdSubst:
+			err = fmt.Errorf("command substitution is not allowed")
+		case *syntax.ProcSubst:
+			err = fmt.Errorf("process substitution is not allowed")
+		case *syntax.ParamExp:
+			if !n.Short {
+				err = fmt.Errorf("braced parameter expansion is not allowed")
+			}
+		case *syntax.FuncDecl:
+			err = fmt.Errorf("function declaration is not allowed")
+		case *syntax.DeclClause:
+			err = fmt.Errorf("'%s' is not allowed", n.Variant.Value)
+		case *syntax.CallExpr:
+			if len(n.Assigns) > 0 {
+				err = fmt.Errorf("variable assignment is not allowed")
+				return false
+			}
+			name, ok := literalWord(n.Args[0])
+			if !ok {
+				err = fmt.Errorf("command name '%s' is not a literal word", wordSource(command, n.Args[0]))
+				return false
+			}
+			cmd := simpleCommand{name: name, line: n.Pos().Line()}
+			for _, arg := range n.Args[1:] {
+				value, ok := literalWord(arg)
+				if !ok {
+					value = wordSource(command, arg)
+				}
+				cmd.args = append(cmd.args, value)
+			}
+			cmds = append(cmds, cmd)
+		}
+		return err == nil
+	})
+	if err != nil {
+		return nil, err
+	}
+	return cmds, nil
+}
+
+// literalWord returns the value of a word made only of plain and quoted literals, with the quotes removed.
+func literalWord(word *syntax.Word) (string, bool) {
+	var sb strings.Builder
+	for _, part := range word.Parts {
+		switch p := part.(type) {
+		case *syntax.Lit:
+			sb.WriteString(p.Value)
+		case *syntax.SglQuoted:
+			sb.WriteString(p.Value)
+		case *syntax.DblQuoted:
+			for _, inner := range p.Parts {
+				lit, ok := inner.(*syntax.Lit)
+				if !ok {
+					return "", false
+				}
+				sb.WriteString(lit.Value)
+			}
+		default:
+			return "", false
+		}
+	}
+	return sb.String(), true
+}
+
+// wordSource returns the word as written in the command line.
+func wordSource(command string, word *syntax.Word) string {
+	return command[word.Pos().Offset():word.End().Offset()]
+}
+
+// nonPOSIXShellSpecials are the characters cmd and PowerShell give a meaning the POSIX grammar of the checks
+// doesn't see: cmd doesn't quote with ', escapes with ^ and expands %VAR%, PowerShell runs the script blocks of
+// { } and the subexpressions of $( ) and ( ), expands $var and @var, and escapes with `. Without them, the
+// operators & | ; < > split the command line the same way in the three grammars, they are checked by
+// checkShellOperators.
+const nonPOSIXShellSpecials = "^%{}@$()'\"`"
+
+// isPOSIXShell reports whether the shell parses the command lines like the POSIX shell grammar of the checks,
+// cmd and PowerShell don't.
+func isPOSIXShell(shell string) bool {
+	return shell != "cmd" && shell != "powershell" && shell != "pwsh"
+}
+
+// checkNonPOSIXShell returns an error for the first character of command the shell, cmd or PowerShell, would
+// parse differently from the POSIX shell grammar the command is checked with.
+func checkNonPOSIXShell(command, shell string) error {
+	if i := strings.IndexAny(command, nonPOSIXShellSpecials); i >= 0 {
+		return fmt.Errorf("'%c' is not allowed with the shell %s, it isn't parsed like in a POSIX shell", command[i], shell)
+	}
+	return nil
+}
+
+// shellPolicy selects the shell operators a command line may use, on top of the allowed commands.
+type shellPolicy struct {
+	chaining    bool // chaining allows running several commands: ; & && || and, outside of scripts, newlines.
+	pipes       bool // pipes allows |.
+	redirection bool // redirection allows redirecting input and output, duplicating a file descriptor (2>&1) is always allowed.
+	script      bool // script allows commands on separate lines even without chaining.
+}
+
+// checkShellOperators returns an error for the first shell operator of command that the policy does not allow.
+func checkShellOperators(command string, policy shellPolicy) error {
+	file, err := syntax.NewParser(syntax.Variant(syntax.LangPOSIX)).Parse(strings.NewReader(command), "")
+	if err != nil {
+		return fmt.Errorf("failed to parse command: %w", err)
+	}
+	if !policy.chaining && !policy.script && len(file.Stmts) > 1 {
+		return fmt.Errorf("running several commands is not allowed by allow_chaining")
+	}
+	syntax.Walk(file, func(node syntax.Node) bool {
+		if err != nil {
+			return false
+		}
+		switch n := node.(type) {
+		case *syntax.Stmt:
+			switch {
+			case !policy.chaining && n.Background:
+				err = fmt.Errorf("running a command in the background with '&' is not allowed by allow_chaining")
+			case !policy.chaining && n.Semicolon.IsValid():
+				err = fmt.Errorf("command chaining with ';' is not allowed by allow_chaining")
+			case !policy.redirection:
+				for _, r := range n.Redirs {
+					if !isFdDuplication(r) {
+						err = fmt.Errorf("redirection '%s' is not allowed by allow_redirection", r.Op)
+						break
+					}
+				}
+			}
+		case *syntax.BinaryCmd:
+			switch n.Op {
+			case syntax.AndStmt, syntax.OrStmt:
+				if !policy.chaining {
+					err = fmt.Errorf("command chaining with '%s' is not allowed by allow_chaining", n.Op)
+				}
+			case syntax.Pipe, syntax.PipeAll:
+				if !policy.pipes {
+					err = fmt.Errorf("pipe '%s' is not allowed by allow_pipes", n.Op)
+				}
+			}
+		case *syntax.Block, *syntax.Subshell:
+			if !policy.chaining && !policy.script && len(stmtsOf(n)) > 1 {
+				err = fmt.Errorf("running several commands is not allowed by allow_chaining")
+			}
+		}
+		return err == nil
+	})
+	return err
+}
+
+// stmtsOf returns the statements of a block or subshell.
+func stmtsOf(node syntax.Node) []*syntax.Stmt {
+	switch n := node.(type) {
+	case *syntax.Block:
+		return n.Stmts
+	case *syntax.Subshell:
+		return n.Stmts
+	}
+	return nil
+}
+
+// isFdDuplication reports whether the redirection only duplicates or closes a file descriptor, e.g. 2>&1.
+func isFdDuplication(r *syntax.Redirect) bool {
+	if r.Op != syntax.DplIn && r.Op != syntax.DplOut {
+		return false
+	}
+	target, ok := literalWord(r.Word)
+	if !ok || target == "" {
+		return false
+	}
+	return target == "-" || strings.Trim(target, "0123456789") == ""
+}
@@ -0,0 +1,60 @@
+// Copyright 2025 CFC4N <cfc4n.cs@gmail.com>. All Rights Reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//   http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+//
+// Repository: https://github.com/gojue/moling
+
+package command
+
+import (
+	"strings"
+	"text/template"
+)
+
+// promptData is the data the command prompt template is rendered with.
+type promptData struct {
+	OS              string // OS is the name and version of the operating system, e.g. "Ubuntu 22.04" or "macOS 15.3".
+	OSName          string // OSName is the name of the operating system, e.g. "Ubuntu" or "macOS".
+	OSVersion       string // OSVersion is the version of the operating system.
+	Shell           string // Shell is the shell commands run through.
+	AllowedCommands string // AllowedCommands is the allowlist of commands and patterns, comma separated.
+	ConfirmCommands string // ConfirmCommands are the commands that need the user's confirmation, comma separated.
+	AllowedDirs     string // AllowedDirs are the directories allowed as working_dir, comma separated.
+}
+
+// parsePrompt parses the prompt template of the default prompt or the prompt file.
+func parsePrompt(prompt string) (*template.Template, error) {
+	return template.New("command_prompt").Option("missingkey=zero").Parse(prompt)
+}
+
+// renderPrompt renders the prompt template for this host and the live configuration.
+func (cs *CommandServer) renderPrompt() (string, error) {
+	data := promptData{
+		OS:              strings.TrimSpace(cs.osName + " " + cs.osVersion),
+		OSName:          cs.osName,
+		OSVersion:       cs.osVersion,
+		Shell:           cs.config.Shell,
+		AllowedCommands: strings.Join(cs.config.allowedCommands, ", "),
+		ConfirmCommands: strings.Join(cs.config.confirmCommands, ", "),
+		AllowedDirs:     strings.Join(cs.allowedDirs(), ", "),
+	}
+	if info := cs.MlConfig().SystemInfo; info != "" {
+		data.OS = info
+	}
+	var sb strings.Builder
+	if err := cs.config.promptTemplate.Execute(&sb, data); err != nil {
+		return "", err
+	}
+	// Prompt files written for older versions have a %s for the operating system.
+	return strings.Replace(sb.String(), "%s", data.OS, 1), nil
+}
@@ -0,0 +1,91 @@
+//go:build !windows
+
+// Copyright 2025 CFC4N <cfc4n.cs@gmail.com>. All Rights Reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//   http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+//
+// Repository: https://github.com/gojue/moling
+
+package command
+
+import (
+	"bufio"
+	"context"
+	"io"
+	"os"
+	"os/exec"
+	"runtime"
+	"strings"
+	"time"
+
+	"golang.org/x/sys/unix"
+)
+
+// detectOS returns the name and version of the operating system, e.g. "Ubuntu" and "22.04", or "macOS" and "15.3".
+func detectOS() (name, version string) {
+	switch runtime.GOOS {
+	case "darwin":
+		name, version = swVers("-productName"), swVers("-productVersion")
+	case "linux":
+		if f, err := os.Open("/etc/os-release"); err == nil {
+			name, version = parseOSRelease(f)
+			_ = f.Close()
+		}
+	}
+	if name == "" {
+		name = kernelInfo(func(u *unix.Utsname) []byte { return u.Sysname[:] })
+	}
+	if version == "" {
+		version = kernelInfo(func(u *unix.Utsname) []byte { return u.Release[:] })
+	}
+	return name, version
+}
+
+// parseOSRelease returns the NAME and VERSION_ID of an os-release file.
+func parseOSRelease(r io.Reader) (name, version string) {
+	scanner := bufio.NewScanner(r)
+	for scanner.Scan() {
+		key, value, ok := strings.Cut(scanner.Text(), "=")
+		if !ok {
+			continue
+		}
+		value = strings.Trim(value, `"'`)
+		switch key {
+		case "NAME":
+			name = value
+		case "VERSION_ID":
+			version = value
+		}
+	}
+	return name, version
+}
+
+// swVers returns a field of the macOS sw_vers tool.
+func swVers(flag string) string {
+	ctx, cancel := context.WithTimeout(context.Background(), 2*time.Second)
+	defer cancel()
+	out, err := exec.CommandContext(ctx, "sw_vers", flag).Output()
+	if err != nil {
+		return ""
+	}
+	return strings.TrimSpace(string(out))
+}
+
+// kernelInfo returns a field of uname, e.g. Linux or 6.8.0-45-generic.
+func kernelInfo(field func(*unix.Utsname) []byte) string {
+	var u unix.Utsname
+	if err := unix.Uname(&u); err != nil {
+		return runtime.GOOS
+	}
+	return unix.ByteSliceToString(field(&u))
+}
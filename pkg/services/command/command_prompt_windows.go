@@ -0,0 +1,43 @@
+//go:build windows
+
+// Copyright 2025 CFC4N <cfc4n.cs@gmail.com>. All Rights Reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//   http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+//
+// Repository: https://github.com/gojue/moling
+
+package command
+
+import (
+	"fmt"
+
+	"golang.org/x/sys/windows/registry"
+)
+
+// detectOS returns the name and version of Windows, e.g. "Windows 10 Pro" and "22H2 (build 19045)".
+func detectOS() (name, version string) {
+	k, err := registry.OpenKey(registry.LOCAL_MACHINE, `SOFTWARE\Microsoft\Windows NT\CurrentVersion`, registry.QUERY_VALUE)
+	if err != nil {
+		return "Windows", ""
+	}
+	defer k.Close()
+	name, _, err = k.GetStringValue("ProductName")
+	if err != nil {
+		name = "Windows"
+	}
+	build, _, _ := k.GetStringValue("CurrentBuild")
+	if display, _, err := k.GetStringValue("DisplayVersion"); err == nil {
+		return name, fmt.Sprintf("%s (build %s)", display, build)
+	}
+	return name, build
+}
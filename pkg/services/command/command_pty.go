@@ -0,0 +1,61 @@
+//go:build !windows
+
+// Copyright 2025 CFC4N <cfc4n.cs@gmail.com>. All Rights Reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//   http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+//
+// Repository: https://github.com/gojue/moling
+
+package command
+
+import (
+	"context"
+	"os"
+	"os/exec"
+	"strings"
+	"syscall"
+
+	"github.com/creack/pty"
+)
+
+// startShellSession starts an interactive shell on a new pseudo terminal. The shell leads its own session,
+// so cancelling ctx kills every process started from it.
+func startShellSession(ctx context.Context, opts ExecOptions) (*exec.Cmd, *os.File, error) {
+	args := append(opts.shellFlags(), "-i")
+	if prefix := opts.Limits.ulimitPrefix(opts.shell()); prefix != "" {
+		// Apply the limits in a non-interactive shell first, which then becomes the interactive one.
+		args = append(opts.shellFlags(), "-c", prefix+"exec "+opts.shell()+" "+strings.Join(args, " "))
+	}
+	env := opts.Env
+	if env == nil {
+		env = os.Environ()
+	}
+	// A plain prompt and no terminal escape sequences keep the output readable.
+	env = append(env, "TERM=dumb", "PS1=$ ")
+	argv, container := opts.Sandbox.wrap(append([]string{opts.shell()}, args...), opts.Dir, env, true)
+	cmd := exec.CommandContext(ctx, argv[0], argv[1:]...)
+	cmd.Dir = opts.Dir
+	cmd.Env = env
+	cmd.Cancel = func() error {
+		if container != "" {
+			opts.Sandbox.removeContainer(container)
+		}
+		return syscall.Kill(-cmd.Process.Pid, syscall.SIGKILL)
+	}
+	cmd.WaitDelay = waitDelay
+	f, err := pty.Start(cmd)
+	if err != nil {
+		return nil, nil, err
+	}
+	return cmd, f, nil
+}
@@ -0,0 +1,166 @@
+// Copyright 2025 CFC4N <cfc4n.cs@gmail.com>. All Rights Reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//   http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+//
+// Repository: https://github.com/gojue/moling
+
+package command
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os/exec"
+	"time"
+)
+
+// DefaultMaxOutputBytes is the number of bytes kept of each of stdout and stderr when ExecOptions.MaxOutput is not set.
+const DefaultMaxOutputBytes = 64 * 1024
+
+// TruncateStrategy selects which part of an output larger than the limit is kept.
+type TruncateStrategy string
+
+const (
+	TruncateHead     TruncateStrategy = "head"      // TruncateHead keeps the beginning of the output.
+	TruncateTail     TruncateStrategy = "tail"      // TruncateTail keeps the end of the output.
+	TruncateHeadTail TruncateStrategy = "head_tail" // TruncateHeadTail keeps half of the limit from each end.
+)
+
+// ExecResult is the structured result of a command execution.
+type ExecResult struct {
+	ExitCode   int    `json:"exit_code"`   // ExitCode is the exit code of the command, -1 if it was killed by a signal.
+	Stdout     string `json:"stdout"`      // Stdout is the standard output of the command.
+	Stderr     string `json:"stderr"`      // Stderr is the standard error of the command.
+	DurationMs int64  `json:"duration_ms"` // DurationMs is the wall time of the command. time.Millisecond
+	Truncated  bool   `json:"truncated"`   // Truncated is true if stdout or stderr exceeded ExecOptions.MaxOutput.
+	TimedOut   bool   `json:"timed_out"`   // TimedOut is true if the command was killed because it exceeded its timeout.
+	Cached     bool   `json:"cached"`      // Cached is true if the result was returned from the cache of cache_commands.
+}
+
+// String returns the result as a JSON object.
+func (r *ExecResult) String() string {
+	data, err := json.Marshal(r)
+	if err != nil {
+		return "{}"
+	}
+	return string(data)
+}
+
+// limitedBuffer is a writer that keeps at most limit bytes of what is written to it, selected by strategy.
+type limitedBuffer struct {
+	limit    int
+	strategy TruncateStrategy
+	head     []byte
+	tail     []byte
+	dropped  int64 // number of bytes discarded between head and tail
+}
+
+func newLimitedBuffer(limit int, strategy TruncateStrategy) *limitedBuffer {
+	return &limitedBuffer{limit: limit, strategy: strategy}
+}
+
+func (b *limitedBuffer) Write(p []byte) (int, error) {
+	n := len(p)
+	var headLimit int
+	switch b.strategy {
+	case TruncateTail:
+		headLimit = 0
+	case TruncateHeadTail:
+		headLimit = b.limit / 2
+	default:
+		headLimit = b.limit
+	}
+	if room := headLimit - len(b.head); room > 0 {
+		k := min(room, len(p))
+		b.head = append(b.head, p[:k]...)
+		p = p[k:]
+	}
+	if len(p) == 0 {
+		return n, nil
+	}
+	b.tail = append(b.tail, p...)
+	if over := len(b.tail) - (b.limit - headLimit); over > 0 {
+		b.tail = append(b.tail[:0], b.tail[over:]...)
+		b.dropped += int64(over)
+	}
+	return n, nil
+}
+
+// truncated reports whether part of the output was discarded.
+func (b *limitedBuffer) truncated() bool {
+	return b.dropped > 0
+}
+
+// String returns the kept output, with a marker where output was discarded between head and tail.
+func (b *limitedBuffer) String() string {
+	if b.dropped > 0 && len(b.head) > 0 && len(b.tail) > 0 {
+		return fmt.Sprintf("%s\n[... %d bytes truncated ...]\n%s", b.head, b.dropped, b.tail)
+	}
+	return string(b.head) + string(b.tail)
+}
+
+// combinedOutput runs cmd with the resource limits of opts and returns its stdout and stderr interleaved.
+func combinedOutput(cmd *exec.Cmd, opts ExecOptions) ([]byte, error) {
+	var output bytes.Buffer
+	cmd.Stdout = &output
+	cmd.Stderr = &output
+	if err := startCommand(cmd, opts); err != nil {
+		return nil, err
+	}
+	err := cmd.Wait()
+	return output.Bytes(), err
+}
+
+// RunCommand executes a command through the platform shell and returns its stdout, stderr and exit code separately.
+// On timeout the command and its children are killed and the partial result is returned together with ErrCommandTimeout.
+func RunCommand(ctx context.Context, command string, opts ExecOptions) (*ExecResult, error) {
+	ctx, cfunc := context.WithTimeout(ctx, opts.timeout())
+	defer cfunc()
+	cmd := newShellCommand(ctx, command, opts)
+	stdout := newLimitedBuffer(opts.maxOutput(), opts.Truncate)
+	stderr := newLimitedBuffer(opts.maxOutput(), opts.Truncate)
+	cmd.Stdout = stdout
+	cmd.Stderr = stderr
+
+	start := time.Now()
+	err := startCommand(cmd, opts)
+	if err == nil {
+		err = cmd.Wait()
+	}
+	result := &ExecResult{
+		ExitCode:   -1,
+		Stdout:     stdout.String(),
+		Stderr:     stderr.String(),
+		DurationMs: time.Since(start).Milliseconds(),
+		Truncated:  stdout.truncated() || stderr.truncated(),
+	}
+	if cmd.ProcessState != nil {
+		result.ExitCode = cmd.ProcessState.ExitCode()
+	}
+
+	var exitErr *exec.ExitError
+	switch {
+	case errors.Is(ctx.Err(), context.DeadlineExceeded):
+		result.TimedOut = true
+		return result, ErrCommandTimeout
+	case errors.Is(err, exec.ErrNotFound):
+		return result, ErrCommandNotFound
+	case err == nil, errors.As(err, &exitErr), errors.Is(err, exec.ErrWaitDelay):
+		// A non-zero exit code is reported in the result, not as an error.
+		return result, nil
+	default:
+		return result, err
+	}
+}
@@ -0,0 +1,106 @@
+// Copyright 2025 CFC4N <cfc4n.cs@gmail.com>. All Rights Reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//   http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+//
+// Repository: https://github.com/gojue/moling
+
+package command
+
+import (
+	"fmt"
+	"os/exec"
+	"slices"
+	"strings"
+)
+
+// SandboxBackend is the program that isolates commands from the system, named after its executable.
+type SandboxBackend string
+
+const (
+	SandboxNone        SandboxBackend = "none"         // SandboxNone runs commands directly.
+	SandboxSandboxExec SandboxBackend = "sandbox-exec" // SandboxSandboxExec runs commands with a macOS sandbox profile.
+	SandboxBwrap       SandboxBackend = "bwrap"        // SandboxBwrap runs commands in Linux namespaces with bubblewrap.
+	SandboxNsjail      SandboxBackend = "nsjail"       // SandboxNsjail runs commands in Linux namespaces with nsjail.
+	SandboxDocker      SandboxBackend = "docker"       // SandboxDocker runs commands in a Docker container.
+	SandboxPodman      SandboxBackend = "podman"       // SandboxPodman runs commands in a Podman container.
+)
+
+// DefaultSandboxImage is the container image used when CommandConfig.SandboxImage is not set.
+const DefaultSandboxImage = "alpine:3"
+
+// Sandbox restricts the filesystem and network access of a command.
+// Everything is readable, only the Writable paths can be written.
+type Sandbox struct {
+	Backend  SandboxBackend // Backend is the sandbox program. default: SandboxNone
+	Writable []string       // Writable are the absolute paths the command may write to.
+	Network  bool           // Network allows network access.
+	Image    string         // Image is the container image of the docker and podman backends.
+}
+
+func (s Sandbox) enabled() bool {
+	return s.Backend != "" && s.Backend != SandboxNone
+}
+
+func (s Sandbox) isContainer() bool {
+	return s.Backend == SandboxDocker || s.Backend == SandboxPodman
+}
+
+// checkSandboxBackend checks that the backend is supported on this platform and installed.
+func checkSandboxBackend(backend string) error {
+	if !slices.Contains(supportedSandboxes(), SandboxBackend(backend)) {
+		names := make([]string, 0, len(supportedSandboxes()))
+		for _, b := range supportedSandboxes() {
+			names = append(names, string(b))
+		}
+		return fmt.Errorf("sandbox must be one of %s, got '%s'", strings.Join(names, ", "), backend)
+	}
+	if SandboxBackend(backend) == SandboxNone {
+		return nil
+	}
+	if _, err := exec.LookPath(backend); err != nil {
+		return fmt.Errorf("sandbox %s is not installed: %w", backend, err)
+	}
+	return nil
+}
+
+// sandboxFor returns the sandbox the command runs in. The sandbox of each simple command is selected by
+// sandbox_commands, falling back to sandbox; if they differ, the first one that is not none is used.
+func (cs *CommandServer) sandboxFor(command string) Sandbox {
+	backend := SandboxBackend(cs.config.Sandbox)
+	if len(cs.config.SandboxCommands) > 0 {
+		if cmds, err := parseSimpleCommands(command); err == nil && len(cmds) > 0 {
+			backend = SandboxNone
+			for _, cmd := range cmds {
+				b, ok := cs.config.SandboxCommands[cmd.name]
+				if !ok {
+					b = cs.config.Sandbox
+				}
+				if SandboxBackend(b) != SandboxNone {
+					backend = SandboxBackend(b)
+					break
+				}
+			}
+		}
+	}
+
+	writable := cs.config.sandboxWritable
+	if len(writable) == 0 {
+		writable = cs.allowedDirs()
+	}
+	return Sandbox{
+		Backend:  backend,
+		Writable: slices.DeleteFunc(slices.Clone(writable), func(p string) bool { return strings.TrimSpace(p) == "" }),
+		Network:  cs.config.SandboxNetwork,
+		Image:    cs.config.SandboxImage,
+	}
+}
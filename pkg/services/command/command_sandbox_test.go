@@ -0,0 +1,123 @@
+//go:build !windows
+
+// Copyright 2025 CFC4N <cfc4n.cs@gmail.com>. All Rights Reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//   http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+//
+// Repository: https://github.com/gojue/moling
+
+package command
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"slices"
+	"strings"
+	"testing"
+
+	"github.com/gojue/moling/pkg/comm"
+)
+
+// TestSandboxConfig verifies the sandbox validation and the selection of the sandbox per command.
+func TestSandboxConfig(t *testing.T) {
+	_, ctx, err := comm.InitTestEnv()
+	if err != nil {
+		t.Fatalf("Failed to initialize test environment: %v", err)
+	}
+	cs, err := NewCommandServer(ctx)
+	if err != nil {
+		t.Fatalf("Failed to create CommandServer: %v", err)
+	}
+	cfg := StructToMap(NewCommandConfig())
+	cfg["sandbox"] = "chroot"
+	if err = cs.LoadConfig(cfg); err == nil {
+		t.Errorf("Expected an error for an unsupported sandbox")
+	}
+	cfg["sandbox"] = "none"
+	cfg["sandbox_commands"] = map[string]any{"curl": "chroot"}
+	if err = cs.LoadConfig(cfg); err == nil {
+		t.Errorf("Expected an error for an unsupported sandbox in sandbox_commands")
+	}
+	cfg["sandbox_commands"] = map[string]any{}
+	if err = cs.LoadConfig(cfg); err != nil {
+		t.Fatalf("Failed to load config: %v", err)
+	}
+
+	cs1 := cs.(*CommandServer)
+	cs1.config.SandboxCommands = map[string]string{"curl": "docker", "git": "bwrap"}
+	tests := map[string]SandboxBackend{
+		"ls -la":                          SandboxNone,
+		"curl https://example.com":        SandboxDocker,
+		"ls && git status":                SandboxBwrap,
+		"git status | curl -d @- x.local": SandboxBwrap,
+	}
+	for command, want := range tests {
+		if got := cs1.sandboxFor(command).Backend; got != want {
+			t.Errorf("sandboxFor(%q) = %s, want %s", command, got, want)
+		}
+	}
+	cs1.config.Sandbox = string(SandboxNsjail)
+	cs1.config.SandboxCommands = map[string]string{"git": "none"}
+	if got := cs1.sandboxFor("git status").Backend; got != SandboxNone {
+		t.Errorf("Expected git to run without a sandbox, got %s", got)
+	}
+	if got := cs1.sandboxFor("git status && ls").Backend; got != SandboxNsjail {
+		t.Errorf("Expected ls to keep the default sandbox, got %s", got)
+	}
+}
+
+// TestSandboxWrap verifies the command lines of the sandbox backends, and runs a command through a fake bwrap.
+func TestSandboxWrap(t *testing.T) {
+	argv := []string{"sh", "-c", "touch out"}
+	s := Sandbox{Backend: SandboxBwrap, Writable: []string{"/work"}}
+	got, _ := s.wrap(argv, "/work/src", nil, false)
+	if !slices.Contains(got, "--unshare-net") || !strings.Contains(strings.Join(got, " "), "--bind /work /work") ||
+		!strings.HasSuffix(strings.Join(got, " "), "--chdir /work/src -- sh -c touch out") {
+		t.Errorf("Unexpected bwrap command line: %q", got)
+	}
+	s.Network = true
+	if got, _ = s.wrap(argv, "", nil, false); slices.Contains(got, "--unshare-net") {
+		t.Errorf("Expected network access with sandbox_network: %q", got)
+	}
+
+	s = Sandbox{Backend: SandboxDocker, Writable: []string{"/work"}, Image: "busybox"}
+	got, name := s.wrap(argv, "/src", []string{"PATH=/bin", "LANG=C"}, false)
+	line := strings.Join(got, " ")
+	if name == "" || !strings.Contains(line, "--name "+name) || !strings.Contains(line, "--network none") ||
+		!strings.Contains(line, "-v /work:/work") || !strings.Contains(line, "-v /src:/src:ro -w /src") ||
+		!strings.Contains(line, "-e LANG") || strings.Contains(line, "-e PATH") || !strings.HasSuffix(line, "busybox sh -c touch out") {
+		t.Errorf("Unexpected docker command line: %q", got)
+	}
+
+	s = Sandbox{Backend: SandboxSandboxExec, Writable: []string{"/work"}}
+	got, _ = s.wrap(argv, "", nil, false)
+	if got[0] != "sandbox-exec" || !strings.Contains(got[2], `(subpath "/work")`) || !strings.Contains(got[2], "(deny network*)") {
+		t.Errorf("Unexpected sandbox-exec command line: %q", got)
+	}
+
+	// A fake bwrap that runs the wrapped command without isolating it.
+	bin := t.TempDir()
+	fake := "#!/bin/sh\nwhile [ \"$1\" != \"--\" ]; do shift; done\nshift\necho sandboxed\nexec \"$@\"\n"
+	if err := os.WriteFile(filepath.Join(bin, "bwrap"), []byte(fake), 0o755); err != nil {
+		t.Fatalf("Failed to write fake bwrap: %v", err)
+	}
+	t.Setenv("PATH", bin+string(os.PathListSeparator)+os.Getenv("PATH"))
+	result, err := RunCommand(context.Background(), "echo hello", ExecOptions{Sandbox: Sandbox{Backend: SandboxBwrap}})
+	if err != nil {
+		t.Fatalf("Failed to run command: %v", err)
+	}
+	if result.Stdout != "sandboxed\nhello\n" {
+		t.Errorf("Expected the command to run through bwrap, got: %q", result.Stdout)
+	}
+}
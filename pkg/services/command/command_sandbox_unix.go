@@ -0,0 +1,148 @@
+//go:build !windows
+
+// Copyright 2025 CFC4N <cfc4n.cs@gmail.com>. All Rights Reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//   http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+//
+// Repository: https://github.com/gojue/moling
+
+package command
+
+import (
+	"fmt"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"runtime"
+	"strings"
+	"sync/atomic"
+
+	"github.com/gojue/moling/pkg/utils"
+)
+
+// nextContainerID numbers the containers started by this process.
+var nextContainerID atomic.Int64
+
+// supportedSandboxes returns the sandbox backends available on this platform.
+func supportedSandboxes() []SandboxBackend {
+	switch runtime.GOOS {
+	case "darwin":
+		return []SandboxBackend{SandboxNone, SandboxSandboxExec, SandboxDocker, SandboxPodman}
+	case "linux":
+		return []SandboxBackend{SandboxNone, SandboxBwrap, SandboxNsjail, SandboxDocker, SandboxPodman}
+	default:
+		return []SandboxBackend{SandboxNone, SandboxDocker, SandboxPodman}
+	}
+}
+
+// wrap returns the command line that runs argv inside the sandbox, in dir and with the KEY=VALUE variables of env,
+// and the name of the container for the container backends. interactive allocates a terminal for shell sessions.
+func (s Sandbox) wrap(argv []string, dir string, env []string, interactive bool) ([]string, string) {
+	switch s.Backend {
+	case SandboxSandboxExec:
+		return append([]string{"sandbox-exec", "-p", s.profile()}, argv...), ""
+	case SandboxBwrap:
+		args := []string{"bwrap", "--ro-bind", "/", "/", "--dev", "/dev", "--proc", "/proc", "--tmpfs", "/tmp"}
+		for _, p := range s.Writable {
+			args = append(args, "--bind", p, p)
+		}
+		args = append(args, "--unshare-pid", "--unshare-ipc", "--unshare-uts", "--die-with-parent")
+		if !s.Network {
+			args = append(args, "--unshare-net")
+		}
+		if dir != "" {
+			args = append(args, "--chdir", dir)
+		}
+		return append(append(args, "--"), argv...), ""
+	case SandboxNsjail:
+		// nsjail applies low resource limits by default, keep ours instead.
+		args := []string{"nsjail", "--mode", "o", "--quiet", "--keep_env", "--time_limit", "0",
+			"--rlimit_as", "soft", "--rlimit_cpu", "soft", "--rlimit_fsize", "soft", "--rlimit_nofile", "soft", "--rlimit_nproc", "soft",
+			"-R", "/", "--tmpfsmount", "/tmp"}
+		for _, p := range s.Writable {
+			args = append(args, "-B", p)
+		}
+		if s.Network {
+			args = append(args, "--disable_clone_newnet")
+		}
+		if interactive {
+			args = append(args, "--skip_setsid")
+		}
+		if dir == "" {
+			dir, _ = os.Getwd()
+		}
+		args = append(args, "--cwd", dir)
+		return append(append(args, "--"), argv...), ""
+	case SandboxDocker, SandboxPodman:
+		name := fmt.Sprintf("moling-%d-%d", os.Getpid(), nextContainerID.Add(1))
+		args := []string{string(s.Backend), "run", "--rm", "-i", "--init", "--name", name, "--read-only", "--tmpfs", "/tmp",
+			"--user", fmt.Sprintf("%d:%d", os.Getuid(), os.Getgid())}
+		if interactive {
+			args = append(args, "-t")
+		}
+		if !s.Network {
+			args = append(args, "--network", "none")
+		}
+		for _, p := range s.Writable {
+			args = append(args, "-v", p+":"+p)
+		}
+		if dir != "" {
+			if !utils.IsPathWithinAny(dir, s.Writable) {
+				args = append(args, "-v", dir+":"+dir+":ro")
+			}
+			args = append(args, "-w", dir)
+		}
+		for _, kv := range env {
+			// The image has its own PATH, the values of the others are read from the environment of the client.
+			if key, _, ok := strings.Cut(kv, "="); ok && key != "PATH" {
+				args = append(args, "-e", key)
+			}
+		}
+		args = append(args, s.image())
+		return append(args, argv...), name
+	default:
+		return argv, ""
+	}
+}
+
+// removeContainer force-removes a container started by wrap, the container outlives a killed docker or podman client.
+func (s Sandbox) removeContainer(name string) {
+	_ = exec.Command(string(s.Backend), "rm", "-f", name).Run()
+}
+
+func (s Sandbox) image() string {
+	if s.Image == "" {
+		return DefaultSandboxImage
+	}
+	return s.Image
+}
+
+// profile returns the macOS sandbox profile: everything is allowed except writing outside of the writable paths
+// and, unless enabled, network access.
+func (s Sandbox) profile() string {
+	var sb strings.Builder
+	sb.WriteString("(version 1)\n(allow default)\n(deny file-write*)\n")
+	sb.WriteString(`(allow file-write* (literal "/dev/null") (literal "/dev/tty") (literal "/dev/dtracehelper") (subpath "/dev/fd")`)
+	for _, p := range s.Writable {
+		// The profile matches real paths, e.g. /private/tmp for /tmp.
+		if real, err := filepath.EvalSymlinks(p); err == nil {
+			p = real
+		}
+		sb.WriteString(fmt.Sprintf(" (subpath %q)", p))
+	}
+	sb.WriteString(")\n")
+	if !s.Network {
+		sb.WriteString("(deny network*)\n")
+	}
+	return sb.String()
+}
@@ -0,0 +1,114 @@
+// Copyright 2025 CFC4N <cfc4n.cs@gmail.com>. All Rights Reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//   http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+//
+// Repository: https://github.com/gojue/moling
+
+package command
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"github.com/mark3labs/mcp-go/mcp"
+
+	"github.com/gojue/moling/pkg/comm"
+	"github.com/gojue/moling/pkg/utils"
+)
+
+// scriptDir is the directory under BasePath where run_script writes the scripts it executes.
+const scriptDir = "cache/scripts"
+
+// handleRunScript checks a multi-line script against the command policy, and executes it if confirmed.
+func (cs *CommandServer) handleRunScript(ctx context.Context, request mcp.CallToolRequest) (*mcp.CallToolResult, error) {
+	args := request.GetArguments()
+	script, ok := args["script"].(string)
+	if !ok || strings.TrimSpace(script) == "" {
+		return comm.NewToolResultError(comm.CodeInvalidArgument, "script must be a non-empty string"), nil
+	}
+	if err := cs.checkScript(script); err != nil {
+		cs.auditRejected(ctx, "run_script", script, err)
+		cs.Logger.Err(err).Msgf("If you want to allow this script, add its commands to %s", cs.MlConfig().ConfigFilePath())
+		return comm.NewToolResultError(comm.CodePolicyDenied, fmt.Sprintf("Error: script is not allowed: %v", err)), nil
+	}
+	opts, err := cs.parseExecOptions(ctx, args)
+	if err != nil {
+		cs.auditRejected(ctx, "run_script", script, err)
+		return comm.NewToolResultErrorFor(err, fmt.Sprintf("Error: %v", err)), nil
+	}
+	opts.Sandbox = cs.sandboxFor(script)
+
+	if confirm, _ := args["confirm"].(bool); !confirm {
+		summary, err := scriptSummary(script)
+		if err != nil {
+			return comm.NewToolResultErrorFor(err, fmt.Sprintf("Error: %v", err)), nil
+		}
+		return mcp.NewToolResultText(summary), nil
+	}
+
+	if err = cs.confirmCommand(ctx, script); err != nil {
+		cs.auditRejected(ctx, "run_script", script, err)
+		return comm.NewToolResultErrorFor(err, fmt.Sprintf("Error: %v", err)), nil
+	}
+
+	path, err := cs.writeScript(script, opts.shell())
+	if err != nil {
+		return comm.NewToolResultErrorFor(err, fmt.Sprintf("Error writing script: %v", err)), nil
+	}
+	defer func() {
+		_ = os.Remove(path)
+	}()
+	cs.Logger.Info().Str("script", path).Msg("running script")
+	return cs.runWithLimits(ctx, scriptCommand(opts.shell(), path), args, opts, AuditEntry{Tool: "run_script", Command: script}), nil
+}
+
+// writeScript writes the script to a new temporary file under BasePath and returns its path.
+func (cs *CommandServer) writeScript(script, shell string) (string, error) {
+	dir := filepath.Join(cs.MlConfig().BasePath, scriptDir)
+	if err := utils.CreateDirectory(dir); err != nil {
+		return "", err
+	}
+	f, err := os.CreateTemp(dir, scriptFilePattern(shell))
+	if err != nil {
+		return "", err
+	}
+	if _, err = f.WriteString(script); err != nil {
+		_ = f.Close()
+		_ = os.Remove(f.Name())
+		return "", err
+	}
+	if err = f.Close(); err != nil {
+		_ = os.Remove(f.Name())
+		return "", err
+	}
+	return f.Name(), nil
+}
+
+// scriptSummary returns the dry-run summary of a script: the commands it runs, by line.
+func scriptSummary(script string) (string, error) {
+	cmds, err := parseSimpleCommands(script)
+	if err != nil {
+		return "", err
+	}
+	lines := strings.Count(strings.TrimRight(script, "\n"), "\n") + 1
+	var sb strings.Builder
+	sb.WriteString(fmt.Sprintf("Dry run: the script has %d lines and runs %d commands, nothing was executed.\n\n", lines, len(cmds)))
+	for _, cmd := range cmds {
+		sb.WriteString(fmt.Sprintf("line %d: %s\n", cmd.line, cmd))
+	}
+	sb.WriteString("\nCall run_script again with the same script and confirm set to true to execute it.")
+	return sb.String(), nil
+}
@@ -0,0 +1,83 @@
+// Copyright 2025 CFC4N <cfc4n.cs@gmail.com>. All Rights Reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//   http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+//
+// Repository: https://github.com/gojue/moling
+
+package command
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"runtime"
+	"strings"
+	"testing"
+
+	"github.com/mark3labs/mcp-go/mcp"
+
+	"github.com/gojue/moling/pkg/comm"
+)
+
+// TestRunScript verifies the dry run, the policy check and the execution of run_script.
+func TestRunScript(t *testing.T) {
+	if runtime.GOOS == "windows" {
+		t.Skip("skipping on windows")
+	}
+	_, ctx, err := comm.InitTestEnv()
+	if err != nil {
+		t.Fatalf("Failed to initialize test environment: %v", err)
+	}
+	cs, err := NewCommandServer(ctx)
+	if err != nil {
+		t.Fatalf("Failed to create CommandServer: %v", err)
+	}
+	if err = cs.LoadConfig(StructToMap(NewCommandConfig())); err != nil {
+		t.Fatalf("Failed to load config: %v", err)
+	}
+	cs1 := cs.(*CommandServer)
+	cs1.MlConfig().BasePath = t.TempDir()
+
+	run := func(args map[string]any) *mcp.CallToolResult {
+		var request mcp.CallToolRequest
+		request.Params.Arguments = args
+		result, err := cs1.handleRunScript(context.Background(), request)
+		if err != nil {
+			t.Fatalf("Failed to run script: %v", err)
+		}
+		return result
+	}
+	text := func(result *mcp.CallToolResult) string {
+		return result.Content[0].(mcp.TextContent).Text
+	}
+
+	script := "echo one\n\ncd /tmp && pwd\n"
+	result := run(map[string]any{"script": script})
+	if result.IsError || !strings.Contains(text(result), "line 3: pwd") || !strings.Contains(text(result), "runs 3 commands") {
+		t.Errorf("Unexpected dry run result: %s", text(result))
+	}
+
+	result = run(map[string]any{"script": "echo one\nid\n", "confirm": true})
+	if !result.IsError || !strings.Contains(text(result), "line 2: 'id'") {
+		t.Errorf("Expected the script to be rejected at line 2, got: %s", text(result))
+	}
+
+	result = run(map[string]any{"script": script, "confirm": true})
+	if result.IsError || !strings.Contains(text(result), `"stdout":"one\n/tmp\n"`) {
+		t.Errorf("Unexpected script result: %s", text(result))
+	}
+	entries, _ := os.ReadDir(filepath.Join(cs1.MlConfig().BasePath, scriptDir))
+	if len(entries) != 0 {
+		t.Errorf("Expected the script file to be removed, found %d files", len(entries))
+	}
+}
@@ -0,0 +1,203 @@
+// Copyright 2025 CFC4N <cfc4n.cs@gmail.com>. All Rights Reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//   http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+//
+// Repository: https://github.com/gojue/moling
+
+package command
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"os"
+	"os/exec"
+	"sort"
+	"strconv"
+	"sync"
+	"time"
+
+	"github.com/gojue/moling/pkg/comm"
+)
+
+const (
+	sessionIDPrefix = "session-"
+	// sessionPollInterval is how often read_session_output checks for new output while waiting.
+	sessionPollInterval = 50 * time.Millisecond
+)
+
+// Session is an interactive shell running on a pseudo terminal, which keeps its working directory
+// and environment between the commands sent to it.
+type Session struct {
+	ID        string
+	Dir       string
+	StartedAt time.Time
+	Client    string // Client is the ID of the client session that opened it, closed when that one ends.
+
+	cmd    *exec.Cmd
+	pty    *os.File
+	cancel context.CancelFunc
+	output *jobOutput
+	done   chan struct{}
+}
+
+// Send writes a command line to the shell.
+func (s *Session) Send(input string) error {
+	if !s.Running() {
+		return fmt.Errorf("session %s has exited", s.ID)
+	}
+	_, err := io.WriteString(s.pty, input+"\n")
+	return err
+}
+
+// Running reports whether the shell is still running.
+func (s *Session) Running() bool {
+	select {
+	case <-s.done:
+		return false
+	default:
+		return true
+	}
+}
+
+// Output returns at most limit bytes of the terminal output after the given offset, see jobOutput.since.
+// If there is no new output yet, it waits up to wait for some to arrive or for the shell to exit.
+func (s *Session) Output(ctx context.Context, offset int64, limit int, wait time.Duration) (string, int64, bool) {
+	deadline := time.Now().Add(wait)
+	for {
+		data, next, truncated := s.output.since(offset, limit)
+		if data != "" || !s.Running() || !time.Now().Before(deadline) {
+			return data, next, truncated
+		}
+		select {
+		case <-ctx.Done():
+			return data, next, truncated
+		case <-s.done:
+		case <-time.After(sessionPollInterval):
+		}
+	}
+}
+
+// Offset returns the current end of the terminal output.
+func (s *Session) Offset() int64 {
+	_, next, _ := s.output.since(0, 0)
+	return next
+}
+
+// wait copies the terminal output until the shell exits.
+func (s *Session) wait() {
+	// Reading the pty fails with EIO once the shell and its children have exited.
+	_, _ = io.Copy(s.output, s.pty)
+	_ = s.cmd.Wait()
+	s.cancel()
+	close(s.done)
+}
+
+// SessionManager is an in-memory registry of interactive shell sessions.
+type SessionManager struct {
+	mu          sync.Mutex
+	ctx         context.Context
+	sessions    map[string]*Session
+	seq         uint64
+	maxSessions int
+}
+
+// NewSessionManager creates a SessionManager. Sessions are killed when ctx is cancelled,
+// at most maxSessions sessions can be open at the same time.
+func NewSessionManager(ctx context.Context, maxSessions int) *SessionManager {
+	return &SessionManager{
+		ctx:         ctx,
+		sessions:    make(map[string]*Session),
+		maxSessions: maxSessions,
+	}
+}
+
+// Open starts a new interactive shell for the client session and registers it.
+func (sm *SessionManager) Open(client string, opts ExecOptions) (*Session, error) {
+	sm.mu.Lock()
+	defer sm.mu.Unlock()
+	if len(sm.sessions) >= sm.maxSessions {
+		return nil, comm.Errorf(comm.CodeTooLarge, "too many shell sessions open (%d), close one first", len(sm.sessions))
+	}
+
+	ctx, cancel := context.WithCancel(sm.ctx)
+	cmd, pty, err := startShellSession(ctx, opts)
+	if err != nil {
+		cancel()
+		return nil, err
+	}
+
+	sm.seq++
+	session := &Session{
+		ID:        sessionIDPrefix + strconv.FormatUint(sm.seq, 10),
+		Dir:       opts.Dir,
+		StartedAt: time.Now(),
+		Client:    client,
+		cmd:       cmd,
+		pty:       pty,
+		cancel:    cancel,
+		output:    &jobOutput{},
+		done:      make(chan struct{}),
+	}
+	sm.sessions[session.ID] = session
+	go session.wait()
+	return session, nil
+}
+
+// Get returns the session with the given ID.
+func (sm *SessionManager) Get(id string) (*Session, bool) {
+	sm.mu.Lock()
+	defer sm.mu.Unlock()
+	session, ok := sm.sessions[id]
+	return session, ok
+}
+
+// Close kills the shell and its children and removes the session.
+func (sm *SessionManager) Close(id string) error {
+	sm.mu.Lock()
+	session, ok := sm.sessions[id]
+	delete(sm.sessions, id)
+	sm.mu.Unlock()
+	if !ok {
+		return comm.Errorf(comm.CodeNotFound, "session %s not found", id)
+	}
+	session.cancel()
+	defer session.pty.Close()
+	select {
+	case <-session.done:
+	case <-time.After(waitDelay + time.Second):
+		return fmt.Errorf("session %s did not exit after being killed", id)
+	}
+	return nil
+}
+
+// CloseAll closes all sessions.
+func (sm *SessionManager) CloseAll() {
+	for _, id := range sm.IDs(func(*Session) bool { return true }) {
+		_ = sm.Close(id)
+	}
+}
+
+// IDs returns the sorted IDs of the sessions matching keep.
+func (sm *SessionManager) IDs(keep func(*Session) bool) []string {
+	sm.mu.Lock()
+	defer sm.mu.Unlock()
+	ids := make([]string, 0, len(sm.sessions))
+	for id, session := range sm.sessions {
+		if keep(session) {
+			ids = append(ids, id)
+		}
+	}
+	sort.Strings(ids)
+	return ids
+}
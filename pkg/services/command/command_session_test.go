@@ -0,0 +1,161 @@
+// Copyright 2025 CFC4N <cfc4n.cs@gmail.com>. All Rights Reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//   http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+//
+// Repository: https://github.com/gojue/moling
+
+package command
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"runtime"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/mark3labs/mcp-go/mcp"
+	"github.com/mark3labs/mcp-go/server"
+
+	"github.com/gojue/moling/pkg/comm"
+)
+
+// TestSessionManager verifies that a shell session keeps its working directory between commands.
+func TestSessionManager(t *testing.T) {
+	if runtime.GOOS == "windows" {
+		t.Skip("shell sessions are not supported on Windows")
+	}
+	dir := t.TempDir()
+	sub := filepath.Join(dir, "sub")
+	if err := os.Mkdir(sub, 0o755); err != nil {
+		t.Fatalf("Failed to create directory: %v", err)
+	}
+
+	sm := NewSessionManager(context.Background(), 1)
+	defer sm.CloseAll()
+	session, err := sm.Open("", ExecOptions{Dir: dir})
+	if err != nil {
+		t.Fatalf("Failed to open session: %v", err)
+	}
+	if _, err = sm.Open("", ExecOptions{}); err == nil {
+		t.Errorf("Expected an error when exceeding max sessions")
+	}
+
+	for _, input := range []string{"cd sub", "pwd"} {
+		if err = session.Send(input); err != nil {
+			t.Fatalf("Failed to send to session: %v", err)
+		}
+	}
+	var output string
+	var offset int64
+	deadline := time.Now().Add(5 * time.Second)
+	for time.Now().Before(deadline) && !strings.Contains(output, sub) {
+		var data string
+		data, offset, _ = session.Output(context.Background(), offset, 0, time.Second)
+		output += data
+	}
+	if !strings.Contains(output, sub) {
+		t.Errorf("Expected the session to keep the working directory %s, got %q", sub, output)
+	}
+
+	if err = sm.Close(session.ID); err != nil {
+		t.Fatalf("Failed to close session: %v", err)
+	}
+	if session.Running() {
+		t.Errorf("Expected the session to have exited")
+	}
+	if _, ok := sm.Get(session.ID); ok {
+		t.Errorf("Expected the session to be removed")
+	}
+}
+
+// TestClientOwnership verifies the shell sessions and the background jobs of a client are denied to the other
+// clients, and released when the client session ends.
+func TestClientOwnership(t *testing.T) {
+	if runtime.GOOS == "windows" {
+		t.Skip("shell sessions are not supported on Windows")
+	}
+	_, ctx, err := comm.InitTestEnv()
+	if err != nil {
+		t.Fatalf("Failed to initialize test environment: %v", err)
+	}
+	srv, err := NewCommandServer(ctx)
+	if err != nil {
+		t.Fatalf("Failed to create CommandServer: %v", err)
+	}
+	if err = srv.LoadConfig(map[string]any{"allowed_command": "sleep"}); err != nil {
+		t.Fatalf("Failed to load config: %v", err)
+	}
+	if err = srv.Init(); err != nil {
+		t.Fatalf("Failed to init: %v", err)
+	}
+	cs := srv.(*CommandServer)
+	defer cs.Close()
+	mcpServer := server.NewMCPServer("test", "1.0")
+	ctxA := mcpServer.WithContext(ctx, server.NewInProcessSession("client-a", nil))
+	ctxB := mcpServer.WithContext(ctx, server.NewInProcessSession("client-b", nil))
+	call := func(ctx context.Context, handler func(context.Context, mcp.CallToolRequest) (*mcp.CallToolResult, error), args map[string]any) (string, bool) {
+		var request mcp.CallToolRequest
+		request.Params.Arguments = args
+		result, err := handler(ctx, request)
+		if err != nil {
+			t.Fatalf("Unexpected error: %v", err)
+		}
+		return result.Content[0].(mcp.TextContent).Text, result.IsError
+	}
+
+	if text, isErr := call(ctxA, cs.handleStartBackgroundCommand, map[string]any{"command": "sleep 30"}); isErr {
+		t.Fatalf("Failed to start the job: %s", text)
+	}
+	job := map[string]any{"job_id": jobIDPrefix + "1"}
+	for name, handler := range map[string]func(context.Context, mcp.CallToolRequest) (*mcp.CallToolResult, error){
+		"get_job_output": cs.handleGetJobOutput,
+		"kill_job":       cs.handleKillJob,
+	} {
+		if text, isErr := call(ctxB, handler, job); !isErr || !strings.Contains(text, "another client") {
+			t.Errorf("Expected %s denied to client B, got %s", name, text)
+		}
+	}
+	if text, _ := call(ctxB, cs.handleListJobs, nil); !strings.Contains(text, "No background jobs") {
+		t.Errorf("Expected the jobs of client A not listed to client B, got %s", text)
+	}
+	if text, _ := call(ctxA, cs.handleListJobs, nil); !strings.Contains(text, jobIDPrefix+"1") {
+		t.Errorf("Expected the job listed to client A, got %s", text)
+	}
+
+	if text, isErr := call(ctxA, cs.handleOpenShellSession, nil); isErr {
+		t.Fatalf("Failed to open the session: %s", text)
+	}
+	session := map[string]any{"session_id": sessionIDPrefix + "1", "input": "sleep 1", "wait_seconds": 0.0}
+	for name, handler := range map[string]func(context.Context, mcp.CallToolRequest) (*mcp.CallToolResult, error){
+		"send_to_session":     cs.handleSendToSession,
+		"read_session_output": cs.handleReadSessionOutput,
+		"close_session":       cs.handleCloseSession,
+	} {
+		if text, isErr := call(ctxB, handler, session); !isErr || !strings.Contains(text, "another client") {
+			t.Errorf("Expected %s denied to client B, got %s", name, text)
+		}
+	}
+	if text, isErr := call(ctxA, cs.handleReadSessionOutput, session); isErr {
+		t.Errorf("Expected client A to read its session, got %s", text)
+	}
+
+	if resources := cs.ClientResources("client-a"); len(resources) != 2 {
+		t.Errorf("Expected the session and the job of client A, got %v", resources)
+	}
+	cs.CloseClientSession("client-a")
+	if resources := cs.ClientResources("client-a"); len(resources) != 0 {
+		t.Errorf("Expected the resources of client A released, got %v", resources)
+	}
+}
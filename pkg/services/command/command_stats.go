@@ -0,0 +1,166 @@
+// Copyright 2025 CFC4N <cfc4n.cs@gmail.com>. All Rights Reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//   http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+//
+// Repository: https://github.com/gojue/moling
+
+package command
+
+import (
+	"cmp"
+	"context"
+	"encoding/json"
+	"expvar"
+	"fmt"
+	"slices"
+	"strings"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"github.com/mark3labs/mcp-go/mcp"
+
+	"github.com/gojue/moling/pkg/comm"
+)
+
+// StatsVarName is the expvar variable the command statistics are published as, served on /debug/vars.
+const StatsVarName = "moling_command_stats"
+
+// BinaryStats are the execution statistics of one binary.
+type BinaryStats struct {
+	Binary         string    `json:"binary"`
+	Invocations    int64     `json:"invocations"`      // Invocations is the number of command lines run with the binary, including cached results.
+	Failures       int64     `json:"failures"`         // Failures is the number of invocations that exited with a non-zero code, timed out or could not run.
+	Timeouts       int64     `json:"timeouts"`         // Timeouts is the number of invocations killed after their timeout.
+	Rejected       int64     `json:"rejected"`         // Rejected is the number of command lines with the binary rejected by the command policy.
+	FailureRate    float64   `json:"failure_rate"`     // FailureRate is Failures / Invocations.
+	MeanDurationMs float64   `json:"mean_duration_ms"` // MeanDurationMs is the mean wall time of the invocations that ran to completion or timed out.
+	LastUsed       time.Time `json:"last_used"`
+
+	totalDurationMs int64
+	timed           int64
+}
+
+// commandStats tracks the execution statistics per binary. A command line counts once for each distinct
+// binary in it, e.g. "ps aux | grep ssh" counts for ps and grep.
+type commandStats struct {
+	mu       sync.Mutex
+	binaries map[string]*BinaryStats
+}
+
+// publishedStats are the statistics published as StatsVarName, those of the last CommandServer created.
+var (
+	publishedStats  atomic.Pointer[commandStats]
+	publishStatsVar sync.Once
+)
+
+// publish makes the statistics available as the StatsVarName expvar variable.
+func (s *commandStats) publish() {
+	publishedStats.Store(s)
+	publishStatsVar.Do(func() {
+		expvar.Publish(StatsVarName, expvar.Func(func() any {
+			return publishedStats.Load().Snapshot()
+		}))
+	})
+}
+
+// Record updates the statistics of the binaries in the command of an audit entry.
+func (s *commandStats) Record(entry AuditEntry) {
+	cmds, err := parseSimpleCommands(entry.Command)
+	if err != nil {
+		return
+	}
+	var names []string
+	for _, cmd := range cmds {
+		if !slices.Contains(names, cmd.name) {
+			names = append(names, cmd.name)
+		}
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if s.binaries == nil {
+		s.binaries = make(map[string]*BinaryStats)
+	}
+	for _, name := range names {
+		b, ok := s.binaries[name]
+		if !ok {
+			b = &BinaryStats{Binary: name}
+			s.binaries[name] = b
+		}
+		b.LastUsed = entry.Time
+		if entry.Status == AuditRejected {
+			b.Rejected++
+			continue
+		}
+		b.Invocations++
+		switch entry.Status {
+		case AuditExecuted:
+			if entry.ExitCode != 0 {
+				b.Failures++
+			}
+			b.totalDurationMs += entry.DurationMs
+			b.timed++
+		case AuditTimeout:
+			b.Failures++
+			b.Timeouts++
+			b.totalDurationMs += entry.DurationMs
+			b.timed++
+		case AuditFailed:
+			b.Failures++
+		}
+	}
+}
+
+// Snapshot returns the statistics of all binaries, most invoked first.
+func (s *commandStats) Snapshot() []BinaryStats {
+	if s == nil {
+		return nil
+	}
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	stats := make([]BinaryStats, 0, len(s.binaries))
+	for _, b := range s.binaries {
+		snapshot := *b
+		if b.Invocations > 0 {
+			snapshot.FailureRate = float64(b.Failures) / float64(b.Invocations)
+		}
+		if b.timed > 0 {
+			snapshot.MeanDurationMs = float64(b.totalDurationMs) / float64(b.timed)
+		}
+		stats = append(stats, snapshot)
+	}
+	slices.SortFunc(stats, func(a, b BinaryStats) int {
+		if c := cmp.Compare(b.Invocations, a.Invocations); c != 0 {
+			return c
+		}
+		return strings.Compare(a.Binary, b.Binary)
+	})
+	return stats
+}
+
+// handleCommandStats returns the execution statistics per binary as a JSON array.
+func (cs *CommandServer) handleCommandStats(ctx context.Context, request mcp.CallToolRequest) (*mcp.CallToolResult, error) {
+	stats := cs.stats.Snapshot()
+	if binary, ok := request.GetArguments()["binary"].(string); ok && binary != "" {
+		stats = slices.DeleteFunc(stats, func(b BinaryStats) bool { return b.Binary != binary })
+	}
+	if len(stats) == 0 {
+		return mcp.NewToolResultText("No commands have been run yet"), nil
+	}
+	data, err := json.Marshal(stats)
+	if err != nil {
+		return comm.NewToolResultErrorFor(err, fmt.Sprintf("Error: %v", err)), nil
+	}
+	return mcp.NewToolResultText(string(data)), nil
+}
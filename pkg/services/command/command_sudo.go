@@ -0,0 +1,81 @@
+// Copyright 2025 CFC4N <cfc4n.cs@gmail.com>. All Rights Reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//   http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+//
+// Repository: https://github.com/gojue/moling
+
+package command
+
+import (
+	"fmt"
+	"slices"
+	"strings"
+)
+
+// sudoCommand is the name of the command that runs another one with elevated privileges.
+const sudoCommand = "sudo"
+
+var (
+	// sudoFlags are the sudo options allowed before the command, anything that starts a shell, edits files
+	// or keeps the environment is rejected.
+	sudoFlags = []string{"-n", "--non-interactive", "-H", "--set-home"}
+	// sudoFlagsWithValue are the sudo options allowed with a value, e.g. -u root.
+	sudoFlagsWithValue = []string{"-u", "--user", "-g", "--group"}
+)
+
+// parseSudo returns the command that sudo runs, after checking that sudo is called only with allowed options.
+func parseSudo(cmd simpleCommand) (simpleCommand, error) {
+	args := cmd.args
+options:
+	for len(args) > 0 && strings.HasPrefix(args[0], "-") {
+		arg := args[0]
+		args = args[1:]
+		switch {
+		case arg == "--":
+			break options
+		case slices.Contains(sudoFlags, arg):
+		case slices.Contains(sudoFlagsWithValue, arg):
+			if len(args) == 0 {
+				return simpleCommand{}, fmt.Errorf("sudo option '%s' needs a value", arg)
+			}
+			args = args[1:]
+		case strings.HasPrefix(arg, "--user=") || strings.HasPrefix(arg, "--group="):
+		case len(arg) > 2 && (arg[:2] == "-u" || arg[:2] == "-g"):
+			// The value is attached, e.g. -uroot.
+		default:
+			return simpleCommand{}, fmt.Errorf("sudo option '%s' is not allowed", arg)
+		}
+	}
+	if len(args) == 0 {
+		return simpleCommand{}, fmt.Errorf("sudo must be followed by a command")
+	}
+	return simpleCommand{name: args[0], args: args[1:], line: cmd.line}, nil
+}
+
+// checkSudo checks a sudo invocation against sudo_commands, which lists exactly the commands that may be elevated.
+func (cs *CommandServer) checkSudo(cmd simpleCommand) error {
+	if len(cs.config.sudoCommands) == 0 {
+		return fmt.Errorf("sudo is not allowed, list the commands that may be elevated in sudo_commands")
+	}
+	inner, err := parseSudo(cmd)
+	if err != nil {
+		return err
+	}
+	if !inner.matches(cs.config.sudoCommands, cs.config.sudoPatterns) {
+		return fmt.Errorf("'%s' does not match any entry of sudo_commands", inner)
+	}
+	if arg, denied := cs.matchDeniedArgument(inner.name, inner.args); denied {
+		return fmt.Errorf("argument '%s' of '%s' is denied by denied_arguments", arg, inner.name)
+	}
+	return nil
+}
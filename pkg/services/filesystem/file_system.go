@@ -20,11 +20,13 @@ package filesystem
 
 import (
 	"context"
-	"encoding/base64"
 	"encoding/json"
 	"fmt"
+	"net/url"
 	"os"
 	"path/filepath"
+	"slices"
+	"strconv"
 	"strings"
 	"time"
 
@@ -42,6 +44,8 @@ const (
 	MaxInlineSize = 1024 * 1024 * 5
 	// MaxBase64Size Maximum size for base64 encoding (1MB)
 	MaxBase64Size = 1024 * 1024 * 1
+	// MaxRangeSize Maximum length of a single file-range:// read (1MB)
+	MaxRangeSize = MaxBase64Size
 )
 const (
 	FilesystemServerName comm.MoLingServerType = "FileSystem"
@@ -93,11 +97,19 @@ func NewFilesystemServer(ctx context.Context) (abstract.Service, error) {
 }
 
 func (fs *FilesystemServer) Init() error {
+	// Share the allowed directories with other services, e.g. for the working_dir of the Command service
+	fs.MlConfig().SetAllowedDirs(fs.config.allowedDirs)
+
 	// Register resource handlers
 	fs.AddResource(mcp.NewResource("file://", "File System",
 		mcp.WithResourceDescription("Access to files and directories on the local file system"),
 	), fs.handleReadResource)
 
+	// Register resource template for chunked retrieval of large files
+	fs.AddResourceTemplate(mcp.NewResourceTemplate("file-range://{+path}{?offset,len}", "File Range",
+		mcp.WithTemplateDescription(fmt.Sprintf("Read a byte range of a file as base64, at most %d bytes per request. Use it to fetch large binary files in chunks.", MaxRangeSize)),
+	), fs.handleReadFileRange)
+
 	pe := abstract.PromptEntry{
 		PromptVar: mcp.Prompt{
 			Name:        "filesystem_prompt",
@@ -221,43 +233,38 @@ func (fs *FilesystemServer) handlePrompt(ctx context.Context, request mcp.GetPro
 	}, nil
 }
 
-// isPathInAllowedDirs checks if a path is within any of the allowed directories
-func (fs *FilesystemServer) isPathInAllowedDirs(path string) bool {
+// isPathInAllowedDirs checks if a path is within any of the allowed directories of the request of ctx
+func (fs *FilesystemServer) isPathInAllowedDirs(ctx context.Context, path string) bool {
 	// Ensure path is absolute and clean
 	absPath, err := filepath.Abs(path)
 	if err != nil {
 		return false
 	}
 
-	// Add trailing separator to ensure we're checking a directory or a file within a directory
-	// and not a prefix match (e.g., /tmp/foo should not match /tmp/foobar)
-	if !strings.HasSuffix(absPath, string(filepath.Separator)) {
-		// If it'fss a file, we need to check its directory
-		if info, err := os.Stat(absPath); err == nil && !info.IsDir() {
-			absPath = filepath.Dir(absPath) + string(filepath.Separator)
-		} else {
-			absPath = absPath + string(filepath.Separator)
-		}
-	}
+	// IsPathWithin compares whole path components, so /tmp/foo does not match /tmp/foobar,
+	// and copes with case-insensitive drive letters and UNC paths on Windows
+	return utils.IsPathWithinAny(absPath, fs.allowedDirs(ctx))
+}
 
-	// Check if the path is within any of the allowed directories
-	for _, dir := range fs.config.allowedDirs {
-		if strings.HasPrefix(absPath, dir) {
-			return true
-		}
+// allowedDirs returns the configured directories, the ones other services share, e.g. browser downloads, and
+// with use_roots the roots of the client of the request of ctx.
+func (fs *FilesystemServer) allowedDirs(ctx context.Context) []string {
+	dirs := append(slices.Clone(fs.config.allowedDirs), fs.MlConfig().SharedDirs()...)
+	if fs.config.UseRoots {
+		dirs = append(dirs, fs.SessionRoots(ctx)...)
 	}
-	return false
+	return dirs
 }
 
-func (fs *FilesystemServer) validatePath(requestedPath string) (string, error) {
+func (fs *FilesystemServer) validatePath(ctx context.Context, requestedPath string) (string, error) {
 	// Always convert to absolute path first
 	var hasPrefix bool
 	var firstDir string
-	for _, dir := range fs.config.allowedDirs {
+	for _, dir := range fs.allowedDirs(ctx) {
 		if firstDir == "" {
 			firstDir = dir
 		}
-		if strings.HasPrefix(requestedPath, dir) {
+		if utils.IsPathWithin(requestedPath, dir) {
 			hasPrefix = true
 			break
 		}
@@ -267,12 +274,12 @@ func (fs *FilesystemServer) validatePath(requestedPath string) (string, error) {
 	}
 	abs, err := filepath.Abs(requestedPath)
 	if err != nil {
-		return "", fmt.Errorf("invalid path: %w", err)
+		return "", comm.Errorf(comm.CodeInvalidArgument, "invalid path: %w", err)
 	}
 
 	// Check if path is within allowed directories
-	if !fs.isPathInAllowedDirs(abs) {
-		return "", fmt.Errorf("access denied - path outside allowed directories: %s", abs)
+	if !fs.isPathInAllowedDirs(ctx, abs) {
+		return "", comm.Errorf(comm.CodePolicyDenied, "access denied - path outside allowed directories: %s", abs)
 	}
 
 	// Handle symlinks
@@ -285,11 +292,11 @@ func (fs *FilesystemServer) validatePath(requestedPath string) (string, error) {
 		parent := filepath.Dir(abs)
 		realParent, err := filepath.EvalSymlinks(parent)
 		if err != nil {
-			return "", fmt.Errorf("parent directory does not exist: %s", parent)
+			return "", comm.Errorf(comm.CodeNotFound, "parent directory does not exist: %s", parent)
 		}
 
-		if !fs.isPathInAllowedDirs(realParent) {
-			return "", fmt.Errorf(
+		if !fs.isPathInAllowedDirs(ctx, realParent) {
+			return "", comm.Errorf(comm.CodePolicyDenied,
 				"access denied - parent directory outside allowed directories",
 			)
 		}
@@ -297,8 +304,8 @@ func (fs *FilesystemServer) validatePath(requestedPath string) (string, error) {
 	}
 
 	// Check if the real path (after resolving symlinks) is still within allowed directories
-	if !fs.isPathInAllowedDirs(realPath) {
-		return "", fmt.Errorf(
+	if !fs.isPathInAllowedDirs(ctx, realPath) {
+		return "", comm.Errorf(comm.CodePolicyDenied,
 			"access denied - symlink target outside allowed directories",
 		)
 	}
@@ -306,6 +313,11 @@ func (fs *FilesystemServer) validatePath(requestedPath string) (string, error) {
 	return realPath, nil
 }
 
+// detectMimeType determines the MIME type of a file, honoring the configured per-extension overrides
+func (fs *FilesystemServer) detectMimeType(path string) string {
+	return utils.DetectMimeTypeWithOverrides(path, fs.config.mimeTypes)
+}
+
 func (fs *FilesystemServer) getFileStats(path string) (FileInfo, error) {
 	info, err := os.Stat(path)
 	if err != nil {
@@ -323,7 +335,7 @@ func (fs *FilesystemServer) getFileStats(path string) (FileInfo, error) {
 	}, nil
 }
 
-func (fs *FilesystemServer) searchFiles(rootPath, pattern string) ([]string, error) {
+func (fs *FilesystemServer) searchFiles(ctx context.Context, rootPath, pattern string) ([]string, error) {
 	var results []string
 	pattern = strings.ToLower(pattern)
 
@@ -335,7 +347,7 @@ func (fs *FilesystemServer) searchFiles(rootPath, pattern string) ([]string, err
 			}
 
 			// Try to validate path
-			if _, err := fs.validatePath(path); err != nil {
+			if _, err := fs.validatePath(ctx, path); err != nil {
 				return nil // Skip invalid paths
 			}
 
@@ -365,7 +377,7 @@ func (fs *FilesystemServer) handleReadResource(ctx context.Context, request mcp.
 	path := strings.TrimPrefix(uri, "file://")
 
 	// Validate the path
-	validPath, err := fs.validatePath(path)
+	validPath, err := fs.validatePath(ctx, path)
 	if err != nil {
 		return nil, err
 	}
@@ -413,7 +425,7 @@ func (fs *FilesystemServer) handleReadResource(ctx context.Context, request mcp.
 	}
 
 	// It'fss a file, determine how to handle it
-	mimeType := utils.DetectMimeType(validPath)
+	mimeType := fs.detectMimeType(validPath)
 
 	// Check file size
 	if fileInfo.Size() > MaxInlineSize {
@@ -427,15 +439,13 @@ func (fs *FilesystemServer) handleReadResource(ctx context.Context, request mcp.
 		}, nil
 	}
 
-	// Read the file content
-	content, err := os.ReadFile(validPath)
-	if err != nil {
-		return nil, err
-	}
-
 	// Handle based on content type
 	if utils.IsTextFile(mimeType) {
 		// It'fss a text file, return as text
+		content, err := os.ReadFile(validPath)
+		if err != nil {
+			return nil, err
+		}
 		return []mcp.ResourceContents{
 			mcp.TextResourceContents{
 				URI:      uri,
@@ -447,11 +457,15 @@ func (fs *FilesystemServer) handleReadResource(ctx context.Context, request mcp.
 		// It'fss a binary file
 		if fileInfo.Size() <= MaxBase64Size {
 			// Small enough for base64 encoding
+			blob, err := utils.EncodeFileBase64(validPath, 0, fileInfo.Size())
+			if err != nil {
+				return nil, err
+			}
 			return []mcp.ResourceContents{
 				mcp.BlobResourceContents{
 					URI:      uri,
 					MIMEType: mimeType,
-					Blob:     base64.StdEncoding.EncodeToString(content),
+					Blob:     blob,
 				},
 			}, nil
 		} else {
@@ -460,33 +474,96 @@ func (fs *FilesystemServer) handleReadResource(ctx context.Context, request mcp.
 				mcp.TextResourceContents{
 					URI:      uri,
 					MIMEType: "text/plain",
-					Text:     fmt.Sprintf("Binary file (%s, %d bytes). Use the read_file tool to access specific portions.", mimeType, fileInfo.Size()),
+					Text:     fmt.Sprintf("Binary file (%s, %d bytes). Read it in chunks via resource URI: %s", mimeType, fileInfo.Size(), utils.PathToRangeResourceURI(validPath, 0, MaxRangeSize)),
 				},
 			}, nil
 		}
 	}
 }
 
+// handleReadFileRange handles file-range://path?offset=&len= resources, returning the requested byte range as a base64 blob.
+func (fs *FilesystemServer) handleReadFileRange(ctx context.Context, request mcp.ReadResourceRequest) ([]mcp.ResourceContents, error) {
+	uri := request.Params.URI
+	fs.Logger.Debug().Str("uri", uri).Msg("handleReadFileRange")
+
+	if !strings.HasPrefix(uri, "file-range://") {
+		return nil, fmt.Errorf("unsupported URI scheme: %s", uri)
+	}
+
+	// The path itself may contain characters the URI template would swallow, so split the query off by hand
+	path, rawQuery, _ := strings.Cut(strings.TrimPrefix(uri, "file-range://"), "?")
+	query, err := url.ParseQuery(rawQuery)
+	if err != nil {
+		return nil, fmt.Errorf("invalid range query: %w", err)
+	}
+	var offset, length int64 = 0, MaxRangeSize
+	if v := query.Get("offset"); v != "" {
+		offset, err = strconv.ParseInt(v, 10, 64)
+		if err != nil || offset < 0 {
+			return nil, fmt.Errorf("offset must be a non-negative integer: %s", v)
+		}
+	}
+	if v := query.Get("len"); v != "" {
+		length, err = strconv.ParseInt(v, 10, 64)
+		if err != nil || length <= 0 {
+			return nil, fmt.Errorf("len must be a positive integer: %s", v)
+		}
+	}
+	if length > MaxRangeSize {
+		length = MaxRangeSize
+	}
+
+	validPath, err := fs.validatePath(ctx, path)
+	if err != nil {
+		return nil, err
+	}
+	fileInfo, err := os.Stat(validPath)
+	if err != nil {
+		return nil, err
+	}
+	if fileInfo.IsDir() {
+		return nil, fmt.Errorf("path is a directory: %s", validPath)
+	}
+	if offset > fileInfo.Size() {
+		return nil, fmt.Errorf("offset %d is beyond end of file (%d bytes)", offset, fileInfo.Size())
+	}
+	if offset+length > fileInfo.Size() {
+		length = fileInfo.Size() - offset
+	}
+
+	blob, err := utils.EncodeFileBase64(validPath, offset, length)
+	if err != nil {
+		return nil, err
+	}
+	return []mcp.ResourceContents{
+		mcp.BlobResourceContents{
+			URI:      uri,
+			MIMEType: fs.detectMimeType(validPath),
+			Blob:     blob,
+		},
+	}, nil
+}
+
 // Tool handlers
 
 func (fs *FilesystemServer) handleReadFile(ctx context.Context, request mcp.CallToolRequest) (*mcp.CallToolResult, error) {
 	args := request.GetArguments()
 	path, ok := args["path"].(string)
 	if !ok {
-		return mcp.NewToolResultError("Path must be a string"), nil
+		return comm.NewToolResultError(comm.CodeInvalidArgument, "Path must be a string"), nil
 	}
 
 	// 判断 前缀是不是已经包含了
 	//path = filepath.Join(fss.config.CachePath, path)
-	validPath, err := fs.validatePath(path)
+	validPath, err := fs.validatePath(ctx, path)
 	if err != nil {
-		return mcp.NewToolResultError(fmt.Sprintf("validate Path Error: %v", err)), nil
+		return comm.NewToolResultErrorFor(err, fmt.Sprintf("validate Path Error: %v", err)), nil
 	}
 
 	// Check if it'fss a directory
 	info, err := os.Stat(validPath)
 	if err != nil {
-		return mcp.NewToolResultError(fmt.Sprintf("check directory error: %v", err)), nil
+		return comm.NewToolResultErrorFor(err, fmt.Sprintf("check directory error: %v", err)), nil
 	}
 
 	if info.IsDir() {
@@ -511,7 +588,7 @@ func (fs *FilesystemServer) handleReadFile(ctx context.Context, request mcp.Call
 	}
 
 	// Determine MIME type
-	mimeType := utils.DetectMimeType(validPath)
+	mimeType := fs.detectMimeType(validPath)
 
 	// Check file size
 	if info.Size() > MaxInlineSize {
@@ -535,19 +612,21 @@ func (fs *FilesystemServer) handleReadFile(ctx context.Context, request mcp.Call
 		}, nil
 	}
 
-	// Read file content
-	content, err := os.ReadFile(validPath)
-	if err != nil {
-		return mcp.NewToolResultError(fmt.Sprintf("Error reading file: %v", err)), nil
-	}
-
 	// Handle based on content type
 	if utils.IsTextFile(mimeType) {
 		// It'fss a text file, return as text
+		content, err := os.ReadFile(validPath)
+		if err != nil {
+			return comm.NewToolResultErrorFor(err, fmt.Sprintf("Error reading file: %v", err)), nil
+		}
 		return mcp.NewToolResultText(string(content)), nil
 	} else if utils.IsImageFile(mimeType) {
 		// It'fss an image file, return as image content
 		if info.Size() <= MaxBase64Size {
+			data, err := utils.EncodeFileBase64(validPath, 0, info.Size())
+			if err != nil {
+				return comm.NewToolResultErrorFor(err, fmt.Sprintf("Error reading file: %v", err)), nil
+			}
 			return &mcp.CallToolResult{
 				Content: []mcp.Content{
 					mcp.TextContent{
@@ -556,7 +635,7 @@ func (fs *FilesystemServer) handleReadFile(ctx context.Context, request mcp.Call
 					},
 					mcp.ImageContent{
 						Type:     "image",
-						Data:     base64.StdEncoding.EncodeToString(content),
+						Data:     data,
 						MIMEType: mimeType,
 					},
 				},
@@ -568,7 +647,7 @@ func (fs *FilesystemServer) handleReadFile(ctx context.Context, request mcp.Call
 				Content: []mcp.Content{
 					mcp.TextContent{
 						Type: "text",
-						Text: fmt.Sprintf("Image file is too large to display inline (%d bytes). Access it via resource URI: %s", info.Size(), resourceURI),
+						Text: fmt.Sprintf("Image file is too large to display inline (%d bytes). Read it in chunks via resource URI: %s", info.Size(), utils.PathToRangeResourceURI(validPath, 0, MaxRangeSize)),
 					},
 					mcp.EmbeddedResource{
 						Type: "resource",
@@ -587,6 +666,10 @@ func (fs *FilesystemServer) handleReadFile(ctx context.Context, request mcp.Call
 
 		if info.Size() <= MaxBase64Size {
 			// Small enough for base64 encoding
+			blob, err := utils.EncodeFileBase64(validPath, 0, info.Size())
+			if err != nil {
+				return comm.NewToolResultErrorFor(err, fmt.Sprintf("Error reading file: %v", err)), nil
+			}
 			return &mcp.CallToolResult{
 				Content: []mcp.Content{
 					mcp.TextContent{
@@ -598,7 +681,7 @@ func (fs *FilesystemServer) handleReadFile(ctx context.Context, request mcp.Call
 						Resource: mcp.BlobResourceContents{
 							URI:      resourceURI,
 							MIMEType: mimeType,
-							Blob:     base64.StdEncoding.EncodeToString(content),
+							Blob:     blob,
 						},
 					},
 				},
@@ -609,7 +692,7 @@ func (fs *FilesystemServer) handleReadFile(ctx context.Context, request mcp.Call
 				Content: []mcp.Content{
 					mcp.TextContent{
 						Type: "text",
-						Text: fmt.Sprintf("Binary file: %s (%s, %d bytes). Access it via resource URI: %s", validPath, mimeType, info.Size(), resourceURI),
+						Text: fmt.Sprintf("Binary file: %s (%s, %d bytes). Read it in chunks via resource URI: %s", validPath, mimeType, info.Size(), utils.PathToRangeResourceURI(validPath, 0, MaxRangeSize)),
 					},
 					mcp.EmbeddedResource{
 						Type: "resource",
@@ -629,16 +712,16 @@ func (fs *FilesystemServer) handleWriteFile(ctx context.Context, request mcp.Cal
 	args := request.GetArguments()
 	path, ok := args["path"].(string)
 	if !ok {
-		return mcp.NewToolResultError("Path must be a string"), nil
+		return comm.NewToolResultError(comm.CodeInvalidArgument, "Path must be a string"), nil
 	}
 	content, ok := args["content"].(string)
 	if !ok {
-		return mcp.NewToolResultError("Content must be a string"), nil
+		return comm.NewToolResultError(comm.CodeInvalidArgument, "Content must be a string"), nil
 	}
 
 	//path = filepath.Join(fss.config.CachePath, path)
 
-	validPath, err := fs.validatePath(path)
+	validPath, err := fs.validatePath(ctx, path)
 	if err != nil {
 		return &mcp.CallToolResult{
 			Content: []mcp.Content{
@@ -653,17 +736,17 @@ func (fs *FilesystemServer) handleWriteFile(ctx context.Context, request mcp.Cal
 
 	// Check if it'fss a directory
 	if info, err := os.Stat(validPath); err == nil && info.IsDir() {
-		return mcp.NewToolResultError(fmt.Sprintf("Error: Cannot write to a directory:%s", validPath)), nil
+		return comm.NewToolResultError(comm.CodeInvalidArgument, fmt.Sprintf("Error: Cannot write to a directory:%s", validPath)), nil
 	}
 
 	// Create parent directories if they don't exist
 	parentDir := filepath.Dir(validPath)
 	if err := os.MkdirAll(parentDir, 0755); err != nil {
-		return mcp.NewToolResultError(fmt.Sprintf("Error creating parent directories: %v", err)), nil
+		return comm.NewToolResultErrorFor(err, fmt.Sprintf("Error creating parent directories: %v", err)), nil
 	}
 
 	if err := os.WriteFile(validPath, []byte(content), 0644); err != nil {
-		return mcp.NewToolResultError(fmt.Sprintf("Error writing file: %v", err)), nil
+		return comm.NewToolResultErrorFor(err, fmt.Sprintf("Error writing file: %v", err)), nil
 	}
 
 	// Get file info for the response
@@ -696,27 +779,27 @@ func (fs *FilesystemServer) handleListDirectory(ctx context.Context, request mcp
 	args := request.GetArguments()
 	path, ok := args["path"].(string)
 	if !ok {
-		return mcp.NewToolResultError("Path must be a string"), nil
+		return comm.NewToolResultError(comm.CodeInvalidArgument, "Path must be a string"), nil
 	}
 
-	validPath, err := fs.validatePath(path)
+	validPath, err := fs.validatePath(ctx, path)
 	if err != nil {
-		return mcp.NewToolResultError(fmt.Sprintf("validate path error: %v, path:%s", err, validPath)), nil
+		return comm.NewToolResultErrorFor(err, fmt.Sprintf("validate path error: %v, path:%s", err, validPath)), nil
 	}
 
 	// Check if it'fss a directory
 	info, err := os.Stat(validPath)
 	if err != nil {
-		return mcp.NewToolResultError(fmt.Sprintf("Check directory %s Error: %v", validPath, err)), nil
+		return comm.NewToolResultErrorFor(err, fmt.Sprintf("Check directory %s Error: %v", validPath, err)), nil
 	}
 
 	if !info.IsDir() {
-		return mcp.NewToolResultError(fmt.Sprintf("Error: Path is not a directory:%s", validPath)), nil
+		return comm.NewToolResultError(comm.CodeInvalidArgument, fmt.Sprintf("Error: Path is not a directory:%s", validPath)), nil
 	}
 
 	entries, err := os.ReadDir(validPath)
 	if err != nil {
-		return mcp.NewToolResultError(fmt.Sprintf("Error reading directory: %v", err)), nil
+		return comm.NewToolResultErrorFor(err, fmt.Sprintf("Error reading directory: %v", err)), nil
 	}
 
 	var result strings.Builder
@@ -763,12 +846,12 @@ func (fs *FilesystemServer) handleCreateDirectory(ctx context.Context, request m
 	args := request.GetArguments()
 	path, ok := args["path"].(string)
 	if !ok {
-		return mcp.NewToolResultError("path must be a string"), nil
+		return comm.NewToolResultError(comm.CodeInvalidArgument, "path must be a string"), nil
 	}
 
-	validPath, err := fs.validatePath(path)
+	validPath, err := fs.validatePath(ctx, path)
 	if err != nil {
-		return mcp.NewToolResultError(fmt.Sprintf("Error: %v", err)), nil
+		return comm.NewToolResultErrorFor(err, fmt.Sprintf("Error: %v", err)), nil
 	}
 
 	// Check if path already exists
@@ -792,11 +875,11 @@ func (fs *FilesystemServer) handleCreateDirectory(ctx context.Context, request m
 				},
 			}, nil
 		}
-		return mcp.NewToolResultError(fmt.Sprintf("Error: Path exists but is not a directory: %s", path)), nil
+		return comm.NewToolResultError(comm.CodeInvalidArgument, fmt.Sprintf("Error: Path exists but is not a directory: %s", path)), nil
 	}
 
 	if err := os.MkdirAll(validPath, 0755); err != nil {
-		return mcp.NewToolResultError(fmt.Sprintf("Error creating directory: %v", err)), nil
+		return comm.NewToolResultErrorFor(err, fmt.Sprintf("Error creating directory: %v", err)), nil
 	}
 
 	resourceURI := utils.PathToResourceURI(validPath)
@@ -822,36 +905,36 @@ func (fs *FilesystemServer) handleMoveFile(ctx context.Context, request mcp.Call
 	args := request.GetArguments()
 	source, ok := args["source"].(string)
 	if !ok {
-		return mcp.NewToolResultError("source must be a string"), nil
+		return comm.NewToolResultError(comm.CodeInvalidArgument, "source must be a string"), nil
 	}
 	destination, ok := args["destination"].(string)
 	if !ok {
-		return mcp.NewToolResultError("destination must be a string"), nil
+		return comm.NewToolResultError(comm.CodeInvalidArgument, "destination must be a string"), nil
 	}
 
-	validSource, err := fs.validatePath(source)
+	validSource, err := fs.validatePath(ctx, source)
 	if err != nil {
-		return mcp.NewToolResultError(fmt.Sprintf("Error with source path: %v", err)), nil
+		return comm.NewToolResultErrorFor(err, fmt.Sprintf("Error with source path: %v", err)), nil
 	}
 
 	// Check if source exists
 	if _, err := os.Stat(validSource); os.IsNotExist(err) {
-		return mcp.NewToolResultError(fmt.Sprintf("Error: Source does not exist: %s", source)), nil
+		return comm.NewToolResultError(comm.CodeNotFound, fmt.Sprintf("Error: Source does not exist: %s", source)), nil
 	}
 
-	validDest, err := fs.validatePath(destination)
+	validDest, err := fs.validatePath(ctx, destination)
 	if err != nil {
-		return mcp.NewToolResultError(fmt.Sprintf("Error with destination path: %v", err)), nil
+		return comm.NewToolResultErrorFor(err, fmt.Sprintf("Error with destination path: %v", err)), nil
 	}
 
 	// Create parent directory for destination if it doesn't exist
 	destDir := filepath.Dir(validDest)
 	if err := os.MkdirAll(destDir, 0755); err != nil {
-		return mcp.NewToolResultError(fmt.Sprintf("Error creating destination directory: %v", err)), nil
+		return comm.NewToolResultErrorFor(err, fmt.Sprintf("Error creating destination directory: %v", err)), nil
 	}
 
 	if err := os.Rename(validSource, validDest); err != nil {
-		return mcp.NewToolResultError(fmt.Sprintf("Error moving file: %v", err)), nil
+		return comm.NewToolResultErrorFor(err, fmt.Sprintf("Error moving file: %v", err)), nil
 	}
 
 	resourceURI := utils.PathToResourceURI(validDest)
@@ -881,31 +964,31 @@ func (fs *FilesystemServer) handleSearchFiles(ctx context.Context, request mcp.C
 	args := request.GetArguments()
 	path, ok := args["path"].(string)
 	if !ok {
-		return mcp.NewToolResultError("path must be a string"), nil
+		return comm.NewToolResultError(comm.CodeInvalidArgument, "path must be a string"), nil
 	}
 	pattern, ok := args["pattern"].(string)
 	if !ok {
-		return mcp.NewToolResultError("pattern must be a string"), nil
+		return comm.NewToolResultError(comm.CodeInvalidArgument, "pattern must be a string"), nil
 	}
 
-	validPath, err := fs.validatePath(path)
+	validPath, err := fs.validatePath(ctx, path)
 	if err != nil {
-		return mcp.NewToolResultError(fmt.Sprintf("Error: %v", err)), nil
+		return comm.NewToolResultErrorFor(err, fmt.Sprintf("Error: %v", err)), nil
 	}
 
 	// Check if it'fss a directory
 	info, err := os.Stat(validPath)
 	if err != nil {
-		return mcp.NewToolResultError(fmt.Sprintf("Error: %v", err)), nil
+		return comm.NewToolResultErrorFor(err, fmt.Sprintf("Error: %v", err)), nil
 	}
 
 	if !info.IsDir() {
-		return mcp.NewToolResultError("Error: Search path must be a directory"), nil
+		return comm.NewToolResultError(comm.CodeInvalidArgument, "Error: Search path must be a directory"), nil
 	}
 
-	results, err := fs.searchFiles(validPath, pattern)
+	results, err := fs.searchFiles(ctx, validPath, pattern)
 	if err != nil {
-		return mcp.NewToolResultError(fmt.Sprintf("Error searching files: %v", err)), nil
+		return comm.NewToolResultErrorFor(err, fmt.Sprintf("Error searching files: %v", err)), nil
 	}
 
 	if len(results) == 0 {
@@ -938,10 +1021,10 @@ func (fs *FilesystemServer) handleGetFileInfo(ctx context.Context, request mcp.C
 	args := request.GetArguments()
 	path, ok := args["path"].(string)
 	if !ok {
-		return mcp.NewToolResultError(fmt.Errorf("path %v must be a string", args["path"]).Error()), nil
+		return comm.NewToolResultError(comm.CodeInvalidArgument, fmt.Errorf("path %v must be a string", args["path"]).Error()), nil
 	}
 
-	validPath, err := fs.validatePath(path)
+	validPath, err := fs.validatePath(ctx, path)
 	if err != nil {
 		return &mcp.CallToolResult{
 			Content: []mcp.Content{
@@ -956,13 +1039,13 @@ func (fs *FilesystemServer) handleGetFileInfo(ctx context.Context, request mcp.C
 
 	info, err := fs.getFileStats(validPath)
 	if err != nil {
-		return mcp.NewToolResultError(fmt.Sprintf("Error getting file info: %v", err)), nil
+		return comm.NewToolResultErrorFor(err, fmt.Sprintf("Error getting file info: %v", err)), nil
 	}
 
 	// Get MIME type for files
 	mimeType := "directory"
 	if info.IsFile {
-		mimeType = utils.DetectMimeType(validPath)
+		mimeType = fs.detectMimeType(validPath)
 	}
 
 	resourceURI := utils.PathToResourceURI(validPath)
@@ -1011,8 +1094,9 @@ func (fs *FilesystemServer) handleGetFileInfo(ctx context.Context, request mcp.C
 
 func (fs *FilesystemServer) handleListAllowedDirectories(ctx context.Context, request mcp.CallToolRequest) (*mcp.CallToolResult, error) {
 	// Remove the trailing separator for display purposes
-	displayDirs := make([]string, len(fs.config.allowedDirs))
-	for i, dir := range fs.config.allowedDirs {
+	dirs := fs.allowedDirs(ctx)
+	displayDirs := make([]string, len(dirs))
+	for i, dir := range dirs {
 		displayDirs[i] = strings.TrimSuffix(dir, string(filepath.Separator))
 	}
 
@@ -1042,6 +1126,23 @@ func (fs *FilesystemServer) Name() comm.MoLingServerType {
 	return FilesystemServerName
 }
 
+// Health checks the allowed directories are accessible directories.
+func (fs *FilesystemServer) Health(ctx context.Context) map[string]error {
+	var failed []string
+	for _, dir := range fs.config.allowedDirs {
+		if info, err := os.Stat(dir); err != nil {
+			failed = append(failed, err.Error())
+		} else if !info.IsDir() {
+			failed = append(failed, fmt.Sprintf("%s is not a directory", dir))
+		}
+	}
+	var err error
+	if len(failed) > 0 {
+		err = fmt.Errorf("%d of %d allowed directories are inaccessible: %s", len(failed), len(fs.config.allowedDirs), strings.Join(failed, "; "))
+	}
+	return map[string]error{"allowed_dirs": err}
+}
+
 func (fs *FilesystemServer) Close() error {
 	// Cancel the context to stop the browser
 	fs.Logger.Debug().Msg("closing FilesystemServer")
@@ -70,6 +70,9 @@ type FileSystemConfig struct {
 	AllowedDir  string `json:"allowed_dir"` // AllowedDirs is a list of allowed directories. split by comma. e.g. /tmp,/var/tmp
 	allowedDirs []string
 	CachePath   string `json:"cache_path"` // CachePath is the root path for the file system.
+	MimeTypes   string `json:"mime_types"` // MimeTypes overrides the MIME type by file extension. split by comma. e.g. .md=text/markdown,.proto=text/plain
+	mimeTypes   map[string]string
+	UseRoots    bool `json:"use_roots"` // UseRoots allows the workspace roots of the clients too, for their session, if they support MCP roots.
 }
 
 // NewFileSystemConfig creates a new FileSystemConfig with the given allowed directories.
@@ -109,6 +112,24 @@ func (fc *FileSystemConfig) Check() error {
 	}
 	fc.allowedDirs = normalized
 
+	fc.mimeTypes = make(map[string]string)
+	for _, pair := range strings.Split(fc.MimeTypes, ",") {
+		pair = strings.TrimSpace(pair)
+		if pair == "" {
+			continue
+		}
+		ext, mimeType, ok := strings.Cut(pair, "=")
+		ext = strings.ToLower(strings.TrimSpace(ext))
+		mimeType = strings.TrimSpace(mimeType)
+		if !ok || ext == "" || mimeType == "" {
+			return fmt.Errorf("invalid mime type override %q, expected .ext=type/subtype", pair)
+		}
+		if !strings.HasPrefix(ext, ".") {
+			ext = "." + ext
+		}
+		fc.mimeTypes[ext] = mimeType
+	}
+
 	if fc.PromptFile != "" {
 		read, err := os.ReadFile(fc.PromptFile)
 		if err != nil {
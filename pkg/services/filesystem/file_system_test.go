@@ -0,0 +1,145 @@
+// Copyright 2025 CFC4N <cfc4n.cs@gmail.com>. All Rights Reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//   http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+//
+// Repository: https://github.com/gojue/moling
+
+package filesystem
+
+import (
+	"bytes"
+	"context"
+	"encoding/base64"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/mark3labs/mcp-go/mcp"
+
+	"github.com/gojue/moling/pkg/comm"
+	"github.com/gojue/moling/pkg/utils"
+)
+
+// newTestServer returns a FilesystemServer allowed in a temporary directory, with a binary file of size bytes.
+func newTestServer(t *testing.T, size int) (*FilesystemServer, string, []byte) {
+	t.Helper()
+	_, ctx, err := comm.InitTestEnv()
+	if err != nil {
+		t.Fatalf("InitTestEnv: %v", err)
+	}
+	srv, err := NewFilesystemServer(ctx)
+	if err != nil {
+		t.Fatalf("NewFilesystemServer: %v", err)
+	}
+	dir := t.TempDir()
+	if err = srv.LoadConfig(map[string]any{"allowed_dir": dir}); err != nil {
+		t.Fatalf("LoadConfig: %v", err)
+	}
+	if err = srv.Init(); err != nil {
+		t.Fatalf("Init: %v", err)
+	}
+	data := make([]byte, size)
+	for i := range data {
+		data[i] = byte(i * 7)
+	}
+	path := filepath.Join(dir, "data.bin")
+	if err = os.WriteFile(path, data, 0o600); err != nil {
+		t.Fatalf("WriteFile: %v", err)
+	}
+	return srv.(*FilesystemServer), path, data
+}
+
+// readResource reads the resource with the handler, and returns its blob decoded.
+func readResource(fs *FilesystemServer, handler func(context.Context, mcp.ReadResourceRequest) ([]mcp.ResourceContents, error), uri string) ([]byte, error) {
+	var request mcp.ReadResourceRequest
+	request.Params.URI = uri
+	contents, err := handler(context.Background(), request)
+	if err != nil {
+		return nil, err
+	}
+	blob, ok := contents[0].(mcp.BlobResourceContents)
+	if !ok {
+		return nil, fmt.Errorf("expected a blob, got %T", contents[0])
+	}
+	return base64.StdEncoding.DecodeString(blob.Blob)
+}
+
+// TestReadFileRange verifies the file-range:// resources return the byte range requested, clipped to the file.
+func TestReadFileRange(t *testing.T) {
+	fs, path, data := newTestServer(t, 1000)
+	tests := []struct {
+		query      string
+		start, end int
+		err        string
+	}{
+		{"", 0, 1000, ""},
+		{"?offset=10&len=20", 10, 30, ""},
+		{"?len=3", 0, 3, ""},
+		{"?offset=990&len=100", 990, 1000, ""},
+		{"?offset=1000", 1000, 1000, ""},
+		{"?offset=1001", 0, 0, "beyond end of file"},
+		{"?offset=-1", 0, 0, "offset must be a non-negative integer"},
+		{"?offset=x", 0, 0, "offset must be a non-negative integer"},
+		{"?len=0", 0, 0, "len must be a positive integer"},
+		{"?len=-5", 0, 0, "len must be a positive integer"},
+	}
+	for _, tt := range tests {
+		got, err := readResource(fs, fs.handleReadFileRange, "file-range://"+path+tt.query)
+		if tt.err != "" {
+			if err == nil || !strings.Contains(err.Error(), tt.err) {
+				t.Errorf("%s: expected the error %q, got %v", tt.query, tt.err, err)
+			}
+			continue
+		}
+		if err != nil {
+			t.Errorf("%s: %v", tt.query, err)
+			continue
+		}
+		if !bytes.Equal(got, data[tt.start:tt.end]) {
+			t.Errorf("%s: expected the bytes %d to %d, got %d bytes", tt.query, tt.start, tt.end, len(got))
+		}
+	}
+
+	if _, err := readResource(fs, fs.handleReadFileRange, "file-range://"+filepath.Dir(path)); err == nil {
+		t.Errorf("expected a directory to be rejected")
+	}
+	if _, err := readResource(fs, fs.handleReadFileRange, "file-range:///etc/passwd?len=10"); err == nil {
+		t.Errorf("expected a path outside the allowed directories to be rejected")
+	}
+}
+
+// TestReadFileRangeMaxSize verifies a range longer than MaxRangeSize is cut to it.
+func TestReadFileRangeMaxSize(t *testing.T) {
+	fs, path, data := newTestServer(t, MaxRangeSize+10)
+	got, err := readResource(fs, fs.handleReadFileRange, utils.PathToRangeResourceURI(path, 5, MaxRangeSize+10))
+	if err != nil {
+		t.Fatalf("handleReadFileRange: %v", err)
+	}
+	if !bytes.Equal(got, data[5:5+MaxRangeSize]) {
+		t.Errorf("expected %d bytes from the offset 5, got %d", MaxRangeSize, len(got))
+	}
+}
+
+// TestReadResourceBinary verifies a binary file read by its file:// resource is returned in base64.
+func TestReadResourceBinary(t *testing.T) {
+	fs, path, data := newTestServer(t, 4099)
+	got, err := readResource(fs, fs.handleReadResource, "file://"+path)
+	if err != nil {
+		t.Fatalf("handleReadResource: %v", err)
+	}
+	if !bytes.Equal(got, data) {
+		t.Errorf("expected the content of the binary file, got %d bytes", len(got))
+	}
+}
@@ -19,6 +19,7 @@ package services
 import (
 	"github.com/gojue/moling/pkg/comm"
 	"github.com/gojue/moling/pkg/services/abstract"
+	"github.com/gojue/moling/pkg/services/aggregate"
 	"github.com/gojue/moling/pkg/services/browser"
 	"github.com/gojue/moling/pkg/services/command"
 	"github.com/gojue/moling/pkg/services/filesystem"
@@ -36,6 +37,15 @@ func ServiceList() map[comm.MoLingServerType]abstract.ServiceFactory {
 	return serviceLists
 }
 
+// ServiceNames returns the names of the services registered, the names of their sections in the config file.
+func ServiceNames() []string {
+	names := make([]string, 0, len(serviceLists))
+	for name := range serviceLists {
+		names = append(names, string(name))
+	}
+	return names
+}
+
 func init() {
 	// Register the filesystem service
 	RegisterServ(filesystem.FilesystemServerName, filesystem.NewFilesystemServer)
@@ -43,4 +53,6 @@ func init() {
 	RegisterServ(browser.BrowserServerName, browser.NewBrowserServer)
 	// Register the command service
 	RegisterServ(command.CommandServerName, command.NewCommandServer)
+	// Register the aggregate service of the upstream MCP servers
+	RegisterServ(aggregate.AggregateServerName, aggregate.NewAggregateServer)
 }
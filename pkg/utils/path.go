@@ -0,0 +1,50 @@
+// Copyright 2025 CFC4N <cfc4n.cs@gmail.com>. All Rights Reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//   http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+//
+// Repository: https://github.com/gojue/moling
+
+package utils
+
+import (
+	"path/filepath"
+	"strings"
+)
+
+// IsPathWithin reports whether path is dir itself or lies beneath it. Both paths are expected to be absolute.
+// Unlike a raw prefix check, /tmp/foobar is not considered to be within /tmp/foo. On Windows the comparison is
+// case-insensitive and understands drive letters, UNC shares and \\?\ long path prefixes.
+func IsPathWithin(path, dir string) bool {
+	path = normalizePolicyPath(path)
+	dir = normalizePolicyPath(dir)
+	if path == "" || dir == "" {
+		return false
+	}
+	if path == dir {
+		return true
+	}
+	if !strings.HasSuffix(dir, string(filepath.Separator)) {
+		dir += string(filepath.Separator)
+	}
+	return strings.HasPrefix(path, dir)
+}
+
+// IsPathWithinAny reports whether path lies within any of the given directories.
+func IsPathWithinAny(path string, dirs []string) bool {
+	for _, dir := range dirs {
+		if IsPathWithin(path, dir) {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,41 @@
+//go:build windows
+
+// Copyright 2025 CFC4N <cfc4n.cs@gmail.com>. All Rights Reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//   http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+//
+// Repository: https://github.com/gojue/moling
+
+package utils
+
+import (
+	"path/filepath"
+	"strings"
+)
+
+// normalizePolicyPath cleans a path so that it can be compared with IsPathWithin.
+// Windows paths are case-insensitive, so the result is upper-cased; the \\?\ and \\?\UNC\ long path
+// prefixes are stripped so that C:\foo, \\?\c:\foo and c:/foo all compare equal.
+func normalizePolicyPath(path string) string {
+	if path == "" {
+		return ""
+	}
+	path = filepath.FromSlash(path)
+	switch {
+	case strings.HasPrefix(strings.ToUpper(path), `\\?\UNC\`):
+		path = `\\` + path[len(`\\?\UNC\`):]
+	case strings.HasPrefix(path, `\\?\`):
+		path = path[len(`\\?\`):]
+	}
+	return strings.ToUpper(filepath.Clean(path))
+}
@@ -19,14 +19,21 @@
 package utils
 
 import (
+	"errors"
 	"fmt"
+	"io"
 	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"time"
 )
 
 var pidFile *os.File
 
-// CreatePIDFile creates and locks a PID file to prevent multiple instances.
-func CreatePIDFile(pidFilePath string) error {
+// CreatePIDFile creates and locks a PID file to prevent multiple instances. A PID file whose process is gone, or
+// isn't a MoLing process, is stale and replaced. force replaces the PID file of a running instance too.
+func CreatePIDFile(pidFilePath string, force bool) error {
 	// Open or create the PID file
 	file, err := os.OpenFile(pidFilePath, os.O_RDWR|os.O_CREATE, 0644)
 	if err != nil {
@@ -40,8 +47,31 @@ func CreatePIDFile(pidFilePath string) error {
 		return fmt.Errorf("failed to lock PID file: %w", err)
 	}
 	if !locked {
-		_ = file.Close()
-		return fmt.Errorf("another instance is already running: %s", pidFilePath)
+		pid, _ := readPID(file)
+		running := processRunning(pid)
+		if !running {
+			_ = file.Close()
+			// The lock is held by another process than the MoLing of the PID: a new PID file, with its own lock,
+			// replaces this one.
+			if err = os.Remove(pidFilePath); err != nil {
+				return fmt.Errorf("failed to remove the PID file of PID %d: %w", pid, err)
+			}
+			return CreatePIDFile(pidFilePath, false)
+		}
+		if !force || pid == os.Getpid() {
+			_ = file.Close()
+			return fmt.Errorf("another instance is already running, PID %d: %s, use --force to take over", pid, pidFilePath)
+		}
+		if err = takeOver(file, pid); err != nil {
+			_ = file.Close()
+			return err
+		}
+		if !isPIDFile(file, pidFilePath) {
+			// The instance removed its PID file at its exit, a new one is created.
+			_ = unlockFile(file)
+			_ = file.Close()
+			return CreatePIDFile(pidFilePath, false)
+		}
 	}
 
 	// Write the current PID to the file
@@ -63,15 +93,91 @@ func CreatePIDFile(pidFilePath string) error {
 	return nil
 }
 
-// RemovePIDFile releases the lock and removes the PID file.
+// takeoverTimeout is how long CreatePIDFile with force waits for the running instance to exit.
+const takeoverTimeout = 10 * time.Second
+
+// takeOver stops the running instance of the PID and waits for it to release the lock of the PID file, then held
+// by file.
+func takeOver(file *os.File, pid int) error {
+	if err := terminateProcess(pid); err != nil {
+		return fmt.Errorf("failed to stop the running instance, PID %d: %w", pid, err)
+	}
+	deadline := time.Now().Add(takeoverTimeout)
+	for {
+		locked, err := lockFile(file)
+		if err != nil {
+			return fmt.Errorf("failed to lock PID file: %w", err)
+		}
+		if locked {
+			return nil
+		}
+		if time.Now().After(deadline) {
+			return fmt.Errorf("the running instance, PID %d, didn't exit within %s", pid, takeoverTimeout)
+		}
+		time.Sleep(100 * time.Millisecond)
+	}
+}
+
+// isPIDFile tells whether the file is still the PID file at the path, not removed or replaced since it was opened.
+func isPIDFile(file *os.File, pidFilePath string) bool {
+	info, err := file.Stat()
+	if err != nil {
+		return false
+	}
+	pathInfo, err := os.Stat(pidFilePath)
+	return err == nil && os.SameFile(info, pathInfo)
+}
+
+// readPID returns the PID written in the PID file, 0 if there is none.
+func readPID(file *os.File) (int, error) {
+	data, err := io.ReadAll(io.NewSectionReader(file, 0, 32))
+	if err != nil {
+		return 0, err
+	}
+	return strconv.Atoi(strings.TrimSpace(string(data)))
+}
+
+// processRunning tells whether the process of the PID is alive and is MoLing, i.e. runs an executable of the same
+// name as this one. A PID reused by another program after a crash is not running.
+func processRunning(pid int) bool {
+	if pid <= 0 || !processAlive(pid) {
+		return false
+	}
+	name, err := processName(pid)
+	exe, exeErr := os.Executable()
+	if err != nil || exeErr != nil {
+		// Unknown, the process is assumed to be MoLing.
+		return true
+	}
+	return sameProgram(name, filepath.Base(exe))
+}
+
+// sameProgram tells whether the process name is the one of the executable, without the extension of Windows. The
+// name may be truncated, e.g. to 15 characters by /proc/<pid>/comm of Linux.
+func sameProgram(name, exe string) bool {
+	name = strings.TrimSuffix(strings.ToLower(filepath.Base(name)), ".exe")
+	exe = strings.TrimSuffix(strings.ToLower(exe), ".exe")
+	if name == exe {
+		return true
+	}
+	return len(name) >= procNameMax && strings.HasPrefix(exe, name)
+}
+
+// RemovePIDFile releases the lock and removes the PID file, unless another instance took it over, i.e. the file
+// at the path isn't the one locked or holds another PID.
 func RemovePIDFile(pidFilePath string) error {
 	if pidFile != nil {
+		pid, _ := readPID(pidFile)
+		owned := pid == os.Getpid() && isPIDFile(pidFile, pidFilePath)
 		err := unlockFile(pidFile)
 		if err != nil {
 			return fmt.Errorf("failed to unlock PID file: %w", err)
 		}
 		_ = pidFile.Close()
 		pidFile = nil
+		if !owned {
+			return nil
+		}
 		err = os.Remove(pidFilePath)
 		if err != nil {
 			return fmt.Errorf("failed to remove PID file: %w", err)
@@ -79,3 +185,34 @@ func RemovePIDFile(pidFilePath string) error {
 	}
 	return nil
 }
+
+// ReadPIDFile returns the PID written in the PID file, and whether its process is running, i.e. still holds the
+// lock of the file and is a MoLing process. A missing PID file is no process running.
+func ReadPIDFile(pidFilePath string) (int, bool, error) {
+	data, err := os.ReadFile(pidFilePath)
+	if errors.Is(err, os.ErrNotExist) {
+		return 0, false, nil
+	}
+	if err != nil {
+		return 0, false, fmt.Errorf("failed to read PID file: %w", err)
+	}
+	pid, err := strconv.Atoi(strings.TrimSpace(string(data)))
+	if err != nil {
+		return 0, false, fmt.Errorf("invalid PID file %s: %w", pidFilePath, err)
+	}
+	file, err := os.OpenFile(pidFilePath, os.O_RDWR, 0644)
+	if err != nil {
+		return 0, false, fmt.Errorf("failed to open PID file: %w", err)
+	}
+	defer file.Close()
+	locked, err := lockFile(file)
+	if err != nil {
+		return 0, false, fmt.Errorf("failed to lock PID file: %w", err)
+	}
+	if locked {
+		// Nobody holds the lock, the PID file was left by a process that crashed.
+		_ = unlockFile(file)
+		return pid, false, nil
+	}
+	return pid, processRunning(pid), nil
+}
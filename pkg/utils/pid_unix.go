@@ -23,9 +23,15 @@ package utils
 import (
 	"errors"
 	"os"
+	"os/exec"
+	"strconv"
+	"strings"
 	"syscall"
 )
 
+// procNameMax is the length the process names may be truncated to, by /proc/<pid>/comm of Linux.
+const procNameMax = 15
+
 func lockFile(file *os.File) (bool, error) {
 	err := syscall.Flock(int(file.Fd()), syscall.LOCK_EX|syscall.LOCK_NB)
 	if err != nil {
@@ -40,3 +46,26 @@ func lockFile(file *os.File) (bool, error) {
 func unlockFile(file *os.File) error {
 	return syscall.Flock(int(file.Fd()), syscall.LOCK_UN)
 }
+
+// processAlive tells whether the process exists, signal 0 checks it without signaling it.
+func processAlive(pid int) bool {
+	err := syscall.Kill(pid, 0)
+	return err == nil || errors.Is(err, syscall.EPERM)
+}
+
+// terminateProcess asks the process to exit with SIGTERM, MoLing shuts down on it.
+func terminateProcess(pid int) error {
+	return syscall.Kill(pid, syscall.SIGTERM)
+}
+
+// processName returns the name of the executable of the process, from /proc on Linux, from ps elsewhere.
+func processName(pid int) (string, error) {
+	if data, err := os.ReadFile("/proc/" + strconv.Itoa(pid) + "/comm"); err == nil {
+		return strings.TrimSpace(string(data)), nil
+	}
+	out, err := exec.Command("ps", "-p", strconv.Itoa(pid), "-o", "comm=").Output()
+	if err != nil {
+		return "", err
+	}
+	return strings.TrimSpace(string(out)), nil
+}
@@ -31,18 +31,33 @@ var (
 	kernel32     = syscall.NewLazyDLL("kernel32.dll")
 	lockFileEx   = kernel32.NewProc("LockFileEx")
 	unlockFileEx = kernel32.NewProc("UnlockFileEx")
+
+	queryFullProcessImageName = kernel32.NewProc("QueryFullProcessImageNameW")
 )
 
+// procNameMax is the length the process names may be truncated to, none on Windows.
+const procNameMax = syscall.MAX_PATH
+
+// processQueryLimitedInformation is the access right to query the exit code and the image name of a process.
+const processQueryLimitedInformation = 0x1000
+
+// stillActive is the exit code of a process still running.
+const stillActive = 259
+
 const (
 	LockfileExclusiveLock   = 2
 	LockfileFailImmediately = 1
 )
 const ErrorLockViolation = syscall.Errno(33) // 0x21
 
+// lockOffsetHigh places the locked byte at 4GB, past the PID written in the file, so the other processes can
+// still read it, e.g. to stop the running instance.
+const lockOffsetHigh = 1
+
 // lockFile locks the given file using Windows API.
 func lockFile(file *os.File) (bool, error) {
 	handle := syscall.Handle(file.Fd())
-	var overlapped syscall.Overlapped
+	overlapped := syscall.Overlapped{OffsetHigh: lockOffsetHigh}
 
 	flags := LockfileExclusiveLock | LockfileFailImmediately
 	r, _, err := lockFileEx.Call(
@@ -67,7 +82,7 @@ func lockFile(file *os.File) (bool, error) {
 // unlockFile unlocks the given file using Windows API.
 func unlockFile(file *os.File) error {
 	handle := syscall.Handle(file.Fd())
-	var overlapped syscall.Overlapped
+	overlapped := syscall.Overlapped{OffsetHigh: lockOffsetHigh}
 
 	r, _, err := unlockFileEx.Call(
 		uintptr(handle),
@@ -83,3 +98,44 @@ func unlockFile(file *os.File) error {
 
 	return nil
 }
+
+// processAlive tells whether the process exists and hasn't exited.
+func processAlive(pid int) bool {
+	handle, err := syscall.OpenProcess(processQueryLimitedInformation, false, uint32(pid))
+	if err != nil {
+		// Access denied is a process running as another user.
+		return errors.Is(err, syscall.ERROR_ACCESS_DENIED)
+	}
+	defer syscall.CloseHandle(handle)
+	var code uint32
+	if err = syscall.GetExitCodeProcess(handle, &code); err != nil {
+		return false
+	}
+	return code == stillActive
+}
+
+// terminateProcess terminates the process, Windows has no signal asking it to exit.
+func terminateProcess(pid int) error {
+	process, err := os.FindProcess(pid)
+	if err != nil {
+		return err
+	}
+	defer process.Release()
+	return process.Kill()
+}
+
+// processName returns the path of the executable of the process.
+func processName(pid int) (string, error) {
+	handle, err := syscall.OpenProcess(processQueryLimitedInformation, false, uint32(pid))
+	if err != nil {
+		return "", err
+	}
+	defer syscall.CloseHandle(handle)
+	buf := make([]uint16, syscall.MAX_PATH)
+	size := uint32(len(buf))
+	r, _, err := queryFullProcessImageName.Call(uintptr(handle), 0, uintptr(unsafe.Pointer(&buf[0])), uintptr(unsafe.Pointer(&size)))
+	if r == 0 {
+		return "", err
+	}
+	return syscall.UTF16ToString(buf[:size]), nil
+}
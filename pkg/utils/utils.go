@@ -17,13 +17,18 @@
 package utils
 
 import (
+	"encoding/base64"
+	"encoding/json"
+	"errors"
 	"fmt"
+	"io"
 	"mime"
 	"net/http"
 	"os"
 	"path/filepath"
 	"reflect"
 	"strings"
+	"unicode/utf8"
 )
 
 // CreateDirectory checks if a directory exists, and creates it if it doesn't
@@ -52,6 +57,19 @@ func StringInSlice(s string, modules []string) bool {
 	return false
 }
 
+// SplitTrim splits s by sep, trims the spaces around each element and drops the empty ones
+func SplitTrim(s, sep string) []string {
+	parts := strings.Split(s, sep)
+	result := make([]string, 0, len(parts))
+	for _, part := range parts {
+		part = strings.TrimSpace(part)
+		if part != "" {
+			result = append(result, part)
+		}
+	}
+	return result
+}
+
 // MergeJSONToStruct 将JSON中的字段合并到结构体中
 func MergeJSONToStruct(target any, jsonMap map[string]any) error {
 	// 获取目标结构体的反射值
@@ -71,9 +89,9 @@ func MergeJSONToStruct(target any, jsonMap map[string]any) error {
 				if fieldVal.CanSet() {
 					// 将JSON值转换为结构体字段的类型
 					jsonVal := reflect.ValueOf(jsonValue)
-					if jsonVal.Type().ConvertibleTo(fieldVal.Type()) {
+					if jsonVal.IsValid() && jsonVal.Type().ConvertibleTo(fieldVal.Type()) {
 						fieldVal.Set(jsonVal.Convert(fieldVal.Type()))
-					} else {
+					} else if err := mergeJSONValue(fieldVal, jsonValue); err != nil {
 						return fmt.Errorf("type mismatch for field %s, value:%v", jsonKey, jsonValue)
 					}
 				}
@@ -83,17 +101,59 @@ func MergeJSONToStruct(target any, jsonMap map[string]any) error {
 	return nil
 }
 
+// mergeJSONValue sets composite fields such as slices and maps, which json.Unmarshal decodes to []any and
+// map[string]any, by round-tripping the value through JSON.
+func mergeJSONValue(fieldVal reflect.Value, jsonValue any) error {
+	switch fieldVal.Kind() {
+	case reflect.Slice, reflect.Map, reflect.Struct, reflect.Ptr:
+	default:
+		return fmt.Errorf("unsupported field kind %s", fieldVal.Kind())
+	}
+	payload, err := json.Marshal(jsonValue)
+	if err != nil {
+		return err
+	}
+	newVal := reflect.New(fieldVal.Type())
+	err = json.Unmarshal(payload, newVal.Interface())
+	if err != nil {
+		return err
+	}
+	fieldVal.Set(newVal.Elem())
+	return nil
+}
+
 // DetectMimeType tries to determine the MIME type of a file
 func DetectMimeType(path string) string {
+	return DetectMimeTypeWithOverrides(path, nil)
+}
+
+// textFileNames are well-known extensionless files that are always text
+var textFileNames = []string{
+	"makefile", "gnumakefile", "dockerfile", "containerfile", "vagrantfile", "jenkinsfile",
+	"license", "licence", "copying", "notice", "authors", "contributors", "changelog", "readme",
+	"todo", "install", "procfile", "gemfile", "rakefile", "brewfile", "codeowners",
+}
+
+// DetectMimeTypeWithOverrides tries to determine the MIME type of a file, consulting the
+// extension→MIME overrides first. The extension keys are lower-case and include the leading dot.
+func DetectMimeTypeWithOverrides(path string, overrides map[string]string) string {
 	// First try by extension
-	ext := filepath.Ext(path)
+	ext := strings.ToLower(filepath.Ext(path))
 	if ext != "" {
+		if mimeType, ok := overrides[ext]; ok {
+			return mimeType
+		}
 		mimeType := mime.TypeByExtension(ext)
 		if mimeType != "" {
 			return mimeType
 		}
 	}
 
+	// Well-known extensionless files such as Makefile or LICENSE
+	if StringInSlice(strings.ToLower(filepath.Base(path)), textFileNames) {
+		return "text/plain; charset=utf-8"
+	}
+
 	// If that fails, try to read a bit of the file
 	file, err := os.Open(path)
 	if err != nil {
@@ -105,11 +165,37 @@ func DetectMimeType(path string) string {
 	buffer := make([]byte, 512)
 	n, err := file.Read(buffer)
 	if err != nil {
+		if errors.Is(err, io.EOF) {
+			// Empty files are treated as text
+			return "text/plain; charset=utf-8"
+		}
 		return "application/octet-stream" // Default
 	}
 
 	// Use http.DetectContentType
-	return http.DetectContentType(buffer[:n])
+	mimeType := http.DetectContentType(buffer[:n])
+	if mimeType == "application/octet-stream" && looksLikeText(buffer[:n]) {
+		// Extensionless UTF-8 files such as dotfiles and scripts
+		return "text/plain; charset=utf-8"
+	}
+	return mimeType
+}
+
+// looksLikeText reports whether the sample is valid UTF-8 without NUL bytes or other binary control characters.
+func looksLikeText(sample []byte) bool {
+	// The sample may end in the middle of a multibyte rune
+	for i := 0; i < utf8.UTFMax && len(sample) > 0 && !utf8.Valid(sample); i++ {
+		sample = sample[:len(sample)-1]
+	}
+	if !utf8.Valid(sample) {
+		return false
+	}
+	for _, b := range sample {
+		if b < 0x20 && b != '\t' && b != '\n' && b != '\r' && b != '\f' && b != 0x1b {
+			return false
+		}
+	}
+	return true
 }
 
 // IsTextFile determines if a file is likely a text file based on MIME type
@@ -132,3 +218,32 @@ func IsImageFile(mimeType string) bool {
 func PathToResourceURI(path string) string {
 	return "file://" + path
 }
+
+// PathToRangeResourceURI converts a file path and a byte range to a file-range:// resource URI
+func PathToRangeResourceURI(path string, offset, length int64) string {
+	return fmt.Sprintf("file-range://%s?offset=%d&len=%d", path, offset, length)
+}
+
+// EncodeFileBase64 streams length bytes of the file starting at offset through a base64 encoder,
+// so the raw content never has to be held in memory next to its encoded form.
+func EncodeFileBase64(path string, offset, length int64) (string, error) {
+	file, err := os.Open(path)
+	if err != nil {
+		return "", err
+	}
+	defer file.Close()
+
+	var sb strings.Builder
+	sb.Grow(base64.StdEncoding.EncodedLen(int(length)))
+	encoder := base64.NewEncoder(base64.StdEncoding, &sb)
+	_, err = io.Copy(encoder, io.NewSectionReader(file, offset, length))
+	if err != nil {
+		return "", err
+	}
+	// Flush any partially encoded block
+	err = encoder.Close()
+	if err != nil {
+		return "", err
+	}
+	return sb.String(), nil
+}
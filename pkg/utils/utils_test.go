@@ -0,0 +1,233 @@
+// Copyright 2025 CFC4N <cfc4n.cs@gmail.com>. All Rights Reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//   http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+//
+// Repository: https://github.com/gojue/moling
+
+package utils
+
+import (
+	"bufio"
+	"bytes"
+	"encoding/base64"
+	"fmt"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"runtime"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestDetectMimeTypeWithOverrides(t *testing.T) {
+	dir := t.TempDir()
+	files := map[string][]byte{
+		"Makefile":   []byte("all:\n\tgo build ./...\n"),
+		".bashrc":    []byte("export PATH=$PATH:/usr/local/bin\n"),
+		"run":        []byte("#!/bin/sh\necho 你好\n"),
+		"empty":      {},
+		"blob":       {0x00, 0x01, 0x02, 0xff, 0xfe},
+		"schema.PRT": []byte("syntax = \"proto3\";\n"),
+	}
+	for name, content := range files {
+		if err := os.WriteFile(filepath.Join(dir, name), content, 0o644); err != nil {
+			t.Fatalf("failed to write %s: %s", name, err.Error())
+		}
+	}
+	overrides := map[string]string{".prt": "text/x-protobuf"}
+
+	tests := []struct {
+		name     string
+		wantText bool
+		wantMime string
+	}{
+		{"Makefile", true, ""},
+		{".bashrc", true, ""},
+		{"run", true, ""},
+		{"empty", true, ""},
+		{"blob", false, "application/octet-stream"},
+		{"schema.PRT", true, "text/x-protobuf"},
+	}
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			got := DetectMimeTypeWithOverrides(filepath.Join(dir, tc.name), overrides)
+			if IsTextFile(got) != tc.wantText {
+				t.Errorf("expected text=%v, got mime %q", tc.wantText, got)
+			}
+			if tc.wantMime != "" && !strings.HasPrefix(got, tc.wantMime) {
+				t.Errorf("expected mime %q, got %q", tc.wantMime, got)
+			}
+		})
+	}
+}
+
+func TestIsPathWithin(t *testing.T) {
+	if runtime.GOOS == "windows" {
+		t.Skip("unix paths only")
+	}
+	tests := []struct {
+		path string
+		dir  string
+		want bool
+	}{
+		{"/tmp/foo", "/tmp/foo", true},
+		{"/tmp/foo/", "/tmp/foo", true},
+		{"/tmp/foo/bar.txt", "/tmp/foo/", true},
+		{"/tmp/foo/../foo/bar", "/tmp/foo", true},
+		{"/tmp/foobar", "/tmp/foo", false},
+		{"/tmp/foo/../bar", "/tmp/foo", false},
+		{"/tmp", "/tmp/foo", false},
+		{"/etc/passwd", "/", true},
+		{"", "/tmp", false},
+	}
+	for _, tc := range tests {
+		if got := IsPathWithin(tc.path, tc.dir); got != tc.want {
+			t.Errorf("IsPathWithin(%q, %q) = %v, want %v", tc.path, tc.dir, got, tc.want)
+		}
+	}
+}
+
+// TestReadPIDFile verifies the PID of the running instance is read with the lock
+// held, and a PID file left without lock is reported not running.
+func TestReadPIDFile(t *testing.T) {
+	pidFilePath := filepath.Join(t.TempDir(), "moling.pid")
+	if _, running, err := ReadPIDFile(pidFilePath); err != nil || running {
+		t.Fatalf("expected no instance without PID file, got running %v, err %v", running, err)
+	}
+
+	if err := CreatePIDFile(pidFilePath, false); err != nil {
+		t.Fatalf("CreatePIDFile: %v", err)
+	}
+	pid, running, err := ReadPIDFile(pidFilePath)
+	if err != nil || !running || pid != os.Getpid() {
+		t.Errorf("expected the instance running with PID %d, got %d, running %v, err %v", os.Getpid(), pid, running, err)
+	}
+	if err = RemovePIDFile(pidFilePath); err != nil {
+		t.Fatalf("RemovePIDFile: %v", err)
+	}
+
+	if err = os.WriteFile(pidFilePath, []byte("12345\n"), 0o644); err != nil {
+		t.Fatalf("failed to write the PID file: %v", err)
+	}
+	if pid, running, err = ReadPIDFile(pidFilePath); err != nil || running || pid != 12345 {
+		t.Errorf("expected the stale PID 12345 not running, got %d, running %v, err %v", pid, running, err)
+	}
+}
+
+// pidHelperEnv is set to the path of the PID file in the environment of the test binary run by
+// TestCreatePIDFileTakeover, which holds it until it is stopped.
+const pidHelperEnv = "MOLING_TEST_PID_FILE"
+
+// TestCreatePIDFileTakeover verifies a running instance is refused unless forced, then stopped before its PID
+// file is taken over, and that it doesn't remove the PID file of the new instance.
+func TestCreatePIDFileTakeover(t *testing.T) {
+	if path := os.Getenv(pidHelperEnv); path != "" {
+		if err := CreatePIDFile(path, false); err != nil {
+			t.Fatalf("CreatePIDFile: %v", err)
+		}
+		fmt.Println("locked")
+		time.Sleep(time.Minute)
+		return
+	}
+	pidFilePath := filepath.Join(t.TempDir(), "moling.pid")
+	cmd := exec.Command(os.Args[0], "-test.run=^TestCreatePIDFileTakeover$")
+	cmd.Env = append(os.Environ(), pidHelperEnv+"="+pidFilePath)
+	out, err := cmd.StdoutPipe()
+	if err != nil {
+		t.Fatalf("StdoutPipe: %v", err)
+	}
+	if err = cmd.Start(); err != nil {
+		t.Fatalf("Start: %v", err)
+	}
+	t.Cleanup(func() { _ = cmd.Process.Kill() })
+	if line, err := bufio.NewReader(out).ReadString('\n'); err != nil || line != "locked\n" {
+		t.Fatalf("expected the helper to lock the PID file, got %q, %v", line, err)
+	}
+
+	if err = CreatePIDFile(pidFilePath, false); err == nil {
+		t.Fatalf("expected the running instance to be refused")
+	}
+	if err = CreatePIDFile(pidFilePath, true); err != nil {
+		t.Fatalf("CreatePIDFile with force: %v", err)
+	}
+	if err = cmd.Wait(); err == nil {
+		t.Errorf("expected the running instance stopped")
+	}
+	if pid, running, err := ReadPIDFile(pidFilePath); err != nil || !running || pid != os.Getpid() {
+		t.Errorf("expected the PID file taken over by PID %d, got %d, running %v, err %v", os.Getpid(), pid, running, err)
+	}
+	if err = RemovePIDFile(pidFilePath); err != nil {
+		t.Fatalf("RemovePIDFile: %v", err)
+	}
+}
+
+// TestRemovePIDFileTakenOver verifies the PID file is kept when it holds the PID of another instance.
+func TestRemovePIDFileTakenOver(t *testing.T) {
+	pidFilePath := filepath.Join(t.TempDir(), "moling.pid")
+	if err := CreatePIDFile(pidFilePath, false); err != nil {
+		t.Fatalf("CreatePIDFile: %v", err)
+	}
+	if err := os.WriteFile(pidFilePath, []byte("12345\n"), 0o644); err != nil {
+		t.Fatalf("failed to write the PID file: %v", err)
+	}
+	if err := RemovePIDFile(pidFilePath); err != nil {
+		t.Fatalf("RemovePIDFile: %v", err)
+	}
+	if _, err := os.Stat(pidFilePath); err != nil {
+		t.Errorf("expected the PID file of the other instance kept, got %v", err)
+	}
+}
+
+// TestSameProgram verifies the process names matching the executable, truncated or with the extension of Windows.
+func TestSameProgram(t *testing.T) {
+	for _, tc := range []struct {
+		name, exe string
+		want      bool
+	}{
+		{"moling", "moling", true},
+		{filepath.Join("Program Files", "MoLing", "moling.exe"), "moling.exe", true},
+		{"moling_darwin_a", "moling_darwin_arm64", procNameMax == 15},
+		{"bash", "moling", false},
+		{"mol", "moling", false},
+	} {
+		if got := sameProgram(tc.name, tc.exe); got != tc.want {
+			t.Errorf("sameProgram(%q, %q) = %v, want %v", tc.name, tc.exe, got, tc.want)
+		}
+	}
+}
+
+// TestEncodeFileBase64 verifies the ranges of a file are encoded whole, the last partial block padded.
+func TestEncodeFileBase64(t *testing.T) {
+	data := []byte{0x00, 0xff, 0x10, 0x80, 0x7f, 0x01, 0xfe}
+	path := filepath.Join(t.TempDir(), "blob")
+	if err := os.WriteFile(path, data, 0o644); err != nil {
+		t.Fatalf("failed to write the file: %s", err.Error())
+	}
+	for _, r := range [][2]int64{{0, 7}, {1, 4}, {2, 5}, {6, 1}, {7, 0}} {
+		blob, err := EncodeFileBase64(path, r[0], r[1])
+		if err != nil {
+			t.Fatalf("EncodeFileBase64(%d, %d): %s", r[0], r[1], err.Error())
+		}
+		want := data[r[0] : r[0]+r[1]]
+		if blob != base64.StdEncoding.EncodeToString(want) {
+			t.Errorf("EncodeFileBase64(%d, %d) = %s, expected %s", r[0], r[1], blob, base64.StdEncoding.EncodeToString(want))
+		}
+		if decoded, _ := base64.StdEncoding.DecodeString(blob); !bytes.Equal(decoded, want) {
+			t.Errorf("EncodeFileBase64(%d, %d) decoded to %v", r[0], r[1], decoded)
+		}
+	}
+	if _, err := EncodeFileBase64(filepath.Join(t.TempDir(), "missing"), 0, 1); err == nil {
+		t.Errorf("expected an error for a missing file")
+	}
+}